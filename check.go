@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/iwanbk/postgres-mcp-go/pkg/pgmcp"
+)
+
+// Statuses of a check
+const (
+	checkOK   = "ok"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+// checkResult is one line of the check report
+type checkResult struct {
+	Status string `json:"status"`
+	Check  string `json:"check"`
+	Detail string `json:"detail"`
+}
+
+// checkReport collects the results of the checks
+type checkReport struct {
+	Results []checkResult `json:"results"`
+	Failed  bool          `json:"failed"`
+}
+
+func (r *checkReport) add(status, check, detail string) {
+	r.Results = append(r.Results, checkResult{Status: status, Check: check, Detail: detail})
+	if status == checkFail {
+		r.Failed = true
+	}
+}
+
+// runCheck validates the flags and the configuration file, connects to each
+// database, verifies the privileges of the role and reports the optional
+// extensions. It fails when any check fails, warnings do not fail.
+func runCheck(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	flags := addServerFlags(fs)
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	fs.Parse(args)
+
+	report := &checkReport{}
+	checkDeployment(flags, report)
+
+	if *asJSON {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+	} else {
+		for _, result := range report.Results {
+			fmt.Printf("%-4s  %-28s %s\n", strings.ToUpper(result.Status), result.Check, result.Detail)
+		}
+	}
+	if report.Failed {
+		return fmt.Errorf("check failed")
+	}
+	return nil
+}
+
+// checkDeployment adds the results of all checks to the report
+func checkDeployment(flags *serverFlags, report *checkReport) {
+	_, databases, err := flags.options()
+	if err != nil {
+		report.add(checkFail, "configuration", err.Error())
+		return
+	}
+	report.add(checkOK, "configuration", "flags and configuration file are valid")
+	if plugins := pgmcp.Plugins(); len(plugins) > 0 {
+		report.add(checkOK, "plugins", "compiled in: "+strings.Join(plugins, ", "))
+	}
+
+	if *flags.logFile != "" {
+		if f, err := os.Open(*flags.logFile); err != nil {
+			report.add(checkFail, "log file", err.Error())
+		} else {
+			f.Close()
+			report.add(checkOK, "log file", *flags.logFile+" is readable")
+		}
+	}
+
+	if *flags.databaseURL == "" && len(databases) == 0 {
+		report.add(checkFail, "database", "no -database_url and no databases in the configuration file")
+		return
+	}
+
+	pooler, _ := db.ParsePooler(*flags.pooler)
+	if *flags.databaseURL != "" {
+		checkDatabase(report, "", *flags.databaseURL, pooler, *flags.cdcSlot != "")
+	}
+	names := make([]string, 0, len(databases))
+	for name := range databases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		checkDatabase(report, name, databases[name], pooler, false)
+	}
+
+	if *flags.databaseURL != "" && !report.Failed {
+		s, err := flags.newServer()
+		if err != nil {
+			report.add(checkFail, "tools", err.Error())
+			return
+		}
+		defer s.Close()
+		tools, err := s.ListTools(context.Background())
+		if err != nil {
+			report.add(checkFail, "tools", err.Error())
+			return
+		}
+		report.add(checkOK, "tools", fmt.Sprintf("%d tools registered", len(tools)))
+	}
+}
+
+// checkDatabase connects to a database and checks the role's privileges.
+// name is empty for the -database_url database.
+func checkDatabase(report *checkReport, name, url string, pooler db.Pooler, cdc bool) {
+	prefix := ""
+	if name != "" {
+		prefix = name + ": "
+	}
+
+	d, err := db.New(url, db.WithPooler(pooler))
+	if err != nil {
+		report.add(checkFail, prefix+"connection", err.Error())
+		return
+	}
+	defer d.Close()
+
+	access, err := d.CheckAccess()
+	if err != nil {
+		report.add(checkFail, prefix+"connection", err.Error())
+		return
+	}
+	report.add(checkOK, prefix+"connection", fmt.Sprintf("PostgreSQL %s as %s", access.ServerVersion, access.User))
+
+	if access.Superuser {
+		report.add(checkWarn, prefix+"role", access.User+" is a superuser, connect as a read-only role (see setup-role)")
+	} else {
+		report.add(checkOK, prefix+"role", access.User+" is not a superuser")
+	}
+
+	if !access.PublicUsage {
+		report.add(checkFail, prefix+"public schema", "no USAGE privilege on the public schema")
+	} else if len(access.UnreadableTables) > 0 {
+		report.add(checkWarn, prefix+"public schema", "no SELECT privilege on: "+strings.Join(access.UnreadableTables, ", "))
+	} else {
+		report.add(checkOK, prefix+"public schema", "all tables are readable")
+	}
+
+	if access.ReadAllStats {
+		report.add(checkOK, prefix+"statistics", "can read the statistics of all sessions and statements")
+	} else {
+		report.add(checkWarn, prefix+"statistics", "grant pg_read_all_stats to see the queries of other roles in the statistics tools")
+	}
+
+	if cdc {
+		if access.Replication || access.Superuser {
+			report.add(checkOK, prefix+"change data capture", "role can use replication slots")
+		} else {
+			report.add(checkFail, prefix+"change data capture", access.User+" lacks the REPLICATION attribute needed by -cdc_slot")
+		}
+	}
+
+	var installed, missing []string
+	for _, ext := range db.OptionalExtensions {
+		if version := access.Extensions[ext]; version != "" {
+			installed = append(installed, ext+" "+version)
+		} else {
+			missing = append(missing, ext)
+		}
+	}
+	if len(installed) > 0 {
+		report.add(checkOK, prefix+"extensions", "installed: "+strings.Join(installed, ", "))
+	}
+	if len(missing) > 0 {
+		report.add(checkOK, prefix+"extensions", "not installed, the tools using them are disabled: "+strings.Join(missing, ", "))
+	}
+}
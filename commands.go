@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// printJSON prints v as indented JSON to stdout
+func printJSON(v any) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %w", err)
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// runTools implements tools list, which prints the name and description of
+// the registered tools
+func runTools(args []string) error {
+	if len(args) == 0 || args[0] != "list" {
+		return fmt.Errorf("usage: postgres-mcp tools list [flags]")
+	}
+	fs := flag.NewFlagSet("tools list", flag.ExitOnError)
+	flags := addServerFlags(fs)
+	asJSON := fs.Bool("json", false, "Print the tool definitions, including their input schema, as JSON")
+	fs.Parse(args[1:])
+
+	s, err := flags.newServer()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	tools, err := s.ListTools(context.Background())
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(tools)
+	}
+	for _, tool := range tools {
+		fmt.Printf("%s\t%s\n", tool.Name, tool.Description)
+	}
+	return nil
+}
+
+// runQuery calls a tool locally, by default the query tool, and prints the
+// JSON result an MCP client would receive
+func runQuery(args []string) error {
+	fs := flag.NewFlagSet("query", flag.ExitOnError)
+	flags := addServerFlags(fs)
+	sql := fs.String("sql", "", "SQL passed as the sql argument of the tool")
+	tool := fs.String("tool", "query", "Name of the tool to call")
+	toolArgs := fs.String("args", "", "Arguments of the tool as a JSON object, e.g. {\"table\": \"users\"}")
+	textOnly := fs.Bool("text", false, "Print only the text content of the result instead of the JSON result")
+	fs.Parse(args)
+
+	arguments := map[string]any{}
+	if *toolArgs != "" {
+		if err := json.Unmarshal([]byte(*toolArgs), &arguments); err != nil {
+			return fmt.Errorf("invalid -args, expected a JSON object: %w", err)
+		}
+	}
+	if *sql != "" {
+		arguments["sql"] = *sql
+	}
+	if *tool == "query" && arguments["sql"] == nil {
+		return fmt.Errorf("please provide the query using the -sql flag")
+	}
+
+	s, err := flags.newServer()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	result, err := s.CallTool(context.Background(), *tool, arguments)
+	if err != nil {
+		return err
+	}
+	if *textOnly {
+		for _, content := range result.Content {
+			if text, ok := content.(mcp.TextContent); ok {
+				fmt.Println(text.Text)
+			}
+		}
+	} else if err := printJSON(result); err != nil {
+		return err
+	}
+	if result.IsError {
+		return fmt.Errorf("tool %s returned an error", *tool)
+	}
+	return nil
+}
+
+// runSchema implements schema dump, which prints the columns of each table
+// of the public schema
+func runSchema(args []string) error {
+	if len(args) == 0 || args[0] != "dump" {
+		return fmt.Errorf("usage: postgres-mcp schema dump [flags]")
+	}
+	fs := flag.NewFlagSet("schema dump", flag.ExitOnError)
+	flags := addServerFlags(fs)
+	fs.Parse(args[1:])
+
+	s, err := flags.newServer()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	schema, err := s.DumpSchema()
+	if err != nil {
+		return err
+	}
+	return printJSON(schema)
+}
@@ -0,0 +1,106 @@
+package anonymize
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Kind is the kind of fake value a column is replaced with
+type Kind string
+
+const (
+	// Hash replaces values with an opaque token
+	Hash Kind = "hash"
+	// Email replaces values with an example.com address
+	Email Kind = "email"
+	// Name replaces values with a first and last name
+	Name Kind = "name"
+	// Phone replaces values with a 555 phone number
+	Phone Kind = "phone"
+	// Address replaces values with a street address
+	Address Kind = "address"
+	// IP replaces values with a private IPv4 address
+	IP Kind = "ip"
+)
+
+// ParseKind validates a replacement kind, empty means Hash
+func ParseKind(s string) (Kind, error) {
+	switch k := Kind(s); k {
+	case "":
+		return Hash, nil
+	case Hash, Email, Name, Phone, Address, IP:
+		return k, nil
+	}
+	return "", fmt.Errorf("invalid anonymization kind %q, must be one of hash, email, name, phone, address, ip", s)
+}
+
+var (
+	firstNames = []string{"Alex", "Blake", "Casey", "Dana", "Eden", "Finley", "Gray", "Harper", "Indigo", "Jordan", "Kai", "Logan", "Morgan", "Noel", "Quinn", "Riley", "Sage", "Taylor", "Val", "Wren"}
+	lastNames  = []string{"Adams", "Brooks", "Carter", "Diaz", "Ellis", "Foster", "Garcia", "Hughes", "Ito", "Jensen", "Kim", "Lopez", "Meyer", "Novak", "Okafor", "Patel", "Reyes", "Silva", "Tanaka", "Weber"}
+	streets    = []string{"Oak", "Maple", "Cedar", "Pine", "Elm", "Birch", "Willow", "Lake", "Hill", "River"}
+)
+
+// Rule replaces the values of the result columns matching a pattern
+type Rule struct {
+	// Pattern is a glob matched against lower-cased result column names, e.g. "email" or "*_phone"
+	Pattern string
+	Kind    Kind
+}
+
+// Anonymizer deterministically replaces the values of configured columns.
+// Equal inputs give equal outputs for the same key, so joins and group-bys
+// on anonymized values stay consistent.
+type Anonymizer struct {
+	key   []byte
+	rules []Rule
+}
+
+// New creates an anonymizer with the HMAC key and column rules
+func New(key []byte, rules []Rule) (*Anonymizer, error) {
+	for _, r := range rules {
+		if _, err := path.Match(r.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid anonymization pattern %q: %w", r.Pattern, err)
+		}
+	}
+	return &Anonymizer{key: key, rules: rules}, nil
+}
+
+// Kind returns the replacement kind of a column, ok is false when the column is not anonymized
+func (a *Anonymizer) Kind(column string) (kind Kind, ok bool) {
+	name := strings.ToLower(column)
+	for _, r := range a.rules {
+		if matched, _ := path.Match(r.Pattern, name); matched {
+			return r.Kind, true
+		}
+	}
+	return "", false
+}
+
+// Replace returns the fake value for a non-NULL value
+func (a *Anonymizer) Replace(kind Kind, value interface{}) string {
+	mac := hmac.New(sha256.New, a.key)
+	fmt.Fprint(mac, value)
+	sum := mac.Sum(nil)
+	pick := func(i int, n int) int {
+		return int(binary.BigEndian.Uint32(sum[i*4:]) % uint32(n))
+	}
+
+	switch kind {
+	case Email:
+		return fmt.Sprintf("user_%s@example.com", hex.EncodeToString(sum[:5]))
+	case Name:
+		return firstNames[pick(0, len(firstNames))] + " " + lastNames[pick(1, len(lastNames))]
+	case Phone:
+		return fmt.Sprintf("+1555%07d", pick(2, 10000000))
+	case Address:
+		return fmt.Sprintf("%d %s St", 1+pick(3, 9999), streets[pick(4, len(streets))])
+	case IP:
+		return fmt.Sprintf("10.%d.%d.%d", sum[0], sum[1], 1+sum[2]%254)
+	}
+	return "anon_" + hex.EncodeToString(sum[:6])
+}
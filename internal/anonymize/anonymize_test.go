@@ -0,0 +1,126 @@
+package anonymize
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestParseKind(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Kind
+		wantErr bool
+	}{
+		{s: "", want: Hash},
+		{s: "hash", want: Hash},
+		{s: "email", want: Email},
+		{s: "name", want: Name},
+		{s: "phone", want: Phone},
+		{s: "address", want: Address},
+		{s: "ip", want: IP},
+		{s: "EMAIL", wantErr: true},
+		{s: "ssn", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseKind(tt.s)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewInvalidPattern(t *testing.T) {
+	if _, err := New([]byte("key"), []Rule{{Pattern: "[email", Kind: Email}}); err == nil {
+		t.Error("got no error, want an invalid pattern error")
+	}
+}
+
+func TestKind(t *testing.T) {
+	a, err := New([]byte("key"), []Rule{
+		{Pattern: "*email*", Kind: Email},
+		{Pattern: "*name", Kind: Name},
+		{Pattern: "customer_*", Kind: Hash},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		column string
+		want   Kind
+		wantOK bool
+	}{
+		{column: "email", want: Email, wantOK: true},
+		{column: "Work_Email", want: Email, wantOK: true},
+		{column: "last_name", want: Name, wantOK: true},
+		{column: "customer_name", want: Name, wantOK: true},
+		{column: "customer_id", want: Hash, wantOK: true},
+		{column: "amount"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.column, func(t *testing.T) {
+			got, ok := a.Kind(tt.column)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("got %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestReplace(t *testing.T) {
+	a, err := New([]byte("key"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := New([]byte("other key"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		kind  Kind
+		value interface{}
+		shape *regexp.Regexp
+	}{
+		{kind: Hash, value: "123-45-6789", shape: regexp.MustCompile(`^anon_[0-9a-f]{12}$`)},
+		{kind: Email, value: "alice@corp.com", shape: regexp.MustCompile(`^user_[0-9a-f]{10}@example\.com$`)},
+		{kind: Name, value: "Alice Smith", shape: regexp.MustCompile(`^[A-Z][a-z]+ [A-Z][a-z]+$`)},
+		{kind: Phone, value: "+44 20 7946 0000", shape: regexp.MustCompile(`^\+1555[0-9]{7}$`)},
+		{kind: Address, value: "1 Main Street", shape: regexp.MustCompile(`^[1-9][0-9]{0,3} [A-Z][a-z]+ St$`)},
+		{kind: IP, value: "203.0.113.7", shape: regexp.MustCompile(`^10\.[0-9]{1,3}\.[0-9]{1,3}\.[0-9]{1,3}$`)},
+		{kind: Hash, value: 42, shape: regexp.MustCompile(`^anon_[0-9a-f]{12}$`)},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.kind), func(t *testing.T) {
+			got := a.Replace(tt.kind, tt.value)
+			if !tt.shape.MatchString(got) {
+				t.Errorf("got %q, want a match of %s", got, tt.shape)
+			}
+			if again := a.Replace(tt.kind, tt.value); again != got {
+				t.Errorf("got %q then %q, want the same value", got, again)
+			}
+			if fresh, _ := New([]byte("key"), nil); fresh.Replace(tt.kind, tt.value) != got {
+				t.Errorf("got %q from another anonymizer with the same key, want %q", fresh.Replace(tt.kind, tt.value), got)
+			}
+		})
+	}
+
+	// Kinds with a large output space differ for another key or value
+	for _, kind := range []Kind{Hash, Email, Phone} {
+		if got, want := other.Replace(kind, "alice@corp.com"), a.Replace(kind, "alice@corp.com"); got == want {
+			t.Errorf("%s: got %q for another key, want a different value", kind, got)
+		}
+		if got, want := a.Replace(kind, "bob@corp.com"), a.Replace(kind, "alice@corp.com"); got == want {
+			t.Errorf("%s: got %q for another value, want a different value", kind, got)
+		}
+	}
+}
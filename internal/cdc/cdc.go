@@ -0,0 +1,250 @@
+package cdc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+)
+
+// Plugin is the logical decoding output plugin the slot must use
+const Plugin = "wal2json"
+
+// batchSize is the maximum number of changes consumed per poll
+const batchSize = 1000
+
+// pluginOptions requests one JSON document per change with commit timestamps
+var pluginOptions = []string{"format-version", "2", "include-timestamp", "1", "include-xids", "1"}
+
+// Change is a row change captured from the replication slot
+type Change struct {
+	LSN       string                 `json:"lsn"`
+	XID       string                 `json:"xid,omitempty"`
+	Action    string                 `json:"action"`
+	Schema    string                 `json:"schema"`
+	Table     string                 `json:"table"`
+	Timestamp time.Time              `json:"timestamp"`
+	Columns   map[string]interface{} `json:"columns,omitempty"`
+	Identity  map[string]interface{} `json:"identity,omitempty"`
+}
+
+// wal2jsonColumn is a column value in a wal2json format-version 2 change
+type wal2jsonColumn struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+// wal2jsonChange is a wal2json format-version 2 change
+type wal2jsonChange struct {
+	Action    string           `json:"action"`
+	Schema    string           `json:"schema"`
+	Table     string           `json:"table"`
+	Timestamp string           `json:"timestamp"`
+	Columns   []wal2jsonColumn `json:"columns"`
+	Identity  []wal2jsonColumn `json:"identity"`
+}
+
+// wal2jsonActions maps the wal2json action codes to readable names.
+// Begin and commit records are not reported.
+var wal2jsonActions = map[string]string{
+	"I": "insert",
+	"U": "update",
+	"D": "delete",
+	"T": "truncate",
+}
+
+// wal2jsonTimeLayout is the timestamp format of wal2json
+const wal2jsonTimeLayout = "2006-01-02 15:04:05.999999-07"
+
+// decodeChange decodes a wal2json change, ok is false for records that are
+// not row changes
+func decodeChange(raw db.SlotChange) (change Change, ok bool, err error) {
+	var w wal2jsonChange
+	if err := json.Unmarshal([]byte(raw.Data), &w); err != nil {
+		return Change{}, false, fmt.Errorf("failed to decode wal2json change at %s: %w", raw.LSN, err)
+	}
+	action, ok := wal2jsonActions[w.Action]
+	if !ok {
+		return Change{}, false, nil
+	}
+
+	change = Change{
+		LSN:      raw.LSN,
+		XID:      raw.XID,
+		Action:   action,
+		Schema:   w.Schema,
+		Table:    w.Table,
+		Columns:  columnMap(w.Columns),
+		Identity: columnMap(w.Identity),
+	}
+	change.Timestamp, err = time.Parse(wal2jsonTimeLayout, w.Timestamp)
+	if err != nil {
+		change.Timestamp = time.Now()
+	}
+	return change, true, nil
+}
+
+// columnMap converts wal2json columns to a name/value map
+func columnMap(columns []wal2jsonColumn) map[string]interface{} {
+	if len(columns) == 0 {
+		return nil
+	}
+	result := make(map[string]interface{}, len(columns))
+	for _, col := range columns {
+		result[col.Name] = col.Value
+	}
+	return result
+}
+
+// Buffer keeps the recent changes per table for a retention period
+type Buffer struct {
+	mu        sync.Mutex
+	retention time.Duration
+	// tables maps "schema.table" to its changes, oldest first
+	tables map[string][]Change
+}
+
+// NewBuffer creates a buffer keeping changes for the retention period
+func NewBuffer(retention time.Duration) *Buffer {
+	return &Buffer{
+		retention: retention,
+		tables:    map[string][]Change{},
+	}
+}
+
+// Add appends changes and drops the expired ones
+func (b *Buffer) Add(changes []Change) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, change := range changes {
+		key := change.Schema + "." + change.Table
+		b.tables[key] = append(b.tables[key], change)
+	}
+	b.expire()
+}
+
+// Recent returns the changes of a table ("schema.table") since the given time
+func (b *Buffer) Recent(table string, since time.Time) []Change {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expire()
+	result := []Change{}
+	for _, change := range b.tables[table] {
+		if !change.Timestamp.Before(since) {
+			result = append(result, change)
+		}
+	}
+	return result
+}
+
+// Tables returns the tables that have buffered changes
+func (b *Buffer) Tables() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expire()
+	tables := make([]string, 0, len(b.tables))
+	for table := range b.tables {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// expire drops changes older than the retention period, b.mu must be held
+func (b *Buffer) expire() {
+	cutoff := time.Now().Add(-b.retention)
+	for table, changes := range b.tables {
+		i := sort.Search(len(changes), func(i int) bool {
+			return !changes[i].Timestamp.Before(cutoff)
+		})
+		if i == len(changes) {
+			delete(b.tables, table)
+		} else if i > 0 {
+			b.tables[table] = append([]Change(nil), changes[i:]...)
+		}
+	}
+}
+
+// SlotConsumer consumes the changes of a logical replication slot
+type SlotConsumer interface {
+	ConsumeSlotChanges(slot string, limit int, options ...string) ([]db.SlotChange, error)
+}
+
+// Poller consumes a wal2json replication slot into a Buffer
+type Poller struct {
+	db       SlotConsumer
+	slot     string
+	interval time.Duration
+	buffer   *Buffer
+	// onChange is called with the "schema.table" names that changed in a poll
+	onChange func(tables []string)
+}
+
+// NewPoller creates a poller reading the slot every interval. onChange may be nil.
+func NewPoller(database SlotConsumer, slot string, interval time.Duration, buffer *Buffer, onChange func(tables []string)) *Poller {
+	return &Poller{
+		db:       database,
+		slot:     slot,
+		interval: interval,
+		buffer:   buffer,
+		onChange: onChange,
+	}
+}
+
+// Run polls the slot until the context is canceled
+func (p *Poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		if err := p.poll(); err != nil {
+			log.Printf("cdc: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll consumes the pending changes of the slot
+func (p *Poller) poll() error {
+	raw, err := p.db.ConsumeSlotChanges(p.slot, batchSize, pluginOptions...)
+	if err != nil {
+		return err
+	}
+
+	var changes []Change
+	changed := map[string]struct{}{}
+	for _, r := range raw {
+		change, ok, err := decodeChange(r)
+		if err != nil {
+			log.Printf("cdc: %v", err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		changes = append(changes, change)
+		changed[change.Schema+"."+change.Table] = struct{}{}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	p.buffer.Add(changes)
+	if p.onChange != nil {
+		tables := make([]string, 0, len(changed))
+		for table := range changed {
+			tables = append(tables, table)
+		}
+		sort.Strings(tables)
+		p.onChange(tables)
+	}
+	return nil
+}
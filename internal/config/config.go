@@ -0,0 +1,208 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/anonymize"
+)
+
+// toolNamePattern restricts tool names to what MCP clients accept
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// Config is the configuration file of the server
+type Config struct {
+	// NamedQueries are pre-approved queries exposed as individual tools
+	NamedQueries []NamedQuery `json:"named_queries,omitempty"`
+	// Databases maps tenant names to database URLs served under /mcp/{name}
+	Databases map[string]string `json:"databases,omitempty"`
+	// PlanDatabases maps names to the URLs of databases compare_plans may
+	// explain queries on, e.g. staging, for the -database_url database only
+	PlanDatabases map[string]string `json:"plan_databases,omitempty"`
+	// Anonymize replaces PII columns in results with deterministic fake values
+	Anonymize *Anonymize `json:"anonymize,omitempty"`
+	// Policy authorizes tool calls before they run
+	Policy *Policy `json:"policy,omitempty"`
+	// Tools selects the registered tools
+	Tools *Tools `json:"tools,omitempty"`
+}
+
+// Tools enables or disables individual tools by name. Names are globs, e.g.
+// "run_*". Disabled tools are not registered, so clients do not list them.
+type Tools struct {
+	// Enabled, when set, are the only tools registered
+	Enabled []string `json:"enabled,omitempty"`
+	// Disabled are not registered, even when enabled
+	Disabled []string `json:"disabled,omitempty"`
+	// Overrides replace the description, title or annotations of tools by name
+	Overrides map[string]ToolOverride `json:"overrides,omitempty"`
+}
+
+// ToolOverride replaces what a tool tells clients about itself. Unset
+// fields keep the built-in value.
+type ToolOverride struct {
+	Title           string `json:"title,omitempty"`
+	Description     string `json:"description,omitempty"`
+	ReadOnlyHint    *bool  `json:"read_only_hint,omitempty"`
+	DestructiveHint *bool  `json:"destructive_hint,omitempty"`
+	IdempotentHint  *bool  `json:"idempotent_hint,omitempty"`
+	OpenWorldHint   *bool  `json:"open_world_hint,omitempty"`
+}
+
+// Policy restricts the tools and the tables tool calls may use. Table
+// patterns are globs matched against "schema.table", e.g. "analytics.*".
+type Policy struct {
+	// AllowedTables, when set, are the only tables tool calls may read
+	AllowedTables []string `json:"allowed_tables,omitempty"`
+	// DeniedTables may not be read, even when allowed
+	DeniedTables []string `json:"denied_tables,omitempty"`
+	// DeniedTools are tool names that may not be called, globs too
+	DeniedTools []string `json:"denied_tools,omitempty"`
+}
+
+// Anonymize configures the anonymization of result columns
+type Anonymize struct {
+	// Key is the HMAC key of the replacements, ANONYMIZE_KEY is used when empty
+	Key     string            `json:"key,omitempty"`
+	Columns []AnonymizeColumn `json:"columns"`
+}
+
+// AnonymizeColumn selects result columns by name and how they are replaced
+type AnonymizeColumn struct {
+	// Pattern is a glob matched against lower-cased column names, e.g. "*email*"
+	Pattern string `json:"pattern"`
+	// Kind is one of hash (default), email, name, phone, address, ip
+	Kind string `json:"kind,omitempty"`
+}
+
+// NamedQuery is a pre-approved, parameterized read-only query exposed as its own tool
+type NamedQuery struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	SQL         string      `json:"sql"`
+	Parameters  []Parameter `json:"parameters,omitempty"`
+}
+
+// Parameter is a bind parameter of a named query. Parameters are bound as
+// $1, $2, ... in the order they are declared.
+type Parameter struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// Parameter types
+const (
+	ParameterString  = "string"
+	ParameterNumber  = "number"
+	ParameterInteger = "integer"
+	ParameterBoolean = "boolean"
+)
+
+// Load reads and validates a JSON configuration file
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var cfg Config
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Validate checks the configuration for errors
+func (c *Config) Validate() error {
+	names := map[string]bool{}
+	for _, q := range c.NamedQueries {
+		if !toolNamePattern.MatchString(q.Name) {
+			return fmt.Errorf("named query %q: name must match %s", q.Name, toolNamePattern)
+		}
+		if names[q.Name] {
+			return fmt.Errorf("named query %q is defined more than once", q.Name)
+		}
+		names[q.Name] = true
+		if q.SQL == "" {
+			return fmt.Errorf("named query %q: sql is required", q.Name)
+		}
+
+		params := map[string]bool{}
+		for _, p := range q.Parameters {
+			if p.Name == "" {
+				return fmt.Errorf("named query %q: parameter name is required", q.Name)
+			}
+			if params[p.Name] {
+				return fmt.Errorf("named query %q: parameter %q is defined more than once", q.Name, p.Name)
+			}
+			params[p.Name] = true
+			switch p.Type {
+			case ParameterString, ParameterNumber, ParameterInteger, ParameterBoolean:
+			default:
+				return fmt.Errorf("named query %q: parameter %q has invalid type %q, must be one of string, number, integer, boolean", q.Name, p.Name, p.Type)
+			}
+		}
+	}
+
+	for name, url := range c.Databases {
+		if !toolNamePattern.MatchString(name) {
+			return fmt.Errorf("database %q: name must match %s", name, toolNamePattern)
+		}
+		if url == "" {
+			return fmt.Errorf("database %q: url is required", name)
+		}
+	}
+
+	for name, url := range c.PlanDatabases {
+		if !toolNamePattern.MatchString(name) {
+			return fmt.Errorf("plan database %q: name must match %s", name, toolNamePattern)
+		}
+		if url == "" {
+			return fmt.Errorf("plan database %q: url is required", name)
+		}
+	}
+
+	if c.Anonymize != nil {
+		for _, col := range c.Anonymize.Columns {
+			if _, err := path.Match(col.Pattern, ""); err != nil || col.Pattern == "" {
+				return fmt.Errorf("anonymize: invalid column pattern %q", col.Pattern)
+			}
+			if _, err := anonymize.ParseKind(col.Kind); err != nil {
+				return fmt.Errorf("anonymize: column %q: %w", col.Pattern, err)
+			}
+		}
+	}
+	if c.Policy != nil {
+		patterns := append(append(append([]string{}, c.Policy.AllowedTables...), c.Policy.DeniedTables...), c.Policy.DeniedTools...)
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+				return fmt.Errorf("policy: invalid pattern %q", pattern)
+			}
+		}
+	}
+	if c.Tools != nil {
+		for _, pattern := range append(append([]string{}, c.Tools.Enabled...), c.Tools.Disabled...) {
+			if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+				return fmt.Errorf("tools: invalid pattern %q", pattern)
+			}
+		}
+		for name := range c.Tools.Overrides {
+			if !toolNamePattern.MatchString(name) {
+				return fmt.Errorf("tools: override %q: name must match %s", name, toolNamePattern)
+			}
+		}
+	}
+	return nil
+}
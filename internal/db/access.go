@@ -0,0 +1,76 @@
+package db
+
+import (
+	"fmt"
+)
+
+// OptionalExtensions are the extensions that enable additional tools
+var OptionalExtensions = []string{"pg_stat_statements", "hypopg", "postgis", "vector", "timescaledb", "citus", "pg_cron"}
+
+// Access describes the connected role and what it can read
+type Access struct {
+	ServerVersion string `db:"server_version" json:"server_version"`
+	User          string `db:"current_user" json:"user"`
+	Superuser     bool   `db:"superuser" json:"superuser"`
+	Replication   bool   `db:"replication" json:"replication"`
+	// ReadAllStats is set when the role can see the statistics of all
+	// sessions and statements, through pg_read_all_stats or pg_monitor
+	ReadAllStats bool `db:"read_all_stats" json:"read_all_stats"`
+	// ReadOnlyDefault is set when transactions default to read-only
+	ReadOnlyDefault bool `db:"read_only_default" json:"read_only_default"`
+	// PublicUsage is set when the role can use the public schema
+	PublicUsage bool `db:"public_usage" json:"public_usage"`
+	// UnreadableTables are the tables of the public schema the role cannot select from
+	UnreadableTables []string `json:"unreadable_tables"`
+	// Extensions maps the optional extensions to their installed version,
+	// empty when not installed
+	Extensions map[string]string `json:"extensions"`
+}
+
+// CheckAccess returns the attributes and privileges of the connected role
+// and the installed optional extensions
+func (d *DB) CheckAccess() (*Access, error) {
+	access := &Access{
+		UnreadableTables: []string{},
+		Extensions:       map[string]string{},
+	}
+
+	query := `SELECT current_setting('server_version') AS server_version, current_user,
+		r.rolsuper AS superuser, r.rolreplication AS replication,
+		pg_has_role(current_user, 'pg_read_all_stats', 'USAGE') AS read_all_stats,
+		current_setting('default_transaction_read_only')::boolean AS read_only_default,
+		has_schema_privilege('public', 'USAGE') AS public_usage
+		FROM pg_catalog.pg_roles r
+		WHERE r.rolname = current_user`
+	if err := d.conn.Get(access, query); err != nil {
+		return nil, fmt.Errorf("failed to check role attributes: %w", err)
+	}
+
+	query = `SELECT c.relname
+		FROM pg_catalog.pg_class c
+		WHERE c.relnamespace = 'public'::regnamespace
+			AND c.relkind IN ('r', 'p', 'v', 'm', 'f')
+			AND NOT has_table_privilege(c.oid, 'SELECT')
+		ORDER BY c.relname`
+	if err := d.conn.Select(&access.UnreadableTables, query); err != nil {
+		return nil, fmt.Errorf("failed to check table privileges: %w", err)
+	}
+
+	var extensions []struct {
+		Name    string `db:"extname"`
+		Version string `db:"extversion"`
+	}
+	query = "SELECT extname, extversion FROM pg_catalog.pg_extension"
+	if err := d.conn.Select(&extensions, query); err != nil {
+		return nil, fmt.Errorf("failed to list extensions: %w", err)
+	}
+	installed := make(map[string]string, len(extensions))
+	for _, ext := range extensions {
+		installed[ext.Name] = ext.Version
+	}
+	for _, name := range OptionalExtensions {
+		access.Extensions[name] = installed[name]
+	}
+
+	return access, nil
+}
@@ -0,0 +1,187 @@
+package db
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// arrayTypePrefix marks array types in the type names reported by the driver,
+// e.g. _INT4 for int[]
+const arrayTypePrefix = "_"
+
+// isArrayType reports whether the type name is an array type
+func isArrayType(typeName string) bool {
+	return strings.HasPrefix(typeName, arrayTypePrefix)
+}
+
+// encodeArray converts the text representation of an array into a JSON array,
+// converting each element according to the element type. Values that cannot
+// be parsed are returned unchanged as a string.
+func (o *encodeOptions) encodeArray(typeName, s string) interface{} {
+	elems, err := parseArray(s)
+	if err != nil {
+		return s
+	}
+	return o.encodeArrayElements(strings.TrimPrefix(typeName, arrayTypePrefix), elems)
+}
+
+// encodeArrayElements converts the parsed elements of a (nested) array
+func (o *encodeOptions) encodeArrayElements(elemType string, elems []interface{}) []interface{} {
+	result := make([]interface{}, len(elems))
+	for i, elem := range elems {
+		switch e := elem.(type) {
+		case []interface{}:
+			result[i] = o.encodeArrayElements(elemType, e)
+		case string:
+			result[i] = o.encodeArrayElement(elemType, e)
+		default:
+			// NULL element
+			result[i] = nil
+		}
+	}
+	return result
+}
+
+// encodeArrayElement converts the text representation of a single array element
+func (o *encodeOptions) encodeArrayElement(elemType, s string) interface{} {
+	switch elemType {
+	case "INT2", "INT4", "INT8", "OID":
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+	case "FLOAT4", "FLOAT8":
+		if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+			return f
+		}
+	case "BOOL":
+		return s == "t"
+	case "BYTEA":
+		if b, err := hex.DecodeString(strings.TrimPrefix(s, `\x`)); err == nil {
+			return o.encodeBytea(b)
+		}
+	default:
+		return o.encodeValue(elemType, []byte(s))
+	}
+	return s
+}
+
+// parseArray parses the text representation of a PostgreSQL array such as
+// {1,2,NULL} or {{"a b",c},{d,e}} into nested slices. Elements are returned
+// as strings, NULL elements as nil.
+func parseArray(s string) ([]interface{}, error) {
+	// Skip the dimension decoration of arrays with non-default bounds,
+	// e.g. [0:2]={1,2,3}
+	if strings.HasPrefix(s, "[") {
+		idx := strings.Index(s, "=")
+		if idx < 0 {
+			return nil, fmt.Errorf("invalid array dimensions")
+		}
+		s = s[idx+1:]
+	}
+
+	p := &arrayParser{s: s}
+	result, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.s) {
+		return nil, fmt.Errorf("unexpected trailing data in array at position %d", p.pos)
+	}
+	return result, nil
+}
+
+// arrayParser is a recursive descent parser for the array text format
+type arrayParser struct {
+	s   string
+	pos int
+}
+
+// parse parses one level of braces starting at the current position
+func (p *arrayParser) parse() ([]interface{}, error) {
+	if p.pos >= len(p.s) || p.s[p.pos] != '{' {
+		return nil, fmt.Errorf("expected '{' at position %d", p.pos)
+	}
+	p.pos++
+
+	result := []interface{}{}
+	if p.pos < len(p.s) && p.s[p.pos] == '}' {
+		p.pos++
+		return result, nil
+	}
+
+	for {
+		if p.pos >= len(p.s) {
+			return nil, fmt.Errorf("unterminated array")
+		}
+
+		switch p.s[p.pos] {
+		case '{':
+			sub, err := p.parse()
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, sub)
+		case '"':
+			elem, err := p.parseQuoted()
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, elem)
+		default:
+			result = append(result, p.parseUnquoted())
+		}
+
+		if p.pos >= len(p.s) {
+			return nil, fmt.Errorf("unterminated array")
+		}
+		switch p.s[p.pos] {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return result, nil
+		default:
+			return nil, fmt.Errorf("unexpected %q at position %d", p.s[p.pos], p.pos)
+		}
+	}
+}
+
+// parseQuoted parses a double-quoted element with backslash escapes
+func (p *arrayParser) parseQuoted() (string, error) {
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch c {
+		case '\\':
+			p.pos++
+			if p.pos >= len(p.s) {
+				return "", fmt.Errorf("unterminated escape in array")
+			}
+			sb.WriteByte(p.s[p.pos])
+		case '"':
+			p.pos++
+			return sb.String(), nil
+		default:
+			sb.WriteByte(c)
+		}
+		p.pos++
+	}
+	return "", fmt.Errorf("unterminated quoted array element")
+}
+
+// parseUnquoted parses an unquoted element, returning nil for NULL
+func (p *arrayParser) parseUnquoted() interface{} {
+	start := p.pos
+	for p.pos < len(p.s) && p.s[p.pos] != ',' && p.s[p.pos] != '}' {
+		p.pos++
+	}
+	elem := strings.TrimSpace(p.s[start:p.pos])
+	if strings.EqualFold(elem, "NULL") {
+		return nil
+	}
+	return elem
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseArray(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []interface{}
+	}{
+		{name: "empty", in: "{}", want: []interface{}{}},
+		{name: "integers", in: "{1,2,3}", want: []interface{}{"1", "2", "3"}},
+		{name: "null", in: "{1,NULL,null}", want: []interface{}{"1", nil, nil}},
+		{name: "quoted null is a string", in: `{"NULL"}`, want: []interface{}{"NULL"}},
+		{name: "quoted", in: `{"a b","c,d","e}f"}`, want: []interface{}{"a b", "c,d", "e}f"}},
+		{name: "escapes", in: `{"a\"b","c\\d"}`, want: []interface{}{`a"b`, `c\d`}},
+		{name: "empty string", in: `{""}`, want: []interface{}{""}},
+		{name: "nested", in: `{{"a b",c},{d,e}}`, want: []interface{}{[]interface{}{"a b", "c"}, []interface{}{"d", "e"}}},
+		{name: "empty nested", in: "{{},{}}", want: []interface{}{[]interface{}{}, []interface{}{}}},
+		{name: "dimensions", in: "[0:2]={1,2,3}", want: []interface{}{"1", "2", "3"}},
+		{name: "multidimensional bounds", in: "[1:1][1:2]={{1,2}}", want: []interface{}{[]interface{}{"1", "2"}}},
+		{name: "unquoted spaces trimmed", in: "{ 1 , 2 }", want: []interface{}{"1", "2"}},
+		{name: "unicode", in: `{"ä","日本"}`, want: []interface{}{"ä", "日本"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseArray(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseArrayErrors(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"1,2",
+		"{1,2",
+		`{"a`,
+		`{"a\`,
+		"{1}x",
+		"{{1}x}",
+		"[0:2]{1}",
+	} {
+		if got, err := parseArray(in); err == nil {
+			t.Errorf("parseArray(%q) = %#v, want an error", in, got)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// backupLogTable lists the backups of the backup schema
+const backupLogTable = "backup_log"
+
+var (
+	// identifierPart matches a quoted or unquoted identifier
+	identifierPart = `(?:"(?:[^"]|"")*"|[A-Za-z_][A-Za-z0-9_$]*)`
+	// writeTarget matches the written table of an UPDATE or DELETE with its
+	// optional ONLY and alias
+	writeTarget = regexp.MustCompile(`(?is)^(ONLY\s+)?(` + identifierPart + `(?:\s*\.\s*` + identifierPart + `)?)(?:\s*\*)?(?:\s+(?:AS\s+)?(` + identifierPart + `))?$`)
+	// truncateTarget matches a table of a TRUNCATE statement
+	truncateTarget = regexp.MustCompile(`(?is)^(ONLY\s+)?(` + identifierPart + `(?:\s*\.\s*` + identifierPart + `)?)(?:\s*\*)?$`)
+	// lastIdentifier matches the table part of a qualified name
+	lastIdentifier = regexp.MustCompile(identifierPart + `$`)
+)
+
+// Backup is a copy of the rows of a table taken before a statement changed
+// or removed them
+type Backup struct {
+	Name       string     `db:"name" json:"name"`
+	Schema     string     `db:"source_schema" json:"schema"`
+	Table      string     `db:"source_table" json:"table"`
+	Operation  string     `db:"operation" json:"operation"`
+	Statement  string     `db:"statement" json:"statement"`
+	Rows       int64      `db:"row_count" json:"rows"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	RestoredAt *time.Time `db:"restored_at" json:"restored_at,omitempty"`
+}
+
+// WithBackupSchema copies the rows UPDATE, DELETE and TRUNCATE statements of
+// the write tools change into timestamped tables of the schema before the
+// statements run, in the same transaction. The schema is created if needed.
+func WithBackupSchema(schema string) Option {
+	return func(d *DB) {
+		d.backupSchema = schema
+	}
+}
+
+// backupBeforeWrite copies the rows a statement is about to change and
+// returns the names of the backup tables, none for statements that do not
+// change existing rows
+func (d *DB) backupBeforeWrite(ctx context.Context, tx *sqlx.Tx, stmt string) ([]string, error) {
+	type source struct {
+		name  string
+		query string
+	}
+	var sources []source
+	operation := ""
+
+	if parts, ok := parseWrite(stmt); ok {
+		m := writeTarget.FindStringSubmatch(parts.target)
+		if m == nil {
+			return nil, fmt.Errorf("cannot find the table of %q", parts.target)
+		}
+		only, name, alias := m[1], m[2], m[3]
+		if alias == "" {
+			alias = lastIdentifier.FindString(name)
+		}
+		// tableoid and ctid pick the written rows once, whatever the joins
+		query := fmt.Sprintf("SELECT * FROM %s%s WHERE (tableoid, ctid) IN (SELECT %s.tableoid, %s.ctid%s)",
+			only, name, alias, alias, parts.selectFrom())
+		sources = append(sources, source{name: name, query: query})
+		operation = strings.ToLower(parts.command)
+	} else if names, cascade, ok := parseTruncate(stmt); ok {
+		// CASCADE would empty the referencing tables without a backup
+		if cascade {
+			return nil, fmt.Errorf("TRUNCATE ... CASCADE cannot be backed up, truncate without CASCADE and list the tables referencing %s too", strings.Join(names, ", "))
+		}
+		for _, target := range names {
+			m := truncateTarget.FindStringSubmatch(target)
+			if m == nil {
+				return nil, fmt.Errorf("cannot find the table of %q", target)
+			}
+			sources = append(sources, source{name: m[2], query: "SELECT * FROM " + m[1] + m[2]})
+		}
+		operation = "truncate"
+	}
+	if len(sources) == 0 {
+		return nil, nil
+	}
+
+	if err := d.ensureBackupLog(ctx, tx); err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, src := range sources {
+		var table struct {
+			Schema string `db:"schema"`
+			Table  string `db:"table_name"`
+		}
+		lookup := `SELECT n.nspname AS schema, c.relname AS table_name
+			FROM pg_catalog.pg_class c JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+			WHERE c.oid = $1::regclass`
+		if err := tx.GetContext(ctx, &table, lookup, src.name); err != nil {
+			return nil, fmt.Errorf("failed to look up table %s: %w", src.name, err)
+		}
+
+		name := backupTableName(table.Schema+"_"+table.Table, operation, time.Now())
+		create := fmt.Sprintf("CREATE TABLE %s AS %s", Identifier{d.backupSchema, name}.Sanitize(), src.query)
+		result, err := tx.ExecContext(ctx, create)
+		if err != nil {
+			return nil, fmt.Errorf("failed to back up %s.%s: %w", table.Schema, table.Table, err)
+		}
+		rows, _ := result.RowsAffected()
+
+		insert := fmt.Sprintf(`INSERT INTO %s (name, source_schema, source_table, operation, statement, row_count)
+			VALUES ($1, $2, $3, $4, $5, $6)`, Identifier{d.backupSchema, backupLogTable}.Sanitize())
+		if _, err := tx.ExecContext(ctx, insert, name, table.Schema, table.Table, operation, stmt, rows); err != nil {
+			return nil, fmt.Errorf("failed to record backup %s: %w", name, err)
+		}
+		backups = append(backups, name)
+	}
+	return backups, nil
+}
+
+// parseTruncate returns the tables of a TRUNCATE statement and whether it
+// cascades to the tables referencing them
+func parseTruncate(stmt string) (tables []string, cascade, ok bool) {
+	stmt = strings.TrimRight(strings.TrimSpace(stmt), ";")
+	masked := maskSQL(stmt)
+	offset := len(masked) - len(strings.TrimLeft(leadingComments.ReplaceAllString(masked, ""), " \t\r\n"))
+	if !keywordAt(masked, offset, "TRUNCATE") {
+		return nil, false, false
+	}
+	start := offset + len("TRUNCATE")
+	if i := strings.IndexFunc(masked[start:], func(r rune) bool { return r != ' ' && r != '\t' && r != '\r' && r != '\n' }); i >= 0 && keywordAt(masked, start+i, "TABLE") {
+		start += i + len("TABLE")
+	}
+	end := firstTopLevelKeyword(masked, start, "RESTART", "CONTINUE", "CASCADE", "RESTRICT")
+
+	for _, part := range splitTopLevel(masked[start:end], stmt[start:end]) {
+		if part = strings.TrimSpace(part); part != "" {
+			tables = append(tables, part)
+		}
+	}
+	return tables, topLevelKeyword(masked, end, "CASCADE") >= 0, len(tables) > 0
+}
+
+// splitTopLevel splits sql at the commas of its masked text
+func splitTopLevel(masked, sql string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(masked); i++ {
+		if masked[i] == ',' {
+			parts = append(parts, sql[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, sql[start:])
+}
+
+// backupTableName returns the name of the backup of a table, e.g.
+// public_orders_delete_20240102_150405_000123, within the identifier length
+// limit, cut on a rune boundary
+func backupTableName(table, operation string, t time.Time) string {
+	suffix := "_" + operation + "_" + strings.ReplaceAll(t.UTC().Format("20060102_150405.000000"), ".", "_")
+	if limit := maxIdentifierLength - len(suffix); len(table) > limit {
+		for limit > 0 && !utf8.RuneStart(table[limit]) {
+			limit--
+		}
+		table = table[:limit]
+	}
+	return table + suffix
+}
+
+// ensureBackupLog creates the backup schema and its log table
+func (d *DB) ensureBackupLog(ctx context.Context, tx *sqlx.Tx) error {
+	statements := []string{
+		"CREATE SCHEMA IF NOT EXISTS " + quoteIdent(d.backupSchema),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			name text PRIMARY KEY,
+			source_schema text NOT NULL,
+			source_table text NOT NULL,
+			operation text NOT NULL,
+			statement text NOT NULL,
+			row_count bigint NOT NULL,
+			created_at timestamptz NOT NULL DEFAULT now(),
+			restored_at timestamptz
+		)`, Identifier{d.backupSchema, backupLogTable}.Sanitize()),
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create backup schema %s: %w", d.backupSchema, err)
+		}
+	}
+	return nil
+}
+
+// ListBackups returns the backups of the backup schema, newest first
+func (d *DB) ListBackups(ctx context.Context) ([]Backup, error) {
+	backups := []Backup{}
+	if d.backupSchema == "" {
+		return backups, nil
+	}
+	var exists bool
+	if err := d.conn.GetContext(ctx, &exists, "SELECT to_regclass($1) IS NOT NULL", Identifier{d.backupSchema, backupLogTable}.Sanitize()); err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+	if !exists {
+		return backups, nil
+	}
+	query := fmt.Sprintf(`SELECT name, source_schema, source_table, operation, statement, row_count, created_at, restored_at
+		FROM %s ORDER BY created_at DESC, name`, Identifier{d.backupSchema, backupLogTable}.Sanitize())
+	if err := d.conn.SelectContext(ctx, &backups, query); err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+	return backups, nil
+}
+
+// RestoreBackup puts the rows of a backup back into their table and returns
+// the number of rows restored. Rows removed by DELETE or TRUNCATE are
+// inserted again; rows changed by UPDATE get their old values back, matched
+// by primary key. Generated columns are left to the database.
+func (d *DB) RestoreBackup(ctx context.Context, name string) (int64, error) {
+	if d.backupSchema == "" {
+		return 0, fmt.Errorf("backups are not enabled")
+	}
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	backupLog := Identifier{d.backupSchema, backupLogTable}.Sanitize()
+	var backup Backup
+	query := fmt.Sprintf(`SELECT name, source_schema, source_table, operation, statement, row_count, created_at, restored_at
+		FROM %s WHERE name = $1 FOR UPDATE`, backupLog)
+	if err := tx.GetContext(ctx, &backup, query, name); err != nil {
+		return 0, fmt.Errorf("backup %s not found: %w", name, err)
+	}
+	if backup.RestoredAt != nil {
+		return 0, fmt.Errorf("backup %s was already restored at %s", name, backup.RestoredAt.Format(time.RFC3339))
+	}
+
+	target := Identifier{backup.Schema, backup.Table}.Sanitize()
+	source := Identifier{d.backupSchema, backup.Name}.Sanitize()
+
+	// The columns of the table that the backup also has, in case the table
+	// was altered since
+	var columns []struct {
+		Name      string `db:"name"`
+		IsPrimary bool   `db:"is_primary"`
+	}
+	columnsQuery := `SELECT a.attname AS name,
+		COALESCE(a.attnum = ANY (i.indkey), false) AS is_primary
+		FROM pg_catalog.pg_attribute a
+		LEFT JOIN pg_catalog.pg_index i ON i.indrelid = a.attrelid AND i.indisprimary
+		WHERE a.attrelid = $1::regclass AND a.attnum > 0 AND NOT a.attisdropped AND a.attgenerated = ''
+		AND EXISTS (SELECT 1 FROM pg_catalog.pg_attribute b
+			WHERE b.attrelid = $2::regclass AND b.attname = a.attname AND NOT b.attisdropped)
+		ORDER BY a.attnum`
+	if err := tx.SelectContext(ctx, &columns, columnsQuery, target, source); err != nil {
+		return 0, fmt.Errorf("failed to get the columns of %s: %w", target, err)
+	}
+
+	var restore string
+	if backup.Operation == "update" {
+		var keys, sets, values []string
+		for _, col := range columns {
+			quoted := quoteIdent(col.Name)
+			if col.IsPrimary {
+				keys = append(keys, fmt.Sprintf("t.%s = b.%s", quoted, quoted))
+			} else {
+				sets = append(sets, quoted)
+				values = append(values, "b."+quoted)
+			}
+		}
+		if len(keys) == 0 {
+			return 0, fmt.Errorf("table %s has no primary key to match the updated rows by", target)
+		}
+		if len(sets) == 0 {
+			return 0, fmt.Errorf("table %s has no columns to restore besides its primary key", target)
+		}
+		restore = fmt.Sprintf("UPDATE %s AS t SET (%s) = ROW(%s) FROM %s AS b WHERE %s",
+			target, strings.Join(sets, ", "), strings.Join(values, ", "), source, strings.Join(keys, " AND "))
+	} else {
+		names := make([]string, len(columns))
+		for i, col := range columns {
+			names[i] = quoteIdent(col.Name)
+		}
+		list := strings.Join(names, ", ")
+		restore = fmt.Sprintf("INSERT INTO %s (%s) OVERRIDING SYSTEM VALUE SELECT %s FROM %s", target, list, list, source)
+	}
+
+	result, err := tx.ExecContext(ctx, restore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to restore backup %s into %s: %w", name, target, err)
+	}
+	rows, _ := result.RowsAffected()
+
+	mark := fmt.Sprintf("UPDATE %s SET restored_at = now() WHERE name = $1", backupLog)
+	if _, err := tx.ExecContext(ctx, mark, name); err != nil {
+		return 0, fmt.Errorf("failed to mark backup %s as restored: %w", name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return rows, nil
+}
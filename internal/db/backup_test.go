@@ -0,0 +1,59 @@
+package db
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestParseTruncate(t *testing.T) {
+	tests := []struct {
+		stmt        string
+		wantTables  []string
+		wantCascade bool
+		wantOK      bool
+	}{
+		{stmt: "TRUNCATE orders", wantTables: []string{"orders"}, wantOK: true},
+		{stmt: "truncate table public.orders, \"Order Items\";", wantTables: []string{"public.orders", `"Order Items"`}, wantOK: true},
+		{stmt: "TRUNCATE ONLY orders RESTART IDENTITY", wantTables: []string{"ONLY orders"}, wantOK: true},
+		{stmt: "TRUNCATE orders RESTART IDENTITY CASCADE", wantTables: []string{"orders"}, wantCascade: true, wantOK: true},
+		{stmt: "-- empty it\nTRUNCATE orders cascade", wantTables: []string{"orders"}, wantCascade: true, wantOK: true},
+		{stmt: "TRUNCATE orders RESTRICT", wantTables: []string{"orders"}, wantOK: true},
+		{stmt: `TRUNCATE "cascade"`, wantTables: []string{`"cascade"`}, wantOK: true},
+		{stmt: "DELETE FROM orders"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stmt, func(t *testing.T) {
+			tables, cascade, ok := parseTruncate(tt.stmt)
+			if !reflect.DeepEqual(tables, tt.wantTables) || cascade != tt.wantCascade || ok != tt.wantOK {
+				t.Errorf("got %v, %v, %v, want %v, %v, %v", tables, cascade, ok, tt.wantTables, tt.wantCascade, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBackupTableName(t *testing.T) {
+	at := time.Date(2024, 1, 2, 15, 4, 5, 123000, time.UTC)
+	tests := []struct {
+		name  string
+		table string
+		want  string
+	}{
+		{name: "short", table: "public_orders", want: "public_orders_delete_20240102_150405_000123"},
+		{name: "long", table: "public_" + strings.Repeat("a", 60), want: "public_" + strings.Repeat("a", 26) + "_delete_20240102_150405_000123"},
+		{name: "multibyte", table: "public_x" + strings.Repeat("é", 30), want: "public_x" + strings.Repeat("é", 12) + "_delete_20240102_150405_000123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := backupTableName(tt.table, "delete", at)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if len(got) > maxIdentifierLength || !utf8.ValidString(got) {
+				t.Errorf("got %q, want valid UTF-8 of at most %d bytes", got, maxIdentifierLength)
+			}
+		})
+	}
+}
@@ -0,0 +1,403 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// DefaultBuilderLimit is the row limit of structured queries when none is given
+	DefaultBuilderLimit = 100
+	// MaxBuilderLimit is the largest row limit accepted by structured queries
+	MaxBuilderLimit = 1000
+)
+
+// FilterOperators are the operators accepted in structured query filters
+var FilterOperators = []string{"=", "!=", "<", "<=", ">", ">=", "like", "ilike", "in", "not in", "is null", "is not null"}
+
+// Filter is a condition on a column of a structured query
+type Filter struct {
+	Column string
+	Op     string
+	// Value is a scalar, a list of scalars for in/not in and unused for is null/is not null
+	Value interface{}
+}
+
+// OrderBy is a sort key of a structured query
+type OrderBy struct {
+	Column     string
+	Descending bool
+}
+
+// SelectParams describes a structured row lookup on a single table
+type SelectParams struct {
+	Schema  string
+	Table   string
+	Columns []string
+	Filters []Filter
+	OrderBy []OrderBy
+	Limit   int
+}
+
+// tableRef is a table of a structured query with its known columns
+type tableRef struct {
+	schema  string
+	table   string
+	columns map[string]bool
+}
+
+// quoted returns the schema-qualified quoted table name
+func (t *tableRef) quoted() string {
+	return Identifier{t.schema, t.table}.Sanitize()
+}
+
+// column validates a column name and returns it quoted
+func (t *tableRef) column(name string) (string, error) {
+	if !t.columns[name] {
+		return "", fmt.Errorf("column %q does not exist in %s.%s", name, t.schema, t.table)
+	}
+	return quoteIdent(name), nil
+}
+
+// tableName is a table as named by a caller, the schema is empty for a name
+// resolved against the search_path
+type tableName struct {
+	schema string
+	table  string
+}
+
+// notFound is the error for a table that does not exist
+func (n tableName) notFound() error {
+	if n.schema == "" {
+		return fmt.Errorf("table %s does not exist on the search_path", n.table)
+	}
+	return fmt.Errorf("table %s.%s does not exist", n.schema, n.table)
+}
+
+// lookupTable returns the columns of a table, failing if the table does not exist
+func (d *DB) lookupTable(schema, table string) (*tableRef, error) {
+	name := tableName{schema: schema, table: table}
+	refs, err := d.lookupTables([]tableName{name})
+	if err != nil {
+		return nil, err
+	}
+	ref, ok := refs[name]
+	if !ok {
+		return nil, name.notFound()
+	}
+	return ref, nil
+}
+
+// lookupTables returns the columns of several tables in one catalog query,
+// keyed by the names as given. Tables that do not exist are left out.
+func (d *DB) lookupTables(names []tableName) (map[tableName]*tableRef, error) {
+	if len(names) == 0 {
+		return map[tableName]*tableRef{}, nil
+	}
+	schemas := make([]string, len(names))
+	tables := make([]string, len(names))
+	for i, name := range names {
+		schemas[i], tables[i] = name.schema, name.table
+	}
+
+	var rows []struct {
+		Position int64  `db:"position"`
+		Schema   string `db:"schema_name"`
+		Table    string `db:"table_name"`
+		Column   string `db:"column_name"`
+	}
+	query := `SELECT t.position, n.nspname AS schema_name, c.relname AS table_name, a.attname AS column_name
+		FROM unnest($1::text[], $2::text[]) WITH ORDINALITY AS t(schema_name, table_name, position)
+		JOIN pg_catalog.pg_class c ON c.oid = to_regclass(CASE WHEN t.schema_name = '' THEN quote_ident(t.table_name)
+			ELSE quote_ident(t.schema_name) || '.' || quote_ident(t.table_name) END)
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = c.oid
+		WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f')
+		AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY t.position, a.attnum`
+	if err := d.conn.Select(&rows, query, pq.Array(schemas), pq.Array(tables)); err != nil {
+		return nil, fmt.Errorf("failed to get columns of %d tables: %w", len(names), err)
+	}
+
+	refs := make(map[tableName]*tableRef, len(names))
+	for _, row := range rows {
+		name := names[row.Position-1]
+		ref, ok := refs[name]
+		if !ok {
+			ref = &tableRef{schema: row.Schema, table: row.Table, columns: map[string]bool{}}
+			refs[name] = ref
+		}
+		ref.columns[row.Column] = true
+	}
+	return refs, nil
+}
+
+// BuildSelect compiles a structured lookup to SQL. Table and column names are
+// checked against the catalog and values are returned as bind arguments.
+func (d *DB) BuildSelect(params SelectParams) (string, []interface{}, error) {
+	ref, err := d.lookupTable(params.Schema, params.Table)
+	if err != nil {
+		return "", nil, err
+	}
+
+	selectList := "*"
+	if len(params.Columns) > 0 {
+		cols := make([]string, 0, len(params.Columns))
+		for _, name := range params.Columns {
+			col, err := ref.column(name)
+			if err != nil {
+				return "", nil, err
+			}
+			cols = append(cols, col)
+		}
+		selectList = strings.Join(cols, ", ")
+	}
+
+	var args []interface{}
+	where, err := buildWhere(params.Filters, ref.column, &args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "SELECT %s FROM %s", selectList, ref.quoted())
+	if where != "" {
+		sb.WriteString(" WHERE " + where)
+	}
+
+	if len(params.OrderBy) > 0 {
+		keys := make([]string, 0, len(params.OrderBy))
+		for _, o := range params.OrderBy {
+			col, err := ref.column(o.Column)
+			if err != nil {
+				return "", nil, err
+			}
+			if o.Descending {
+				col += " DESC"
+			}
+			keys = append(keys, col)
+		}
+		sb.WriteString(" ORDER BY " + strings.Join(keys, ", "))
+	}
+
+	args = append(args, builderLimit(params.Limit))
+	fmt.Fprintf(&sb, " LIMIT $%d", len(args))
+
+	return sb.String(), args, nil
+}
+
+// buildWhere compiles filters to a condition joined with AND, appending the
+// values to args. column validates and quotes a column reference.
+func buildWhere(filters []Filter, column func(name string) (string, error), args *[]interface{}) (string, error) {
+	conds := make([]string, 0, len(filters))
+	for _, f := range filters {
+		col, err := column(f.Column)
+		if err != nil {
+			return "", err
+		}
+		cond, err := buildCondition(col, f, args)
+		if err != nil {
+			return "", err
+		}
+		conds = append(conds, cond)
+	}
+	return strings.Join(conds, " AND "), nil
+}
+
+// buildCondition compiles a single filter on an already quoted column
+func buildCondition(col string, f Filter, args *[]interface{}) (string, error) {
+	op := strings.ToLower(strings.Join(strings.Fields(f.Op), " "))
+	bind := func(v interface{}) (string, error) {
+		switch v.(type) {
+		case string, float64, bool, int, int64:
+		default:
+			return "", fmt.Errorf("filter on %s: value must be a string, number or boolean", f.Column)
+		}
+		*args = append(*args, v)
+		return fmt.Sprintf("$%d", len(*args)), nil
+	}
+
+	switch op {
+	case "=", "!=", "<", "<=", ">", ">=":
+		p, err := bind(f.Value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s %s %s", col, op, p), nil
+	case "like", "ilike":
+		p, err := bind(f.Value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s::text %s %s", col, strings.ToUpper(op), p), nil
+	case "in", "not in":
+		values, ok := f.Value.([]interface{})
+		if !ok || len(values) == 0 {
+			return "", fmt.Errorf("filter on %s: %s requires a non-empty list of values", f.Column, op)
+		}
+		placeholders := make([]string, 0, len(values))
+		for _, v := range values {
+			p, err := bind(v)
+			if err != nil {
+				return "", err
+			}
+			placeholders = append(placeholders, p)
+		}
+		return fmt.Sprintf("%s %s (%s)", col, strings.ToUpper(op), strings.Join(placeholders, ", ")), nil
+	case "is null", "is not null":
+		return fmt.Sprintf("%s %s", col, strings.ToUpper(op)), nil
+	}
+	return "", fmt.Errorf("filter on %s: invalid operator %q, must be one of %s", f.Column, f.Op, strings.Join(FilterOperators, ", "))
+}
+
+// builderLimit clamps a requested row limit
+func builderLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultBuilderLimit
+	}
+	if limit > MaxBuilderLimit {
+		return MaxBuilderLimit
+	}
+	return limit
+}
+
+// AggregateFunctions are the aggregate functions accepted in structured aggregations
+var AggregateFunctions = []string{"count", "count_distinct", "sum", "avg", "min", "max"}
+
+// Aggregation is an aggregate function applied to a column
+type Aggregation struct {
+	Func string
+	// Column is the aggregated column, empty or "*" for count(*)
+	Column string
+	// Alias is the result column name, derived from the function and column when empty
+	Alias string
+}
+
+// AggregateParams describes a structured group-by query on a single table
+type AggregateParams struct {
+	Schema       string
+	Table        string
+	GroupBy      []string
+	Aggregations []Aggregation
+	Filters      []Filter
+	// Having filters reference aggregations by alias
+	Having []Filter
+	// OrderBy keys are group by columns or aggregation aliases
+	OrderBy []OrderBy
+	Limit   int
+}
+
+// BuildAggregate compiles a structured aggregation to SQL. Table and column
+// names are checked against the catalog and values are returned as bind arguments.
+func (d *DB) BuildAggregate(params AggregateParams) (string, []interface{}, error) {
+	if len(params.Aggregations) == 0 {
+		return "", nil, fmt.Errorf("at least one aggregation is required")
+	}
+	ref, err := d.lookupTable(params.Schema, params.Table)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// outputs maps the names usable in having and order by to their expressions
+	outputs := map[string]string{}
+	var selectList, groupBy []string
+	for _, name := range params.GroupBy {
+		col, err := ref.column(name)
+		if err != nil {
+			return "", nil, err
+		}
+		selectList = append(selectList, col)
+		groupBy = append(groupBy, col)
+		outputs[name] = col
+	}
+	for _, agg := range params.Aggregations {
+		expr, alias, err := aggregateExpr(ref, agg)
+		if err != nil {
+			return "", nil, err
+		}
+		if _, ok := outputs[alias]; ok {
+			return "", nil, fmt.Errorf("duplicate output column %q, set a distinct alias", alias)
+		}
+		outputs[alias] = expr
+		selectList = append(selectList, expr+" AS "+quoteIdent(alias))
+	}
+	output := func(name string) (string, error) {
+		expr, ok := outputs[name]
+		if !ok {
+			return "", fmt.Errorf("%q is neither a group by column nor an aggregation alias", name)
+		}
+		return expr, nil
+	}
+
+	var args []interface{}
+	where, err := buildWhere(params.Filters, ref.column, &args)
+	if err != nil {
+		return "", nil, err
+	}
+	having, err := buildWhere(params.Having, output, &args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "SELECT %s FROM %s", strings.Join(selectList, ", "), ref.quoted())
+	if where != "" {
+		sb.WriteString(" WHERE " + where)
+	}
+	if len(groupBy) > 0 {
+		sb.WriteString(" GROUP BY " + strings.Join(groupBy, ", "))
+	}
+	if having != "" {
+		sb.WriteString(" HAVING " + having)
+	}
+
+	if len(params.OrderBy) > 0 {
+		keys := make([]string, 0, len(params.OrderBy))
+		for _, o := range params.OrderBy {
+			expr, err := output(o.Column)
+			if err != nil {
+				return "", nil, err
+			}
+			if o.Descending {
+				expr += " DESC"
+			}
+			keys = append(keys, expr)
+		}
+		sb.WriteString(" ORDER BY " + strings.Join(keys, ", "))
+	}
+
+	args = append(args, builderLimit(params.Limit))
+	fmt.Fprintf(&sb, " LIMIT $%d", len(args))
+
+	return sb.String(), args, nil
+}
+
+// aggregateExpr returns the SQL expression and the output name of an aggregation
+func aggregateExpr(ref *tableRef, agg Aggregation) (string, string, error) {
+	fn := strings.ToLower(agg.Func)
+	alias := agg.Alias
+
+	if fn == "count" && (agg.Column == "" || agg.Column == "*") {
+		if alias == "" {
+			alias = "count"
+		}
+		return "count(*)", alias, nil
+	}
+
+	col, err := ref.column(agg.Column)
+	if err != nil {
+		return "", "", err
+	}
+	if alias == "" {
+		alias = fn + "_" + agg.Column
+	}
+	switch fn {
+	case "count_distinct":
+		return fmt.Sprintf("count(DISTINCT %s)", col), alias, nil
+	case "count", "sum", "avg", "min", "max":
+		return fmt.Sprintf("%s(%s)", fn, col), alias, nil
+	}
+	return "", "", fmt.Errorf("invalid aggregate function %q, must be one of %s", agg.Func, strings.Join(AggregateFunctions, ", "))
+}
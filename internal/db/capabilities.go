@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// versionFeatures maps the version-dependent features reported in
+// Capabilities to the server_version_num they appeared in
+var versionFeatures = map[string]int{
+	"generated_columns":      120000,
+	"drop_database_force":    130000,
+	"multirange_types":       140000,
+	"merge":                  150000,
+	"nulls_not_distinct":     150000,
+	"security_invoker_views": 150000,
+	"pg_stat_io":             160000,
+	"json_table":             170000,
+	"merge_returning":        170000,
+}
+
+// Capabilities are the server version, the installed extensions and the
+// features the tools and queries can rely on
+type Capabilities struct {
+	ServerVersion    string `json:"server_version"`
+	ServerVersionNum int    `json:"server_version_num"`
+	MajorVersion     int    `json:"major_version"`
+	// Extensions maps the installed extensions to their version
+	Extensions map[string]string `json:"extensions"`
+	// Features maps the version-dependent features to whether the server
+	// has them, e.g. merge for MERGE
+	Features map[string]bool `json:"features"`
+	ProbedAt time.Time       `json:"probed_at"`
+}
+
+// HasExtension reports whether an extension is installed
+func (c *Capabilities) HasExtension(name string) bool {
+	_, ok := c.Extensions[name]
+	return ok
+}
+
+// GetCapabilities probes the server version and the installed extensions
+func (d *DB) GetCapabilities(ctx context.Context) (*Capabilities, error) {
+	caps := &Capabilities{
+		Extensions: map[string]string{},
+		Features:   map[string]bool{},
+		ProbedAt:   time.Now(),
+	}
+
+	var version struct {
+		Version    string `db:"server_version"`
+		VersionNum int    `db:"server_version_num"`
+	}
+	query := `SELECT current_setting('server_version') AS server_version,
+		current_setting('server_version_num')::int AS server_version_num`
+	if err := d.conn.GetContext(ctx, &version, query); err != nil {
+		return nil, fmt.Errorf("failed to get server version: %w", err)
+	}
+	caps.ServerVersion = version.Version
+	caps.ServerVersionNum = version.VersionNum
+	caps.MajorVersion = version.VersionNum / 10000
+	for feature, since := range versionFeatures {
+		caps.Features[feature] = version.VersionNum >= since
+	}
+
+	var extensions []struct {
+		Name    string `db:"extname"`
+		Version string `db:"extversion"`
+	}
+	query = "SELECT extname, extversion FROM pg_catalog.pg_extension"
+	if err := d.conn.SelectContext(ctx, &extensions, query); err != nil {
+		return nil, fmt.Errorf("failed to list extensions: %w", err)
+	}
+	for _, ext := range extensions {
+		caps.Extensions[ext.Name] = ext.Version
+	}
+	return caps, nil
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"fmt"
+)
+
+const (
+	// localDatabaseSize is the size of the current database on this server
+	localDatabaseSize = "pg_database_size(current_database())"
+	// clusterDatabaseSize is the size of the current database on the Citus
+	// coordinator and all workers
+	clusterDatabaseSize = `(pg_database_size(current_database()) + COALESCE((SELECT sum(result::bigint)
+		FROM run_command_on_workers('SELECT pg_database_size(current_database())') WHERE success), 0))::bigint`
+	// localRelationSize is the total size of the relation c on this server
+	localRelationSize = "pg_total_relation_size(c.oid)"
+	// clusterRelationSize is the total size of the relation c including the
+	// shards of Citus tables on the workers
+	clusterRelationSize = `CASE WHEN c.oid IN (SELECT logicalrelid FROM pg_catalog.pg_dist_partition)
+		THEN citus_total_relation_size(c.oid) ELSE pg_total_relation_size(c.oid) END`
+)
+
+// DistributedTable is a Citus distributed or reference table
+type DistributedTable struct {
+	Table string `db:"table_name" json:"table"`
+	// Type is distributed, reference or local
+	Type               string  `db:"table_type" json:"type"`
+	DistributionColumn *string `db:"distribution_column" json:"distribution_column"`
+	ColocationID       int     `db:"colocation_id" json:"colocation_id"`
+	ShardCount         int     `db:"shard_count" json:"shard_count"`
+	Size               string  `db:"table_size" json:"size"`
+	Owner              string  `db:"table_owner" json:"owner"`
+}
+
+// WorkerNode is a Citus worker with the shards it holds
+type WorkerNode struct {
+	Name       string `db:"node_name" json:"name"`
+	Port       int    `db:"node_port" json:"port"`
+	Shards     int    `db:"shards" json:"shards"`
+	ShardBytes int64  `db:"shard_bytes" json:"shard_bytes"`
+	ShardSize  string `db:"shard_size" json:"shard_size"`
+}
+
+// CitusCluster is the distribution metadata of a Citus cluster
+type CitusCluster struct {
+	Tables  []DistributedTable `json:"tables"`
+	Workers []WorkerNode       `json:"workers"`
+	// ColocationGroups maps a colocation ID to its tables, which are sharded
+	// alike and can be joined on their distribution columns without moving data
+	ColocationGroups map[int][]string `json:"colocation_groups"`
+}
+
+// IsCitus reports whether the Citus extension is installed
+func (d *DB) IsCitus() (bool, error) {
+	return d.HasExtension("citus")
+}
+
+// sizeExpressions returns the SQL expressions of the database size and of the
+// size of relation c, covering the whole cluster when Citus is installed
+func (d *DB) sizeExpressions() (database, relation string, err error) {
+	citus, err := d.IsCitus()
+	if err != nil {
+		return "", "", err
+	}
+	if citus {
+		return clusterDatabaseSize, clusterRelationSize, nil
+	}
+	return localDatabaseSize, localRelationSize, nil
+}
+
+// GetCitusCluster returns the Citus tables with their distribution column,
+// shard count and colocation group, and the shards held by each worker
+func (d *DB) GetCitusCluster() (*CitusCluster, error) {
+	cluster := &CitusCluster{
+		Tables:           []DistributedTable{},
+		Workers:          []WorkerNode{},
+		ColocationGroups: map[int][]string{},
+	}
+
+	query := `SELECT table_name::text AS table_name, citus_table_type AS table_type,
+		NULLIF(distribution_column, '<none>') AS distribution_column,
+		colocation_id, shard_count, table_size, table_owner::text AS table_owner
+		FROM citus_tables
+		ORDER BY table_name::text`
+	if err := d.conn.Select(&cluster.Tables, query); err != nil {
+		return nil, fmt.Errorf("failed to list Citus tables: %w", err)
+	}
+	for _, table := range cluster.Tables {
+		if table.Type == "distributed" {
+			cluster.ColocationGroups[table.ColocationID] = append(cluster.ColocationGroups[table.ColocationID], table.Table)
+		}
+	}
+
+	query = `SELECT n.nodename AS node_name, n.nodeport AS node_port,
+		count(s.shardid) AS shards,
+		COALESCE(sum(s.shard_size), 0)::bigint AS shard_bytes,
+		pg_size_pretty(COALESCE(sum(s.shard_size), 0)) AS shard_size
+		FROM pg_catalog.pg_dist_node n
+		LEFT JOIN citus_shards s ON s.nodename = n.nodename AND s.nodeport = n.nodeport
+		WHERE n.isactive AND n.noderole = 'primary'
+		GROUP BY n.nodename, n.nodeport
+		ORDER BY n.nodename, n.nodeport`
+	if err := d.conn.Select(&cluster.Workers, query); err != nil {
+		return nil, fmt.Errorf("failed to list Citus workers: %w", err)
+	}
+
+	return cluster, nil
+}
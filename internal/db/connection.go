@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// SessionParameters are the session settings reported by GetConnectionInfo,
+// the ones that change how statements are parsed, run or formatted
+var SessionParameters = []string{
+	"application_name", "client_encoding", "DateStyle", "IntervalStyle", "TimeZone",
+	"search_path", "standard_conforming_strings", "transaction_isolation",
+	"default_transaction_read_only", "statement_timeout", "lock_timeout",
+	"idle_in_transaction_session_timeout", "work_mem", "max_parallel_workers_per_gather",
+}
+
+// ConnectionInfo describes the server and a session of the connection pool
+type ConnectionInfo struct {
+	ServerVersion string `db:"server_version" json:"server_version"`
+	// ServerVersionNum is the version as a number, e.g. 160002 for 16.2
+	ServerVersionNum int `db:"server_version_num" json:"server_version_num"`
+	// MajorVersion is ServerVersionNum / 10000
+	MajorVersion  int    `db:"-" json:"major_version"`
+	VersionString string `db:"version" json:"version"`
+	Database      string `db:"database" json:"database"`
+	// SessionUser is the user that logged in, CurrentUser the role privileges
+	// are checked against, which SET ROLE and SECURITY DEFINER functions change
+	SessionUser   string  `db:"session_user" json:"session_user"`
+	CurrentUser   string  `db:"current_user" json:"current_user"`
+	ServerAddress *string `db:"server_address" json:"server_address"`
+	ServerPort    *int    `db:"server_port" json:"server_port"`
+	BackendPID    int     `db:"backend_pid" json:"backend_pid"`
+	InRecovery    bool    `db:"in_recovery" json:"in_recovery"`
+	SSL           bool    `db:"ssl" json:"ssl"`
+	SSLVersion    *string `db:"ssl_version" json:"ssl_version,omitempty"`
+	SSLCipher     *string `db:"ssl_cipher" json:"ssl_cipher,omitempty"`
+	// Parameters maps SessionParameters to their values
+	Parameters map[string]string `db:"-" json:"parameters"`
+}
+
+// GetConnectionInfo returns the server version, the database, the user and
+// role, SSL and the session parameters of a pooled connection. Unix socket
+// connections have no server address and port.
+func (d *DB) GetConnectionInfo(ctx context.Context) (*ConnectionInfo, error) {
+	// The queries run on one connection, so SSL and the parameters describe
+	// the same session
+	conn, err := d.conn.Connx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a connection: %w", err)
+	}
+	defer conn.Close()
+
+	info := &ConnectionInfo{}
+	query := `SELECT current_setting('server_version') AS server_version,
+		current_setting('server_version_num')::int AS server_version_num,
+		version() AS version, current_database() AS database,
+		session_user, current_user,
+		host(inet_server_addr()) AS server_address, inet_server_port() AS server_port,
+		pg_backend_pid() AS backend_pid, pg_is_in_recovery() AS in_recovery,
+		COALESCE(ssl.ssl, false) AS ssl, ssl.version AS ssl_version, ssl.cipher AS ssl_cipher
+		FROM (SELECT 1) one
+		LEFT JOIN pg_catalog.pg_stat_ssl ssl ON ssl.pid = pg_backend_pid()`
+	if err := conn.GetContext(ctx, info, query); err != nil {
+		return nil, fmt.Errorf("failed to get connection info: %w", err)
+	}
+	info.MajorVersion = info.ServerVersionNum / 10000
+
+	var params []struct {
+		Name  string `db:"name"`
+		Value string `db:"setting"`
+	}
+	query = `SELECT name, current_setting(name) AS setting
+		FROM pg_catalog.pg_settings
+		WHERE name = ANY($1)
+		ORDER BY name`
+	if err := conn.SelectContext(ctx, &params, query, pq.Array(SessionParameters)); err != nil {
+		return nil, fmt.Errorf("failed to get session parameters: %w", err)
+	}
+	info.Parameters = make(map[string]string, len(params))
+	for _, p := range params {
+		info.Parameters[p.Name] = p.Value
+	}
+	return info, nil
+}
@@ -0,0 +1,202 @@
+package db
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/lib/pq"
+)
+
+// connParams are the settings of a connection string that identify a database
+type connParams map[string]string
+
+// socketFilePrefix is the file name prefix of PostgreSQL unix sockets
+const socketFilePrefix = ".s.PGSQL."
+
+// normalizeConnString converts a unix socket path, either the socket
+// directory or the socket file, to a key=value connection string. URLs and
+// key=value connection strings are returned unchanged.
+func normalizeConnString(s string) string {
+	if !strings.HasPrefix(s, "/") {
+		return s
+	}
+	dir, file := path.Split(s)
+	if port, ok := strings.CutPrefix(file, socketFilePrefix); ok {
+		return "host=" + quoteDSNValue(strings.TrimSuffix(dir, "/")) + " port=" + quoteDSNValue(port)
+	}
+	return "host=" + quoteDSNValue(s)
+}
+
+// quoteDSNValue quotes a key=value connection string value
+func quoteDSNValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
+
+// parseConnString parses a postgres:// or postgresql:// URL or a libpq
+// key=value connection string
+func parseConnString(s string) (connParams, error) {
+	dsn := s
+	if strings.HasPrefix(s, "postgres://") || strings.HasPrefix(s, "postgresql://") {
+		var err error
+		if dsn, err = pq.ParseURL(s); err != nil {
+			return nil, fmt.Errorf("failed to parse database URL: %w", err)
+		}
+	}
+	return parseKeyValueDSN(dsn)
+}
+
+// parseKeyValueDSN parses a libpq key=value connection string. Values may be
+// single-quoted, with backslash escaping quotes and backslashes.
+func parseKeyValueDSN(dsn string) (connParams, error) {
+	params := connParams{}
+	r := []rune(dsn)
+	i := 0
+	skipSpace := func() {
+		for i < len(r) && unicode.IsSpace(r[i]) {
+			i++
+		}
+	}
+
+	for {
+		skipSpace()
+		if i >= len(r) {
+			return params, nil
+		}
+
+		start := i
+		for i < len(r) && r[i] != '=' && !unicode.IsSpace(r[i]) {
+			i++
+		}
+		key := string(r[start:i])
+		skipSpace()
+		if i >= len(r) || r[i] != '=' {
+			return nil, fmt.Errorf("invalid connection string: missing \"=\" after %q", key)
+		}
+		i++
+		skipSpace()
+
+		var value strings.Builder
+		if i < len(r) && r[i] == '\'' {
+			i++
+			for {
+				if i >= len(r) {
+					return nil, fmt.Errorf("invalid connection string: unterminated quoted value of %q", key)
+				}
+				if r[i] == '\'' {
+					i++
+					break
+				}
+				if r[i] == '\\' && i+1 < len(r) {
+					i++
+				}
+				value.WriteRune(r[i])
+				i++
+			}
+		} else {
+			for i < len(r) && !unicode.IsSpace(r[i]) {
+				if r[i] == '\\' && i+1 < len(r) {
+					i++
+				}
+				value.WriteRune(r[i])
+				i++
+			}
+		}
+		params[key] = value.String()
+	}
+}
+
+// resourceBaseURL returns the postgres://user@host:port/dbname URL that
+// identifies the database in resource URIs. It never contains the password
+// or any other parameter, which may hold secrets such as sslpassword. Unix
+// socket hosts are left out, giving postgres:///dbname.
+func (p connParams) resourceBaseURL() string {
+	u := url.URL{Scheme: "postgres", Path: "/" + p.databaseName()}
+	if user := p["user"]; user != "" {
+		u.User = url.User(user)
+	}
+
+	hosts, _ := p.hosts()
+	u.Host = strings.Join(hosts, ",")
+
+	return u.String()
+}
+
+// databaseName returns the database name, which like in libpq defaults to
+// the user name
+func (p connParams) databaseName() string {
+	if dbname := p["dbname"]; dbname != "" {
+		return dbname
+	}
+	if user := p["user"]; user != "" {
+		return user
+	}
+	return "postgres"
+}
+
+// hosts returns the network hosts with their ports, formatted for a URL, and
+// the unix socket directories
+func (p connParams) hosts() (network, sockets []string) {
+	ports := strings.Split(p["port"], ",")
+	for i, host := range strings.Split(p["host"], ",") {
+		if host == "" {
+			continue
+		}
+		if strings.HasPrefix(host, "/") || strings.HasPrefix(host, "@") {
+			sockets = append(sockets, host)
+			continue
+		}
+		if strings.Contains(host, ":") {
+			// IPv6 address
+			host = "[" + host + "]"
+		}
+		port := ports[0]
+		if i < len(ports) {
+			port = ports[i]
+		}
+		if port != "" {
+			host += ":" + port
+		}
+		network = append(network, host)
+	}
+	return network, sockets
+}
+
+// url returns the connection settings as a postgres:// URL. Unix socket
+// directories are passed as the host query parameter.
+func (p connParams) url() string {
+	u := url.URL{Scheme: "postgres", Path: "/" + p.databaseName()}
+	if user := p["user"]; user != "" {
+		if password, ok := p["password"]; ok {
+			u.User = url.UserPassword(user, password)
+		} else {
+			u.User = url.User(user)
+		}
+	}
+
+	network, sockets := p.hosts()
+	u.Host = strings.Join(network, ",")
+
+	query := url.Values{}
+	for key, value := range p {
+		switch key {
+		case "user", "password", "dbname", "host":
+			continue
+		case "port":
+			if len(network) > 0 {
+				continue
+			}
+		}
+		query.Set(key, value)
+	}
+	if len(sockets) > 0 {
+		query.Set("host", strings.Join(sockets, ","))
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
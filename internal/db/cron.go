@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// MaxCronRuns is the largest number of runs listed for a pg_cron job
+const MaxCronRuns = 100
+
+// CronJob is a pg_cron job with the outcome of its last run
+type CronJob struct {
+	ID       int64   `db:"jobid" json:"id"`
+	Name     *string `db:"jobname" json:"name"`
+	Schedule string  `db:"schedule" json:"schedule"`
+	Command  string  `db:"command" json:"command"`
+	Database string  `db:"database" json:"database"`
+	Username string  `db:"username" json:"username"`
+	Active   bool    `db:"active" json:"active"`
+	// The run statistics cover the runs kept in cron.job_run_details
+	Runs     int64    `db:"runs" json:"runs"`
+	Failures int64    `db:"failures" json:"failures"`
+	LastRun  *CronRun `db:"-" json:"last_run"`
+}
+
+// CronRun is a run of a pg_cron job
+type CronRun struct {
+	RunID         int64      `db:"runid" json:"run_id"`
+	Status        string     `db:"status" json:"status"`
+	ReturnMessage *string    `db:"return_message" json:"return_message"`
+	StartTime     *time.Time `db:"start_time" json:"start_time"`
+	EndTime       *time.Time `db:"end_time" json:"end_time"`
+	DurationMS    *float64   `db:"duration_ms" json:"duration_ms"`
+}
+
+// ListCronJobs returns the pg_cron jobs with their run counts and last run
+func (d *DB) ListCronJobs() ([]CronJob, error) {
+	var rows []struct {
+		CronJob
+		LastRunID    sql.NullInt64   `db:"last_runid"`
+		LastStatus   sql.NullString  `db:"last_status"`
+		LastMessage  *string         `db:"last_return_message"`
+		LastStart    *time.Time      `db:"last_start_time"`
+		LastEnd      *time.Time      `db:"last_end_time"`
+		LastDuration sql.NullFloat64 `db:"last_duration_ms"`
+	}
+	query := `SELECT j.jobid, j.jobname, j.schedule, j.command, j.database, j.username, j.active,
+		COALESCE(stats.runs, 0) AS runs, COALESCE(stats.failures, 0) AS failures,
+		last.runid AS last_runid, last.status AS last_status,
+		last.return_message AS last_return_message,
+		last.start_time AS last_start_time, last.end_time AS last_end_time,
+		EXTRACT(EPOCH FROM last.end_time - last.start_time) * 1000 AS last_duration_ms
+		FROM cron.job j
+		LEFT JOIN LATERAL (SELECT count(*) AS runs, count(*) FILTER (WHERE r.status = 'failed') AS failures
+			FROM cron.job_run_details r WHERE r.jobid = j.jobid) stats ON true
+		LEFT JOIN LATERAL (SELECT r.runid, r.status, r.return_message, r.start_time, r.end_time
+			FROM cron.job_run_details r WHERE r.jobid = j.jobid
+			ORDER BY r.runid DESC LIMIT 1) last ON true
+		ORDER BY j.jobid`
+	if err := d.conn.Select(&rows, query); err != nil {
+		return nil, fmt.Errorf("failed to list pg_cron jobs: %w", err)
+	}
+
+	jobs := make([]CronJob, 0, len(rows))
+	for _, row := range rows {
+		job := row.CronJob
+		if row.LastRunID.Valid {
+			job.LastRun = &CronRun{
+				RunID:         row.LastRunID.Int64,
+				Status:        row.LastStatus.String,
+				ReturnMessage: row.LastMessage,
+				StartTime:     row.LastStart,
+				EndTime:       row.LastEnd,
+			}
+			if row.LastDuration.Valid {
+				job.LastRun.DurationMS = &row.LastDuration.Float64
+			}
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// CronJobRuns returns the most recent runs of a pg_cron job, newest first
+func (d *DB) CronJobRuns(jobID int64, limit int) ([]CronRun, error) {
+	if limit <= 0 || limit > MaxCronRuns {
+		limit = MaxCronRuns
+	}
+	runs := []CronRun{}
+	query := `SELECT runid, status, return_message, start_time, end_time,
+		EXTRACT(EPOCH FROM end_time - start_time) * 1000 AS duration_ms
+		FROM cron.job_run_details
+		WHERE jobid = $1
+		ORDER BY runid DESC
+		LIMIT $2`
+	if err := d.conn.Select(&runs, query, jobID, limit); err != nil {
+		return nil, fmt.Errorf("failed to list runs of pg_cron job %d: %w", jobID, err)
+	}
+	return runs, nil
+}
+
+// ScheduleCronJob schedules a command with pg_cron and returns the job ID.
+// A job of the same name is replaced, as cron.schedule does.
+func (d *DB) ScheduleCronJob(ctx context.Context, name, schedule, command string) (int64, error) {
+	var jobID int64
+	if err := d.conn.GetContext(ctx, &jobID, "SELECT cron.schedule($1, $2, $3)", name, schedule, command); err != nil {
+		return 0, fmt.Errorf("failed to schedule pg_cron job %s: %w", name, err)
+	}
+	return jobID, nil
+}
+
+// SetCronJobActive enables or disables a pg_cron job
+func (d *DB) SetCronJobActive(ctx context.Context, jobID int64, active bool) error {
+	var exists bool
+	if err := d.conn.GetContext(ctx, &exists, "SELECT EXISTS (SELECT 1 FROM cron.job WHERE jobid = $1)", jobID); err != nil {
+		return fmt.Errorf("failed to look up pg_cron job %d: %w", jobID, err)
+	}
+	if !exists {
+		return fmt.Errorf("pg_cron job %d does not exist", jobID)
+	}
+	if _, err := d.conn.ExecContext(ctx, "SELECT cron.alter_job(job_id := $1, active := $2)", jobID, active); err != nil {
+		return fmt.Errorf("failed to alter pg_cron job %d: %w", jobID, err)
+	}
+	return nil
+}
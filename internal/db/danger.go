@@ -0,0 +1,205 @@
+package db
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// dropStatement matches DROP statements with the kind of object dropped
+	dropStatement = regexp.MustCompile(`(?is)^DROP\s+((MATERIALIZED\s+VIEW|FOREIGN\s+TABLE|[A-Z]+))\b`)
+	// truncateStatement matches TRUNCATE statements
+	truncateStatement = regexp.MustCompile(`(?is)^TRUNCATE\b`)
+	// unfilteredStatement matches DELETE and UPDATE statements
+	unfilteredStatement = regexp.MustCompile(`(?is)^(DELETE|UPDATE)\b`)
+	// withStatement matches WITH queries
+	withStatement = regexp.MustCompile(`(?is)^WITH\b`)
+	// dataModifyingCTE matches the DELETE and UPDATE of a WITH query
+	dataModifyingCTE = regexp.MustCompile(`(?i)\b(DELETE|UPDATE)\b`)
+	// conditionWord matches the words of a WHERE condition
+	conditionWord = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_$]*`)
+	// constantWords are the words a condition referencing no column can have
+	constantWords = map[string]bool{"TRUE": true, "FALSE": true, "AND": true, "OR": true, "NOT": true, "NULL": true, "IS": true}
+	// currentOf matches the condition of a statement on the row of a cursor
+	currentOf = regexp.MustCompile(`(?i)^CURRENT\s+OF\b`)
+)
+
+// DestructiveReason returns why a statement is destructive, empty when it is
+// not: DROP, TRUNCATE, and DELETE or UPDATE without a top-level WHERE clause
+// or with a constant one, which apply to every row of the table. DELETE and
+// UPDATE whose rows cannot be verified, in WITH queries or WHERE CURRENT OF,
+// count as destructive too.
+func DestructiveReason(stmt string) string {
+	command := strings.TrimSpace(leadingComments.ReplaceAllString(maskSQL(stmt), ""))
+	switch {
+	case dropStatement.MatchString(command):
+		kind := strings.ToUpper(strings.Join(strings.Fields(dropStatement.FindStringSubmatch(command)[1]), " "))
+		return "DROP " + kind + " removes the object and its data"
+	case truncateStatement.MatchString(command):
+		return "TRUNCATE removes all rows of the table"
+	case unfilteredStatement.MatchString(command):
+		verb := strings.ToUpper(unfilteredStatement.FindString(command))
+		parts, ok := parseWrite(stmt)
+		switch {
+		case !ok:
+			return verb + " cannot be verified to filter the rows it writes"
+		case parts.where == "":
+			return verb + " without a WHERE clause applies to every row of the table"
+		case constantCondition(parts.where):
+			return verb + " with a constant WHERE condition applies to every row of the table or none"
+		}
+	case withStatement.MatchString(command) && dataModifyingCTE.MatchString(command):
+		return "WITH query with DELETE or UPDATE cannot be verified to filter the rows it writes"
+	}
+	return ""
+}
+
+// constantCondition reports whether a WHERE condition references no column,
+// e.g. true, 1 = 1 or false OR true
+func constantCondition(where string) bool {
+	if strings.Contains(where, `"`) {
+		return false
+	}
+	for _, word := range conditionWord.FindAllString(maskSQL(where), -1) {
+		if !constantWords[strings.ToUpper(word)] {
+			return false
+		}
+	}
+	return true
+}
+
+// writeParts are the parts of an UPDATE or DELETE statement selecting the
+// rows it writes
+type writeParts struct {
+	// command is UPDATE or DELETE
+	command string
+	// target is the written table with its optional ONLY and alias
+	target string
+	// from are the joined tables of UPDATE ... FROM and DELETE ... USING
+	from  string
+	where string
+}
+
+// parseWrite splits an UPDATE or DELETE statement into its parts. ok is false
+// for other statements and for the forms the rows cannot be selected from,
+// such as WITH queries and WHERE CURRENT OF.
+func parseWrite(stmt string) (parts writeParts, ok bool) {
+	stmt = strings.TrimRight(strings.TrimSpace(stmt), ";")
+	masked := maskSQL(stmt)
+	offset := len(masked) - len(strings.TrimLeft(leadingComments.ReplaceAllString(masked, ""), " \t\r\n"))
+	command := masked[offset:]
+
+	rest := offset
+	switch {
+	case keywordAt(command, 0, "DELETE"):
+		parts.command = "DELETE"
+		fromAt := topLevelKeyword(masked, offset, "FROM")
+		if fromAt < 0 {
+			return writeParts{}, false
+		}
+		rest = fromAt + len("FROM")
+		end := firstTopLevelKeyword(masked, rest, "USING", "WHERE", "RETURNING")
+		parts.target = stmt[rest:end]
+		if keywordAt(masked[end:], 0, "USING") {
+			usingEnd := firstTopLevelKeyword(masked, end+len("USING"), "WHERE", "RETURNING")
+			parts.from = stmt[end+len("USING") : usingEnd]
+			end = usingEnd
+		}
+		rest = end
+	case keywordAt(command, 0, "UPDATE"):
+		parts.command = "UPDATE"
+		setAt := topLevelKeyword(masked, offset, "SET")
+		if setAt < 0 {
+			return writeParts{}, false
+		}
+		parts.target = stmt[offset+len("UPDATE") : setAt]
+		end := firstTopLevelKeyword(masked, setAt, "FROM", "WHERE", "RETURNING")
+		if keywordAt(masked[end:], 0, "FROM") {
+			fromEnd := firstTopLevelKeyword(masked, end+len("FROM"), "WHERE", "RETURNING")
+			parts.from = stmt[end+len("FROM") : fromEnd]
+			end = fromEnd
+		}
+		rest = end
+	default:
+		return writeParts{}, false
+	}
+
+	if keywordAt(masked[rest:], 0, "WHERE") {
+		whereEnd := firstTopLevelKeyword(masked, rest+len("WHERE"), "RETURNING")
+		parts.where = strings.TrimSpace(stmt[rest+len("WHERE") : whereEnd])
+		if currentOf.MatchString(parts.where) {
+			return writeParts{}, false
+		}
+	}
+	parts.target = strings.TrimSpace(parts.target)
+	parts.from = strings.TrimSpace(parts.from)
+	return parts, true
+}
+
+// selectFrom returns the FROM and WHERE clauses selecting the written rows
+func (p writeParts) selectFrom() string {
+	clause := " FROM " + p.target
+	if p.from != "" {
+		clause += ", " + p.from
+	}
+	if p.where != "" {
+		clause += " WHERE " + p.where
+	}
+	return clause
+}
+
+// CountAffectedRowsQuery returns a SELECT count(*) query with the tables and
+// the WHERE clause of an UPDATE or DELETE statement, counting the rows it
+// would write. ok is false for other statements and for the forms it cannot
+// be derived from, such as WITH queries and WHERE CURRENT OF. With FROM or
+// USING joins the count is the number of joined rows, an upper bound.
+func CountAffectedRowsQuery(stmt string) (query string, ok bool) {
+	parts, ok := parseWrite(stmt)
+	if !ok {
+		return "", false
+	}
+	return "SELECT count(*) AS affected_rows" + parts.selectFrom(), true
+}
+
+// keywordAt reports whether the keyword starts at i of the masked SQL as a
+// whole word
+func keywordAt(masked string, i int, keyword string) bool {
+	if i+len(keyword) > len(masked) || !strings.EqualFold(masked[i:i+len(keyword)], keyword) {
+		return false
+	}
+	if i > 0 && isIdentChar(masked[i-1]) {
+		return false
+	}
+	return i+len(keyword) == len(masked) || !isIdentChar(masked[i+len(keyword)])
+}
+
+// topLevelKeyword returns the index of the first keyword at or after from
+// outside of parentheses, -1 if there is none
+func topLevelKeyword(masked string, from int, keyword string) int {
+	depth := 0
+	for i := from; i < len(masked); i++ {
+		switch masked[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		default:
+			if depth == 0 && keywordAt(masked, i, keyword) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// firstTopLevelKeyword returns the index of the first of the keywords at or
+// after from outside of parentheses, the end of the SQL if there is none
+func firstTopLevelKeyword(masked string, from int, keywords ...string) int {
+	first := len(masked)
+	for _, keyword := range keywords {
+		if i := topLevelKeyword(masked, from, keyword); i >= 0 && i < first {
+			first = i
+		}
+	}
+	return first
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDestructiveReason(t *testing.T) {
+	tests := []struct {
+		name string
+		stmt string
+		// want is a substring of the reason, empty for safe statements
+		want string
+	}{
+		{name: "select", stmt: "SELECT * FROM t"},
+		{name: "insert", stmt: "INSERT INTO t VALUES (1)"},
+		{name: "filtered delete", stmt: "DELETE FROM t WHERE id = 1"},
+		{name: "filtered update", stmt: "UPDATE t SET x = 1 WHERE id = $1"},
+		{name: "filtered quoted column", stmt: `DELETE FROM t WHERE "id" = 1`},
+		{name: "delete using with filter", stmt: "DELETE FROM t USING u WHERE t.id = u.id"},
+		{name: "update from with filter", stmt: "UPDATE t SET x = u.x FROM u WHERE t.id = u.id RETURNING t.id"},
+		{name: "drop table", stmt: "DROP TABLE t", want: "DROP TABLE"},
+		{name: "drop materialized view", stmt: "drop materialized  view v", want: "DROP MATERIALIZED VIEW"},
+		{name: "truncate", stmt: "TRUNCATE t", want: "TRUNCATE"},
+		{name: "leading comment", stmt: "-- clean up\nDELETE FROM t", want: "without a WHERE clause"},
+		{name: "unfiltered delete", stmt: "DELETE FROM t", want: "without a WHERE clause"},
+		{name: "unfiltered update", stmt: "UPDATE t SET x = 1 RETURNING *", want: "without a WHERE clause"},
+		{name: "where in subquery", stmt: "UPDATE t SET x = (SELECT y FROM u WHERE u.id = 1)", want: "without a WHERE clause"},
+		{name: "where in string", stmt: "UPDATE t SET note = 'WHERE'", want: "without a WHERE clause"},
+		{name: "where in comment", stmt: "DELETE FROM t -- WHERE id = 1", want: "without a WHERE clause"},
+		{name: "constant condition", stmt: "DELETE FROM t USING u WHERE false OR true", want: "constant WHERE condition"},
+		{name: "tautology", stmt: "UPDATE t SET x = 1 WHERE 1 = 1", want: "constant WHERE condition"},
+		{name: "current of", stmt: "DELETE FROM t WHERE CURRENT OF c", want: "cannot be verified"},
+		{name: "data-modifying cte", stmt: "WITH d AS (DELETE FROM t RETURNING 1) SELECT count(*) FROM d", want: "WITH query"},
+		{name: "updating cte", stmt: "with u as (update t set x = 1 where id = 1 returning *) select * from u", want: "WITH query"},
+		{name: "reading cte", stmt: "WITH r AS (SELECT 1) SELECT * FROM r"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DestructiveReason(tt.stmt)
+			if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package db
+
+import "context"
+
+//go:generate moq -rm -out dbmock/database.go -pkg dbmock . Database
+
+// Querier runs queries and builds the SQL of the structured query tools
+type Querier interface {
+	ExecuteReadOnlyQueryContext(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*QueryResult, error)
+	NewWorkspace(ctx context.Context) (*Workspace, error)
+	BeginTransaction(ctx context.Context) (*Transaction, error)
+	ExplainQuery(ctx context.Context, query string, hypotheticalIndexes []string) (*QueryPlan, error)
+	ReferencedTables(ctx context.Context, query string) ([]string, error)
+	SuggestIndexes(ctx context.Context, queries []string) ([]IndexAdvice, error)
+	VectorSearch(params VectorSearchParams) (*QueryResult, error)
+	BuildSelect(params SelectParams) (string, []interface{}, error)
+	BuildJoin(params JoinParams) (string, []interface{}, error)
+	BuildAggregate(params AggregateParams) (string, []interface{}, error)
+	BuildTimeBucket(params TimeBucketParams) (string, []interface{}, error)
+	BuildCreateTable(ctx context.Context, params CreateTableParams) (*DDL, error)
+	BuildAddColumn(ctx context.Context, params AddColumnParams) (*DDL, error)
+	BuildCreateIndex(ctx context.Context, params CreateIndexParams) (*DDL, error)
+	CheckDataQuality(ctx context.Context, params QualityParams) (*QualityReport, error)
+	GenerateTestData(ctx context.Context, schema, table string, rows int) (*TestDataResult, error)
+}
+
+// Introspector reads the schema, the statistics and the configuration
+type Introspector interface {
+	GetTableNames() ([]string, error)
+	GetTableSchema(tableName string) ([]TableColumn, error)
+	GetTableSchemas(tableNames []string) (map[string][]TableColumn, error)
+	GetAllTableSchemas(schema, pattern string) ([]TableSchema, error)
+	GetSchemaSummary(schema string) ([]TableSummary, error)
+	DescribeTable(ctx context.Context, schema, table string) (*TableDescription, error)
+	ResolveTable(ctx context.Context, name string) (*TableResolution, error)
+	SchemaFingerprint(ctx context.Context, tableNames []string) (string, error)
+	TableFingerprints(ctx context.Context) (map[string]string, error)
+	GetColumnSamples(schema string, maxSamples int) (map[string][]string, error)
+	FindTables(keyword string) ([]string, error)
+	GetQueryContext(tableNames []string) (*QueryContext, error)
+	GetOverview() (*DatabaseOverview, error)
+	DatabaseSize() (int64, error)
+	GetStorageReport() (*StorageReport, error)
+	GetMaintenanceStatus() (*MaintenanceStatus, error)
+	GetReplicationStatus() (*ReplicationStatus, error)
+	GetSettings(filter SettingsFilter) ([]Setting, error)
+	GetConnectionInfo(ctx context.Context) (*ConnectionInfo, error)
+	GetPrivileges(schema, table, role string) (*PrivilegeReport, error)
+	ListRoles(includeSystem bool) ([]Role, error)
+	TopStatements(limit int) ([]string, error)
+	TopQueries(orderBy string, limit int) ([]StatementStats, error)
+	GetErrorCounters() (*ErrorCounters, error)
+	HasExtension(name string) (bool, error)
+	GetCapabilities(ctx context.Context) (*Capabilities, error)
+	IsCitus() (bool, error)
+	GetCitusCluster() (*CitusCluster, error)
+	GetForeignData() (*ForeignData, error)
+	GetForeignTableServers() (map[string]string, error)
+	ListSpatialColumns() ([]SpatialColumn, error)
+	ListVectorColumns() ([]VectorColumn, error)
+	ListHypertables() ([]Hypertable, error)
+	ListChunks(schema, table string) ([]Chunk, error)
+	ListContinuousAggregates() ([]ContinuousAggregate, error)
+	ListCronJobs() ([]CronJob, error)
+	CronJobRuns(jobID int64, limit int) ([]CronRun, error)
+}
+
+// Database is everything the MCP server uses of a database. DB implements
+// it for PostgreSQL; dbmock.DatabaseMock lets tool handlers be tested
+// without a live database.
+type Database interface {
+	Querier
+	Introspector
+
+	Analyze(ctx context.Context, schema, table string) error
+	Vacuum(ctx context.Context, schema, table string, analyze bool) error
+	RunScript(ctx context.Context, statements []string, continueOnError bool) (*ScriptResult, error)
+	ListBackups(ctx context.Context) ([]Backup, error)
+	RestoreBackup(ctx context.Context, name string) (int64, error)
+	Dump(ctx context.Context, params DumpParams) (*DumpResult, error)
+	ScratchDatabaseURL(name string) (string, error)
+	CreateScratchDatabase(ctx context.Context, name, template string) error
+	DropScratchDatabase(ctx context.Context, name string) error
+	InstallDDLTrigger(ctx context.Context) error
+	UninstallDDLTrigger(ctx context.Context) error
+	DDLTriggerInstalled(ctx context.Context) (bool, error)
+	RestoreDump(ctx context.Context, pgRestore, path, database string) error
+	ScheduleCronJob(ctx context.Context, name, schedule, command string) (int64, error)
+	SetCronJobActive(ctx context.Context, jobID int64, active bool) error
+	EnsureLogicalSlot(slot, plugin string) error
+	ConsumeSlotChanges(slot string, limit int, options ...string) ([]SlotChange, error)
+	NewNotificationListener(handler func(Notification)) *NotificationListener
+
+	Ping(ctx context.Context) error
+	ResourceBaseURL() string
+	Close() error
+}
+
+var _ Database = (*DB)(nil)
@@ -1,43 +1,133 @@
 package db
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
-	"net/url"
+	"strings"
+	"time"
 
+	"github.com/iwanbk/postgres-mcp-go/internal/anonymize"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/lib/pq"
 )
 
 // DB represents a database connection
 type DB struct {
 	conn            *sqlx.DB
+	databaseURL     string
 	resourceBaseURL string
+	encode          encodeOptions
+	anonymizer      *anonymize.Anonymizer
+	pooler          Pooler
+	lazy            bool
+	timeZone        string
+	backupSchema    string
 }
 
-// New creates a new DB instance
-func New(databaseURL string) (*DB, error) {
-	// Parse the database URL to create the resource base URL
-	parsedURL, err := url.Parse(databaseURL)
+// Option configures a DB
+type Option func(*DB)
+
+// WithNumericFormat sets how numeric/decimal query results are returned
+func WithNumericFormat(format NumericFormat) Option {
+	return func(d *DB) {
+		d.encode.numeric = format
+	}
+}
+
+// WithByteaFormat sets how bytea query results are returned. maxBytes limits
+// the bytes encoded by the hex and base64 formats, zero means no limit.
+func WithByteaFormat(format ByteaFormat, maxBytes int) Option {
+	return func(d *DB) {
+		d.encode.bytea = format
+		d.encode.byteaMaxBytes = maxBytes
+	}
+}
+
+// WithGeoFormat sets how PostGIS geometry/geography query results are returned
+func WithGeoFormat(format GeoFormat) Option {
+	return func(d *DB) {
+		d.encode.geo = format
+	}
+}
+
+// WithAnonymizer replaces the values of the configured columns in query
+// results and sample values with deterministic fake values
+func WithAnonymizer(a *anonymize.Anonymizer) Option {
+	return func(d *DB) {
+		d.anonymizer = a
+	}
+}
+
+// WithLazyConnect creates the connection pool without connecting, so New
+// succeeds while the database is unreachable
+func WithLazyConnect() Option {
+	return func(d *DB) {
+		d.lazy = true
+	}
+}
+
+// newDB returns a DB with the default options
+func newDB() *DB {
+	return &DB{
+		encode: encodeOptions{
+			numeric: NumericFloat,
+			bytea:   ByteaBase64,
+			geo:     GeoJSON,
+		},
+		pooler: PoolerNone,
+	}
+}
+
+// New creates a new DB instance. databaseURL is a postgres:// URL, a libpq
+// key=value connection string or the path of a unix socket.
+func New(databaseURL string, opts ...Option) (*DB, error) {
+	databaseURL = normalizeConnString(databaseURL)
+
+	// Parse the connection string to create the password-free resource base URL
+	params, err := parseConnString(databaseURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse database URL: %w", err)
+		return nil, err
 	}
 
-	// Create resource base URL (postgres:// instead of postgresql://)
-	resourceBaseURL := *parsedURL
-	resourceBaseURL.Scheme = "postgres"
-	// Remove password for security
-	resourceBaseURL.User = url.User(parsedURL.User.Username())
+	d := newDB()
+	d.databaseURL = databaseURL
+	d.resourceBaseURL = params.resourceBaseURL()
+	for _, opt := range opts {
+		opt(d)
+	}
 
 	// Connect to the database
-	conn, err := sqlx.Connect("postgres", databaseURL)
+	connect := sqlx.Connect
+	if d.lazy {
+		connect = sqlx.Open
+	}
+	conn, err := connect("postgres", d.connectionString())
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	d.conn = conn
 
-	return &DB{
-		conn:            conn,
-		resourceBaseURL: resourceBaseURL.String(),
-	}, nil
+	return d, nil
+}
+
+// NewFromConn uses an existing connection pool of the lib/pq driver. The
+// resource URIs name the database as postgres:///dbname; the pooler option
+// does not apply, the pool is used as configured.
+func NewFromConn(conn *sql.DB, opts ...Option) (*DB, error) {
+	d := newDB()
+	d.conn = sqlx.NewDb(conn, "postgres")
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	var dbname string
+	if err := d.conn.Get(&dbname, "SELECT current_database()"); err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	d.resourceBaseURL = connParams{"dbname": dbname}.resourceBaseURL()
+
+	return d, nil
 }
 
 // Close closes the database connection
@@ -45,6 +135,14 @@ func (d *DB) Close() error {
 	return d.conn.Close()
 }
 
+// Ping checks that the database is reachable
+func (d *DB) Ping(ctx context.Context) error {
+	if err := d.conn.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return nil
+}
+
 // ResourceBaseURL returns the base URL for resources
 func (d *DB) ResourceBaseURL() string {
 	return d.resourceBaseURL
@@ -65,12 +163,17 @@ func (d *DB) GetTableNames() ([]string, error) {
 type TableColumn struct {
 	ColumnName string `db:"column_name"`
 	DataType   string `db:"data_type"`
+	// Remote is set for the columns of foreign tables, whose rows are fetched from a foreign server
+	Remote bool `db:"remote" json:",omitempty"`
 }
 
 // GetTableSchema returns the schema for a specific table
 func (d *DB) GetTableSchema(tableName string) ([]TableColumn, error) {
 	var columns []TableColumn
-	query := "SELECT column_name, data_type FROM information_schema.columns WHERE table_name = $1"
+	query := `SELECT c.column_name, c.data_type, t.table_type = 'FOREIGN' AS remote
+		FROM information_schema.columns c
+		JOIN information_schema.tables t USING (table_catalog, table_schema, table_name)
+		WHERE c.table_name = $1`
 	err := d.conn.Select(&columns, query, tableName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get table schema: %w", err)
@@ -78,50 +181,220 @@ func (d *DB) GetTableSchema(tableName string) ([]TableColumn, error) {
 	return columns, nil
 }
 
-// ExecuteReadOnlyQuery executes a read-only SQL query
-func (d *DB) ExecuteReadOnlyQuery(query string) ([]map[string]interface{}, error) {
+// GetTableSchemas returns the schemas of several tables in one catalog query,
+// keyed by table name. Tables without columns are left out.
+func (d *DB) GetTableSchemas(tableNames []string) (map[string][]TableColumn, error) {
+	var rows []struct {
+		TableName string `db:"table_name"`
+		TableColumn
+	}
+	query := `SELECT c.table_name, c.column_name, c.data_type, t.table_type = 'FOREIGN' AS remote
+		FROM information_schema.columns c
+		JOIN information_schema.tables t USING (table_catalog, table_schema, table_name)
+		WHERE c.table_name = ANY($1)
+		ORDER BY c.table_name, c.table_schema, c.ordinal_position`
+	if err := d.conn.Select(&rows, query, pq.Array(tableNames)); err != nil {
+		return nil, fmt.Errorf("failed to get table schemas: %w", err)
+	}
+	schemas := make(map[string][]TableColumn, len(tableNames))
+	for _, row := range rows {
+		schemas[row.TableName] = append(schemas[row.TableName], row.TableColumn)
+	}
+	return schemas, nil
+}
+
+// ExecuteReadOnlyQuery executes a read-only SQL query with optional bind parameters
+func (d *DB) ExecuteReadOnlyQuery(query string, args ...interface{}) ([]map[string]interface{}, error) {
+	return d.ExecuteReadOnlyQueryContext(context.Background(), query, args...)
+}
+
+// ExecuteReadOnlyQueryContext executes a read-only SQL query with optional bind
+// parameters. Canceling the context cancels the running query.
+func (d *DB) ExecuteReadOnlyQueryContext(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	result, err := d.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return result.Rows, nil
+}
+
+// ResultColumn is a column of a query result
+type ResultColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// QueryResult is the result of a query with its columns
+type QueryResult struct {
+	Columns []ResultColumn
+	Rows    []map[string]interface{}
+	// Notices are the notices and warnings raised while the query ran
+	Notices []Notice
+}
+
+// QueryContext executes a read-only SQL query like ExecuteReadOnlyQueryContext
+// and returns the result columns with the rows
+func (d *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*QueryResult, error) {
+	// The query runs on a connection of its own to collect its notices
+	conn, err := d.conn.Connx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a connection: %w", err)
+	}
+	defer conn.Close()
+	return d.readOnlyQuery(ctx, conn, false, query, args...)
+}
+
+// readOnlyQuery executes a query in a read-only transaction. The transaction
+// is committed when commit is set, which keeps changes to temporary tables.
+func (d *DB) readOnlyQuery(ctx context.Context, conn *sqlx.Conn, commit bool, query string, args ...interface{}) (*QueryResult, error) {
+	notices := &noticeCollector{}
+	if err := setNoticeHandler(conn, notices.handle); err != nil {
+		return nil, fmt.Errorf("failed to set notice handler: %w", err)
+	}
+	defer setNoticeHandler(conn, nil)
+
 	// Begin a read-only transaction
-	tx, err := d.conn.Beginx()
+	tx, err := conn.BeginTxx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
 	// Set transaction to read-only
-	_, err = tx.Exec("SET TRANSACTION READ ONLY")
+	_, err = tx.ExecContext(ctx, "SET TRANSACTION READ ONLY")
 	if err != nil {
 		tx.Rollback()
 		return nil, fmt.Errorf("failed to set transaction to read-only: %w", err)
 	}
 
+	timeZone := d.queryTimeZone(ctx)
+	if timeZone != "" {
+		if err := setTimeZone(ctx, tx, timeZone); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
 	// Execute the query
-	rows, err := tx.Queryx(query)
+	rows, err := tx.QueryxContext(ctx, query, args...)
 	if err != nil {
 		tx.Rollback()
 		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
 	defer rows.Close()
 
+	result, err := d.readRows(rows, timeZone, 0)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if commit {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		result.Notices = notices.collected()
+		return result, nil
+	}
+
+	// Rollback the transaction (since it's read-only, there's nothing to commit)
+	if err := tx.Rollback(); err != nil {
+		return nil, fmt.Errorf("failed to rollback transaction: %w", err)
+	}
+
+	result.Notices = notices.collected()
+	return result, nil
+}
+
+// readRows reads and encodes the rows of a query, at most limit rows unless
+// limit is zero. timestamptz values are returned in timeZone when set.
+func (d *DB) readRows(rows *sqlx.Rows, timeZone string, limit int) (*QueryResult, error) {
+	// The column types decide how the values are encoded
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get column types: %w", err)
+	}
+
+	// Look up the anonymized columns once per query
+	anonymized := make([]anonymize.Kind, len(columnTypes))
+	if d.anonymizer != nil {
+		for i, ct := range columnTypes {
+			anonymized[i], _ = d.anonymizer.Kind(ct.Name())
+		}
+	}
+
+	// Duplicate column names, e.g. of SELECT a.id, b.id, are made unique
+	names := uniqueColumnNames(columnNames(columnTypes))
+	result := &QueryResult{Columns: []ResultColumn{}, Rows: []map[string]interface{}{}}
+	for i, ct := range columnTypes {
+		if !d.encode.omitColumn(ct.DatabaseTypeName()) {
+			result.Columns = append(result.Columns, ResultColumn{Name: names[i], Type: strings.ToLower(ct.DatabaseTypeName())})
+		}
+	}
+
 	// Process the results
-	result := []map[string]interface{}{}
-	for rows.Next() {
-		row := make(map[string]interface{})
-		if err := rows.MapScan(row); err != nil {
-			tx.Rollback()
+	for (limit == 0 || len(result.Rows) < limit) && rows.Next() {
+		values, err := rows.SliceScan()
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
-		result = append(result, row)
+		row := make(map[string]interface{}, len(values))
+		for i, value := range values {
+			typeName := columnTypes[i].DatabaseTypeName()
+			if d.encode.omitColumn(typeName) {
+				continue
+			}
+			if t, ok := value.(time.Time); ok && timeZone != "" && typeName == "TIMESTAMPTZ" {
+				value = inTimeZone(t, timeZone)
+			}
+			encoded := d.encode.encodeValue(typeName, value)
+			if anonymized[i] != "" && encoded != nil {
+				encoded = d.anonymizer.Replace(anonymized[i], encoded)
+			}
+			row[names[i]] = encoded
+		}
+		result.Rows = append(result.Rows, row)
 	}
 
 	// Check for errors from iterating over rows
 	if err := rows.Err(); err != nil {
-		tx.Rollback()
 		return nil, fmt.Errorf("error iterating over rows: %w", err)
 	}
+	return result, nil
+}
 
-	// Rollback the transaction (since it's read-only, there's nothing to commit)
-	if err := tx.Rollback(); err != nil {
-		return nil, fmt.Errorf("failed to rollback transaction: %w", err)
+// columnNames returns the names of the result columns
+func columnNames(columnTypes []*sql.ColumnType) []string {
+	names := make([]string, len(columnTypes))
+	for i, ct := range columnTypes {
+		names[i] = ct.Name()
 	}
+	return names
+}
 
-	return result, nil
+// uniqueColumnNames returns the names of the result columns, with a _2, _3,
+// ... suffix added to repeated names so that no column is lost in the row maps
+func uniqueColumnNames(columns []string) []string {
+	taken := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		taken[column] = true
+	}
+
+	names := make([]string, len(columns))
+	seen := make(map[string]bool, len(columns))
+	for i, column := range columns {
+		name := column
+		if seen[name] {
+			for n := 2; ; n++ {
+				candidate := fmt.Sprintf("%s_%d", column, n)
+				if !taken[candidate] {
+					name = candidate
+					taken[candidate] = true
+					break
+				}
+			}
+		}
+		seen[name] = true
+		names[i] = name
+	}
+	return names
 }
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUniqueColumnNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []string
+		want    []string
+	}{
+		{name: "unique", columns: []string{"id", "name"}, want: []string{"id", "name"}},
+		{name: "repeated", columns: []string{"id", "id", "id"}, want: []string{"id", "id_2", "id_3"}},
+		{name: "suffix taken by a column", columns: []string{"id", "id", "id_2"}, want: []string{"id", "id_3", "id_2"}},
+		{name: "several repeated names", columns: []string{"a", "b", "a", "b"}, want: []string{"a", "b", "a_2", "b_2"}},
+		{name: "unnamed expressions", columns: []string{"?column?", "?column?"}, want: []string{"?column?", "?column?_2"}},
+		{name: "empty", columns: []string{}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := uniqueColumnNames(tt.columns); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
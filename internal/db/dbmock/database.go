@@ -0,0 +1,3500 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package dbmock
+
+import (
+	"context"
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"sync"
+)
+
+// Ensure, that DatabaseMock does implement db.Database.
+// If this is not the case, regenerate this file with moq.
+var _ db.Database = &DatabaseMock{}
+
+// DatabaseMock is a mock implementation of db.Database.
+//
+//	func TestSomethingThatUsesDatabase(t *testing.T) {
+//
+//		// make and configure a mocked db.Database
+//		mockedDatabase := &DatabaseMock{
+//			AnalyzeFunc: func(ctx context.Context, schema string, table string) error {
+//				panic("mock out the Analyze method")
+//			},
+//			BeginTransactionFunc: func(ctx context.Context) (*db.Transaction, error) {
+//				panic("mock out the BeginTransaction method")
+//			},
+//			BuildAddColumnFunc: func(ctx context.Context, params db.AddColumnParams) (*db.DDL, error) {
+//				panic("mock out the BuildAddColumn method")
+//			},
+//			BuildAggregateFunc: func(params db.AggregateParams) (string, []interface{}, error) {
+//				panic("mock out the BuildAggregate method")
+//			},
+//			BuildCreateIndexFunc: func(ctx context.Context, params db.CreateIndexParams) (*db.DDL, error) {
+//				panic("mock out the BuildCreateIndex method")
+//			},
+//			BuildCreateTableFunc: func(ctx context.Context, params db.CreateTableParams) (*db.DDL, error) {
+//				panic("mock out the BuildCreateTable method")
+//			},
+//			BuildJoinFunc: func(params db.JoinParams) (string, []interface{}, error) {
+//				panic("mock out the BuildJoin method")
+//			},
+//			BuildSelectFunc: func(params db.SelectParams) (string, []interface{}, error) {
+//				panic("mock out the BuildSelect method")
+//			},
+//			BuildTimeBucketFunc: func(params db.TimeBucketParams) (string, []interface{}, error) {
+//				panic("mock out the BuildTimeBucket method")
+//			},
+//			CheckDataQualityFunc: func(ctx context.Context, params db.QualityParams) (*db.QualityReport, error) {
+//				panic("mock out the CheckDataQuality method")
+//			},
+//			CloseFunc: func() error {
+//				panic("mock out the Close method")
+//			},
+//			ConsumeSlotChangesFunc: func(slot string, limit int, options ...string) ([]db.SlotChange, error) {
+//				panic("mock out the ConsumeSlotChanges method")
+//			},
+//			CreateScratchDatabaseFunc: func(ctx context.Context, name string, template string) error {
+//				panic("mock out the CreateScratchDatabase method")
+//			},
+//			CronJobRunsFunc: func(jobID int64, limit int) ([]db.CronRun, error) {
+//				panic("mock out the CronJobRuns method")
+//			},
+//			DDLTriggerInstalledFunc: func(ctx context.Context) (bool, error) {
+//				panic("mock out the DDLTriggerInstalled method")
+//			},
+//			DatabaseSizeFunc: func() (int64, error) {
+//				panic("mock out the DatabaseSize method")
+//			},
+//			DescribeTableFunc: func(ctx context.Context, schema string, table string) (*db.TableDescription, error) {
+//				panic("mock out the DescribeTable method")
+//			},
+//			DropScratchDatabaseFunc: func(ctx context.Context, name string) error {
+//				panic("mock out the DropScratchDatabase method")
+//			},
+//			DumpFunc: func(ctx context.Context, params db.DumpParams) (*db.DumpResult, error) {
+//				panic("mock out the Dump method")
+//			},
+//			EnsureLogicalSlotFunc: func(slot string, plugin string) error {
+//				panic("mock out the EnsureLogicalSlot method")
+//			},
+//			ExecuteReadOnlyQueryContextFunc: func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+//				panic("mock out the ExecuteReadOnlyQueryContext method")
+//			},
+//			ExplainQueryFunc: func(ctx context.Context, query string, hypotheticalIndexes []string) (*db.QueryPlan, error) {
+//				panic("mock out the ExplainQuery method")
+//			},
+//			FindTablesFunc: func(keyword string) ([]string, error) {
+//				panic("mock out the FindTables method")
+//			},
+//			GenerateTestDataFunc: func(ctx context.Context, schema string, table string, rows int) (*db.TestDataResult, error) {
+//				panic("mock out the GenerateTestData method")
+//			},
+//			GetAllTableSchemasFunc: func(schema string, pattern string) ([]db.TableSchema, error) {
+//				panic("mock out the GetAllTableSchemas method")
+//			},
+//			GetCapabilitiesFunc: func(ctx context.Context) (*db.Capabilities, error) {
+//				panic("mock out the GetCapabilities method")
+//			},
+//			GetCitusClusterFunc: func() (*db.CitusCluster, error) {
+//				panic("mock out the GetCitusCluster method")
+//			},
+//			GetColumnSamplesFunc: func(schema string, maxSamples int) (map[string][]string, error) {
+//				panic("mock out the GetColumnSamples method")
+//			},
+//			GetConnectionInfoFunc: func(ctx context.Context) (*db.ConnectionInfo, error) {
+//				panic("mock out the GetConnectionInfo method")
+//			},
+//			GetErrorCountersFunc: func() (*db.ErrorCounters, error) {
+//				panic("mock out the GetErrorCounters method")
+//			},
+//			GetForeignDataFunc: func() (*db.ForeignData, error) {
+//				panic("mock out the GetForeignData method")
+//			},
+//			GetForeignTableServersFunc: func() (map[string]string, error) {
+//				panic("mock out the GetForeignTableServers method")
+//			},
+//			GetMaintenanceStatusFunc: func() (*db.MaintenanceStatus, error) {
+//				panic("mock out the GetMaintenanceStatus method")
+//			},
+//			GetOverviewFunc: func() (*db.DatabaseOverview, error) {
+//				panic("mock out the GetOverview method")
+//			},
+//			GetPrivilegesFunc: func(schema string, table string, role string) (*db.PrivilegeReport, error) {
+//				panic("mock out the GetPrivileges method")
+//			},
+//			GetQueryContextFunc: func(tableNames []string) (*db.QueryContext, error) {
+//				panic("mock out the GetQueryContext method")
+//			},
+//			GetReplicationStatusFunc: func() (*db.ReplicationStatus, error) {
+//				panic("mock out the GetReplicationStatus method")
+//			},
+//			GetSchemaSummaryFunc: func(schema string) ([]db.TableSummary, error) {
+//				panic("mock out the GetSchemaSummary method")
+//			},
+//			GetSettingsFunc: func(filter db.SettingsFilter) ([]db.Setting, error) {
+//				panic("mock out the GetSettings method")
+//			},
+//			GetStorageReportFunc: func() (*db.StorageReport, error) {
+//				panic("mock out the GetStorageReport method")
+//			},
+//			GetTableNamesFunc: func() ([]string, error) {
+//				panic("mock out the GetTableNames method")
+//			},
+//			GetTableSchemaFunc: func(tableName string) ([]db.TableColumn, error) {
+//				panic("mock out the GetTableSchema method")
+//			},
+//			GetTableSchemasFunc: func(tableNames []string) (map[string][]db.TableColumn, error) {
+//				panic("mock out the GetTableSchemas method")
+//			},
+//			HasExtensionFunc: func(name string) (bool, error) {
+//				panic("mock out the HasExtension method")
+//			},
+//			InstallDDLTriggerFunc: func(ctx context.Context) error {
+//				panic("mock out the InstallDDLTrigger method")
+//			},
+//			IsCitusFunc: func() (bool, error) {
+//				panic("mock out the IsCitus method")
+//			},
+//			ListBackupsFunc: func(ctx context.Context) ([]db.Backup, error) {
+//				panic("mock out the ListBackups method")
+//			},
+//			ListChunksFunc: func(schema string, table string) ([]db.Chunk, error) {
+//				panic("mock out the ListChunks method")
+//			},
+//			ListContinuousAggregatesFunc: func() ([]db.ContinuousAggregate, error) {
+//				panic("mock out the ListContinuousAggregates method")
+//			},
+//			ListCronJobsFunc: func() ([]db.CronJob, error) {
+//				panic("mock out the ListCronJobs method")
+//			},
+//			ListHypertablesFunc: func() ([]db.Hypertable, error) {
+//				panic("mock out the ListHypertables method")
+//			},
+//			ListRolesFunc: func(includeSystem bool) ([]db.Role, error) {
+//				panic("mock out the ListRoles method")
+//			},
+//			ListSpatialColumnsFunc: func() ([]db.SpatialColumn, error) {
+//				panic("mock out the ListSpatialColumns method")
+//			},
+//			ListVectorColumnsFunc: func() ([]db.VectorColumn, error) {
+//				panic("mock out the ListVectorColumns method")
+//			},
+//			NewNotificationListenerFunc: func(handler func(db.Notification)) *db.NotificationListener {
+//				panic("mock out the NewNotificationListener method")
+//			},
+//			NewWorkspaceFunc: func(ctx context.Context) (*db.Workspace, error) {
+//				panic("mock out the NewWorkspace method")
+//			},
+//			PingFunc: func(ctx context.Context) error {
+//				panic("mock out the Ping method")
+//			},
+//			QueryContextFunc: func(ctx context.Context, query string, args ...interface{}) (*db.QueryResult, error) {
+//				panic("mock out the QueryContext method")
+//			},
+//			ReferencedTablesFunc: func(ctx context.Context, query string) ([]string, error) {
+//				panic("mock out the ReferencedTables method")
+//			},
+//			ResolveTableFunc: func(ctx context.Context, name string) (*db.TableResolution, error) {
+//				panic("mock out the ResolveTable method")
+//			},
+//			ResourceBaseURLFunc: func() string {
+//				panic("mock out the ResourceBaseURL method")
+//			},
+//			RestoreBackupFunc: func(ctx context.Context, name string) (int64, error) {
+//				panic("mock out the RestoreBackup method")
+//			},
+//			RestoreDumpFunc: func(ctx context.Context, pgRestore string, path string, database string) error {
+//				panic("mock out the RestoreDump method")
+//			},
+//			RunScriptFunc: func(ctx context.Context, statements []string, continueOnError bool) (*db.ScriptResult, error) {
+//				panic("mock out the RunScript method")
+//			},
+//			ScheduleCronJobFunc: func(ctx context.Context, name string, schedule string, command string) (int64, error) {
+//				panic("mock out the ScheduleCronJob method")
+//			},
+//			SchemaFingerprintFunc: func(ctx context.Context, tableNames []string) (string, error) {
+//				panic("mock out the SchemaFingerprint method")
+//			},
+//			ScratchDatabaseURLFunc: func(name string) (string, error) {
+//				panic("mock out the ScratchDatabaseURL method")
+//			},
+//			SetCronJobActiveFunc: func(ctx context.Context, jobID int64, active bool) error {
+//				panic("mock out the SetCronJobActive method")
+//			},
+//			SuggestIndexesFunc: func(ctx context.Context, queries []string) ([]db.IndexAdvice, error) {
+//				panic("mock out the SuggestIndexes method")
+//			},
+//			TableFingerprintsFunc: func(ctx context.Context) (map[string]string, error) {
+//				panic("mock out the TableFingerprints method")
+//			},
+//			TopQueriesFunc: func(orderBy string, limit int) ([]db.StatementStats, error) {
+//				panic("mock out the TopQueries method")
+//			},
+//			TopStatementsFunc: func(limit int) ([]string, error) {
+//				panic("mock out the TopStatements method")
+//			},
+//			UninstallDDLTriggerFunc: func(ctx context.Context) error {
+//				panic("mock out the UninstallDDLTrigger method")
+//			},
+//			VacuumFunc: func(ctx context.Context, schema string, table string, analyze bool) error {
+//				panic("mock out the Vacuum method")
+//			},
+//			VectorSearchFunc: func(params db.VectorSearchParams) (*db.QueryResult, error) {
+//				panic("mock out the VectorSearch method")
+//			},
+//		}
+//
+//		// use mockedDatabase in code that requires db.Database
+//		// and then make assertions.
+//
+//	}
+type DatabaseMock struct {
+	// AnalyzeFunc mocks the Analyze method.
+	AnalyzeFunc func(ctx context.Context, schema string, table string) error
+
+	// BeginTransactionFunc mocks the BeginTransaction method.
+	BeginTransactionFunc func(ctx context.Context) (*db.Transaction, error)
+
+	// BuildAddColumnFunc mocks the BuildAddColumn method.
+	BuildAddColumnFunc func(ctx context.Context, params db.AddColumnParams) (*db.DDL, error)
+
+	// BuildAggregateFunc mocks the BuildAggregate method.
+	BuildAggregateFunc func(params db.AggregateParams) (string, []interface{}, error)
+
+	// BuildCreateIndexFunc mocks the BuildCreateIndex method.
+	BuildCreateIndexFunc func(ctx context.Context, params db.CreateIndexParams) (*db.DDL, error)
+
+	// BuildCreateTableFunc mocks the BuildCreateTable method.
+	BuildCreateTableFunc func(ctx context.Context, params db.CreateTableParams) (*db.DDL, error)
+
+	// BuildJoinFunc mocks the BuildJoin method.
+	BuildJoinFunc func(params db.JoinParams) (string, []interface{}, error)
+
+	// BuildSelectFunc mocks the BuildSelect method.
+	BuildSelectFunc func(params db.SelectParams) (string, []interface{}, error)
+
+	// BuildTimeBucketFunc mocks the BuildTimeBucket method.
+	BuildTimeBucketFunc func(params db.TimeBucketParams) (string, []interface{}, error)
+
+	// CheckDataQualityFunc mocks the CheckDataQuality method.
+	CheckDataQualityFunc func(ctx context.Context, params db.QualityParams) (*db.QualityReport, error)
+
+	// CloseFunc mocks the Close method.
+	CloseFunc func() error
+
+	// ConsumeSlotChangesFunc mocks the ConsumeSlotChanges method.
+	ConsumeSlotChangesFunc func(slot string, limit int, options ...string) ([]db.SlotChange, error)
+
+	// CreateScratchDatabaseFunc mocks the CreateScratchDatabase method.
+	CreateScratchDatabaseFunc func(ctx context.Context, name string, template string) error
+
+	// CronJobRunsFunc mocks the CronJobRuns method.
+	CronJobRunsFunc func(jobID int64, limit int) ([]db.CronRun, error)
+
+	// DDLTriggerInstalledFunc mocks the DDLTriggerInstalled method.
+	DDLTriggerInstalledFunc func(ctx context.Context) (bool, error)
+
+	// DatabaseSizeFunc mocks the DatabaseSize method.
+	DatabaseSizeFunc func() (int64, error)
+
+	// DescribeTableFunc mocks the DescribeTable method.
+	DescribeTableFunc func(ctx context.Context, schema string, table string) (*db.TableDescription, error)
+
+	// DropScratchDatabaseFunc mocks the DropScratchDatabase method.
+	DropScratchDatabaseFunc func(ctx context.Context, name string) error
+
+	// DumpFunc mocks the Dump method.
+	DumpFunc func(ctx context.Context, params db.DumpParams) (*db.DumpResult, error)
+
+	// EnsureLogicalSlotFunc mocks the EnsureLogicalSlot method.
+	EnsureLogicalSlotFunc func(slot string, plugin string) error
+
+	// ExecuteReadOnlyQueryContextFunc mocks the ExecuteReadOnlyQueryContext method.
+	ExecuteReadOnlyQueryContextFunc func(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error)
+
+	// ExplainQueryFunc mocks the ExplainQuery method.
+	ExplainQueryFunc func(ctx context.Context, query string, hypotheticalIndexes []string) (*db.QueryPlan, error)
+
+	// FindTablesFunc mocks the FindTables method.
+	FindTablesFunc func(keyword string) ([]string, error)
+
+	// GenerateTestDataFunc mocks the GenerateTestData method.
+	GenerateTestDataFunc func(ctx context.Context, schema string, table string, rows int) (*db.TestDataResult, error)
+
+	// GetAllTableSchemasFunc mocks the GetAllTableSchemas method.
+	GetAllTableSchemasFunc func(schema string, pattern string) ([]db.TableSchema, error)
+
+	// GetCapabilitiesFunc mocks the GetCapabilities method.
+	GetCapabilitiesFunc func(ctx context.Context) (*db.Capabilities, error)
+
+	// GetCitusClusterFunc mocks the GetCitusCluster method.
+	GetCitusClusterFunc func() (*db.CitusCluster, error)
+
+	// GetColumnSamplesFunc mocks the GetColumnSamples method.
+	GetColumnSamplesFunc func(schema string, maxSamples int) (map[string][]string, error)
+
+	// GetConnectionInfoFunc mocks the GetConnectionInfo method.
+	GetConnectionInfoFunc func(ctx context.Context) (*db.ConnectionInfo, error)
+
+	// GetErrorCountersFunc mocks the GetErrorCounters method.
+	GetErrorCountersFunc func() (*db.ErrorCounters, error)
+
+	// GetForeignDataFunc mocks the GetForeignData method.
+	GetForeignDataFunc func() (*db.ForeignData, error)
+
+	// GetForeignTableServersFunc mocks the GetForeignTableServers method.
+	GetForeignTableServersFunc func() (map[string]string, error)
+
+	// GetMaintenanceStatusFunc mocks the GetMaintenanceStatus method.
+	GetMaintenanceStatusFunc func() (*db.MaintenanceStatus, error)
+
+	// GetOverviewFunc mocks the GetOverview method.
+	GetOverviewFunc func() (*db.DatabaseOverview, error)
+
+	// GetPrivilegesFunc mocks the GetPrivileges method.
+	GetPrivilegesFunc func(schema string, table string, role string) (*db.PrivilegeReport, error)
+
+	// GetQueryContextFunc mocks the GetQueryContext method.
+	GetQueryContextFunc func(tableNames []string) (*db.QueryContext, error)
+
+	// GetReplicationStatusFunc mocks the GetReplicationStatus method.
+	GetReplicationStatusFunc func() (*db.ReplicationStatus, error)
+
+	// GetSchemaSummaryFunc mocks the GetSchemaSummary method.
+	GetSchemaSummaryFunc func(schema string) ([]db.TableSummary, error)
+
+	// GetSettingsFunc mocks the GetSettings method.
+	GetSettingsFunc func(filter db.SettingsFilter) ([]db.Setting, error)
+
+	// GetStorageReportFunc mocks the GetStorageReport method.
+	GetStorageReportFunc func() (*db.StorageReport, error)
+
+	// GetTableNamesFunc mocks the GetTableNames method.
+	GetTableNamesFunc func() ([]string, error)
+
+	// GetTableSchemaFunc mocks the GetTableSchema method.
+	GetTableSchemaFunc func(tableName string) ([]db.TableColumn, error)
+
+	// GetTableSchemasFunc mocks the GetTableSchemas method.
+	GetTableSchemasFunc func(tableNames []string) (map[string][]db.TableColumn, error)
+
+	// HasExtensionFunc mocks the HasExtension method.
+	HasExtensionFunc func(name string) (bool, error)
+
+	// InstallDDLTriggerFunc mocks the InstallDDLTrigger method.
+	InstallDDLTriggerFunc func(ctx context.Context) error
+
+	// IsCitusFunc mocks the IsCitus method.
+	IsCitusFunc func() (bool, error)
+
+	// ListBackupsFunc mocks the ListBackups method.
+	ListBackupsFunc func(ctx context.Context) ([]db.Backup, error)
+
+	// ListChunksFunc mocks the ListChunks method.
+	ListChunksFunc func(schema string, table string) ([]db.Chunk, error)
+
+	// ListContinuousAggregatesFunc mocks the ListContinuousAggregates method.
+	ListContinuousAggregatesFunc func() ([]db.ContinuousAggregate, error)
+
+	// ListCronJobsFunc mocks the ListCronJobs method.
+	ListCronJobsFunc func() ([]db.CronJob, error)
+
+	// ListHypertablesFunc mocks the ListHypertables method.
+	ListHypertablesFunc func() ([]db.Hypertable, error)
+
+	// ListRolesFunc mocks the ListRoles method.
+	ListRolesFunc func(includeSystem bool) ([]db.Role, error)
+
+	// ListSpatialColumnsFunc mocks the ListSpatialColumns method.
+	ListSpatialColumnsFunc func() ([]db.SpatialColumn, error)
+
+	// ListVectorColumnsFunc mocks the ListVectorColumns method.
+	ListVectorColumnsFunc func() ([]db.VectorColumn, error)
+
+	// NewNotificationListenerFunc mocks the NewNotificationListener method.
+	NewNotificationListenerFunc func(handler func(db.Notification)) *db.NotificationListener
+
+	// NewWorkspaceFunc mocks the NewWorkspace method.
+	NewWorkspaceFunc func(ctx context.Context) (*db.Workspace, error)
+
+	// PingFunc mocks the Ping method.
+	PingFunc func(ctx context.Context) error
+
+	// QueryContextFunc mocks the QueryContext method.
+	QueryContextFunc func(ctx context.Context, query string, args ...interface{}) (*db.QueryResult, error)
+
+	// ReferencedTablesFunc mocks the ReferencedTables method.
+	ReferencedTablesFunc func(ctx context.Context, query string) ([]string, error)
+
+	// ResolveTableFunc mocks the ResolveTable method.
+	ResolveTableFunc func(ctx context.Context, name string) (*db.TableResolution, error)
+
+	// ResourceBaseURLFunc mocks the ResourceBaseURL method.
+	ResourceBaseURLFunc func() string
+
+	// RestoreBackupFunc mocks the RestoreBackup method.
+	RestoreBackupFunc func(ctx context.Context, name string) (int64, error)
+
+	// RestoreDumpFunc mocks the RestoreDump method.
+	RestoreDumpFunc func(ctx context.Context, pgRestore string, path string, database string) error
+
+	// RunScriptFunc mocks the RunScript method.
+	RunScriptFunc func(ctx context.Context, statements []string, continueOnError bool) (*db.ScriptResult, error)
+
+	// ScheduleCronJobFunc mocks the ScheduleCronJob method.
+	ScheduleCronJobFunc func(ctx context.Context, name string, schedule string, command string) (int64, error)
+
+	// SchemaFingerprintFunc mocks the SchemaFingerprint method.
+	SchemaFingerprintFunc func(ctx context.Context, tableNames []string) (string, error)
+
+	// ScratchDatabaseURLFunc mocks the ScratchDatabaseURL method.
+	ScratchDatabaseURLFunc func(name string) (string, error)
+
+	// SetCronJobActiveFunc mocks the SetCronJobActive method.
+	SetCronJobActiveFunc func(ctx context.Context, jobID int64, active bool) error
+
+	// SuggestIndexesFunc mocks the SuggestIndexes method.
+	SuggestIndexesFunc func(ctx context.Context, queries []string) ([]db.IndexAdvice, error)
+
+	// TableFingerprintsFunc mocks the TableFingerprints method.
+	TableFingerprintsFunc func(ctx context.Context) (map[string]string, error)
+
+	// TopQueriesFunc mocks the TopQueries method.
+	TopQueriesFunc func(orderBy string, limit int) ([]db.StatementStats, error)
+
+	// TopStatementsFunc mocks the TopStatements method.
+	TopStatementsFunc func(limit int) ([]string, error)
+
+	// UninstallDDLTriggerFunc mocks the UninstallDDLTrigger method.
+	UninstallDDLTriggerFunc func(ctx context.Context) error
+
+	// VacuumFunc mocks the Vacuum method.
+	VacuumFunc func(ctx context.Context, schema string, table string, analyze bool) error
+
+	// VectorSearchFunc mocks the VectorSearch method.
+	VectorSearchFunc func(params db.VectorSearchParams) (*db.QueryResult, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Analyze holds details about calls to the Analyze method.
+		Analyze []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Schema is the schema argument value.
+			Schema string
+			// Table is the table argument value.
+			Table string
+		}
+		// BeginTransaction holds details about calls to the BeginTransaction method.
+		BeginTransaction []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// BuildAddColumn holds details about calls to the BuildAddColumn method.
+		BuildAddColumn []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Params is the params argument value.
+			Params db.AddColumnParams
+		}
+		// BuildAggregate holds details about calls to the BuildAggregate method.
+		BuildAggregate []struct {
+			// Params is the params argument value.
+			Params db.AggregateParams
+		}
+		// BuildCreateIndex holds details about calls to the BuildCreateIndex method.
+		BuildCreateIndex []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Params is the params argument value.
+			Params db.CreateIndexParams
+		}
+		// BuildCreateTable holds details about calls to the BuildCreateTable method.
+		BuildCreateTable []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Params is the params argument value.
+			Params db.CreateTableParams
+		}
+		// BuildJoin holds details about calls to the BuildJoin method.
+		BuildJoin []struct {
+			// Params is the params argument value.
+			Params db.JoinParams
+		}
+		// BuildSelect holds details about calls to the BuildSelect method.
+		BuildSelect []struct {
+			// Params is the params argument value.
+			Params db.SelectParams
+		}
+		// BuildTimeBucket holds details about calls to the BuildTimeBucket method.
+		BuildTimeBucket []struct {
+			// Params is the params argument value.
+			Params db.TimeBucketParams
+		}
+		// CheckDataQuality holds details about calls to the CheckDataQuality method.
+		CheckDataQuality []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Params is the params argument value.
+			Params db.QualityParams
+		}
+		// Close holds details about calls to the Close method.
+		Close []struct {
+		}
+		// ConsumeSlotChanges holds details about calls to the ConsumeSlotChanges method.
+		ConsumeSlotChanges []struct {
+			// Slot is the slot argument value.
+			Slot string
+			// Limit is the limit argument value.
+			Limit int
+			// Options is the options argument value.
+			Options []string
+		}
+		// CreateScratchDatabase holds details about calls to the CreateScratchDatabase method.
+		CreateScratchDatabase []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Name is the name argument value.
+			Name string
+			// Template is the template argument value.
+			Template string
+		}
+		// CronJobRuns holds details about calls to the CronJobRuns method.
+		CronJobRuns []struct {
+			// JobID is the jobID argument value.
+			JobID int64
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// DDLTriggerInstalled holds details about calls to the DDLTriggerInstalled method.
+		DDLTriggerInstalled []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// DatabaseSize holds details about calls to the DatabaseSize method.
+		DatabaseSize []struct {
+		}
+		// DescribeTable holds details about calls to the DescribeTable method.
+		DescribeTable []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Schema is the schema argument value.
+			Schema string
+			// Table is the table argument value.
+			Table string
+		}
+		// DropScratchDatabase holds details about calls to the DropScratchDatabase method.
+		DropScratchDatabase []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Name is the name argument value.
+			Name string
+		}
+		// Dump holds details about calls to the Dump method.
+		Dump []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Params is the params argument value.
+			Params db.DumpParams
+		}
+		// EnsureLogicalSlot holds details about calls to the EnsureLogicalSlot method.
+		EnsureLogicalSlot []struct {
+			// Slot is the slot argument value.
+			Slot string
+			// Plugin is the plugin argument value.
+			Plugin string
+		}
+		// ExecuteReadOnlyQueryContext holds details about calls to the ExecuteReadOnlyQueryContext method.
+		ExecuteReadOnlyQueryContext []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Query is the query argument value.
+			Query string
+			// Args is the args argument value.
+			Args []interface{}
+		}
+		// ExplainQuery holds details about calls to the ExplainQuery method.
+		ExplainQuery []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Query is the query argument value.
+			Query string
+			// HypotheticalIndexes is the hypotheticalIndexes argument value.
+			HypotheticalIndexes []string
+		}
+		// FindTables holds details about calls to the FindTables method.
+		FindTables []struct {
+			// Keyword is the keyword argument value.
+			Keyword string
+		}
+		// GenerateTestData holds details about calls to the GenerateTestData method.
+		GenerateTestData []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Schema is the schema argument value.
+			Schema string
+			// Table is the table argument value.
+			Table string
+			// Rows is the rows argument value.
+			Rows int
+		}
+		// GetAllTableSchemas holds details about calls to the GetAllTableSchemas method.
+		GetAllTableSchemas []struct {
+			// Schema is the schema argument value.
+			Schema string
+			// Pattern is the pattern argument value.
+			Pattern string
+		}
+		// GetCapabilities holds details about calls to the GetCapabilities method.
+		GetCapabilities []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// GetCitusCluster holds details about calls to the GetCitusCluster method.
+		GetCitusCluster []struct {
+		}
+		// GetColumnSamples holds details about calls to the GetColumnSamples method.
+		GetColumnSamples []struct {
+			// Schema is the schema argument value.
+			Schema string
+			// MaxSamples is the maxSamples argument value.
+			MaxSamples int
+		}
+		// GetConnectionInfo holds details about calls to the GetConnectionInfo method.
+		GetConnectionInfo []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// GetErrorCounters holds details about calls to the GetErrorCounters method.
+		GetErrorCounters []struct {
+		}
+		// GetForeignData holds details about calls to the GetForeignData method.
+		GetForeignData []struct {
+		}
+		// GetForeignTableServers holds details about calls to the GetForeignTableServers method.
+		GetForeignTableServers []struct {
+		}
+		// GetMaintenanceStatus holds details about calls to the GetMaintenanceStatus method.
+		GetMaintenanceStatus []struct {
+		}
+		// GetOverview holds details about calls to the GetOverview method.
+		GetOverview []struct {
+		}
+		// GetPrivileges holds details about calls to the GetPrivileges method.
+		GetPrivileges []struct {
+			// Schema is the schema argument value.
+			Schema string
+			// Table is the table argument value.
+			Table string
+			// Role is the role argument value.
+			Role string
+		}
+		// GetQueryContext holds details about calls to the GetQueryContext method.
+		GetQueryContext []struct {
+			// TableNames is the tableNames argument value.
+			TableNames []string
+		}
+		// GetReplicationStatus holds details about calls to the GetReplicationStatus method.
+		GetReplicationStatus []struct {
+		}
+		// GetSchemaSummary holds details about calls to the GetSchemaSummary method.
+		GetSchemaSummary []struct {
+			// Schema is the schema argument value.
+			Schema string
+		}
+		// GetSettings holds details about calls to the GetSettings method.
+		GetSettings []struct {
+			// Filter is the filter argument value.
+			Filter db.SettingsFilter
+		}
+		// GetStorageReport holds details about calls to the GetStorageReport method.
+		GetStorageReport []struct {
+		}
+		// GetTableNames holds details about calls to the GetTableNames method.
+		GetTableNames []struct {
+		}
+		// GetTableSchema holds details about calls to the GetTableSchema method.
+		GetTableSchema []struct {
+			// TableName is the tableName argument value.
+			TableName string
+		}
+		// GetTableSchemas holds details about calls to the GetTableSchemas method.
+		GetTableSchemas []struct {
+			// TableNames is the tableNames argument value.
+			TableNames []string
+		}
+		// HasExtension holds details about calls to the HasExtension method.
+		HasExtension []struct {
+			// Name is the name argument value.
+			Name string
+		}
+		// InstallDDLTrigger holds details about calls to the InstallDDLTrigger method.
+		InstallDDLTrigger []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// IsCitus holds details about calls to the IsCitus method.
+		IsCitus []struct {
+		}
+		// ListBackups holds details about calls to the ListBackups method.
+		ListBackups []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// ListChunks holds details about calls to the ListChunks method.
+		ListChunks []struct {
+			// Schema is the schema argument value.
+			Schema string
+			// Table is the table argument value.
+			Table string
+		}
+		// ListContinuousAggregates holds details about calls to the ListContinuousAggregates method.
+		ListContinuousAggregates []struct {
+		}
+		// ListCronJobs holds details about calls to the ListCronJobs method.
+		ListCronJobs []struct {
+		}
+		// ListHypertables holds details about calls to the ListHypertables method.
+		ListHypertables []struct {
+		}
+		// ListRoles holds details about calls to the ListRoles method.
+		ListRoles []struct {
+			// IncludeSystem is the includeSystem argument value.
+			IncludeSystem bool
+		}
+		// ListSpatialColumns holds details about calls to the ListSpatialColumns method.
+		ListSpatialColumns []struct {
+		}
+		// ListVectorColumns holds details about calls to the ListVectorColumns method.
+		ListVectorColumns []struct {
+		}
+		// NewNotificationListener holds details about calls to the NewNotificationListener method.
+		NewNotificationListener []struct {
+			// Handler is the handler argument value.
+			Handler func(db.Notification)
+		}
+		// NewWorkspace holds details about calls to the NewWorkspace method.
+		NewWorkspace []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// Ping holds details about calls to the Ping method.
+		Ping []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// QueryContext holds details about calls to the QueryContext method.
+		QueryContext []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Query is the query argument value.
+			Query string
+			// Args is the args argument value.
+			Args []interface{}
+		}
+		// ReferencedTables holds details about calls to the ReferencedTables method.
+		ReferencedTables []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Query is the query argument value.
+			Query string
+		}
+		// ResolveTable holds details about calls to the ResolveTable method.
+		ResolveTable []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Name is the name argument value.
+			Name string
+		}
+		// ResourceBaseURL holds details about calls to the ResourceBaseURL method.
+		ResourceBaseURL []struct {
+		}
+		// RestoreBackup holds details about calls to the RestoreBackup method.
+		RestoreBackup []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Name is the name argument value.
+			Name string
+		}
+		// RestoreDump holds details about calls to the RestoreDump method.
+		RestoreDump []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// PgRestore is the pgRestore argument value.
+			PgRestore string
+			// Path is the path argument value.
+			Path string
+			// Database is the database argument value.
+			Database string
+		}
+		// RunScript holds details about calls to the RunScript method.
+		RunScript []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Statements is the statements argument value.
+			Statements []string
+			// ContinueOnError is the continueOnError argument value.
+			ContinueOnError bool
+		}
+		// ScheduleCronJob holds details about calls to the ScheduleCronJob method.
+		ScheduleCronJob []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Name is the name argument value.
+			Name string
+			// Schedule is the schedule argument value.
+			Schedule string
+			// Command is the command argument value.
+			Command string
+		}
+		// SchemaFingerprint holds details about calls to the SchemaFingerprint method.
+		SchemaFingerprint []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// TableNames is the tableNames argument value.
+			TableNames []string
+		}
+		// ScratchDatabaseURL holds details about calls to the ScratchDatabaseURL method.
+		ScratchDatabaseURL []struct {
+			// Name is the name argument value.
+			Name string
+		}
+		// SetCronJobActive holds details about calls to the SetCronJobActive method.
+		SetCronJobActive []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// JobID is the jobID argument value.
+			JobID int64
+			// Active is the active argument value.
+			Active bool
+		}
+		// SuggestIndexes holds details about calls to the SuggestIndexes method.
+		SuggestIndexes []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Queries is the queries argument value.
+			Queries []string
+		}
+		// TableFingerprints holds details about calls to the TableFingerprints method.
+		TableFingerprints []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// TopQueries holds details about calls to the TopQueries method.
+		TopQueries []struct {
+			// OrderBy is the orderBy argument value.
+			OrderBy string
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// TopStatements holds details about calls to the TopStatements method.
+		TopStatements []struct {
+			// Limit is the limit argument value.
+			Limit int
+		}
+		// UninstallDDLTrigger holds details about calls to the UninstallDDLTrigger method.
+		UninstallDDLTrigger []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// Vacuum holds details about calls to the Vacuum method.
+		Vacuum []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Schema is the schema argument value.
+			Schema string
+			// Table is the table argument value.
+			Table string
+			// Analyze is the analyze argument value.
+			Analyze bool
+		}
+		// VectorSearch holds details about calls to the VectorSearch method.
+		VectorSearch []struct {
+			// Params is the params argument value.
+			Params db.VectorSearchParams
+		}
+	}
+	lockAnalyze                     sync.RWMutex
+	lockBeginTransaction            sync.RWMutex
+	lockBuildAddColumn              sync.RWMutex
+	lockBuildAggregate              sync.RWMutex
+	lockBuildCreateIndex            sync.RWMutex
+	lockBuildCreateTable            sync.RWMutex
+	lockBuildJoin                   sync.RWMutex
+	lockBuildSelect                 sync.RWMutex
+	lockBuildTimeBucket             sync.RWMutex
+	lockCheckDataQuality            sync.RWMutex
+	lockClose                       sync.RWMutex
+	lockConsumeSlotChanges          sync.RWMutex
+	lockCreateScratchDatabase       sync.RWMutex
+	lockCronJobRuns                 sync.RWMutex
+	lockDDLTriggerInstalled         sync.RWMutex
+	lockDatabaseSize                sync.RWMutex
+	lockDescribeTable               sync.RWMutex
+	lockDropScratchDatabase         sync.RWMutex
+	lockDump                        sync.RWMutex
+	lockEnsureLogicalSlot           sync.RWMutex
+	lockExecuteReadOnlyQueryContext sync.RWMutex
+	lockExplainQuery                sync.RWMutex
+	lockFindTables                  sync.RWMutex
+	lockGenerateTestData            sync.RWMutex
+	lockGetAllTableSchemas          sync.RWMutex
+	lockGetCapabilities             sync.RWMutex
+	lockGetCitusCluster             sync.RWMutex
+	lockGetColumnSamples            sync.RWMutex
+	lockGetConnectionInfo           sync.RWMutex
+	lockGetErrorCounters            sync.RWMutex
+	lockGetForeignData              sync.RWMutex
+	lockGetForeignTableServers      sync.RWMutex
+	lockGetMaintenanceStatus        sync.RWMutex
+	lockGetOverview                 sync.RWMutex
+	lockGetPrivileges               sync.RWMutex
+	lockGetQueryContext             sync.RWMutex
+	lockGetReplicationStatus        sync.RWMutex
+	lockGetSchemaSummary            sync.RWMutex
+	lockGetSettings                 sync.RWMutex
+	lockGetStorageReport            sync.RWMutex
+	lockGetTableNames               sync.RWMutex
+	lockGetTableSchema              sync.RWMutex
+	lockGetTableSchemas             sync.RWMutex
+	lockHasExtension                sync.RWMutex
+	lockInstallDDLTrigger           sync.RWMutex
+	lockIsCitus                     sync.RWMutex
+	lockListBackups                 sync.RWMutex
+	lockListChunks                  sync.RWMutex
+	lockListContinuousAggregates    sync.RWMutex
+	lockListCronJobs                sync.RWMutex
+	lockListHypertables             sync.RWMutex
+	lockListRoles                   sync.RWMutex
+	lockListSpatialColumns          sync.RWMutex
+	lockListVectorColumns           sync.RWMutex
+	lockNewNotificationListener     sync.RWMutex
+	lockNewWorkspace                sync.RWMutex
+	lockPing                        sync.RWMutex
+	lockQueryContext                sync.RWMutex
+	lockReferencedTables            sync.RWMutex
+	lockResolveTable                sync.RWMutex
+	lockResourceBaseURL             sync.RWMutex
+	lockRestoreBackup               sync.RWMutex
+	lockRestoreDump                 sync.RWMutex
+	lockRunScript                   sync.RWMutex
+	lockScheduleCronJob             sync.RWMutex
+	lockSchemaFingerprint           sync.RWMutex
+	lockScratchDatabaseURL          sync.RWMutex
+	lockSetCronJobActive            sync.RWMutex
+	lockSuggestIndexes              sync.RWMutex
+	lockTableFingerprints           sync.RWMutex
+	lockTopQueries                  sync.RWMutex
+	lockTopStatements               sync.RWMutex
+	lockUninstallDDLTrigger         sync.RWMutex
+	lockVacuum                      sync.RWMutex
+	lockVectorSearch                sync.RWMutex
+}
+
+// Analyze calls AnalyzeFunc.
+func (mock *DatabaseMock) Analyze(ctx context.Context, schema string, table string) error {
+	if mock.AnalyzeFunc == nil {
+		panic("DatabaseMock.AnalyzeFunc: method is nil but Database.Analyze was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Schema string
+		Table  string
+	}{
+		Ctx:    ctx,
+		Schema: schema,
+		Table:  table,
+	}
+	mock.lockAnalyze.Lock()
+	mock.calls.Analyze = append(mock.calls.Analyze, callInfo)
+	mock.lockAnalyze.Unlock()
+	return mock.AnalyzeFunc(ctx, schema, table)
+}
+
+// AnalyzeCalls gets all the calls that were made to Analyze.
+// Check the length with:
+//
+//	len(mockedDatabase.AnalyzeCalls())
+func (mock *DatabaseMock) AnalyzeCalls() []struct {
+	Ctx    context.Context
+	Schema string
+	Table  string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Schema string
+		Table  string
+	}
+	mock.lockAnalyze.RLock()
+	calls = mock.calls.Analyze
+	mock.lockAnalyze.RUnlock()
+	return calls
+}
+
+// BeginTransaction calls BeginTransactionFunc.
+func (mock *DatabaseMock) BeginTransaction(ctx context.Context) (*db.Transaction, error) {
+	if mock.BeginTransactionFunc == nil {
+		panic("DatabaseMock.BeginTransactionFunc: method is nil but Database.BeginTransaction was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockBeginTransaction.Lock()
+	mock.calls.BeginTransaction = append(mock.calls.BeginTransaction, callInfo)
+	mock.lockBeginTransaction.Unlock()
+	return mock.BeginTransactionFunc(ctx)
+}
+
+// BeginTransactionCalls gets all the calls that were made to BeginTransaction.
+// Check the length with:
+//
+//	len(mockedDatabase.BeginTransactionCalls())
+func (mock *DatabaseMock) BeginTransactionCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockBeginTransaction.RLock()
+	calls = mock.calls.BeginTransaction
+	mock.lockBeginTransaction.RUnlock()
+	return calls
+}
+
+// BuildAddColumn calls BuildAddColumnFunc.
+func (mock *DatabaseMock) BuildAddColumn(ctx context.Context, params db.AddColumnParams) (*db.DDL, error) {
+	if mock.BuildAddColumnFunc == nil {
+		panic("DatabaseMock.BuildAddColumnFunc: method is nil but Database.BuildAddColumn was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Params db.AddColumnParams
+	}{
+		Ctx:    ctx,
+		Params: params,
+	}
+	mock.lockBuildAddColumn.Lock()
+	mock.calls.BuildAddColumn = append(mock.calls.BuildAddColumn, callInfo)
+	mock.lockBuildAddColumn.Unlock()
+	return mock.BuildAddColumnFunc(ctx, params)
+}
+
+// BuildAddColumnCalls gets all the calls that were made to BuildAddColumn.
+// Check the length with:
+//
+//	len(mockedDatabase.BuildAddColumnCalls())
+func (mock *DatabaseMock) BuildAddColumnCalls() []struct {
+	Ctx    context.Context
+	Params db.AddColumnParams
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Params db.AddColumnParams
+	}
+	mock.lockBuildAddColumn.RLock()
+	calls = mock.calls.BuildAddColumn
+	mock.lockBuildAddColumn.RUnlock()
+	return calls
+}
+
+// BuildAggregate calls BuildAggregateFunc.
+func (mock *DatabaseMock) BuildAggregate(params db.AggregateParams) (string, []interface{}, error) {
+	if mock.BuildAggregateFunc == nil {
+		panic("DatabaseMock.BuildAggregateFunc: method is nil but Database.BuildAggregate was just called")
+	}
+	callInfo := struct {
+		Params db.AggregateParams
+	}{
+		Params: params,
+	}
+	mock.lockBuildAggregate.Lock()
+	mock.calls.BuildAggregate = append(mock.calls.BuildAggregate, callInfo)
+	mock.lockBuildAggregate.Unlock()
+	return mock.BuildAggregateFunc(params)
+}
+
+// BuildAggregateCalls gets all the calls that were made to BuildAggregate.
+// Check the length with:
+//
+//	len(mockedDatabase.BuildAggregateCalls())
+func (mock *DatabaseMock) BuildAggregateCalls() []struct {
+	Params db.AggregateParams
+} {
+	var calls []struct {
+		Params db.AggregateParams
+	}
+	mock.lockBuildAggregate.RLock()
+	calls = mock.calls.BuildAggregate
+	mock.lockBuildAggregate.RUnlock()
+	return calls
+}
+
+// BuildCreateIndex calls BuildCreateIndexFunc.
+func (mock *DatabaseMock) BuildCreateIndex(ctx context.Context, params db.CreateIndexParams) (*db.DDL, error) {
+	if mock.BuildCreateIndexFunc == nil {
+		panic("DatabaseMock.BuildCreateIndexFunc: method is nil but Database.BuildCreateIndex was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Params db.CreateIndexParams
+	}{
+		Ctx:    ctx,
+		Params: params,
+	}
+	mock.lockBuildCreateIndex.Lock()
+	mock.calls.BuildCreateIndex = append(mock.calls.BuildCreateIndex, callInfo)
+	mock.lockBuildCreateIndex.Unlock()
+	return mock.BuildCreateIndexFunc(ctx, params)
+}
+
+// BuildCreateIndexCalls gets all the calls that were made to BuildCreateIndex.
+// Check the length with:
+//
+//	len(mockedDatabase.BuildCreateIndexCalls())
+func (mock *DatabaseMock) BuildCreateIndexCalls() []struct {
+	Ctx    context.Context
+	Params db.CreateIndexParams
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Params db.CreateIndexParams
+	}
+	mock.lockBuildCreateIndex.RLock()
+	calls = mock.calls.BuildCreateIndex
+	mock.lockBuildCreateIndex.RUnlock()
+	return calls
+}
+
+// BuildCreateTable calls BuildCreateTableFunc.
+func (mock *DatabaseMock) BuildCreateTable(ctx context.Context, params db.CreateTableParams) (*db.DDL, error) {
+	if mock.BuildCreateTableFunc == nil {
+		panic("DatabaseMock.BuildCreateTableFunc: method is nil but Database.BuildCreateTable was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Params db.CreateTableParams
+	}{
+		Ctx:    ctx,
+		Params: params,
+	}
+	mock.lockBuildCreateTable.Lock()
+	mock.calls.BuildCreateTable = append(mock.calls.BuildCreateTable, callInfo)
+	mock.lockBuildCreateTable.Unlock()
+	return mock.BuildCreateTableFunc(ctx, params)
+}
+
+// BuildCreateTableCalls gets all the calls that were made to BuildCreateTable.
+// Check the length with:
+//
+//	len(mockedDatabase.BuildCreateTableCalls())
+func (mock *DatabaseMock) BuildCreateTableCalls() []struct {
+	Ctx    context.Context
+	Params db.CreateTableParams
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Params db.CreateTableParams
+	}
+	mock.lockBuildCreateTable.RLock()
+	calls = mock.calls.BuildCreateTable
+	mock.lockBuildCreateTable.RUnlock()
+	return calls
+}
+
+// BuildJoin calls BuildJoinFunc.
+func (mock *DatabaseMock) BuildJoin(params db.JoinParams) (string, []interface{}, error) {
+	if mock.BuildJoinFunc == nil {
+		panic("DatabaseMock.BuildJoinFunc: method is nil but Database.BuildJoin was just called")
+	}
+	callInfo := struct {
+		Params db.JoinParams
+	}{
+		Params: params,
+	}
+	mock.lockBuildJoin.Lock()
+	mock.calls.BuildJoin = append(mock.calls.BuildJoin, callInfo)
+	mock.lockBuildJoin.Unlock()
+	return mock.BuildJoinFunc(params)
+}
+
+// BuildJoinCalls gets all the calls that were made to BuildJoin.
+// Check the length with:
+//
+//	len(mockedDatabase.BuildJoinCalls())
+func (mock *DatabaseMock) BuildJoinCalls() []struct {
+	Params db.JoinParams
+} {
+	var calls []struct {
+		Params db.JoinParams
+	}
+	mock.lockBuildJoin.RLock()
+	calls = mock.calls.BuildJoin
+	mock.lockBuildJoin.RUnlock()
+	return calls
+}
+
+// BuildSelect calls BuildSelectFunc.
+func (mock *DatabaseMock) BuildSelect(params db.SelectParams) (string, []interface{}, error) {
+	if mock.BuildSelectFunc == nil {
+		panic("DatabaseMock.BuildSelectFunc: method is nil but Database.BuildSelect was just called")
+	}
+	callInfo := struct {
+		Params db.SelectParams
+	}{
+		Params: params,
+	}
+	mock.lockBuildSelect.Lock()
+	mock.calls.BuildSelect = append(mock.calls.BuildSelect, callInfo)
+	mock.lockBuildSelect.Unlock()
+	return mock.BuildSelectFunc(params)
+}
+
+// BuildSelectCalls gets all the calls that were made to BuildSelect.
+// Check the length with:
+//
+//	len(mockedDatabase.BuildSelectCalls())
+func (mock *DatabaseMock) BuildSelectCalls() []struct {
+	Params db.SelectParams
+} {
+	var calls []struct {
+		Params db.SelectParams
+	}
+	mock.lockBuildSelect.RLock()
+	calls = mock.calls.BuildSelect
+	mock.lockBuildSelect.RUnlock()
+	return calls
+}
+
+// BuildTimeBucket calls BuildTimeBucketFunc.
+func (mock *DatabaseMock) BuildTimeBucket(params db.TimeBucketParams) (string, []interface{}, error) {
+	if mock.BuildTimeBucketFunc == nil {
+		panic("DatabaseMock.BuildTimeBucketFunc: method is nil but Database.BuildTimeBucket was just called")
+	}
+	callInfo := struct {
+		Params db.TimeBucketParams
+	}{
+		Params: params,
+	}
+	mock.lockBuildTimeBucket.Lock()
+	mock.calls.BuildTimeBucket = append(mock.calls.BuildTimeBucket, callInfo)
+	mock.lockBuildTimeBucket.Unlock()
+	return mock.BuildTimeBucketFunc(params)
+}
+
+// BuildTimeBucketCalls gets all the calls that were made to BuildTimeBucket.
+// Check the length with:
+//
+//	len(mockedDatabase.BuildTimeBucketCalls())
+func (mock *DatabaseMock) BuildTimeBucketCalls() []struct {
+	Params db.TimeBucketParams
+} {
+	var calls []struct {
+		Params db.TimeBucketParams
+	}
+	mock.lockBuildTimeBucket.RLock()
+	calls = mock.calls.BuildTimeBucket
+	mock.lockBuildTimeBucket.RUnlock()
+	return calls
+}
+
+// CheckDataQuality calls CheckDataQualityFunc.
+func (mock *DatabaseMock) CheckDataQuality(ctx context.Context, params db.QualityParams) (*db.QualityReport, error) {
+	if mock.CheckDataQualityFunc == nil {
+		panic("DatabaseMock.CheckDataQualityFunc: method is nil but Database.CheckDataQuality was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Params db.QualityParams
+	}{
+		Ctx:    ctx,
+		Params: params,
+	}
+	mock.lockCheckDataQuality.Lock()
+	mock.calls.CheckDataQuality = append(mock.calls.CheckDataQuality, callInfo)
+	mock.lockCheckDataQuality.Unlock()
+	return mock.CheckDataQualityFunc(ctx, params)
+}
+
+// CheckDataQualityCalls gets all the calls that were made to CheckDataQuality.
+// Check the length with:
+//
+//	len(mockedDatabase.CheckDataQualityCalls())
+func (mock *DatabaseMock) CheckDataQualityCalls() []struct {
+	Ctx    context.Context
+	Params db.QualityParams
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Params db.QualityParams
+	}
+	mock.lockCheckDataQuality.RLock()
+	calls = mock.calls.CheckDataQuality
+	mock.lockCheckDataQuality.RUnlock()
+	return calls
+}
+
+// Close calls CloseFunc.
+func (mock *DatabaseMock) Close() error {
+	if mock.CloseFunc == nil {
+		panic("DatabaseMock.CloseFunc: method is nil but Database.Close was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockClose.Lock()
+	mock.calls.Close = append(mock.calls.Close, callInfo)
+	mock.lockClose.Unlock()
+	return mock.CloseFunc()
+}
+
+// CloseCalls gets all the calls that were made to Close.
+// Check the length with:
+//
+//	len(mockedDatabase.CloseCalls())
+func (mock *DatabaseMock) CloseCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockClose.RLock()
+	calls = mock.calls.Close
+	mock.lockClose.RUnlock()
+	return calls
+}
+
+// ConsumeSlotChanges calls ConsumeSlotChangesFunc.
+func (mock *DatabaseMock) ConsumeSlotChanges(slot string, limit int, options ...string) ([]db.SlotChange, error) {
+	if mock.ConsumeSlotChangesFunc == nil {
+		panic("DatabaseMock.ConsumeSlotChangesFunc: method is nil but Database.ConsumeSlotChanges was just called")
+	}
+	callInfo := struct {
+		Slot    string
+		Limit   int
+		Options []string
+	}{
+		Slot:    slot,
+		Limit:   limit,
+		Options: options,
+	}
+	mock.lockConsumeSlotChanges.Lock()
+	mock.calls.ConsumeSlotChanges = append(mock.calls.ConsumeSlotChanges, callInfo)
+	mock.lockConsumeSlotChanges.Unlock()
+	return mock.ConsumeSlotChangesFunc(slot, limit, options...)
+}
+
+// ConsumeSlotChangesCalls gets all the calls that were made to ConsumeSlotChanges.
+// Check the length with:
+//
+//	len(mockedDatabase.ConsumeSlotChangesCalls())
+func (mock *DatabaseMock) ConsumeSlotChangesCalls() []struct {
+	Slot    string
+	Limit   int
+	Options []string
+} {
+	var calls []struct {
+		Slot    string
+		Limit   int
+		Options []string
+	}
+	mock.lockConsumeSlotChanges.RLock()
+	calls = mock.calls.ConsumeSlotChanges
+	mock.lockConsumeSlotChanges.RUnlock()
+	return calls
+}
+
+// CreateScratchDatabase calls CreateScratchDatabaseFunc.
+func (mock *DatabaseMock) CreateScratchDatabase(ctx context.Context, name string, template string) error {
+	if mock.CreateScratchDatabaseFunc == nil {
+		panic("DatabaseMock.CreateScratchDatabaseFunc: method is nil but Database.CreateScratchDatabase was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Name     string
+		Template string
+	}{
+		Ctx:      ctx,
+		Name:     name,
+		Template: template,
+	}
+	mock.lockCreateScratchDatabase.Lock()
+	mock.calls.CreateScratchDatabase = append(mock.calls.CreateScratchDatabase, callInfo)
+	mock.lockCreateScratchDatabase.Unlock()
+	return mock.CreateScratchDatabaseFunc(ctx, name, template)
+}
+
+// CreateScratchDatabaseCalls gets all the calls that were made to CreateScratchDatabase.
+// Check the length with:
+//
+//	len(mockedDatabase.CreateScratchDatabaseCalls())
+func (mock *DatabaseMock) CreateScratchDatabaseCalls() []struct {
+	Ctx      context.Context
+	Name     string
+	Template string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Name     string
+		Template string
+	}
+	mock.lockCreateScratchDatabase.RLock()
+	calls = mock.calls.CreateScratchDatabase
+	mock.lockCreateScratchDatabase.RUnlock()
+	return calls
+}
+
+// CronJobRuns calls CronJobRunsFunc.
+func (mock *DatabaseMock) CronJobRuns(jobID int64, limit int) ([]db.CronRun, error) {
+	if mock.CronJobRunsFunc == nil {
+		panic("DatabaseMock.CronJobRunsFunc: method is nil but Database.CronJobRuns was just called")
+	}
+	callInfo := struct {
+		JobID int64
+		Limit int
+	}{
+		JobID: jobID,
+		Limit: limit,
+	}
+	mock.lockCronJobRuns.Lock()
+	mock.calls.CronJobRuns = append(mock.calls.CronJobRuns, callInfo)
+	mock.lockCronJobRuns.Unlock()
+	return mock.CronJobRunsFunc(jobID, limit)
+}
+
+// CronJobRunsCalls gets all the calls that were made to CronJobRuns.
+// Check the length with:
+//
+//	len(mockedDatabase.CronJobRunsCalls())
+func (mock *DatabaseMock) CronJobRunsCalls() []struct {
+	JobID int64
+	Limit int
+} {
+	var calls []struct {
+		JobID int64
+		Limit int
+	}
+	mock.lockCronJobRuns.RLock()
+	calls = mock.calls.CronJobRuns
+	mock.lockCronJobRuns.RUnlock()
+	return calls
+}
+
+// DDLTriggerInstalled calls DDLTriggerInstalledFunc.
+func (mock *DatabaseMock) DDLTriggerInstalled(ctx context.Context) (bool, error) {
+	if mock.DDLTriggerInstalledFunc == nil {
+		panic("DatabaseMock.DDLTriggerInstalledFunc: method is nil but Database.DDLTriggerInstalled was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockDDLTriggerInstalled.Lock()
+	mock.calls.DDLTriggerInstalled = append(mock.calls.DDLTriggerInstalled, callInfo)
+	mock.lockDDLTriggerInstalled.Unlock()
+	return mock.DDLTriggerInstalledFunc(ctx)
+}
+
+// DDLTriggerInstalledCalls gets all the calls that were made to DDLTriggerInstalled.
+// Check the length with:
+//
+//	len(mockedDatabase.DDLTriggerInstalledCalls())
+func (mock *DatabaseMock) DDLTriggerInstalledCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockDDLTriggerInstalled.RLock()
+	calls = mock.calls.DDLTriggerInstalled
+	mock.lockDDLTriggerInstalled.RUnlock()
+	return calls
+}
+
+// DatabaseSize calls DatabaseSizeFunc.
+func (mock *DatabaseMock) DatabaseSize() (int64, error) {
+	if mock.DatabaseSizeFunc == nil {
+		panic("DatabaseMock.DatabaseSizeFunc: method is nil but Database.DatabaseSize was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockDatabaseSize.Lock()
+	mock.calls.DatabaseSize = append(mock.calls.DatabaseSize, callInfo)
+	mock.lockDatabaseSize.Unlock()
+	return mock.DatabaseSizeFunc()
+}
+
+// DatabaseSizeCalls gets all the calls that were made to DatabaseSize.
+// Check the length with:
+//
+//	len(mockedDatabase.DatabaseSizeCalls())
+func (mock *DatabaseMock) DatabaseSizeCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockDatabaseSize.RLock()
+	calls = mock.calls.DatabaseSize
+	mock.lockDatabaseSize.RUnlock()
+	return calls
+}
+
+// DescribeTable calls DescribeTableFunc.
+func (mock *DatabaseMock) DescribeTable(ctx context.Context, schema string, table string) (*db.TableDescription, error) {
+	if mock.DescribeTableFunc == nil {
+		panic("DatabaseMock.DescribeTableFunc: method is nil but Database.DescribeTable was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Schema string
+		Table  string
+	}{
+		Ctx:    ctx,
+		Schema: schema,
+		Table:  table,
+	}
+	mock.lockDescribeTable.Lock()
+	mock.calls.DescribeTable = append(mock.calls.DescribeTable, callInfo)
+	mock.lockDescribeTable.Unlock()
+	return mock.DescribeTableFunc(ctx, schema, table)
+}
+
+// DescribeTableCalls gets all the calls that were made to DescribeTable.
+// Check the length with:
+//
+//	len(mockedDatabase.DescribeTableCalls())
+func (mock *DatabaseMock) DescribeTableCalls() []struct {
+	Ctx    context.Context
+	Schema string
+	Table  string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Schema string
+		Table  string
+	}
+	mock.lockDescribeTable.RLock()
+	calls = mock.calls.DescribeTable
+	mock.lockDescribeTable.RUnlock()
+	return calls
+}
+
+// DropScratchDatabase calls DropScratchDatabaseFunc.
+func (mock *DatabaseMock) DropScratchDatabase(ctx context.Context, name string) error {
+	if mock.DropScratchDatabaseFunc == nil {
+		panic("DatabaseMock.DropScratchDatabaseFunc: method is nil but Database.DropScratchDatabase was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Name string
+	}{
+		Ctx:  ctx,
+		Name: name,
+	}
+	mock.lockDropScratchDatabase.Lock()
+	mock.calls.DropScratchDatabase = append(mock.calls.DropScratchDatabase, callInfo)
+	mock.lockDropScratchDatabase.Unlock()
+	return mock.DropScratchDatabaseFunc(ctx, name)
+}
+
+// DropScratchDatabaseCalls gets all the calls that were made to DropScratchDatabase.
+// Check the length with:
+//
+//	len(mockedDatabase.DropScratchDatabaseCalls())
+func (mock *DatabaseMock) DropScratchDatabaseCalls() []struct {
+	Ctx  context.Context
+	Name string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Name string
+	}
+	mock.lockDropScratchDatabase.RLock()
+	calls = mock.calls.DropScratchDatabase
+	mock.lockDropScratchDatabase.RUnlock()
+	return calls
+}
+
+// Dump calls DumpFunc.
+func (mock *DatabaseMock) Dump(ctx context.Context, params db.DumpParams) (*db.DumpResult, error) {
+	if mock.DumpFunc == nil {
+		panic("DatabaseMock.DumpFunc: method is nil but Database.Dump was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Params db.DumpParams
+	}{
+		Ctx:    ctx,
+		Params: params,
+	}
+	mock.lockDump.Lock()
+	mock.calls.Dump = append(mock.calls.Dump, callInfo)
+	mock.lockDump.Unlock()
+	return mock.DumpFunc(ctx, params)
+}
+
+// DumpCalls gets all the calls that were made to Dump.
+// Check the length with:
+//
+//	len(mockedDatabase.DumpCalls())
+func (mock *DatabaseMock) DumpCalls() []struct {
+	Ctx    context.Context
+	Params db.DumpParams
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Params db.DumpParams
+	}
+	mock.lockDump.RLock()
+	calls = mock.calls.Dump
+	mock.lockDump.RUnlock()
+	return calls
+}
+
+// EnsureLogicalSlot calls EnsureLogicalSlotFunc.
+func (mock *DatabaseMock) EnsureLogicalSlot(slot string, plugin string) error {
+	if mock.EnsureLogicalSlotFunc == nil {
+		panic("DatabaseMock.EnsureLogicalSlotFunc: method is nil but Database.EnsureLogicalSlot was just called")
+	}
+	callInfo := struct {
+		Slot   string
+		Plugin string
+	}{
+		Slot:   slot,
+		Plugin: plugin,
+	}
+	mock.lockEnsureLogicalSlot.Lock()
+	mock.calls.EnsureLogicalSlot = append(mock.calls.EnsureLogicalSlot, callInfo)
+	mock.lockEnsureLogicalSlot.Unlock()
+	return mock.EnsureLogicalSlotFunc(slot, plugin)
+}
+
+// EnsureLogicalSlotCalls gets all the calls that were made to EnsureLogicalSlot.
+// Check the length with:
+//
+//	len(mockedDatabase.EnsureLogicalSlotCalls())
+func (mock *DatabaseMock) EnsureLogicalSlotCalls() []struct {
+	Slot   string
+	Plugin string
+} {
+	var calls []struct {
+		Slot   string
+		Plugin string
+	}
+	mock.lockEnsureLogicalSlot.RLock()
+	calls = mock.calls.EnsureLogicalSlot
+	mock.lockEnsureLogicalSlot.RUnlock()
+	return calls
+}
+
+// ExecuteReadOnlyQueryContext calls ExecuteReadOnlyQueryContextFunc.
+func (mock *DatabaseMock) ExecuteReadOnlyQueryContext(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	if mock.ExecuteReadOnlyQueryContextFunc == nil {
+		panic("DatabaseMock.ExecuteReadOnlyQueryContextFunc: method is nil but Database.ExecuteReadOnlyQueryContext was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Query string
+		Args  []interface{}
+	}{
+		Ctx:   ctx,
+		Query: query,
+		Args:  args,
+	}
+	mock.lockExecuteReadOnlyQueryContext.Lock()
+	mock.calls.ExecuteReadOnlyQueryContext = append(mock.calls.ExecuteReadOnlyQueryContext, callInfo)
+	mock.lockExecuteReadOnlyQueryContext.Unlock()
+	return mock.ExecuteReadOnlyQueryContextFunc(ctx, query, args...)
+}
+
+// ExecuteReadOnlyQueryContextCalls gets all the calls that were made to ExecuteReadOnlyQueryContext.
+// Check the length with:
+//
+//	len(mockedDatabase.ExecuteReadOnlyQueryContextCalls())
+func (mock *DatabaseMock) ExecuteReadOnlyQueryContextCalls() []struct {
+	Ctx   context.Context
+	Query string
+	Args  []interface{}
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Query string
+		Args  []interface{}
+	}
+	mock.lockExecuteReadOnlyQueryContext.RLock()
+	calls = mock.calls.ExecuteReadOnlyQueryContext
+	mock.lockExecuteReadOnlyQueryContext.RUnlock()
+	return calls
+}
+
+// ExplainQuery calls ExplainQueryFunc.
+func (mock *DatabaseMock) ExplainQuery(ctx context.Context, query string, hypotheticalIndexes []string) (*db.QueryPlan, error) {
+	if mock.ExplainQueryFunc == nil {
+		panic("DatabaseMock.ExplainQueryFunc: method is nil but Database.ExplainQuery was just called")
+	}
+	callInfo := struct {
+		Ctx                 context.Context
+		Query               string
+		HypotheticalIndexes []string
+	}{
+		Ctx:                 ctx,
+		Query:               query,
+		HypotheticalIndexes: hypotheticalIndexes,
+	}
+	mock.lockExplainQuery.Lock()
+	mock.calls.ExplainQuery = append(mock.calls.ExplainQuery, callInfo)
+	mock.lockExplainQuery.Unlock()
+	return mock.ExplainQueryFunc(ctx, query, hypotheticalIndexes)
+}
+
+// ExplainQueryCalls gets all the calls that were made to ExplainQuery.
+// Check the length with:
+//
+//	len(mockedDatabase.ExplainQueryCalls())
+func (mock *DatabaseMock) ExplainQueryCalls() []struct {
+	Ctx                 context.Context
+	Query               string
+	HypotheticalIndexes []string
+} {
+	var calls []struct {
+		Ctx                 context.Context
+		Query               string
+		HypotheticalIndexes []string
+	}
+	mock.lockExplainQuery.RLock()
+	calls = mock.calls.ExplainQuery
+	mock.lockExplainQuery.RUnlock()
+	return calls
+}
+
+// FindTables calls FindTablesFunc.
+func (mock *DatabaseMock) FindTables(keyword string) ([]string, error) {
+	if mock.FindTablesFunc == nil {
+		panic("DatabaseMock.FindTablesFunc: method is nil but Database.FindTables was just called")
+	}
+	callInfo := struct {
+		Keyword string
+	}{
+		Keyword: keyword,
+	}
+	mock.lockFindTables.Lock()
+	mock.calls.FindTables = append(mock.calls.FindTables, callInfo)
+	mock.lockFindTables.Unlock()
+	return mock.FindTablesFunc(keyword)
+}
+
+// FindTablesCalls gets all the calls that were made to FindTables.
+// Check the length with:
+//
+//	len(mockedDatabase.FindTablesCalls())
+func (mock *DatabaseMock) FindTablesCalls() []struct {
+	Keyword string
+} {
+	var calls []struct {
+		Keyword string
+	}
+	mock.lockFindTables.RLock()
+	calls = mock.calls.FindTables
+	mock.lockFindTables.RUnlock()
+	return calls
+}
+
+// GenerateTestData calls GenerateTestDataFunc.
+func (mock *DatabaseMock) GenerateTestData(ctx context.Context, schema string, table string, rows int) (*db.TestDataResult, error) {
+	if mock.GenerateTestDataFunc == nil {
+		panic("DatabaseMock.GenerateTestDataFunc: method is nil but Database.GenerateTestData was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Schema string
+		Table  string
+		Rows   int
+	}{
+		Ctx:    ctx,
+		Schema: schema,
+		Table:  table,
+		Rows:   rows,
+	}
+	mock.lockGenerateTestData.Lock()
+	mock.calls.GenerateTestData = append(mock.calls.GenerateTestData, callInfo)
+	mock.lockGenerateTestData.Unlock()
+	return mock.GenerateTestDataFunc(ctx, schema, table, rows)
+}
+
+// GenerateTestDataCalls gets all the calls that were made to GenerateTestData.
+// Check the length with:
+//
+//	len(mockedDatabase.GenerateTestDataCalls())
+func (mock *DatabaseMock) GenerateTestDataCalls() []struct {
+	Ctx    context.Context
+	Schema string
+	Table  string
+	Rows   int
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Schema string
+		Table  string
+		Rows   int
+	}
+	mock.lockGenerateTestData.RLock()
+	calls = mock.calls.GenerateTestData
+	mock.lockGenerateTestData.RUnlock()
+	return calls
+}
+
+// GetAllTableSchemas calls GetAllTableSchemasFunc.
+func (mock *DatabaseMock) GetAllTableSchemas(schema string, pattern string) ([]db.TableSchema, error) {
+	if mock.GetAllTableSchemasFunc == nil {
+		panic("DatabaseMock.GetAllTableSchemasFunc: method is nil but Database.GetAllTableSchemas was just called")
+	}
+	callInfo := struct {
+		Schema  string
+		Pattern string
+	}{
+		Schema:  schema,
+		Pattern: pattern,
+	}
+	mock.lockGetAllTableSchemas.Lock()
+	mock.calls.GetAllTableSchemas = append(mock.calls.GetAllTableSchemas, callInfo)
+	mock.lockGetAllTableSchemas.Unlock()
+	return mock.GetAllTableSchemasFunc(schema, pattern)
+}
+
+// GetAllTableSchemasCalls gets all the calls that were made to GetAllTableSchemas.
+// Check the length with:
+//
+//	len(mockedDatabase.GetAllTableSchemasCalls())
+func (mock *DatabaseMock) GetAllTableSchemasCalls() []struct {
+	Schema  string
+	Pattern string
+} {
+	var calls []struct {
+		Schema  string
+		Pattern string
+	}
+	mock.lockGetAllTableSchemas.RLock()
+	calls = mock.calls.GetAllTableSchemas
+	mock.lockGetAllTableSchemas.RUnlock()
+	return calls
+}
+
+// GetCapabilities calls GetCapabilitiesFunc.
+func (mock *DatabaseMock) GetCapabilities(ctx context.Context) (*db.Capabilities, error) {
+	if mock.GetCapabilitiesFunc == nil {
+		panic("DatabaseMock.GetCapabilitiesFunc: method is nil but Database.GetCapabilities was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockGetCapabilities.Lock()
+	mock.calls.GetCapabilities = append(mock.calls.GetCapabilities, callInfo)
+	mock.lockGetCapabilities.Unlock()
+	return mock.GetCapabilitiesFunc(ctx)
+}
+
+// GetCapabilitiesCalls gets all the calls that were made to GetCapabilities.
+// Check the length with:
+//
+//	len(mockedDatabase.GetCapabilitiesCalls())
+func (mock *DatabaseMock) GetCapabilitiesCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockGetCapabilities.RLock()
+	calls = mock.calls.GetCapabilities
+	mock.lockGetCapabilities.RUnlock()
+	return calls
+}
+
+// GetCitusCluster calls GetCitusClusterFunc.
+func (mock *DatabaseMock) GetCitusCluster() (*db.CitusCluster, error) {
+	if mock.GetCitusClusterFunc == nil {
+		panic("DatabaseMock.GetCitusClusterFunc: method is nil but Database.GetCitusCluster was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockGetCitusCluster.Lock()
+	mock.calls.GetCitusCluster = append(mock.calls.GetCitusCluster, callInfo)
+	mock.lockGetCitusCluster.Unlock()
+	return mock.GetCitusClusterFunc()
+}
+
+// GetCitusClusterCalls gets all the calls that were made to GetCitusCluster.
+// Check the length with:
+//
+//	len(mockedDatabase.GetCitusClusterCalls())
+func (mock *DatabaseMock) GetCitusClusterCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockGetCitusCluster.RLock()
+	calls = mock.calls.GetCitusCluster
+	mock.lockGetCitusCluster.RUnlock()
+	return calls
+}
+
+// GetColumnSamples calls GetColumnSamplesFunc.
+func (mock *DatabaseMock) GetColumnSamples(schema string, maxSamples int) (map[string][]string, error) {
+	if mock.GetColumnSamplesFunc == nil {
+		panic("DatabaseMock.GetColumnSamplesFunc: method is nil but Database.GetColumnSamples was just called")
+	}
+	callInfo := struct {
+		Schema     string
+		MaxSamples int
+	}{
+		Schema:     schema,
+		MaxSamples: maxSamples,
+	}
+	mock.lockGetColumnSamples.Lock()
+	mock.calls.GetColumnSamples = append(mock.calls.GetColumnSamples, callInfo)
+	mock.lockGetColumnSamples.Unlock()
+	return mock.GetColumnSamplesFunc(schema, maxSamples)
+}
+
+// GetColumnSamplesCalls gets all the calls that were made to GetColumnSamples.
+// Check the length with:
+//
+//	len(mockedDatabase.GetColumnSamplesCalls())
+func (mock *DatabaseMock) GetColumnSamplesCalls() []struct {
+	Schema     string
+	MaxSamples int
+} {
+	var calls []struct {
+		Schema     string
+		MaxSamples int
+	}
+	mock.lockGetColumnSamples.RLock()
+	calls = mock.calls.GetColumnSamples
+	mock.lockGetColumnSamples.RUnlock()
+	return calls
+}
+
+// GetConnectionInfo calls GetConnectionInfoFunc.
+func (mock *DatabaseMock) GetConnectionInfo(ctx context.Context) (*db.ConnectionInfo, error) {
+	if mock.GetConnectionInfoFunc == nil {
+		panic("DatabaseMock.GetConnectionInfoFunc: method is nil but Database.GetConnectionInfo was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockGetConnectionInfo.Lock()
+	mock.calls.GetConnectionInfo = append(mock.calls.GetConnectionInfo, callInfo)
+	mock.lockGetConnectionInfo.Unlock()
+	return mock.GetConnectionInfoFunc(ctx)
+}
+
+// GetConnectionInfoCalls gets all the calls that were made to GetConnectionInfo.
+// Check the length with:
+//
+//	len(mockedDatabase.GetConnectionInfoCalls())
+func (mock *DatabaseMock) GetConnectionInfoCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockGetConnectionInfo.RLock()
+	calls = mock.calls.GetConnectionInfo
+	mock.lockGetConnectionInfo.RUnlock()
+	return calls
+}
+
+// GetErrorCounters calls GetErrorCountersFunc.
+func (mock *DatabaseMock) GetErrorCounters() (*db.ErrorCounters, error) {
+	if mock.GetErrorCountersFunc == nil {
+		panic("DatabaseMock.GetErrorCountersFunc: method is nil but Database.GetErrorCounters was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockGetErrorCounters.Lock()
+	mock.calls.GetErrorCounters = append(mock.calls.GetErrorCounters, callInfo)
+	mock.lockGetErrorCounters.Unlock()
+	return mock.GetErrorCountersFunc()
+}
+
+// GetErrorCountersCalls gets all the calls that were made to GetErrorCounters.
+// Check the length with:
+//
+//	len(mockedDatabase.GetErrorCountersCalls())
+func (mock *DatabaseMock) GetErrorCountersCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockGetErrorCounters.RLock()
+	calls = mock.calls.GetErrorCounters
+	mock.lockGetErrorCounters.RUnlock()
+	return calls
+}
+
+// GetForeignData calls GetForeignDataFunc.
+func (mock *DatabaseMock) GetForeignData() (*db.ForeignData, error) {
+	if mock.GetForeignDataFunc == nil {
+		panic("DatabaseMock.GetForeignDataFunc: method is nil but Database.GetForeignData was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockGetForeignData.Lock()
+	mock.calls.GetForeignData = append(mock.calls.GetForeignData, callInfo)
+	mock.lockGetForeignData.Unlock()
+	return mock.GetForeignDataFunc()
+}
+
+// GetForeignDataCalls gets all the calls that were made to GetForeignData.
+// Check the length with:
+//
+//	len(mockedDatabase.GetForeignDataCalls())
+func (mock *DatabaseMock) GetForeignDataCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockGetForeignData.RLock()
+	calls = mock.calls.GetForeignData
+	mock.lockGetForeignData.RUnlock()
+	return calls
+}
+
+// GetForeignTableServers calls GetForeignTableServersFunc.
+func (mock *DatabaseMock) GetForeignTableServers() (map[string]string, error) {
+	if mock.GetForeignTableServersFunc == nil {
+		panic("DatabaseMock.GetForeignTableServersFunc: method is nil but Database.GetForeignTableServers was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockGetForeignTableServers.Lock()
+	mock.calls.GetForeignTableServers = append(mock.calls.GetForeignTableServers, callInfo)
+	mock.lockGetForeignTableServers.Unlock()
+	return mock.GetForeignTableServersFunc()
+}
+
+// GetForeignTableServersCalls gets all the calls that were made to GetForeignTableServers.
+// Check the length with:
+//
+//	len(mockedDatabase.GetForeignTableServersCalls())
+func (mock *DatabaseMock) GetForeignTableServersCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockGetForeignTableServers.RLock()
+	calls = mock.calls.GetForeignTableServers
+	mock.lockGetForeignTableServers.RUnlock()
+	return calls
+}
+
+// GetMaintenanceStatus calls GetMaintenanceStatusFunc.
+func (mock *DatabaseMock) GetMaintenanceStatus() (*db.MaintenanceStatus, error) {
+	if mock.GetMaintenanceStatusFunc == nil {
+		panic("DatabaseMock.GetMaintenanceStatusFunc: method is nil but Database.GetMaintenanceStatus was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockGetMaintenanceStatus.Lock()
+	mock.calls.GetMaintenanceStatus = append(mock.calls.GetMaintenanceStatus, callInfo)
+	mock.lockGetMaintenanceStatus.Unlock()
+	return mock.GetMaintenanceStatusFunc()
+}
+
+// GetMaintenanceStatusCalls gets all the calls that were made to GetMaintenanceStatus.
+// Check the length with:
+//
+//	len(mockedDatabase.GetMaintenanceStatusCalls())
+func (mock *DatabaseMock) GetMaintenanceStatusCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockGetMaintenanceStatus.RLock()
+	calls = mock.calls.GetMaintenanceStatus
+	mock.lockGetMaintenanceStatus.RUnlock()
+	return calls
+}
+
+// GetOverview calls GetOverviewFunc.
+func (mock *DatabaseMock) GetOverview() (*db.DatabaseOverview, error) {
+	if mock.GetOverviewFunc == nil {
+		panic("DatabaseMock.GetOverviewFunc: method is nil but Database.GetOverview was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockGetOverview.Lock()
+	mock.calls.GetOverview = append(mock.calls.GetOverview, callInfo)
+	mock.lockGetOverview.Unlock()
+	return mock.GetOverviewFunc()
+}
+
+// GetOverviewCalls gets all the calls that were made to GetOverview.
+// Check the length with:
+//
+//	len(mockedDatabase.GetOverviewCalls())
+func (mock *DatabaseMock) GetOverviewCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockGetOverview.RLock()
+	calls = mock.calls.GetOverview
+	mock.lockGetOverview.RUnlock()
+	return calls
+}
+
+// GetPrivileges calls GetPrivilegesFunc.
+func (mock *DatabaseMock) GetPrivileges(schema string, table string, role string) (*db.PrivilegeReport, error) {
+	if mock.GetPrivilegesFunc == nil {
+		panic("DatabaseMock.GetPrivilegesFunc: method is nil but Database.GetPrivileges was just called")
+	}
+	callInfo := struct {
+		Schema string
+		Table  string
+		Role   string
+	}{
+		Schema: schema,
+		Table:  table,
+		Role:   role,
+	}
+	mock.lockGetPrivileges.Lock()
+	mock.calls.GetPrivileges = append(mock.calls.GetPrivileges, callInfo)
+	mock.lockGetPrivileges.Unlock()
+	return mock.GetPrivilegesFunc(schema, table, role)
+}
+
+// GetPrivilegesCalls gets all the calls that were made to GetPrivileges.
+// Check the length with:
+//
+//	len(mockedDatabase.GetPrivilegesCalls())
+func (mock *DatabaseMock) GetPrivilegesCalls() []struct {
+	Schema string
+	Table  string
+	Role   string
+} {
+	var calls []struct {
+		Schema string
+		Table  string
+		Role   string
+	}
+	mock.lockGetPrivileges.RLock()
+	calls = mock.calls.GetPrivileges
+	mock.lockGetPrivileges.RUnlock()
+	return calls
+}
+
+// GetQueryContext calls GetQueryContextFunc.
+func (mock *DatabaseMock) GetQueryContext(tableNames []string) (*db.QueryContext, error) {
+	if mock.GetQueryContextFunc == nil {
+		panic("DatabaseMock.GetQueryContextFunc: method is nil but Database.GetQueryContext was just called")
+	}
+	callInfo := struct {
+		TableNames []string
+	}{
+		TableNames: tableNames,
+	}
+	mock.lockGetQueryContext.Lock()
+	mock.calls.GetQueryContext = append(mock.calls.GetQueryContext, callInfo)
+	mock.lockGetQueryContext.Unlock()
+	return mock.GetQueryContextFunc(tableNames)
+}
+
+// GetQueryContextCalls gets all the calls that were made to GetQueryContext.
+// Check the length with:
+//
+//	len(mockedDatabase.GetQueryContextCalls())
+func (mock *DatabaseMock) GetQueryContextCalls() []struct {
+	TableNames []string
+} {
+	var calls []struct {
+		TableNames []string
+	}
+	mock.lockGetQueryContext.RLock()
+	calls = mock.calls.GetQueryContext
+	mock.lockGetQueryContext.RUnlock()
+	return calls
+}
+
+// GetReplicationStatus calls GetReplicationStatusFunc.
+func (mock *DatabaseMock) GetReplicationStatus() (*db.ReplicationStatus, error) {
+	if mock.GetReplicationStatusFunc == nil {
+		panic("DatabaseMock.GetReplicationStatusFunc: method is nil but Database.GetReplicationStatus was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockGetReplicationStatus.Lock()
+	mock.calls.GetReplicationStatus = append(mock.calls.GetReplicationStatus, callInfo)
+	mock.lockGetReplicationStatus.Unlock()
+	return mock.GetReplicationStatusFunc()
+}
+
+// GetReplicationStatusCalls gets all the calls that were made to GetReplicationStatus.
+// Check the length with:
+//
+//	len(mockedDatabase.GetReplicationStatusCalls())
+func (mock *DatabaseMock) GetReplicationStatusCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockGetReplicationStatus.RLock()
+	calls = mock.calls.GetReplicationStatus
+	mock.lockGetReplicationStatus.RUnlock()
+	return calls
+}
+
+// GetSchemaSummary calls GetSchemaSummaryFunc.
+func (mock *DatabaseMock) GetSchemaSummary(schema string) ([]db.TableSummary, error) {
+	if mock.GetSchemaSummaryFunc == nil {
+		panic("DatabaseMock.GetSchemaSummaryFunc: method is nil but Database.GetSchemaSummary was just called")
+	}
+	callInfo := struct {
+		Schema string
+	}{
+		Schema: schema,
+	}
+	mock.lockGetSchemaSummary.Lock()
+	mock.calls.GetSchemaSummary = append(mock.calls.GetSchemaSummary, callInfo)
+	mock.lockGetSchemaSummary.Unlock()
+	return mock.GetSchemaSummaryFunc(schema)
+}
+
+// GetSchemaSummaryCalls gets all the calls that were made to GetSchemaSummary.
+// Check the length with:
+//
+//	len(mockedDatabase.GetSchemaSummaryCalls())
+func (mock *DatabaseMock) GetSchemaSummaryCalls() []struct {
+	Schema string
+} {
+	var calls []struct {
+		Schema string
+	}
+	mock.lockGetSchemaSummary.RLock()
+	calls = mock.calls.GetSchemaSummary
+	mock.lockGetSchemaSummary.RUnlock()
+	return calls
+}
+
+// GetSettings calls GetSettingsFunc.
+func (mock *DatabaseMock) GetSettings(filter db.SettingsFilter) ([]db.Setting, error) {
+	if mock.GetSettingsFunc == nil {
+		panic("DatabaseMock.GetSettingsFunc: method is nil but Database.GetSettings was just called")
+	}
+	callInfo := struct {
+		Filter db.SettingsFilter
+	}{
+		Filter: filter,
+	}
+	mock.lockGetSettings.Lock()
+	mock.calls.GetSettings = append(mock.calls.GetSettings, callInfo)
+	mock.lockGetSettings.Unlock()
+	return mock.GetSettingsFunc(filter)
+}
+
+// GetSettingsCalls gets all the calls that were made to GetSettings.
+// Check the length with:
+//
+//	len(mockedDatabase.GetSettingsCalls())
+func (mock *DatabaseMock) GetSettingsCalls() []struct {
+	Filter db.SettingsFilter
+} {
+	var calls []struct {
+		Filter db.SettingsFilter
+	}
+	mock.lockGetSettings.RLock()
+	calls = mock.calls.GetSettings
+	mock.lockGetSettings.RUnlock()
+	return calls
+}
+
+// GetStorageReport calls GetStorageReportFunc.
+func (mock *DatabaseMock) GetStorageReport() (*db.StorageReport, error) {
+	if mock.GetStorageReportFunc == nil {
+		panic("DatabaseMock.GetStorageReportFunc: method is nil but Database.GetStorageReport was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockGetStorageReport.Lock()
+	mock.calls.GetStorageReport = append(mock.calls.GetStorageReport, callInfo)
+	mock.lockGetStorageReport.Unlock()
+	return mock.GetStorageReportFunc()
+}
+
+// GetStorageReportCalls gets all the calls that were made to GetStorageReport.
+// Check the length with:
+//
+//	len(mockedDatabase.GetStorageReportCalls())
+func (mock *DatabaseMock) GetStorageReportCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockGetStorageReport.RLock()
+	calls = mock.calls.GetStorageReport
+	mock.lockGetStorageReport.RUnlock()
+	return calls
+}
+
+// GetTableNames calls GetTableNamesFunc.
+func (mock *DatabaseMock) GetTableNames() ([]string, error) {
+	if mock.GetTableNamesFunc == nil {
+		panic("DatabaseMock.GetTableNamesFunc: method is nil but Database.GetTableNames was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockGetTableNames.Lock()
+	mock.calls.GetTableNames = append(mock.calls.GetTableNames, callInfo)
+	mock.lockGetTableNames.Unlock()
+	return mock.GetTableNamesFunc()
+}
+
+// GetTableNamesCalls gets all the calls that were made to GetTableNames.
+// Check the length with:
+//
+//	len(mockedDatabase.GetTableNamesCalls())
+func (mock *DatabaseMock) GetTableNamesCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockGetTableNames.RLock()
+	calls = mock.calls.GetTableNames
+	mock.lockGetTableNames.RUnlock()
+	return calls
+}
+
+// GetTableSchema calls GetTableSchemaFunc.
+func (mock *DatabaseMock) GetTableSchema(tableName string) ([]db.TableColumn, error) {
+	if mock.GetTableSchemaFunc == nil {
+		panic("DatabaseMock.GetTableSchemaFunc: method is nil but Database.GetTableSchema was just called")
+	}
+	callInfo := struct {
+		TableName string
+	}{
+		TableName: tableName,
+	}
+	mock.lockGetTableSchema.Lock()
+	mock.calls.GetTableSchema = append(mock.calls.GetTableSchema, callInfo)
+	mock.lockGetTableSchema.Unlock()
+	return mock.GetTableSchemaFunc(tableName)
+}
+
+// GetTableSchemaCalls gets all the calls that were made to GetTableSchema.
+// Check the length with:
+//
+//	len(mockedDatabase.GetTableSchemaCalls())
+func (mock *DatabaseMock) GetTableSchemaCalls() []struct {
+	TableName string
+} {
+	var calls []struct {
+		TableName string
+	}
+	mock.lockGetTableSchema.RLock()
+	calls = mock.calls.GetTableSchema
+	mock.lockGetTableSchema.RUnlock()
+	return calls
+}
+
+// GetTableSchemas calls GetTableSchemasFunc.
+func (mock *DatabaseMock) GetTableSchemas(tableNames []string) (map[string][]db.TableColumn, error) {
+	if mock.GetTableSchemasFunc == nil {
+		panic("DatabaseMock.GetTableSchemasFunc: method is nil but Database.GetTableSchemas was just called")
+	}
+	callInfo := struct {
+		TableNames []string
+	}{
+		TableNames: tableNames,
+	}
+	mock.lockGetTableSchemas.Lock()
+	mock.calls.GetTableSchemas = append(mock.calls.GetTableSchemas, callInfo)
+	mock.lockGetTableSchemas.Unlock()
+	return mock.GetTableSchemasFunc(tableNames)
+}
+
+// GetTableSchemasCalls gets all the calls that were made to GetTableSchemas.
+// Check the length with:
+//
+//	len(mockedDatabase.GetTableSchemasCalls())
+func (mock *DatabaseMock) GetTableSchemasCalls() []struct {
+	TableNames []string
+} {
+	var calls []struct {
+		TableNames []string
+	}
+	mock.lockGetTableSchemas.RLock()
+	calls = mock.calls.GetTableSchemas
+	mock.lockGetTableSchemas.RUnlock()
+	return calls
+}
+
+// HasExtension calls HasExtensionFunc.
+func (mock *DatabaseMock) HasExtension(name string) (bool, error) {
+	if mock.HasExtensionFunc == nil {
+		panic("DatabaseMock.HasExtensionFunc: method is nil but Database.HasExtension was just called")
+	}
+	callInfo := struct {
+		Name string
+	}{
+		Name: name,
+	}
+	mock.lockHasExtension.Lock()
+	mock.calls.HasExtension = append(mock.calls.HasExtension, callInfo)
+	mock.lockHasExtension.Unlock()
+	return mock.HasExtensionFunc(name)
+}
+
+// HasExtensionCalls gets all the calls that were made to HasExtension.
+// Check the length with:
+//
+//	len(mockedDatabase.HasExtensionCalls())
+func (mock *DatabaseMock) HasExtensionCalls() []struct {
+	Name string
+} {
+	var calls []struct {
+		Name string
+	}
+	mock.lockHasExtension.RLock()
+	calls = mock.calls.HasExtension
+	mock.lockHasExtension.RUnlock()
+	return calls
+}
+
+// InstallDDLTrigger calls InstallDDLTriggerFunc.
+func (mock *DatabaseMock) InstallDDLTrigger(ctx context.Context) error {
+	if mock.InstallDDLTriggerFunc == nil {
+		panic("DatabaseMock.InstallDDLTriggerFunc: method is nil but Database.InstallDDLTrigger was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockInstallDDLTrigger.Lock()
+	mock.calls.InstallDDLTrigger = append(mock.calls.InstallDDLTrigger, callInfo)
+	mock.lockInstallDDLTrigger.Unlock()
+	return mock.InstallDDLTriggerFunc(ctx)
+}
+
+// InstallDDLTriggerCalls gets all the calls that were made to InstallDDLTrigger.
+// Check the length with:
+//
+//	len(mockedDatabase.InstallDDLTriggerCalls())
+func (mock *DatabaseMock) InstallDDLTriggerCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockInstallDDLTrigger.RLock()
+	calls = mock.calls.InstallDDLTrigger
+	mock.lockInstallDDLTrigger.RUnlock()
+	return calls
+}
+
+// IsCitus calls IsCitusFunc.
+func (mock *DatabaseMock) IsCitus() (bool, error) {
+	if mock.IsCitusFunc == nil {
+		panic("DatabaseMock.IsCitusFunc: method is nil but Database.IsCitus was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockIsCitus.Lock()
+	mock.calls.IsCitus = append(mock.calls.IsCitus, callInfo)
+	mock.lockIsCitus.Unlock()
+	return mock.IsCitusFunc()
+}
+
+// IsCitusCalls gets all the calls that were made to IsCitus.
+// Check the length with:
+//
+//	len(mockedDatabase.IsCitusCalls())
+func (mock *DatabaseMock) IsCitusCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockIsCitus.RLock()
+	calls = mock.calls.IsCitus
+	mock.lockIsCitus.RUnlock()
+	return calls
+}
+
+// ListBackups calls ListBackupsFunc.
+func (mock *DatabaseMock) ListBackups(ctx context.Context) ([]db.Backup, error) {
+	if mock.ListBackupsFunc == nil {
+		panic("DatabaseMock.ListBackupsFunc: method is nil but Database.ListBackups was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockListBackups.Lock()
+	mock.calls.ListBackups = append(mock.calls.ListBackups, callInfo)
+	mock.lockListBackups.Unlock()
+	return mock.ListBackupsFunc(ctx)
+}
+
+// ListBackupsCalls gets all the calls that were made to ListBackups.
+// Check the length with:
+//
+//	len(mockedDatabase.ListBackupsCalls())
+func (mock *DatabaseMock) ListBackupsCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockListBackups.RLock()
+	calls = mock.calls.ListBackups
+	mock.lockListBackups.RUnlock()
+	return calls
+}
+
+// ListChunks calls ListChunksFunc.
+func (mock *DatabaseMock) ListChunks(schema string, table string) ([]db.Chunk, error) {
+	if mock.ListChunksFunc == nil {
+		panic("DatabaseMock.ListChunksFunc: method is nil but Database.ListChunks was just called")
+	}
+	callInfo := struct {
+		Schema string
+		Table  string
+	}{
+		Schema: schema,
+		Table:  table,
+	}
+	mock.lockListChunks.Lock()
+	mock.calls.ListChunks = append(mock.calls.ListChunks, callInfo)
+	mock.lockListChunks.Unlock()
+	return mock.ListChunksFunc(schema, table)
+}
+
+// ListChunksCalls gets all the calls that were made to ListChunks.
+// Check the length with:
+//
+//	len(mockedDatabase.ListChunksCalls())
+func (mock *DatabaseMock) ListChunksCalls() []struct {
+	Schema string
+	Table  string
+} {
+	var calls []struct {
+		Schema string
+		Table  string
+	}
+	mock.lockListChunks.RLock()
+	calls = mock.calls.ListChunks
+	mock.lockListChunks.RUnlock()
+	return calls
+}
+
+// ListContinuousAggregates calls ListContinuousAggregatesFunc.
+func (mock *DatabaseMock) ListContinuousAggregates() ([]db.ContinuousAggregate, error) {
+	if mock.ListContinuousAggregatesFunc == nil {
+		panic("DatabaseMock.ListContinuousAggregatesFunc: method is nil but Database.ListContinuousAggregates was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockListContinuousAggregates.Lock()
+	mock.calls.ListContinuousAggregates = append(mock.calls.ListContinuousAggregates, callInfo)
+	mock.lockListContinuousAggregates.Unlock()
+	return mock.ListContinuousAggregatesFunc()
+}
+
+// ListContinuousAggregatesCalls gets all the calls that were made to ListContinuousAggregates.
+// Check the length with:
+//
+//	len(mockedDatabase.ListContinuousAggregatesCalls())
+func (mock *DatabaseMock) ListContinuousAggregatesCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockListContinuousAggregates.RLock()
+	calls = mock.calls.ListContinuousAggregates
+	mock.lockListContinuousAggregates.RUnlock()
+	return calls
+}
+
+// ListCronJobs calls ListCronJobsFunc.
+func (mock *DatabaseMock) ListCronJobs() ([]db.CronJob, error) {
+	if mock.ListCronJobsFunc == nil {
+		panic("DatabaseMock.ListCronJobsFunc: method is nil but Database.ListCronJobs was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockListCronJobs.Lock()
+	mock.calls.ListCronJobs = append(mock.calls.ListCronJobs, callInfo)
+	mock.lockListCronJobs.Unlock()
+	return mock.ListCronJobsFunc()
+}
+
+// ListCronJobsCalls gets all the calls that were made to ListCronJobs.
+// Check the length with:
+//
+//	len(mockedDatabase.ListCronJobsCalls())
+func (mock *DatabaseMock) ListCronJobsCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockListCronJobs.RLock()
+	calls = mock.calls.ListCronJobs
+	mock.lockListCronJobs.RUnlock()
+	return calls
+}
+
+// ListHypertables calls ListHypertablesFunc.
+func (mock *DatabaseMock) ListHypertables() ([]db.Hypertable, error) {
+	if mock.ListHypertablesFunc == nil {
+		panic("DatabaseMock.ListHypertablesFunc: method is nil but Database.ListHypertables was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockListHypertables.Lock()
+	mock.calls.ListHypertables = append(mock.calls.ListHypertables, callInfo)
+	mock.lockListHypertables.Unlock()
+	return mock.ListHypertablesFunc()
+}
+
+// ListHypertablesCalls gets all the calls that were made to ListHypertables.
+// Check the length with:
+//
+//	len(mockedDatabase.ListHypertablesCalls())
+func (mock *DatabaseMock) ListHypertablesCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockListHypertables.RLock()
+	calls = mock.calls.ListHypertables
+	mock.lockListHypertables.RUnlock()
+	return calls
+}
+
+// ListRoles calls ListRolesFunc.
+func (mock *DatabaseMock) ListRoles(includeSystem bool) ([]db.Role, error) {
+	if mock.ListRolesFunc == nil {
+		panic("DatabaseMock.ListRolesFunc: method is nil but Database.ListRoles was just called")
+	}
+	callInfo := struct {
+		IncludeSystem bool
+	}{
+		IncludeSystem: includeSystem,
+	}
+	mock.lockListRoles.Lock()
+	mock.calls.ListRoles = append(mock.calls.ListRoles, callInfo)
+	mock.lockListRoles.Unlock()
+	return mock.ListRolesFunc(includeSystem)
+}
+
+// ListRolesCalls gets all the calls that were made to ListRoles.
+// Check the length with:
+//
+//	len(mockedDatabase.ListRolesCalls())
+func (mock *DatabaseMock) ListRolesCalls() []struct {
+	IncludeSystem bool
+} {
+	var calls []struct {
+		IncludeSystem bool
+	}
+	mock.lockListRoles.RLock()
+	calls = mock.calls.ListRoles
+	mock.lockListRoles.RUnlock()
+	return calls
+}
+
+// ListSpatialColumns calls ListSpatialColumnsFunc.
+func (mock *DatabaseMock) ListSpatialColumns() ([]db.SpatialColumn, error) {
+	if mock.ListSpatialColumnsFunc == nil {
+		panic("DatabaseMock.ListSpatialColumnsFunc: method is nil but Database.ListSpatialColumns was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockListSpatialColumns.Lock()
+	mock.calls.ListSpatialColumns = append(mock.calls.ListSpatialColumns, callInfo)
+	mock.lockListSpatialColumns.Unlock()
+	return mock.ListSpatialColumnsFunc()
+}
+
+// ListSpatialColumnsCalls gets all the calls that were made to ListSpatialColumns.
+// Check the length with:
+//
+//	len(mockedDatabase.ListSpatialColumnsCalls())
+func (mock *DatabaseMock) ListSpatialColumnsCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockListSpatialColumns.RLock()
+	calls = mock.calls.ListSpatialColumns
+	mock.lockListSpatialColumns.RUnlock()
+	return calls
+}
+
+// ListVectorColumns calls ListVectorColumnsFunc.
+func (mock *DatabaseMock) ListVectorColumns() ([]db.VectorColumn, error) {
+	if mock.ListVectorColumnsFunc == nil {
+		panic("DatabaseMock.ListVectorColumnsFunc: method is nil but Database.ListVectorColumns was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockListVectorColumns.Lock()
+	mock.calls.ListVectorColumns = append(mock.calls.ListVectorColumns, callInfo)
+	mock.lockListVectorColumns.Unlock()
+	return mock.ListVectorColumnsFunc()
+}
+
+// ListVectorColumnsCalls gets all the calls that were made to ListVectorColumns.
+// Check the length with:
+//
+//	len(mockedDatabase.ListVectorColumnsCalls())
+func (mock *DatabaseMock) ListVectorColumnsCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockListVectorColumns.RLock()
+	calls = mock.calls.ListVectorColumns
+	mock.lockListVectorColumns.RUnlock()
+	return calls
+}
+
+// NewNotificationListener calls NewNotificationListenerFunc.
+func (mock *DatabaseMock) NewNotificationListener(handler func(db.Notification)) *db.NotificationListener {
+	if mock.NewNotificationListenerFunc == nil {
+		panic("DatabaseMock.NewNotificationListenerFunc: method is nil but Database.NewNotificationListener was just called")
+	}
+	callInfo := struct {
+		Handler func(db.Notification)
+	}{
+		Handler: handler,
+	}
+	mock.lockNewNotificationListener.Lock()
+	mock.calls.NewNotificationListener = append(mock.calls.NewNotificationListener, callInfo)
+	mock.lockNewNotificationListener.Unlock()
+	return mock.NewNotificationListenerFunc(handler)
+}
+
+// NewNotificationListenerCalls gets all the calls that were made to NewNotificationListener.
+// Check the length with:
+//
+//	len(mockedDatabase.NewNotificationListenerCalls())
+func (mock *DatabaseMock) NewNotificationListenerCalls() []struct {
+	Handler func(db.Notification)
+} {
+	var calls []struct {
+		Handler func(db.Notification)
+	}
+	mock.lockNewNotificationListener.RLock()
+	calls = mock.calls.NewNotificationListener
+	mock.lockNewNotificationListener.RUnlock()
+	return calls
+}
+
+// NewWorkspace calls NewWorkspaceFunc.
+func (mock *DatabaseMock) NewWorkspace(ctx context.Context) (*db.Workspace, error) {
+	if mock.NewWorkspaceFunc == nil {
+		panic("DatabaseMock.NewWorkspaceFunc: method is nil but Database.NewWorkspace was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockNewWorkspace.Lock()
+	mock.calls.NewWorkspace = append(mock.calls.NewWorkspace, callInfo)
+	mock.lockNewWorkspace.Unlock()
+	return mock.NewWorkspaceFunc(ctx)
+}
+
+// NewWorkspaceCalls gets all the calls that were made to NewWorkspace.
+// Check the length with:
+//
+//	len(mockedDatabase.NewWorkspaceCalls())
+func (mock *DatabaseMock) NewWorkspaceCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockNewWorkspace.RLock()
+	calls = mock.calls.NewWorkspace
+	mock.lockNewWorkspace.RUnlock()
+	return calls
+}
+
+// Ping calls PingFunc.
+func (mock *DatabaseMock) Ping(ctx context.Context) error {
+	if mock.PingFunc == nil {
+		panic("DatabaseMock.PingFunc: method is nil but Database.Ping was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockPing.Lock()
+	mock.calls.Ping = append(mock.calls.Ping, callInfo)
+	mock.lockPing.Unlock()
+	return mock.PingFunc(ctx)
+}
+
+// PingCalls gets all the calls that were made to Ping.
+// Check the length with:
+//
+//	len(mockedDatabase.PingCalls())
+func (mock *DatabaseMock) PingCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockPing.RLock()
+	calls = mock.calls.Ping
+	mock.lockPing.RUnlock()
+	return calls
+}
+
+// QueryContext calls QueryContextFunc.
+func (mock *DatabaseMock) QueryContext(ctx context.Context, query string, args ...interface{}) (*db.QueryResult, error) {
+	if mock.QueryContextFunc == nil {
+		panic("DatabaseMock.QueryContextFunc: method is nil but Database.QueryContext was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Query string
+		Args  []interface{}
+	}{
+		Ctx:   ctx,
+		Query: query,
+		Args:  args,
+	}
+	mock.lockQueryContext.Lock()
+	mock.calls.QueryContext = append(mock.calls.QueryContext, callInfo)
+	mock.lockQueryContext.Unlock()
+	return mock.QueryContextFunc(ctx, query, args...)
+}
+
+// QueryContextCalls gets all the calls that were made to QueryContext.
+// Check the length with:
+//
+//	len(mockedDatabase.QueryContextCalls())
+func (mock *DatabaseMock) QueryContextCalls() []struct {
+	Ctx   context.Context
+	Query string
+	Args  []interface{}
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Query string
+		Args  []interface{}
+	}
+	mock.lockQueryContext.RLock()
+	calls = mock.calls.QueryContext
+	mock.lockQueryContext.RUnlock()
+	return calls
+}
+
+// ReferencedTables calls ReferencedTablesFunc.
+func (mock *DatabaseMock) ReferencedTables(ctx context.Context, query string) ([]string, error) {
+	if mock.ReferencedTablesFunc == nil {
+		panic("DatabaseMock.ReferencedTablesFunc: method is nil but Database.ReferencedTables was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Query string
+	}{
+		Ctx:   ctx,
+		Query: query,
+	}
+	mock.lockReferencedTables.Lock()
+	mock.calls.ReferencedTables = append(mock.calls.ReferencedTables, callInfo)
+	mock.lockReferencedTables.Unlock()
+	return mock.ReferencedTablesFunc(ctx, query)
+}
+
+// ReferencedTablesCalls gets all the calls that were made to ReferencedTables.
+// Check the length with:
+//
+//	len(mockedDatabase.ReferencedTablesCalls())
+func (mock *DatabaseMock) ReferencedTablesCalls() []struct {
+	Ctx   context.Context
+	Query string
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Query string
+	}
+	mock.lockReferencedTables.RLock()
+	calls = mock.calls.ReferencedTables
+	mock.lockReferencedTables.RUnlock()
+	return calls
+}
+
+// ResolveTable calls ResolveTableFunc.
+func (mock *DatabaseMock) ResolveTable(ctx context.Context, name string) (*db.TableResolution, error) {
+	if mock.ResolveTableFunc == nil {
+		panic("DatabaseMock.ResolveTableFunc: method is nil but Database.ResolveTable was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Name string
+	}{
+		Ctx:  ctx,
+		Name: name,
+	}
+	mock.lockResolveTable.Lock()
+	mock.calls.ResolveTable = append(mock.calls.ResolveTable, callInfo)
+	mock.lockResolveTable.Unlock()
+	return mock.ResolveTableFunc(ctx, name)
+}
+
+// ResolveTableCalls gets all the calls that were made to ResolveTable.
+// Check the length with:
+//
+//	len(mockedDatabase.ResolveTableCalls())
+func (mock *DatabaseMock) ResolveTableCalls() []struct {
+	Ctx  context.Context
+	Name string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Name string
+	}
+	mock.lockResolveTable.RLock()
+	calls = mock.calls.ResolveTable
+	mock.lockResolveTable.RUnlock()
+	return calls
+}
+
+// ResourceBaseURL calls ResourceBaseURLFunc.
+func (mock *DatabaseMock) ResourceBaseURL() string {
+	if mock.ResourceBaseURLFunc == nil {
+		panic("DatabaseMock.ResourceBaseURLFunc: method is nil but Database.ResourceBaseURL was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockResourceBaseURL.Lock()
+	mock.calls.ResourceBaseURL = append(mock.calls.ResourceBaseURL, callInfo)
+	mock.lockResourceBaseURL.Unlock()
+	return mock.ResourceBaseURLFunc()
+}
+
+// ResourceBaseURLCalls gets all the calls that were made to ResourceBaseURL.
+// Check the length with:
+//
+//	len(mockedDatabase.ResourceBaseURLCalls())
+func (mock *DatabaseMock) ResourceBaseURLCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockResourceBaseURL.RLock()
+	calls = mock.calls.ResourceBaseURL
+	mock.lockResourceBaseURL.RUnlock()
+	return calls
+}
+
+// RestoreBackup calls RestoreBackupFunc.
+func (mock *DatabaseMock) RestoreBackup(ctx context.Context, name string) (int64, error) {
+	if mock.RestoreBackupFunc == nil {
+		panic("DatabaseMock.RestoreBackupFunc: method is nil but Database.RestoreBackup was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Name string
+	}{
+		Ctx:  ctx,
+		Name: name,
+	}
+	mock.lockRestoreBackup.Lock()
+	mock.calls.RestoreBackup = append(mock.calls.RestoreBackup, callInfo)
+	mock.lockRestoreBackup.Unlock()
+	return mock.RestoreBackupFunc(ctx, name)
+}
+
+// RestoreBackupCalls gets all the calls that were made to RestoreBackup.
+// Check the length with:
+//
+//	len(mockedDatabase.RestoreBackupCalls())
+func (mock *DatabaseMock) RestoreBackupCalls() []struct {
+	Ctx  context.Context
+	Name string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Name string
+	}
+	mock.lockRestoreBackup.RLock()
+	calls = mock.calls.RestoreBackup
+	mock.lockRestoreBackup.RUnlock()
+	return calls
+}
+
+// RestoreDump calls RestoreDumpFunc.
+func (mock *DatabaseMock) RestoreDump(ctx context.Context, pgRestore string, path string, database string) error {
+	if mock.RestoreDumpFunc == nil {
+		panic("DatabaseMock.RestoreDumpFunc: method is nil but Database.RestoreDump was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		PgRestore string
+		Path      string
+		Database  string
+	}{
+		Ctx:       ctx,
+		PgRestore: pgRestore,
+		Path:      path,
+		Database:  database,
+	}
+	mock.lockRestoreDump.Lock()
+	mock.calls.RestoreDump = append(mock.calls.RestoreDump, callInfo)
+	mock.lockRestoreDump.Unlock()
+	return mock.RestoreDumpFunc(ctx, pgRestore, path, database)
+}
+
+// RestoreDumpCalls gets all the calls that were made to RestoreDump.
+// Check the length with:
+//
+//	len(mockedDatabase.RestoreDumpCalls())
+func (mock *DatabaseMock) RestoreDumpCalls() []struct {
+	Ctx       context.Context
+	PgRestore string
+	Path      string
+	Database  string
+} {
+	var calls []struct {
+		Ctx       context.Context
+		PgRestore string
+		Path      string
+		Database  string
+	}
+	mock.lockRestoreDump.RLock()
+	calls = mock.calls.RestoreDump
+	mock.lockRestoreDump.RUnlock()
+	return calls
+}
+
+// RunScript calls RunScriptFunc.
+func (mock *DatabaseMock) RunScript(ctx context.Context, statements []string, continueOnError bool) (*db.ScriptResult, error) {
+	if mock.RunScriptFunc == nil {
+		panic("DatabaseMock.RunScriptFunc: method is nil but Database.RunScript was just called")
+	}
+	callInfo := struct {
+		Ctx             context.Context
+		Statements      []string
+		ContinueOnError bool
+	}{
+		Ctx:             ctx,
+		Statements:      statements,
+		ContinueOnError: continueOnError,
+	}
+	mock.lockRunScript.Lock()
+	mock.calls.RunScript = append(mock.calls.RunScript, callInfo)
+	mock.lockRunScript.Unlock()
+	return mock.RunScriptFunc(ctx, statements, continueOnError)
+}
+
+// RunScriptCalls gets all the calls that were made to RunScript.
+// Check the length with:
+//
+//	len(mockedDatabase.RunScriptCalls())
+func (mock *DatabaseMock) RunScriptCalls() []struct {
+	Ctx             context.Context
+	Statements      []string
+	ContinueOnError bool
+} {
+	var calls []struct {
+		Ctx             context.Context
+		Statements      []string
+		ContinueOnError bool
+	}
+	mock.lockRunScript.RLock()
+	calls = mock.calls.RunScript
+	mock.lockRunScript.RUnlock()
+	return calls
+}
+
+// ScheduleCronJob calls ScheduleCronJobFunc.
+func (mock *DatabaseMock) ScheduleCronJob(ctx context.Context, name string, schedule string, command string) (int64, error) {
+	if mock.ScheduleCronJobFunc == nil {
+		panic("DatabaseMock.ScheduleCronJobFunc: method is nil but Database.ScheduleCronJob was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Name     string
+		Schedule string
+		Command  string
+	}{
+		Ctx:      ctx,
+		Name:     name,
+		Schedule: schedule,
+		Command:  command,
+	}
+	mock.lockScheduleCronJob.Lock()
+	mock.calls.ScheduleCronJob = append(mock.calls.ScheduleCronJob, callInfo)
+	mock.lockScheduleCronJob.Unlock()
+	return mock.ScheduleCronJobFunc(ctx, name, schedule, command)
+}
+
+// ScheduleCronJobCalls gets all the calls that were made to ScheduleCronJob.
+// Check the length with:
+//
+//	len(mockedDatabase.ScheduleCronJobCalls())
+func (mock *DatabaseMock) ScheduleCronJobCalls() []struct {
+	Ctx      context.Context
+	Name     string
+	Schedule string
+	Command  string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Name     string
+		Schedule string
+		Command  string
+	}
+	mock.lockScheduleCronJob.RLock()
+	calls = mock.calls.ScheduleCronJob
+	mock.lockScheduleCronJob.RUnlock()
+	return calls
+}
+
+// SchemaFingerprint calls SchemaFingerprintFunc.
+func (mock *DatabaseMock) SchemaFingerprint(ctx context.Context, tableNames []string) (string, error) {
+	if mock.SchemaFingerprintFunc == nil {
+		panic("DatabaseMock.SchemaFingerprintFunc: method is nil but Database.SchemaFingerprint was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		TableNames []string
+	}{
+		Ctx:        ctx,
+		TableNames: tableNames,
+	}
+	mock.lockSchemaFingerprint.Lock()
+	mock.calls.SchemaFingerprint = append(mock.calls.SchemaFingerprint, callInfo)
+	mock.lockSchemaFingerprint.Unlock()
+	return mock.SchemaFingerprintFunc(ctx, tableNames)
+}
+
+// SchemaFingerprintCalls gets all the calls that were made to SchemaFingerprint.
+// Check the length with:
+//
+//	len(mockedDatabase.SchemaFingerprintCalls())
+func (mock *DatabaseMock) SchemaFingerprintCalls() []struct {
+	Ctx        context.Context
+	TableNames []string
+} {
+	var calls []struct {
+		Ctx        context.Context
+		TableNames []string
+	}
+	mock.lockSchemaFingerprint.RLock()
+	calls = mock.calls.SchemaFingerprint
+	mock.lockSchemaFingerprint.RUnlock()
+	return calls
+}
+
+// ScratchDatabaseURL calls ScratchDatabaseURLFunc.
+func (mock *DatabaseMock) ScratchDatabaseURL(name string) (string, error) {
+	if mock.ScratchDatabaseURLFunc == nil {
+		panic("DatabaseMock.ScratchDatabaseURLFunc: method is nil but Database.ScratchDatabaseURL was just called")
+	}
+	callInfo := struct {
+		Name string
+	}{
+		Name: name,
+	}
+	mock.lockScratchDatabaseURL.Lock()
+	mock.calls.ScratchDatabaseURL = append(mock.calls.ScratchDatabaseURL, callInfo)
+	mock.lockScratchDatabaseURL.Unlock()
+	return mock.ScratchDatabaseURLFunc(name)
+}
+
+// ScratchDatabaseURLCalls gets all the calls that were made to ScratchDatabaseURL.
+// Check the length with:
+//
+//	len(mockedDatabase.ScratchDatabaseURLCalls())
+func (mock *DatabaseMock) ScratchDatabaseURLCalls() []struct {
+	Name string
+} {
+	var calls []struct {
+		Name string
+	}
+	mock.lockScratchDatabaseURL.RLock()
+	calls = mock.calls.ScratchDatabaseURL
+	mock.lockScratchDatabaseURL.RUnlock()
+	return calls
+}
+
+// SetCronJobActive calls SetCronJobActiveFunc.
+func (mock *DatabaseMock) SetCronJobActive(ctx context.Context, jobID int64, active bool) error {
+	if mock.SetCronJobActiveFunc == nil {
+		panic("DatabaseMock.SetCronJobActiveFunc: method is nil but Database.SetCronJobActive was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		JobID  int64
+		Active bool
+	}{
+		Ctx:    ctx,
+		JobID:  jobID,
+		Active: active,
+	}
+	mock.lockSetCronJobActive.Lock()
+	mock.calls.SetCronJobActive = append(mock.calls.SetCronJobActive, callInfo)
+	mock.lockSetCronJobActive.Unlock()
+	return mock.SetCronJobActiveFunc(ctx, jobID, active)
+}
+
+// SetCronJobActiveCalls gets all the calls that were made to SetCronJobActive.
+// Check the length with:
+//
+//	len(mockedDatabase.SetCronJobActiveCalls())
+func (mock *DatabaseMock) SetCronJobActiveCalls() []struct {
+	Ctx    context.Context
+	JobID  int64
+	Active bool
+} {
+	var calls []struct {
+		Ctx    context.Context
+		JobID  int64
+		Active bool
+	}
+	mock.lockSetCronJobActive.RLock()
+	calls = mock.calls.SetCronJobActive
+	mock.lockSetCronJobActive.RUnlock()
+	return calls
+}
+
+// SuggestIndexes calls SuggestIndexesFunc.
+func (mock *DatabaseMock) SuggestIndexes(ctx context.Context, queries []string) ([]db.IndexAdvice, error) {
+	if mock.SuggestIndexesFunc == nil {
+		panic("DatabaseMock.SuggestIndexesFunc: method is nil but Database.SuggestIndexes was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Queries []string
+	}{
+		Ctx:     ctx,
+		Queries: queries,
+	}
+	mock.lockSuggestIndexes.Lock()
+	mock.calls.SuggestIndexes = append(mock.calls.SuggestIndexes, callInfo)
+	mock.lockSuggestIndexes.Unlock()
+	return mock.SuggestIndexesFunc(ctx, queries)
+}
+
+// SuggestIndexesCalls gets all the calls that were made to SuggestIndexes.
+// Check the length with:
+//
+//	len(mockedDatabase.SuggestIndexesCalls())
+func (mock *DatabaseMock) SuggestIndexesCalls() []struct {
+	Ctx     context.Context
+	Queries []string
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Queries []string
+	}
+	mock.lockSuggestIndexes.RLock()
+	calls = mock.calls.SuggestIndexes
+	mock.lockSuggestIndexes.RUnlock()
+	return calls
+}
+
+// TableFingerprints calls TableFingerprintsFunc.
+func (mock *DatabaseMock) TableFingerprints(ctx context.Context) (map[string]string, error) {
+	if mock.TableFingerprintsFunc == nil {
+		panic("DatabaseMock.TableFingerprintsFunc: method is nil but Database.TableFingerprints was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockTableFingerprints.Lock()
+	mock.calls.TableFingerprints = append(mock.calls.TableFingerprints, callInfo)
+	mock.lockTableFingerprints.Unlock()
+	return mock.TableFingerprintsFunc(ctx)
+}
+
+// TableFingerprintsCalls gets all the calls that were made to TableFingerprints.
+// Check the length with:
+//
+//	len(mockedDatabase.TableFingerprintsCalls())
+func (mock *DatabaseMock) TableFingerprintsCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockTableFingerprints.RLock()
+	calls = mock.calls.TableFingerprints
+	mock.lockTableFingerprints.RUnlock()
+	return calls
+}
+
+// TopQueries calls TopQueriesFunc.
+func (mock *DatabaseMock) TopQueries(orderBy string, limit int) ([]db.StatementStats, error) {
+	if mock.TopQueriesFunc == nil {
+		panic("DatabaseMock.TopQueriesFunc: method is nil but Database.TopQueries was just called")
+	}
+	callInfo := struct {
+		OrderBy string
+		Limit   int
+	}{
+		OrderBy: orderBy,
+		Limit:   limit,
+	}
+	mock.lockTopQueries.Lock()
+	mock.calls.TopQueries = append(mock.calls.TopQueries, callInfo)
+	mock.lockTopQueries.Unlock()
+	return mock.TopQueriesFunc(orderBy, limit)
+}
+
+// TopQueriesCalls gets all the calls that were made to TopQueries.
+// Check the length with:
+//
+//	len(mockedDatabase.TopQueriesCalls())
+func (mock *DatabaseMock) TopQueriesCalls() []struct {
+	OrderBy string
+	Limit   int
+} {
+	var calls []struct {
+		OrderBy string
+		Limit   int
+	}
+	mock.lockTopQueries.RLock()
+	calls = mock.calls.TopQueries
+	mock.lockTopQueries.RUnlock()
+	return calls
+}
+
+// TopStatements calls TopStatementsFunc.
+func (mock *DatabaseMock) TopStatements(limit int) ([]string, error) {
+	if mock.TopStatementsFunc == nil {
+		panic("DatabaseMock.TopStatementsFunc: method is nil but Database.TopStatements was just called")
+	}
+	callInfo := struct {
+		Limit int
+	}{
+		Limit: limit,
+	}
+	mock.lockTopStatements.Lock()
+	mock.calls.TopStatements = append(mock.calls.TopStatements, callInfo)
+	mock.lockTopStatements.Unlock()
+	return mock.TopStatementsFunc(limit)
+}
+
+// TopStatementsCalls gets all the calls that were made to TopStatements.
+// Check the length with:
+//
+//	len(mockedDatabase.TopStatementsCalls())
+func (mock *DatabaseMock) TopStatementsCalls() []struct {
+	Limit int
+} {
+	var calls []struct {
+		Limit int
+	}
+	mock.lockTopStatements.RLock()
+	calls = mock.calls.TopStatements
+	mock.lockTopStatements.RUnlock()
+	return calls
+}
+
+// UninstallDDLTrigger calls UninstallDDLTriggerFunc.
+func (mock *DatabaseMock) UninstallDDLTrigger(ctx context.Context) error {
+	if mock.UninstallDDLTriggerFunc == nil {
+		panic("DatabaseMock.UninstallDDLTriggerFunc: method is nil but Database.UninstallDDLTrigger was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockUninstallDDLTrigger.Lock()
+	mock.calls.UninstallDDLTrigger = append(mock.calls.UninstallDDLTrigger, callInfo)
+	mock.lockUninstallDDLTrigger.Unlock()
+	return mock.UninstallDDLTriggerFunc(ctx)
+}
+
+// UninstallDDLTriggerCalls gets all the calls that were made to UninstallDDLTrigger.
+// Check the length with:
+//
+//	len(mockedDatabase.UninstallDDLTriggerCalls())
+func (mock *DatabaseMock) UninstallDDLTriggerCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockUninstallDDLTrigger.RLock()
+	calls = mock.calls.UninstallDDLTrigger
+	mock.lockUninstallDDLTrigger.RUnlock()
+	return calls
+}
+
+// Vacuum calls VacuumFunc.
+func (mock *DatabaseMock) Vacuum(ctx context.Context, schema string, table string, analyze bool) error {
+	if mock.VacuumFunc == nil {
+		panic("DatabaseMock.VacuumFunc: method is nil but Database.Vacuum was just called")
+	}
+	callInfo := struct {
+		Ctx     context.Context
+		Schema  string
+		Table   string
+		Analyze bool
+	}{
+		Ctx:     ctx,
+		Schema:  schema,
+		Table:   table,
+		Analyze: analyze,
+	}
+	mock.lockVacuum.Lock()
+	mock.calls.Vacuum = append(mock.calls.Vacuum, callInfo)
+	mock.lockVacuum.Unlock()
+	return mock.VacuumFunc(ctx, schema, table, analyze)
+}
+
+// VacuumCalls gets all the calls that were made to Vacuum.
+// Check the length with:
+//
+//	len(mockedDatabase.VacuumCalls())
+func (mock *DatabaseMock) VacuumCalls() []struct {
+	Ctx     context.Context
+	Schema  string
+	Table   string
+	Analyze bool
+} {
+	var calls []struct {
+		Ctx     context.Context
+		Schema  string
+		Table   string
+		Analyze bool
+	}
+	mock.lockVacuum.RLock()
+	calls = mock.calls.Vacuum
+	mock.lockVacuum.RUnlock()
+	return calls
+}
+
+// VectorSearch calls VectorSearchFunc.
+func (mock *DatabaseMock) VectorSearch(params db.VectorSearchParams) (*db.QueryResult, error) {
+	if mock.VectorSearchFunc == nil {
+		panic("DatabaseMock.VectorSearchFunc: method is nil but Database.VectorSearch was just called")
+	}
+	callInfo := struct {
+		Params db.VectorSearchParams
+	}{
+		Params: params,
+	}
+	mock.lockVectorSearch.Lock()
+	mock.calls.VectorSearch = append(mock.calls.VectorSearch, callInfo)
+	mock.lockVectorSearch.Unlock()
+	return mock.VectorSearchFunc(params)
+}
+
+// VectorSearchCalls gets all the calls that were made to VectorSearch.
+// Check the length with:
+//
+//	len(mockedDatabase.VectorSearchCalls())
+func (mock *DatabaseMock) VectorSearchCalls() []struct {
+	Params db.VectorSearchParams
+} {
+	var calls []struct {
+		Params db.VectorSearchParams
+	}
+	mock.lockVectorSearch.RLock()
+	calls = mock.calls.VectorSearch
+	mock.lockVectorSearch.RUnlock()
+	return calls
+}
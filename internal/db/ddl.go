@@ -0,0 +1,421 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+)
+
+// IndexMethods are the access methods accepted by BuildCreateIndex
+var IndexMethods = []string{"btree", "hash", "gist", "spgist", "gin", "brin"}
+
+// maxIdentifierLength is the length PostgreSQL truncates identifiers to
+const maxIdentifierLength = 63
+
+// plainIdentifier matches names that need no quoting in SQL
+var plainIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_$]*$`)
+
+// ColumnDef is a column of the DDL tools
+type ColumnDef struct {
+	Name string
+	// Type is a PostgreSQL type name, e.g. text, varchar(100) or integer[]
+	Type       string
+	NotNull    bool
+	PrimaryKey bool
+	Unique     bool
+	// Default is an SQL expression
+	Default string
+	// References is the referenced "table.column" or "schema.table.column"
+	References string
+}
+
+// CreateTableParams describes a table to create
+type CreateTableParams struct {
+	Schema  string
+	Table   string
+	Columns []ColumnDef
+}
+
+// AddColumnParams describes a column to add to a table
+type AddColumnParams struct {
+	Schema string
+	Table  string
+	Column ColumnDef
+}
+
+// CreateIndexParams describes an index to create
+type CreateIndexParams struct {
+	Schema string
+	Table  string
+	// Name defaults to table_columns_idx
+	Name    string
+	Columns []string
+	Unique  bool
+	// Method defaults to btree
+	Method string
+}
+
+// DDL is a generated DDL statement with the warnings found validating it
+// against the schema. Problems that would make it fail are returned as errors.
+type DDL struct {
+	SQL      string   `json:"sql"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// BuildCreateTable generates a CREATE TABLE statement. The schema must exist
+// and the table must not; types, defaults and referenced columns are checked
+// against the catalog.
+func (d *DB) BuildCreateTable(ctx context.Context, params CreateTableParams) (*DDL, error) {
+	if params.Schema == "" {
+		params.Schema = "public"
+	}
+	if err := checkIdentifier("table", params.Table); err != nil {
+		return nil, err
+	}
+	if len(params.Columns) == 0 {
+		return nil, fmt.Errorf("at least one column is required")
+	}
+
+	var exists bool
+	if err := d.conn.GetContext(ctx, &exists, "SELECT EXISTS (SELECT 1 FROM pg_catalog.pg_namespace WHERE nspname = $1)", params.Schema); err != nil {
+		return nil, fmt.Errorf("failed to look up schema %s: %w", params.Schema, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("schema %s does not exist", params.Schema)
+	}
+	if exists, err := d.relationExists(ctx, params.Schema, params.Table); err != nil {
+		return nil, err
+	} else if exists {
+		return nil, fmt.Errorf("%s.%s already exists", params.Schema, params.Table)
+	}
+
+	ddl := &DDL{}
+	names := map[string]bool{}
+	var primaryKey []string
+	for _, col := range params.Columns {
+		if names[col.Name] {
+			return nil, fmt.Errorf("column %q is given twice", col.Name)
+		}
+		names[col.Name] = true
+		if col.PrimaryKey {
+			primaryKey = append(primaryKey, quoteIdent(col.Name))
+		}
+	}
+
+	self := &tableRef{schema: params.Schema, table: params.Table, columns: names}
+	defs := make([]string, 0, len(params.Columns)+1)
+	for _, col := range params.Columns {
+		// A single primary key column is declared inline
+		def, err := d.columnDefinition(ctx, self, col, len(primaryKey) == 1, ddl)
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+	}
+	if len(primaryKey) > 1 {
+		defs = append(defs, "PRIMARY KEY ("+strings.Join(primaryKey, ", ")+")")
+	}
+	if len(primaryKey) == 0 {
+		ddl.Warnings = append(ddl.Warnings, fmt.Sprintf("%s has no primary key", params.Table))
+	}
+	ddl.addNameWarning("table", params.Table)
+
+	ddl.SQL = fmt.Sprintf("CREATE TABLE %s (\n    %s\n)", self.quoted(), strings.Join(defs, ",\n    "))
+	return ddl, nil
+}
+
+// BuildAddColumn generates an ALTER TABLE ... ADD COLUMN statement. The table
+// must exist without the column, and a NOT NULL column without a default can
+// only be added to an empty table.
+func (d *DB) BuildAddColumn(ctx context.Context, params AddColumnParams) (*DDL, error) {
+	ref, err := d.lookupTable(params.Schema, params.Table)
+	if err != nil {
+		return nil, err
+	}
+	col := params.Column
+	if ref.columns[col.Name] {
+		return nil, fmt.Errorf("column %q already exists in %s.%s", col.Name, ref.schema, ref.table)
+	}
+
+	if col.PrimaryKey {
+		var hasPrimaryKey bool
+		query := `SELECT EXISTS (SELECT 1 FROM pg_catalog.pg_index
+			WHERE indrelid = $1::regclass AND indisprimary)`
+		if err := d.conn.GetContext(ctx, &hasPrimaryKey, query, ref.quoted()); err != nil {
+			return nil, fmt.Errorf("failed to look up the primary key of %s.%s: %w", ref.schema, ref.table, err)
+		}
+		if hasPrimaryKey {
+			return nil, fmt.Errorf("%s.%s already has a primary key", ref.schema, ref.table)
+		}
+	}
+	if (col.NotNull || col.PrimaryKey) && col.Default == "" {
+		var hasRows bool
+		if err := d.conn.GetContext(ctx, &hasRows, fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s)", ref.quoted())); err != nil {
+			return nil, fmt.Errorf("failed to check whether %s.%s has rows: %w", ref.schema, ref.table, err)
+		}
+		if hasRows {
+			return nil, fmt.Errorf("%s.%s has rows, a NOT NULL column needs a default", ref.schema, ref.table)
+		}
+	}
+
+	ddl := &DDL{}
+	def, err := d.columnDefinition(ctx, ref, col, true, ddl)
+	if err != nil {
+		return nil, err
+	}
+	if col.PrimaryKey || col.Unique {
+		ddl.Warnings = append(ddl.Warnings, fmt.Sprintf("building the index of %s blocks writes to %s.%s until it is done", col.Name, ref.schema, ref.table))
+	}
+	ddl.SQL = fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", ref.quoted(), def)
+	return ddl, nil
+}
+
+// BuildCreateIndex generates a CREATE INDEX statement on existing columns.
+// Existing indexes that already lead with the same columns are reported as
+// warnings.
+func (d *DB) BuildCreateIndex(ctx context.Context, params CreateIndexParams) (*DDL, error) {
+	ref, err := d.lookupTable(params.Schema, params.Table)
+	if err != nil {
+		return nil, err
+	}
+	if len(params.Columns) == 0 {
+		return nil, fmt.Errorf("at least one column is required")
+	}
+	method := strings.ToLower(params.Method)
+	if method == "" {
+		method = "btree"
+	}
+	if !slices.Contains(IndexMethods, method) {
+		return nil, fmt.Errorf("invalid index method %q, must be one of %s", params.Method, strings.Join(IndexMethods, ", "))
+	}
+	if params.Unique && method != "btree" {
+		return nil, fmt.Errorf("only btree indexes can be unique")
+	}
+
+	cols := make([]string, 0, len(params.Columns))
+	for _, name := range params.Columns {
+		col, err := ref.column(name)
+		if err != nil {
+			return nil, err
+		}
+		cols = append(cols, col)
+	}
+
+	name := params.Name
+	if name == "" {
+		name = defaultIndexName(params.Table, params.Columns)
+	}
+	if err := checkIdentifier("index", name); err != nil {
+		return nil, err
+	}
+	if exists, err := d.relationExists(ctx, ref.schema, name); err != nil {
+		return nil, err
+	} else if exists {
+		return nil, fmt.Errorf("%s.%s already exists", ref.schema, name)
+	}
+
+	ddl := &DDL{}
+	var existing []struct {
+		Name    string         `db:"name"`
+		Method  string         `db:"method"`
+		Columns pq.StringArray `db:"columns"`
+	}
+	query := `SELECT i.relname AS name, am.amname AS method,
+		ARRAY(SELECT a.attname
+			FROM unnest(x.indkey::int2[]) WITH ORDINALITY AS k(attnum, ord)
+			JOIN pg_catalog.pg_attribute a ON a.attrelid = x.indrelid AND a.attnum = k.attnum
+			ORDER BY k.ord) AS columns
+		FROM pg_catalog.pg_index x
+		JOIN pg_catalog.pg_class i ON i.oid = x.indexrelid
+		JOIN pg_catalog.pg_am am ON am.oid = i.relam
+		WHERE x.indrelid = $1::regclass AND x.indpred IS NULL`
+	if err := d.conn.SelectContext(ctx, &existing, query, ref.quoted()); err != nil {
+		return nil, fmt.Errorf("failed to get indexes of %s.%s: %w", ref.schema, ref.table, err)
+	}
+	for _, index := range existing {
+		if index.Method == method && len(index.Columns) >= len(params.Columns) && slices.Equal(index.Columns[:len(params.Columns)], params.Columns) {
+			ddl.Warnings = append(ddl.Warnings, fmt.Sprintf("index %s on (%s) already covers these columns", index.Name, strings.Join(index.Columns, ", ")))
+		}
+	}
+	ddl.addNameWarning("index", name)
+
+	unique := ""
+	if params.Unique {
+		unique = "UNIQUE "
+	}
+	ddl.SQL = fmt.Sprintf("CREATE %sINDEX %s ON %s USING %s (%s)", unique, quoteIdent(name), ref.quoted(), method, strings.Join(cols, ", "))
+	return ddl, nil
+}
+
+// defaultIndexName returns table_column_idx, shortened to the identifier
+// length limit on a rune boundary
+func defaultIndexName(table string, columns []string) string {
+	name := table + "_" + strings.Join(columns, "_")
+	if len(name)+len("_idx") > maxIdentifierLength {
+		cut := maxIdentifierLength - len("_idx")
+		for cut > 0 && !utf8.RuneStart(name[cut]) {
+			cut--
+		}
+		name = name[:cut]
+	}
+	return name + "_idx"
+}
+
+// columnDefinition validates a column and returns its definition. table is
+// the table the column belongs to, which may not exist yet; inlinePrimaryKey
+// declares PRIMARY KEY on the column itself.
+func (d *DB) columnDefinition(ctx context.Context, table *tableRef, col ColumnDef, inlinePrimaryKey bool, ddl *DDL) (string, error) {
+	if err := checkIdentifier("column", col.Name); err != nil {
+		return "", err
+	}
+	typ := strings.TrimSpace(col.Type)
+	if typ == "" {
+		return "", fmt.Errorf("column %s needs a type", col.Name)
+	}
+	if strings.ContainsAny(typ, ";'\"") {
+		return "", fmt.Errorf("invalid type %q of column %s", typ, col.Name)
+	}
+	var known bool
+	if err := d.conn.GetContext(ctx, &known, "SELECT to_regtype($1) IS NOT NULL", typ); err != nil {
+		return "", fmt.Errorf("invalid type %q of column %s: %w", typ, col.Name, err)
+	}
+	if !known {
+		return "", fmt.Errorf("type %q of column %s does not exist", typ, col.Name)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(quoteIdent(col.Name) + " " + typ)
+	if col.PrimaryKey && inlinePrimaryKey {
+		sb.WriteString(" PRIMARY KEY")
+	} else if col.NotNull {
+		sb.WriteString(" NOT NULL")
+	}
+	if col.Unique && !(col.PrimaryKey && inlinePrimaryKey) {
+		sb.WriteString(" UNIQUE")
+	}
+
+	if col.Default != "" {
+		// The default must be a single expression of the column type;
+		// planning it checks it without evaluating it
+		if err := checkExpression(col.Default); err != nil {
+			return "", fmt.Errorf("default of column %s %w", col.Name, err)
+		}
+		if _, err := d.ExplainQuery(ctx, fmt.Sprintf("SELECT (%s)::%s", col.Default, typ), nil); err != nil {
+			return "", fmt.Errorf("invalid default of column %s: %w", col.Name, err)
+		}
+		sb.WriteString(" DEFAULT " + col.Default)
+	}
+
+	if col.References != "" {
+		ref, err := d.referencedColumn(ctx, table, col.References)
+		if err != nil {
+			return "", fmt.Errorf("invalid reference of column %s: %w", col.Name, err)
+		}
+		sb.WriteString(" REFERENCES " + ref)
+	}
+
+	ddl.addNameWarning("column", col.Name)
+	return sb.String(), nil
+}
+
+// checkExpression checks that an expression spliced into DDL cannot end
+// it or run into the rest of it: it must have no comments, semicolons or
+// unbalanced parentheses
+func checkExpression(expr string) error {
+	if hasComments(expr) {
+		return fmt.Errorf("must not contain comments")
+	}
+	depth := 0
+	for _, c := range maskSQL(expr) {
+		switch c {
+		case ';':
+			return fmt.Errorf("must be a single expression")
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("has unbalanced parentheses")
+			}
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("has unbalanced parentheses")
+	}
+	return nil
+}
+
+// referencedColumn checks that a "table.column" or "schema.table.column"
+// reference names a column with a unique constraint and returns it as
+// table (column). References to the table being created are not checked.
+func (d *DB) referencedColumn(ctx context.Context, table *tableRef, reference string) (string, error) {
+	i := strings.LastIndex(reference, ".")
+	if i < 0 {
+		return "", fmt.Errorf("reference %q must be table.column or schema.table.column", reference)
+	}
+	schema, name := splitTableName(reference[:i])
+	column := reference[i+1:]
+	if (schema == "" || schema == table.schema) && name == table.table {
+		if !table.columns[column] {
+			return "", fmt.Errorf("column %q does not exist in %s.%s", column, table.schema, name)
+		}
+		return table.quoted() + " (" + quoteIdent(column) + ")", nil
+	}
+
+	ref, err := d.lookupTable(schema, name)
+	if err != nil {
+		return "", err
+	}
+	col, err := ref.column(column)
+	if err != nil {
+		return "", err
+	}
+	var unique bool
+	query := `SELECT EXISTS (SELECT 1 FROM pg_catalog.pg_index i
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = i.indrelid
+		WHERE i.indrelid = $1::regclass AND i.indisunique AND i.indnkeyatts = 1
+		AND i.indpred IS NULL AND a.attname = $2 AND i.indkey[0] = a.attnum)`
+	if err := d.conn.GetContext(ctx, &unique, query, ref.quoted(), column); err != nil {
+		return "", fmt.Errorf("failed to look up the constraints of %s.%s: %w", schema, name, err)
+	}
+	if !unique {
+		return "", fmt.Errorf("%s.%s.%s is not a primary key or unique column", schema, name, column)
+	}
+	return ref.quoted() + " (" + col + ")", nil
+}
+
+// relationExists reports whether a schema has a table, view, index or other
+// relation of the name
+func (d *DB) relationExists(ctx context.Context, schema, name string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2)`
+	if err := d.conn.GetContext(ctx, &exists, query, schema, name); err != nil {
+		return false, fmt.Errorf("failed to look up %s.%s: %w", schema, name, err)
+	}
+	return exists, nil
+}
+
+// checkIdentifier rejects empty names and names PostgreSQL would truncate
+func checkIdentifier(kind, name string) error {
+	if name == "" {
+		return fmt.Errorf("%s name is required", kind)
+	}
+	if len(name) > maxIdentifierLength {
+		return fmt.Errorf("%s name %q is longer than %d bytes", kind, name, maxIdentifierLength)
+	}
+	return nil
+}
+
+// addNameWarning warns about names that have to be quoted in every query
+func (ddl *DDL) addNameWarning(kind, name string) {
+	if !plainIdentifier.MatchString(name) {
+		ddl.Warnings = append(ddl.Warnings, fmt.Sprintf("%s name %q must be double-quoted in queries, prefer lower case letters, digits and underscores", kind, name))
+	}
+}
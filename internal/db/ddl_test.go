@@ -0,0 +1,52 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCheckExpression(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{expr: "0"},
+		{expr: "now()"},
+		{expr: "'a (b' || lower('C')"},
+		{expr: `"weird)name"`},
+		{expr: "$$;)$$"},
+		{expr: "1); DROP TABLE t", wantErr: true},
+		{expr: "0 -- rest", wantErr: true},
+		{expr: "0 /* rest */", wantErr: true},
+		{expr: "0) CHECK (true", wantErr: true},
+		{expr: "(0", wantErr: true},
+		{expr: "0)", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if err := checkExpression(tt.expr); (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDefaultIndexName(t *testing.T) {
+	if got, want := defaultIndexName("orders", []string{"customer_id", "created_at"}), "orders_customer_id_created_at_idx"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	for _, table := range []string{strings.Repeat("a", 80), strings.Repeat("a", 58) + "é" + strings.Repeat("b", 10), strings.Repeat("é", 40)} {
+		got := defaultIndexName(table, []string{"id"})
+		if len(got) > maxIdentifierLength {
+			t.Errorf("got %d bytes, want at most %d", len(got), maxIdentifierLength)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("got invalid UTF-8 %q", got)
+		}
+		if !strings.HasSuffix(got, "_idx") {
+			t.Errorf("got %q, want an _idx suffix", got)
+		}
+	}
+}
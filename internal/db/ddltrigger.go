@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// DDLChannel is the notification channel the DDL event triggers notify
+	DDLChannel = "pgmcp_ddl"
+	// ddlFunction is the event trigger function, created in the public schema
+	ddlFunction = "public.pgmcp_ddl_notify"
+	// ddlCommandTrigger and ddlDropTrigger are the event triggers
+	ddlCommandTrigger = "pgmcp_ddl_command_end"
+	ddlDropTrigger    = "pgmcp_ddl_sql_drop"
+	// maxDDLPayload keeps the payload below the 8000 byte NOTIFY limit
+	maxDDLPayload = 7900
+)
+
+// DDLEvent is the payload of a notification of the DDL event triggers
+type DDLEvent struct {
+	// Tag is the command tag, e.g. ALTER TABLE
+	Tag string `json:"tag"`
+	// Tables are the names of the relations the command created, changed or
+	// dropped. Nil means unknown, when there were too many to notify.
+	Tables []string `json:"tables"`
+}
+
+// ParseDDLEvent parses the payload of a DDLChannel notification
+func ParseDDLEvent(payload string) (*DDLEvent, error) {
+	var event DDLEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		return nil, fmt.Errorf("invalid DDL notification %q: %w", payload, err)
+	}
+	return &event, nil
+}
+
+// ddlTriggerStatements create the event trigger function and the event
+// triggers notifying DDLChannel after every DDL command, with the relations
+// the command touched. ddl_command_end reports the created and altered
+// relations, sql_drop the dropped ones.
+var ddlTriggerStatements = []string{
+	`CREATE OR REPLACE FUNCTION ` + ddlFunction + `() RETURNS event_trigger
+	LANGUAGE plpgsql AS $$
+	DECLARE
+		tables json;
+		payload text;
+	BEGIN
+		IF TG_EVENT = 'sql_drop' THEN
+			SELECT json_agg(DISTINCT d.object_name) INTO tables
+			FROM pg_event_trigger_dropped_objects() d
+			WHERE d.object_type IN ('table', 'view', 'materialized view', 'foreign table');
+		ELSE
+			SELECT json_agg(DISTINCT c.relname) INTO tables
+			FROM pg_event_trigger_ddl_commands() cmd
+			JOIN pg_catalog.pg_class c ON c.oid = cmd.objid
+			WHERE cmd.classid = 'pg_catalog.pg_class'::regclass;
+		END IF;
+		IF tables IS NULL THEN
+			RETURN;
+		END IF;
+		payload := json_build_object('tag', TG_TAG, 'tables', tables)::text;
+		IF octet_length(payload) > ` + fmt.Sprint(maxDDLPayload) + ` THEN
+			payload := json_build_object('tag', TG_TAG, 'tables', NULL)::text;
+		END IF;
+		PERFORM pg_notify('` + DDLChannel + `', payload);
+	END
+	$$`,
+	`DROP EVENT TRIGGER IF EXISTS ` + ddlCommandTrigger,
+	`CREATE EVENT TRIGGER ` + ddlCommandTrigger + ` ON ddl_command_end EXECUTE PROCEDURE ` + ddlFunction + `()`,
+	`DROP EVENT TRIGGER IF EXISTS ` + ddlDropTrigger,
+	`CREATE EVENT TRIGGER ` + ddlDropTrigger + ` ON sql_drop EXECUTE PROCEDURE ` + ddlFunction + `()`,
+}
+
+// InstallDDLTrigger creates the event triggers notifying DDLChannel of DDL
+// commands, replacing earlier versions. Event triggers can only be created by
+// superusers.
+func (d *DB) InstallDDLTrigger(ctx context.Context) error {
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	for _, stmt := range ddlTriggerStatements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to install DDL event trigger: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to install DDL event trigger: %w", err)
+	}
+	return nil
+}
+
+// UninstallDDLTrigger drops the event triggers and their function
+func (d *DB) UninstallDDLTrigger(ctx context.Context) error {
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	for _, stmt := range []string{
+		`DROP EVENT TRIGGER IF EXISTS ` + ddlCommandTrigger,
+		`DROP EVENT TRIGGER IF EXISTS ` + ddlDropTrigger,
+		`DROP FUNCTION IF EXISTS ` + ddlFunction + `()`,
+	} {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to uninstall DDL event trigger: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to uninstall DDL event trigger: %w", err)
+	}
+	return nil
+}
+
+// DDLTriggerInstalled reports whether both event triggers exist and are enabled
+func (d *DB) DDLTriggerInstalled(ctx context.Context) (bool, error) {
+	var count int
+	query := `SELECT count(*) FROM pg_catalog.pg_event_trigger
+		WHERE evtname IN ($1, $2) AND evtenabled <> 'D'`
+	if err := d.conn.GetContext(ctx, &count, query, ddlCommandTrigger, ddlDropTrigger); err != nil {
+		return false, fmt.Errorf("failed to check the DDL event trigger: %w", err)
+	}
+	return count == 2, nil
+}
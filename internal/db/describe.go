@@ -0,0 +1,259 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// TableDescription is the full definition of a table: its columns, indexes
+// and constraints
+type TableDescription struct {
+	Schema  string `json:"schema"`
+	Name    string `json:"name"`
+	Kind    string `json:"kind"`
+	Comment string `json:"comment,omitempty"`
+	// PartitionKey is the PARTITION BY clause of a partitioned table
+	PartitionKey string `json:"partition_key,omitempty"`
+	// PartitionOf is the parent of a partition with its bound
+	PartitionOf string `json:"partition_of,omitempty"`
+	// DatabaseLocale is the encoding and the default collation of the
+	// columns without one of their own
+	DatabaseLocale *DatabaseLocale         `json:"database_locale"`
+	Columns        []ColumnDescription     `json:"columns"`
+	Indexes        []IndexDescription      `json:"indexes"`
+	Constraints    []ConstraintDescription `json:"constraints"`
+}
+
+// ColumnDescription is a column of a described table
+type ColumnDescription struct {
+	Name    string `db:"name" json:"name"`
+	Type    string `db:"type" json:"type"`
+	NotNull bool   `db:"not_null" json:"not_null"`
+	// Default is the default expression, nil for identity and generated
+	// columns
+	Default *string `db:"default_expr" json:"default"`
+	// Identity is ALWAYS or BY DEFAULT for identity columns
+	Identity string `db:"identity" json:"identity,omitempty"`
+	// Generated is the expression of a generated column, Storage whether it
+	// is STORED or VIRTUAL (PostgreSQL 18)
+	Generated *string `db:"generated_expr" json:"generated,omitempty"`
+	Storage   string  `db:"generated_storage" json:"generated_storage,omitempty"`
+	// Collation is the collation of a text column that does not use the
+	// database default; Nondeterministic is set when it compares strings
+	// that differ, e.g. in case, as equal (PostgreSQL 12)
+	Collation        *string `db:"collation" json:"collation,omitempty"`
+	Nondeterministic bool    `db:"nondeterministic" json:"nondeterministic_collation,omitempty"`
+	// DomainChecks are the CHECK constraints of the column's domain type
+	DomainChecks pq.StringArray `db:"domain_checks" json:"domain_checks,omitempty"`
+	Comment      *string        `db:"comment" json:"comment,omitempty"`
+	// Insert tells whether an INSERT must (required), may (optional) or
+	// cannot (never) give the column a value; it is empty for views
+	Insert string `db:"-" json:"insert,omitempty"`
+}
+
+// Column insert rules of ColumnDescription
+const (
+	InsertRequired = "required"
+	InsertOptional = "optional"
+	InsertNever    = "never"
+)
+
+// IndexDescription is an index of a described table
+type IndexDescription struct {
+	Name       string `db:"name" json:"name"`
+	Definition string `db:"definition" json:"definition"`
+	Primary    bool   `db:"is_primary" json:"primary"`
+	Unique     bool   `db:"is_unique" json:"unique"`
+	// NullsNotDistinct is set for unique indexes treating NULLs as equal
+	// (PostgreSQL 15)
+	NullsNotDistinct bool `db:"nulls_not_distinct" json:"nulls_not_distinct,omitempty"`
+	// Partitioned is set for the index of a partitioned table, which only
+	// exists through the indexes of its partitions
+	Partitioned bool `db:"partitioned" json:"partitioned,omitempty"`
+	// Parent is the partitioned index this partition's index is attached to
+	Parent *string `db:"parent" json:"parent,omitempty"`
+	Valid  bool    `db:"is_valid" json:"valid"`
+	// Constraint is the constraint the index enforces
+	Constraint *string `db:"constraint_name" json:"constraint,omitempty"`
+}
+
+// ConstraintDescription is a constraint of a described table
+type ConstraintDescription struct {
+	Name string `db:"name" json:"name"`
+	// Type is primary key, unique, foreign key, check, exclusion, not null
+	// (PostgreSQL 18) or trigger
+	Type       string `db:"type" json:"type"`
+	Definition string `db:"definition" json:"definition"`
+	// Columns are the constrained columns, empty for checks of the whole row
+	Columns pq.StringArray `db:"columns" json:"columns"`
+	// Expression is the boolean expression of a check constraint, which new
+	// and updated rows must not make false
+	Expression *string `db:"expression" json:"expression,omitempty"`
+	Deferrable bool    `db:"deferrable" json:"deferrable,omitempty"`
+	Validated  bool    `db:"validated" json:"validated"`
+	// Inherited is set for constraints of a partition or child table that
+	// come from its parent
+	Inherited bool `db:"inherited" json:"inherited,omitempty"`
+}
+
+// DescribeTable returns the columns, indexes and constraints of a table,
+// view or foreign table, with the database locale. The catalog columns of newer servers (generated
+// columns, partitioned indexes, NULLS NOT DISTINCT) are only read when the
+// server has them.
+func (d *DB) DescribeTable(ctx context.Context, schema, table string) (*TableDescription, error) {
+	if schema == "" {
+		var err error
+		if schema, err = d.searchPathSchema(table); err != nil {
+			return nil, err
+		}
+	}
+	version, err := d.serverVersionNum(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var rel struct {
+		OID          int64          `db:"oid"`
+		Kind         string         `db:"kind"`
+		Comment      sql.NullString `db:"comment"`
+		PartitionKey sql.NullString `db:"partition_key"`
+		PartitionOf  sql.NullString `db:"partition_of"`
+	}
+	query := `SELECT c.oid,
+		CASE c.relkind WHEN 'v' THEN 'view' WHEN 'm' THEN 'materialized view'
+			WHEN 'f' THEN 'foreign table' WHEN 'p' THEN 'partitioned table' ELSE 'table' END AS kind,
+		obj_description(c.oid, 'pg_class') AS comment,
+		CASE WHEN c.relkind = 'p' THEN pg_get_partkeydef(c.oid) END AS partition_key,
+		(SELECT pn.nspname || '.' || p.relname || ' ' || pg_get_expr(c.relpartbound, c.oid)
+			FROM pg_catalog.pg_inherits i
+			JOIN pg_catalog.pg_class p ON p.oid = i.inhparent
+			JOIN pg_catalog.pg_namespace pn ON pn.oid = p.relnamespace
+			WHERE i.inhrelid = c.oid AND c.relispartition) AS partition_of
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2 AND c.relkind IN ('r', 'p', 'v', 'm', 'f')`
+	if err := d.conn.GetContext(ctx, &rel, query, schema, table); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("table %s.%s does not exist", schema, table)
+		}
+		return nil, fmt.Errorf("failed to describe %s.%s: %w", schema, table, err)
+	}
+	desc := &TableDescription{
+		Schema:       schema,
+		Name:         table,
+		Kind:         rel.Kind,
+		Comment:      rel.Comment.String,
+		PartitionKey: rel.PartitionKey.String,
+		PartitionOf:  rel.PartitionOf.String,
+		Columns:      []ColumnDescription{},
+		Indexes:      []IndexDescription{},
+		Constraints:  []ConstraintDescription{},
+	}
+
+	if desc.DatabaseLocale, err = d.databaseLocale(ctx, version); err != nil {
+		return nil, err
+	}
+
+	// attgenerated and nondeterministic collations appeared in PostgreSQL 12;
+	// the default expression of a generated column is its expression
+	generated, nondeterministic := "''", "false"
+	if version >= 120000 {
+		generated, nondeterministic = "a.attgenerated", "NOT co.collisdeterministic"
+	}
+	query = fmt.Sprintf(`SELECT a.attname AS name, format_type(a.atttypid, a.atttypmod) AS type,
+		a.attnotnull AS not_null,
+		CASE WHEN %[1]s = '' THEN pg_get_expr(ad.adbin, ad.adrelid) END AS default_expr,
+		CASE a.attidentity WHEN 'a' THEN 'ALWAYS' WHEN 'd' THEN 'BY DEFAULT' ELSE '' END AS identity,
+		CASE WHEN %[1]s <> '' THEN pg_get_expr(ad.adbin, ad.adrelid) END AS generated_expr,
+		CASE %[1]s WHEN 's' THEN 'STORED' WHEN 'v' THEN 'VIRTUAL' ELSE '' END AS generated_storage,
+		CASE WHEN co.collname IS NOT NULL AND co.collname <> 'default'
+			THEN CASE WHEN cn.nspname = 'pg_catalog' THEN quote_ident(co.collname)
+				ELSE quote_ident(cn.nspname) || '.' || quote_ident(co.collname) END
+		END AS collation,
+		COALESCE(%[2]s, false) AS nondeterministic,
+		ARRAY(SELECT pg_get_constraintdef(dc.oid) FROM pg_catalog.pg_constraint dc
+			WHERE dc.contypid = a.atttypid AND dc.contype = 'c' ORDER BY dc.conname)::text[] AS domain_checks,
+		col_description(a.attrelid, a.attnum) AS comment
+		FROM pg_catalog.pg_attribute a
+		LEFT JOIN pg_catalog.pg_attrdef ad ON ad.adrelid = a.attrelid AND ad.adnum = a.attnum
+		LEFT JOIN pg_catalog.pg_collation co ON co.oid = a.attcollation
+		LEFT JOIN pg_catalog.pg_namespace cn ON cn.oid = co.collnamespace
+		WHERE a.attrelid = $1 AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, generated, nondeterministic)
+	if err := d.conn.SelectContext(ctx, &desc.Columns, query, rel.OID); err != nil {
+		return nil, fmt.Errorf("failed to get columns of %s.%s: %w", schema, table, err)
+	}
+	if rel.Kind != "view" && rel.Kind != "materialized view" {
+		for i := range desc.Columns {
+			desc.Columns[i].Insert = insertRule(desc.Columns[i])
+		}
+	}
+
+	nullsNotDistinct := "false"
+	if version >= 150000 {
+		nullsNotDistinct = "i.indnullsnotdistinct"
+	}
+	query = fmt.Sprintf(`SELECT ic.relname AS name, pg_get_indexdef(i.indexrelid) AS definition,
+		i.indisprimary AS is_primary, i.indisunique AS is_unique,
+		%s AS nulls_not_distinct, ic.relkind = 'I' AS partitioned,
+		(SELECT pn.nspname || '.' || p.relname
+			FROM pg_catalog.pg_inherits inh
+			JOIN pg_catalog.pg_class p ON p.oid = inh.inhparent
+			JOIN pg_catalog.pg_namespace pn ON pn.oid = p.relnamespace
+			WHERE inh.inhrelid = i.indexrelid) AS parent,
+		i.indisvalid AS is_valid,
+		(SELECT con.conname FROM pg_catalog.pg_constraint con
+			WHERE con.conindid = i.indexrelid AND con.conrelid = i.indrelid LIMIT 1) AS constraint_name
+		FROM pg_catalog.pg_index i
+		JOIN pg_catalog.pg_class ic ON ic.oid = i.indexrelid
+		WHERE i.indrelid = $1
+		ORDER BY NOT i.indisprimary, ic.relname`, nullsNotDistinct)
+	if err := d.conn.SelectContext(ctx, &desc.Indexes, query, rel.OID); err != nil {
+		return nil, fmt.Errorf("failed to get indexes of %s.%s: %w", schema, table, err)
+	}
+
+	query = `SELECT con.conname AS name,
+		CASE con.contype WHEN 'p' THEN 'primary key' WHEN 'u' THEN 'unique'
+			WHEN 'f' THEN 'foreign key' WHEN 'c' THEN 'check' WHEN 'x' THEN 'exclusion'
+			WHEN 'n' THEN 'not null' WHEN 't' THEN 'trigger' ELSE con.contype::text END AS type,
+		pg_get_constraintdef(con.oid) AS definition,
+		ARRAY(SELECT a.attname FROM unnest(con.conkey) WITH ORDINALITY AS k(attnum, n)
+			JOIN pg_catalog.pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+			ORDER BY k.n)::text[] AS columns,
+		CASE WHEN con.contype = 'c' THEN pg_get_expr(con.conbin, con.conrelid) END AS expression,
+		con.condeferrable AS deferrable, con.convalidated AS validated,
+		NOT con.conislocal AS inherited
+		FROM pg_catalog.pg_constraint con
+		WHERE con.conrelid = $1
+		ORDER BY CASE con.contype WHEN 'p' THEN 0 WHEN 'u' THEN 1 WHEN 'x' THEN 2 WHEN 'f' THEN 3 ELSE 4 END, con.conname`
+	if err := d.conn.SelectContext(ctx, &desc.Constraints, query, rel.OID); err != nil {
+		return nil, fmt.Errorf("failed to get constraints of %s.%s: %w", schema, table, err)
+	}
+	return desc, nil
+}
+
+// insertRule returns whether an INSERT must, may or cannot set a column.
+// Generated columns are always computed, and GENERATED ALWAYS identity
+// columns only take a value with OVERRIDING SYSTEM VALUE.
+func insertRule(col ColumnDescription) string {
+	switch {
+	case col.Generated != nil, col.Identity == "ALWAYS":
+		return InsertNever
+	case !col.NotNull, col.Default != nil, col.Identity != "":
+		return InsertOptional
+	default:
+		return InsertRequired
+	}
+}
+
+// serverVersionNum returns the server version as a number, e.g. 160002
+func (d *DB) serverVersionNum(ctx context.Context) (int, error) {
+	var version int
+	if err := d.conn.GetContext(ctx, &version, "SELECT current_setting('server_version_num')::int"); err != nil {
+		return 0, fmt.Errorf("failed to get server version: %w", err)
+	}
+	return version, nil
+}
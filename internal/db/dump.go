@@ -0,0 +1,148 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxDumpStderr bounds the error output of pg_dump and pg_restore kept for
+// the error message
+const maxDumpStderr = 4096
+
+// Dump formats of pg_dump
+const (
+	DumpCustom = "custom"
+	DumpPlain  = "plain"
+)
+
+// DumpParams describes a logical backup made with pg_dump
+type DumpParams struct {
+	// PgDump is the pg_dump binary, looked up in PATH without a directory
+	PgDump string
+	// Dir is the directory the dump file is written to
+	Dir string
+	// Format is DumpCustom (the default) or DumpPlain
+	Format     string
+	SchemaOnly bool
+	// Schemas and Tables limit the dump, like -n and -t
+	Schemas []string
+	Tables  []string
+}
+
+// DumpResult is a dump file written by Dump
+type DumpResult struct {
+	Name     string        `json:"name"`
+	Path     string        `json:"path"`
+	Format   string        `json:"format"`
+	Bytes    int64         `json:"bytes"`
+	Duration time.Duration `json:"-"`
+}
+
+// Dump runs pg_dump against the database and writes a timestamped file to
+// the dump directory. A failed dump leaves no file behind.
+func (d *DB) Dump(ctx context.Context, params DumpParams) (*DumpResult, error) {
+	if d.databaseURL == "" {
+		return nil, fmt.Errorf("dumps need the server to be started with a connection string")
+	}
+	conn, err := parseConnString(d.databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	format, ext := params.Format, ".dump"
+	switch format {
+	case "", DumpCustom:
+		format = DumpCustom
+	case DumpPlain:
+		ext = ".sql"
+	default:
+		return nil, fmt.Errorf("invalid dump format %q, use %s or %s", params.Format, DumpCustom, DumpPlain)
+	}
+
+	start := time.Now()
+	name := fmt.Sprintf("%s_%s%s", conn.databaseName(), start.UTC().Format("20060102T150405Z"), ext)
+	if params.SchemaOnly {
+		name = strings.TrimSuffix(name, ext) + "_schema" + ext
+	}
+	path := filepath.Join(params.Dir, name)
+
+	args := []string{"--format=" + format, "--file=" + path}
+	if params.SchemaOnly {
+		args = append(args, "--schema-only")
+	}
+	for _, schema := range params.Schemas {
+		args = append(args, "--schema="+schema)
+	}
+	for _, table := range params.Tables {
+		args = append(args, "--table="+table)
+	}
+	if err := runClientProgram(ctx, params.PgDump, conn, args...); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dump file: %w", err)
+	}
+	return &DumpResult{
+		Name:     name,
+		Path:     path,
+		Format:   format,
+		Bytes:    info.Size(),
+		Duration: time.Since(start),
+	}, nil
+}
+
+// runClientProgram runs a PostgreSQL client program such as pg_dump against
+// the database of conn. The password is passed in the environment, not on
+// the command line, and the error holds the program's error output.
+func runClientProgram(ctx context.Context, program string, conn connParams, args ...string) error {
+	dsn, password := conn.withoutPassword()
+	args = append([]string{"--dbname=" + dsn, "--no-password"}, args...)
+
+	cmd := exec.CommandContext(ctx, program, args...)
+	cmd.Env = os.Environ()
+	if password != "" {
+		cmd.Env = append(cmd.Env, "PGPASSWORD="+password)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		name := filepath.Base(program)
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > maxDumpStderr {
+			msg = msg[:maxDumpStderr] + "..."
+		}
+		if msg != "" {
+			return fmt.Errorf("%s failed: %w: %s", name, err, msg)
+		}
+		return fmt.Errorf("%s failed: %w", name, err)
+	}
+	return nil
+}
+
+// withoutPassword returns the settings as a key=value connection string
+// without the password, and the password
+func (p connParams) withoutPassword() (string, string) {
+	keys := make([]string, 0, len(p))
+	for key := range p {
+		if key != "password" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+quoteDSNValue(p[key]))
+	}
+	return strings.Join(pairs, " "), p["password"]
+}
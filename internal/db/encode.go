@@ -0,0 +1,167 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// NumericFormat controls how numeric/decimal values are returned
+type NumericFormat string
+
+const (
+	// NumericFloat returns numeric values as float64, which may lose precision
+	NumericFloat NumericFormat = "float"
+	// NumericString returns numeric values as their exact string representation
+	NumericString NumericFormat = "string"
+	// NumericObject wraps the exact string representation as {"numeric": "..."}
+	NumericObject NumericFormat = "object"
+)
+
+// ParseNumericFormat validates a numeric format name
+func ParseNumericFormat(s string) (NumericFormat, error) {
+	switch f := NumericFormat(s); f {
+	case NumericFloat, NumericString, NumericObject:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid numeric format %q, must be one of float, string, object", s)
+	}
+}
+
+// ByteaFormat controls how bytea values are returned
+type ByteaFormat string
+
+const (
+	// ByteaOmit drops bytea columns from the result
+	ByteaOmit ByteaFormat = "omit"
+	// ByteaHash returns only the length and SHA-256 hash of the value
+	ByteaHash ByteaFormat = "hash"
+	// ByteaHex returns the value hex-encoded in PostgreSQL's \x format
+	ByteaHex ByteaFormat = "hex"
+	// ByteaBase64 returns the value base64-encoded
+	ByteaBase64 ByteaFormat = "base64"
+)
+
+// ParseByteaFormat validates a bytea format name
+func ParseByteaFormat(s string) (ByteaFormat, error) {
+	switch f := ByteaFormat(s); f {
+	case ByteaOmit, ByteaHash, ByteaHex, ByteaBase64:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid bytea format %q, must be one of omit, hash, hex, base64", s)
+	}
+}
+
+// encodeOptions controls how column values are converted for output
+type encodeOptions struct {
+	numeric NumericFormat
+	bytea   ByteaFormat
+	// byteaMaxBytes limits the bytes encoded by the hex and base64 formats,
+	// zero means no limit
+	byteaMaxBytes int
+	geo           GeoFormat
+}
+
+// omitColumn reports whether columns of the given type are left out of results
+func (o *encodeOptions) omitColumn(typeName string) bool {
+	return (typeName == "BYTEA" || typeName == "_BYTEA") && o.bytea == ByteaOmit
+}
+
+// encodeValue converts a value scanned by the driver into a value suitable
+// for JSON encoding, based on the PostgreSQL type name of its column
+func (o *encodeOptions) encodeValue(typeName string, value interface{}) interface{} {
+	// NULL is always JSON null, whatever the type and format options
+	if value == nil {
+		return nil
+	}
+
+	b, ok := value.([]byte)
+	if !ok {
+		return value
+	}
+
+	switch typeName {
+	case "NUMERIC":
+		return o.encodeNumeric(string(b))
+	case "BYTEA":
+		return o.encodeBytea(b)
+	case "INTERVAL":
+		return encodeInterval(string(b))
+	case "MONEY":
+		return o.encodeMoney(string(b))
+	case "INT4RANGE", "INT8RANGE", "NUMRANGE", "DATERANGE", "TSRANGE", "TSTZRANGE":
+		return o.encodeRange(rangeElementTypes[typeName], string(b))
+	case "JSON", "JSONB":
+		// Embed documents as nested JSON instead of escaped strings
+		if json.Valid(b) {
+			return json.RawMessage(b)
+		}
+		return string(b)
+	default:
+		if isArrayType(typeName) {
+			return o.encodeArray(typeName, string(b))
+		}
+		// Types unknown to the driver, such as the PostGIS ones, have no name
+		if typeName == "" {
+			if geom, ok := o.encodeGeometry(string(b)); ok {
+				return geom
+			}
+		}
+		// The driver returns types it does not decode as their text representation
+		return string(b)
+	}
+}
+
+// encodeNumeric converts the text representation of a numeric value
+func (o *encodeOptions) encodeNumeric(s string) interface{} {
+	switch o.numeric {
+	case NumericString:
+		return s
+	case NumericObject:
+		return map[string]string{"numeric": s}
+	default:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			// NaN and Infinity have no JSON number representation
+			return s
+		}
+		return f
+	}
+}
+
+// encodeBytea converts a bytea value according to the configured format.
+// Values cut to byteaMaxBytes are returned as an object carrying the full length.
+func (o *encodeOptions) encodeBytea(b []byte) interface{} {
+	if o.bytea == ByteaHash {
+		sum := sha256.Sum256(b)
+		return map[string]interface{}{
+			"length": len(b),
+			"sha256": hex.EncodeToString(sum[:]),
+		}
+	}
+
+	data := b
+	truncated := o.byteaMaxBytes > 0 && len(b) > o.byteaMaxBytes
+	if truncated {
+		data = b[:o.byteaMaxBytes]
+	}
+
+	var encoded string
+	if o.bytea == ByteaHex {
+		encoded = "\\x" + hex.EncodeToString(data)
+	} else {
+		encoded = base64.StdEncoding.EncodeToString(data)
+	}
+	if !truncated {
+		return encoded
+	}
+	return map[string]interface{}{
+		string(o.bytea): encoded,
+		"length":        len(b),
+		"truncated":     true,
+	}
+}
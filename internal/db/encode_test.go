@@ -0,0 +1,65 @@
+package db
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeValueNull(t *testing.T) {
+	typeNames := []string{
+		"", "TEXT", "INT4", "BOOL", "NUMERIC", "BYTEA", "INTERVAL", "MONEY",
+		"INT4RANGE", "INT8RANGE", "NUMRANGE", "DATERANGE", "TSRANGE", "TSTZRANGE",
+		"JSON", "JSONB", "_INT4", "_TEXT", "_NUMERIC", "_BYTEA",
+	}
+	for _, numeric := range []NumericFormat{NumericFloat, NumericString, NumericObject} {
+		for _, bytea := range []ByteaFormat{ByteaOmit, ByteaHash, ByteaHex, ByteaBase64} {
+			for _, geo := range []GeoFormat{GeoJSON, GeoWKT, GeoEWKB} {
+				o := &encodeOptions{numeric: numeric, bytea: bytea, byteaMaxBytes: 4, geo: geo}
+				for _, typeName := range typeNames {
+					if got := o.encodeValue(typeName, nil); got != nil {
+						t.Errorf("%s with %s/%s/%s: got %#v, want nil", typeName, numeric, bytea, geo, got)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestEncodeValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  encodeOptions
+		typeName string
+		value    interface{}
+		want     interface{}
+	}{
+		{name: "numeric float", options: encodeOptions{numeric: NumericFloat}, typeName: "NUMERIC", value: []byte("1.5"), want: 1.5},
+		{name: "numeric nan", options: encodeOptions{numeric: NumericFloat}, typeName: "NUMERIC", value: []byte("NaN"), want: "NaN"},
+		{name: "numeric string", options: encodeOptions{numeric: NumericString}, typeName: "NUMERIC", value: []byte("12345678901234567890.5"), want: "12345678901234567890.5"},
+		{name: "numeric object", options: encodeOptions{numeric: NumericObject}, typeName: "NUMERIC", value: []byte("1.5"), want: map[string]string{"numeric": "1.5"}},
+		{name: "bytea base64", options: encodeOptions{bytea: ByteaBase64}, typeName: "BYTEA", value: []byte("hi"), want: "aGk="},
+		{name: "bytea hex", options: encodeOptions{bytea: ByteaHex}, typeName: "BYTEA", value: []byte("hi"), want: `\x6869`},
+		{name: "bytea truncated", options: encodeOptions{bytea: ByteaHex, byteaMaxBytes: 1}, typeName: "BYTEA", value: []byte("hi"), want: map[string]interface{}{"hex": `\x68`, "length": 2, "truncated": true}},
+		{name: "json", typeName: "JSONB", value: []byte(`{"a": 1}`), want: json.RawMessage(`{"a": 1}`)},
+		{name: "invalid json", typeName: "JSON", value: []byte(`{`), want: "{"},
+		{name: "text", typeName: "TEXT", value: []byte("abc"), want: "abc"},
+		{name: "decoded value", typeName: "INT8", value: int64(7), want: int64(7)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.options.encodeValue(tt.typeName, tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOmitColumn(t *testing.T) {
+	o := &encodeOptions{bytea: ByteaOmit}
+	for typeName, want := range map[string]bool{"BYTEA": true, "_BYTEA": true, "TEXT": false} {
+		if got := o.omitColumn(typeName); got != want {
+			t.Errorf("%s: got %v, want %v", typeName, got, want)
+		}
+	}
+}
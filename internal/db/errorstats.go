@@ -0,0 +1,28 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrorCounters are the cumulative error statistics of the current database
+type ErrorCounters struct {
+	Commits          int64      `db:"xact_commit" json:"commits"`
+	Rollbacks        int64      `db:"xact_rollback" json:"rollbacks"`
+	Deadlocks        int64      `db:"deadlocks" json:"deadlocks"`
+	Conflicts        int64      `db:"conflicts" json:"recovery_conflicts"`
+	ChecksumFailures *int64     `db:"checksum_failures" json:"checksum_failures"`
+	StatsReset       *time.Time `db:"stats_reset" json:"stats_reset"`
+}
+
+// GetErrorCounters returns the error counters of pg_stat_database for the current database
+func (d *DB) GetErrorCounters() (*ErrorCounters, error) {
+	var counters ErrorCounters
+	query := `SELECT xact_commit, xact_rollback, deadlocks, conflicts, checksum_failures, stats_reset
+		FROM pg_catalog.pg_stat_database
+		WHERE datname = current_database()`
+	if err := d.conn.Get(&counters, query); err != nil {
+		return nil, fmt.Errorf("failed to get error counters: %w", err)
+	}
+	return &counters, nil
+}
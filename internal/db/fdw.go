@@ -0,0 +1,108 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// maskedOption masks the values of secret-looking FDW options, e.g.
+// password=secret becomes password=***
+const maskedOption = `ARRAY(SELECT CASE WHEN split_part(opt, '=', 1) ~* '(pass|secret|token|key|cert)'
+	THEN split_part(opt, '=', 1) || '=***' ELSE opt END FROM unnest(%s) AS opt)`
+
+// ForeignServer is a foreign server with its wrapper
+type ForeignServer struct {
+	Name    string         `db:"name" json:"name"`
+	Wrapper string         `db:"wrapper" json:"wrapper"`
+	Type    *string        `db:"type" json:"type,omitempty"`
+	Version *string        `db:"version" json:"version,omitempty"`
+	Options pq.StringArray `db:"options" json:"options"`
+}
+
+// UserMapping maps a local role to a foreign server; secret options are masked
+type UserMapping struct {
+	Server  string         `db:"server" json:"server"`
+	Role    string         `db:"role" json:"role"`
+	Options pq.StringArray `db:"options" json:"options"`
+}
+
+// ForeignTable is a table whose rows live on a foreign server
+type ForeignTable struct {
+	Schema  string         `db:"schema" json:"schema"`
+	Table   string         `db:"table_name" json:"table"`
+	Server  string         `db:"server" json:"server"`
+	Options pq.StringArray `db:"options" json:"options"`
+}
+
+// ForeignData is the foreign data wrapper configuration of the database
+type ForeignData struct {
+	Servers      []ForeignServer `json:"servers"`
+	UserMappings []UserMapping   `json:"user_mappings"`
+	Tables       []ForeignTable  `json:"tables"`
+}
+
+// GetForeignData returns the foreign servers, the user mappings and the
+// foreign tables. Options that look like passwords, secrets or keys have
+// their value masked.
+func (d *DB) GetForeignData() (*ForeignData, error) {
+	data := &ForeignData{
+		Servers:      []ForeignServer{},
+		UserMappings: []UserMapping{},
+		Tables:       []ForeignTable{},
+	}
+
+	query := `SELECT s.srvname AS name, w.fdwname AS wrapper, s.srvtype AS type, s.srvversion AS version,
+		` + fmt.Sprintf(maskedOption, "COALESCE(s.srvoptions, '{}')") + ` AS options
+		FROM pg_catalog.pg_foreign_server s
+		JOIN pg_catalog.pg_foreign_data_wrapper w ON w.oid = s.srvfdw
+		ORDER BY s.srvname`
+	if err := d.conn.Select(&data.Servers, query); err != nil {
+		return nil, fmt.Errorf("failed to list foreign servers: %w", err)
+	}
+
+	// pg_user_mappings only shows the options to the owner of the mapping or server
+	query = `SELECT srvname AS server, usename AS role,
+		` + fmt.Sprintf(maskedOption, "COALESCE(umoptions, '{}')") + ` AS options
+		FROM pg_catalog.pg_user_mappings
+		ORDER BY srvname, usename`
+	if err := d.conn.Select(&data.UserMappings, query); err != nil {
+		return nil, fmt.Errorf("failed to list user mappings: %w", err)
+	}
+
+	query = `SELECT n.nspname AS schema, c.relname AS table_name, s.srvname AS server,
+		` + fmt.Sprintf(maskedOption, "COALESCE(ft.ftoptions, '{}')") + ` AS options
+		FROM pg_catalog.pg_foreign_table ft
+		JOIN pg_catalog.pg_class c ON c.oid = ft.ftrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_foreign_server s ON s.oid = ft.ftserver
+		ORDER BY n.nspname, c.relname`
+	if err := d.conn.Select(&data.Tables, query); err != nil {
+		return nil, fmt.Errorf("failed to list foreign tables: %w", err)
+	}
+
+	return data, nil
+}
+
+// GetForeignTableServers returns the foreign tables of the public schema
+// mapped to the name of their foreign server
+func (d *DB) GetForeignTableServers() (map[string]string, error) {
+	var rows []struct {
+		Table  string `db:"table_name"`
+		Server string `db:"server"`
+	}
+	query := `SELECT c.relname AS table_name, s.srvname AS server
+		FROM pg_catalog.pg_foreign_table ft
+		JOIN pg_catalog.pg_class c ON c.oid = ft.ftrelid
+		JOIN pg_catalog.pg_foreign_server s ON s.oid = ft.ftserver
+		WHERE c.relnamespace = 'public'::regnamespace`
+	if err := d.conn.Select(&rows, query); err != nil {
+		return nil, fmt.Errorf("failed to list foreign tables: %w", err)
+	}
+
+	servers := make(map[string]string, len(rows))
+	for _, row := range rows {
+		servers[row.Table] = row.Server
+	}
+	return servers, nil
+}
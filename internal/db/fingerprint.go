@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// relationVersions selects a version string per table of the non-system
+// schemas, made of the row versions (xmin) of its pg_class, pg_namespace and
+// pg_attribute rows. Every DDL statement on a table, such as adding,
+// renaming or retyping a column, writes new versions of these rows.
+const relationVersions = `SELECT c.relname,
+		c.oid::text || ':' || c.xmin::text || ':' || n.xmin::text || ':' ||
+			COALESCE((SELECT string_agg(a.attnum::text || '/' || a.xmin::text, ' ' ORDER BY a.attnum)
+				FROM pg_catalog.pg_attribute a WHERE a.attrelid = c.oid AND a.attnum > 0), '') AS version
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f')
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND n.nspname NOT LIKE 'pg\_toast%'`
+
+// SchemaFingerprint returns a hash of the catalog rows describing the tables
+// of the given names, or of all tables of the non-system schemas when none
+// are given. Creating or dropping a table changes the set of rows, so an
+// unchanged fingerprint means an unchanged schema. It costs one catalog query
+// and no table access.
+func (d *DB) SchemaFingerprint(ctx context.Context, tableNames []string) (string, error) {
+	var fingerprint string
+	query := `SELECT md5(COALESCE(string_agg(rel.version, ',' ORDER BY rel.version), ''))
+		FROM (` + relationVersions + `
+			AND (COALESCE(cardinality($1::text[]), 0) = 0 OR c.relname = ANY($1))
+		) rel`
+	if err := d.conn.GetContext(ctx, &fingerprint, query, pq.Array(tableNames)); err != nil {
+		return "", fmt.Errorf("failed to get schema fingerprint: %w", err)
+	}
+	return fingerprint, nil
+}
+
+// TableFingerprints returns the schema fingerprint of every table name of the
+// non-system schemas, equal to SchemaFingerprint of that name alone
+func (d *DB) TableFingerprints(ctx context.Context) (map[string]string, error) {
+	var rows []struct {
+		Name        string `db:"relname"`
+		Fingerprint string `db:"fingerprint"`
+	}
+	query := `SELECT rel.relname, md5(string_agg(rel.version, ',' ORDER BY rel.version)) AS fingerprint
+		FROM (` + relationVersions + `) rel
+		GROUP BY rel.relname`
+	if err := d.conn.SelectContext(ctx, &rows, query); err != nil {
+		return nil, fmt.Errorf("failed to get table fingerprints: %w", err)
+	}
+	fingerprints := make(map[string]string, len(rows))
+	for _, row := range rows {
+		fingerprints[row.Name] = row.Fingerprint
+	}
+	return fingerprints, nil
+}
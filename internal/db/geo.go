@@ -0,0 +1,383 @@
+package db
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// GeoFormat controls how PostGIS geometry/geography values are returned
+type GeoFormat string
+
+const (
+	// GeoJSON returns geometries as GeoJSON objects, like ST_AsGeoJSON
+	GeoJSON GeoFormat = "geojson"
+	// GeoWKT returns geometries as well-known text, like ST_AsText
+	GeoWKT GeoFormat = "wkt"
+	// GeoEWKB returns geometries unchanged as hex-encoded EWKB
+	GeoEWKB GeoFormat = "ewkb"
+)
+
+// ParseGeoFormat validates a geometry format name
+func ParseGeoFormat(s string) (GeoFormat, error) {
+	switch f := GeoFormat(s); f {
+	case GeoJSON, GeoWKT, GeoEWKB:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid geo format %q, must be one of geojson, wkt, ewkb", s)
+	}
+}
+
+// SpatialColumn represents a PostGIS geometry or geography column
+type SpatialColumn struct {
+	Schema         string `db:"table_schema" json:"schema"`
+	Table          string `db:"table_name" json:"table"`
+	Column         string `db:"column_name" json:"column"`
+	Kind           string `db:"kind" json:"kind"`
+	GeometryType   string `db:"geometry_type" json:"geometry_type"`
+	SRID           int    `db:"srid" json:"srid"`
+	CoordDimension int    `db:"coord_dimension" json:"coord_dimension"`
+}
+
+// ListSpatialColumns returns the geometry and geography columns registered by PostGIS
+func (d *DB) ListSpatialColumns() ([]SpatialColumn, error) {
+	installed, err := d.HasExtension("postgis")
+	if err != nil {
+		return nil, err
+	}
+	if !installed {
+		return nil, fmt.Errorf("the postgis extension is not installed")
+	}
+
+	columns := []SpatialColumn{}
+	query := `SELECT f_table_schema::text AS table_schema, f_table_name::text AS table_name,
+		f_geometry_column::text AS column_name, 'geometry' AS kind,
+		type AS geometry_type, srid, coord_dimension
+		FROM geometry_columns
+		UNION ALL
+		SELECT f_table_schema::text, f_table_name::text, f_geography_column::text, 'geography',
+		type, srid, coord_dimension
+		FROM geography_columns
+		ORDER BY table_schema, table_name, column_name`
+	if err := d.conn.Select(&columns, query); err != nil {
+		return nil, fmt.Errorf("failed to list spatial columns: %w", err)
+	}
+	return columns, nil
+}
+
+// HasExtension reports whether the named extension is installed in the database
+func (d *DB) HasExtension(name string) (bool, error) {
+	var installed bool
+	query := "SELECT EXISTS (SELECT 1 FROM pg_catalog.pg_extension WHERE extname = $1)"
+	if err := d.conn.Get(&installed, query, name); err != nil {
+		return false, fmt.Errorf("failed to check extension %s: %w", name, err)
+	}
+	return installed, nil
+}
+
+// encodeGeometry converts a hex-encoded EWKB value, the text output of the
+// PostGIS geometry and geography types, into the configured format. The
+// driver does not know the PostGIS types, so ok is false when the value is
+// not a geometry.
+func (o *encodeOptions) encodeGeometry(s string) (value interface{}, ok bool) {
+	if o.geo == GeoEWKB || len(s) < 10 {
+		return nil, false
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+	g, err := decodeEWKB(b)
+	if err != nil {
+		return nil, false
+	}
+	if o.geo == GeoWKT {
+		return g.wkt(), true
+	}
+	return g.geoJSON(), true
+}
+
+// WKB geometry type codes
+const (
+	wkbPoint              = 1
+	wkbLineString         = 2
+	wkbPolygon            = 3
+	wkbMultiPoint         = 4
+	wkbMultiLineString    = 5
+	wkbMultiPolygon       = 6
+	wkbGeometryCollection = 7
+)
+
+// EWKB flags in the high bits of the geometry type
+const (
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+// geometry is a decoded WKB geometry
+type geometry struct {
+	kind string
+	// dims is the WKT dimension qualifier: "", "Z", "M" or "ZM"
+	dims string
+	// coordinates has the GeoJSON nesting for the geometry kind
+	coordinates interface{}
+	// geometries holds the members of a GeometryCollection
+	geometries []*geometry
+}
+
+// wkbReader decodes (E)WKB
+type wkbReader struct {
+	b     []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+// decodeEWKB decodes a complete WKB or PostGIS EWKB geometry
+func decodeEWKB(b []byte) (*geometry, error) {
+	r := &wkbReader{b: b}
+	g, err := r.readGeometry()
+	if err != nil {
+		return nil, err
+	}
+	if r.pos != len(b) {
+		return nil, fmt.Errorf("unexpected trailing bytes in WKB")
+	}
+	return g, nil
+}
+
+func (r *wkbReader) readUint32() (uint32, error) {
+	if r.pos+4 > len(r.b) {
+		return 0, fmt.Errorf("unexpected end of WKB")
+	}
+	v := r.order.Uint32(r.b[r.pos:])
+	r.pos += 4
+	return v, nil
+}
+
+func (r *wkbReader) readCount() (int, error) {
+	n, err := r.readUint32()
+	if err != nil {
+		return 0, err
+	}
+	// Every element takes at least 8 bytes, reject corrupt counts early
+	if int(n) > (len(r.b)-r.pos)/8+1 {
+		return 0, fmt.Errorf("invalid WKB element count %d", n)
+	}
+	return int(n), nil
+}
+
+func (r *wkbReader) readPoint(dims int) ([]float64, error) {
+	if r.pos+8*dims > len(r.b) {
+		return nil, fmt.Errorf("unexpected end of WKB")
+	}
+	point := make([]float64, dims)
+	for i := range point {
+		point[i] = math.Float64frombits(r.order.Uint64(r.b[r.pos:]))
+		r.pos += 8
+	}
+	return point, nil
+}
+
+func (r *wkbReader) readPoints(dims int) ([][]float64, error) {
+	n, err := r.readCount()
+	if err != nil {
+		return nil, err
+	}
+	points := make([][]float64, n)
+	for i := range points {
+		if points[i], err = r.readPoint(dims); err != nil {
+			return nil, err
+		}
+	}
+	return points, nil
+}
+
+func (r *wkbReader) readGeometry() (*geometry, error) {
+	if r.pos >= len(r.b) {
+		return nil, fmt.Errorf("unexpected end of WKB")
+	}
+	switch r.b[r.pos] {
+	case 0:
+		r.order = binary.BigEndian
+	case 1:
+		r.order = binary.LittleEndian
+	default:
+		return nil, fmt.Errorf("invalid WKB byte order")
+	}
+	r.pos++
+
+	typ, err := r.readUint32()
+	if err != nil {
+		return nil, err
+	}
+	hasZ := typ&ewkbZ != 0
+	hasM := typ&ewkbM != 0
+	if typ&ewkbSRID != 0 {
+		if _, err := r.readUint32(); err != nil {
+			return nil, err
+		}
+	}
+	base := typ & 0x0fffffff
+	// ISO WKB encodes the dimensions as thousands
+	switch base / 1000 {
+	case 1:
+		hasZ = true
+	case 2:
+		hasM = true
+	case 3:
+		hasZ, hasM = true, true
+	}
+	base %= 1000
+
+	g := &geometry{}
+	dims := 2
+	if hasZ {
+		g.dims += "Z"
+		dims++
+	}
+	if hasM {
+		g.dims += "M"
+		dims++
+	}
+
+	switch base {
+	case wkbPoint:
+		g.kind = "Point"
+		point, err := r.readPoint(dims)
+		if err != nil {
+			return nil, err
+		}
+		if math.IsNaN(point[0]) {
+			// Empty points are encoded with NaN coordinates
+			point = []float64{}
+		}
+		g.coordinates = point
+	case wkbLineString:
+		g.kind = "LineString"
+		if g.coordinates, err = r.readPoints(dims); err != nil {
+			return nil, err
+		}
+	case wkbPolygon:
+		g.kind = "Polygon"
+		n, err := r.readCount()
+		if err != nil {
+			return nil, err
+		}
+		rings := make([][][]float64, n)
+		for i := range rings {
+			if rings[i], err = r.readPoints(dims); err != nil {
+				return nil, err
+			}
+		}
+		g.coordinates = rings
+	case wkbMultiPoint, wkbMultiLineString, wkbMultiPolygon, wkbGeometryCollection:
+		g.kind = map[uint32]string{
+			wkbMultiPoint:         "MultiPoint",
+			wkbMultiLineString:    "MultiLineString",
+			wkbMultiPolygon:       "MultiPolygon",
+			wkbGeometryCollection: "GeometryCollection",
+		}[base]
+		n, err := r.readCount()
+		if err != nil {
+			return nil, err
+		}
+		members := make([]*geometry, n)
+		coordinates := make([]interface{}, n)
+		for i := range members {
+			if members[i], err = r.readGeometry(); err != nil {
+				return nil, err
+			}
+			coordinates[i] = members[i].coordinates
+		}
+		if base == wkbGeometryCollection {
+			g.geometries = members
+		} else {
+			g.coordinates = coordinates
+		}
+	default:
+		return nil, fmt.Errorf("unsupported WKB geometry type %d", base)
+	}
+	return g, nil
+}
+
+// geoJSON returns the GeoJSON representation of the geometry
+func (g *geometry) geoJSON() map[string]interface{} {
+	if g.kind == "GeometryCollection" {
+		geometries := make([]interface{}, len(g.geometries))
+		for i, member := range g.geometries {
+			geometries[i] = member.geoJSON()
+		}
+		return map[string]interface{}{"type": g.kind, "geometries": geometries}
+	}
+	return map[string]interface{}{"type": g.kind, "coordinates": g.coordinates}
+}
+
+// wkt returns the well-known text representation of the geometry
+func (g *geometry) wkt() string {
+	name := strings.ToUpper(g.kind)
+	if g.dims != "" {
+		name += " " + g.dims + " "
+	}
+	body := g.wktBody()
+	if body == "" {
+		return strings.TrimSpace(name) + " EMPTY"
+	}
+	return name + body
+}
+
+// wktBody returns the parenthesized part of the WKT, empty for empty geometries
+func (g *geometry) wktBody() string {
+	var parts []string
+	switch g.kind {
+	case "GeometryCollection":
+		for _, member := range g.geometries {
+			parts = append(parts, member.wkt())
+		}
+	case "Point":
+		point := g.coordinates.([]float64)
+		if len(point) == 0 {
+			return ""
+		}
+		return "(" + wktPoint(point) + ")"
+	case "LineString":
+		return wktPoints(g.coordinates.([][]float64))
+	case "Polygon":
+		for _, ring := range g.coordinates.([][][]float64) {
+			parts = append(parts, wktPoints(ring))
+		}
+	default:
+		for _, member := range g.coordinates.([]interface{}) {
+			sub := &geometry{kind: strings.TrimPrefix(g.kind, "Multi"), coordinates: member}
+			parts = append(parts, sub.wktBody())
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(parts, ",") + ")"
+}
+
+// wktPoints formats a list of points as a parenthesized WKT coordinate list
+func wktPoints(points [][]float64) string {
+	if len(points) == 0 {
+		return ""
+	}
+	parts := make([]string, len(points))
+	for i, point := range points {
+		parts[i] = wktPoint(point)
+	}
+	return "(" + strings.Join(parts, ",") + ")"
+}
+
+// wktPoint formats the coordinates of a single point
+func wktPoint(point []float64) string {
+	parts := make([]string, len(point))
+	for i, c := range point {
+		parts[i] = strconv.FormatFloat(c, 'f', -1, 64)
+	}
+	return strings.Join(parts, " ")
+}
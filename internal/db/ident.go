@@ -0,0 +1,35 @@
+package db
+
+import (
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Identifier is an SQL identifier, qualified when it has several parts, e.g.
+// Identifier{"public", "orders"} for public.orders. All identifiers taken
+// from tool arguments or the catalog are interpolated into SQL through it.
+type Identifier []string
+
+// Sanitize returns the identifier quoted and dot separated, safe to
+// interpolate into SQL whatever its parts contain. NUL bytes, which
+// PostgreSQL does not accept in identifiers, are dropped.
+func (id Identifier) Sanitize() string {
+	parts := make([]string, len(id))
+	for i, part := range id {
+		part = strings.ReplaceAll(part, "\x00", "")
+		parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+	}
+	return strings.Join(parts, ".")
+}
+
+// quoteIdent quotes a single identifier
+func quoteIdent(name string) string {
+	return Identifier{name}.Sanitize()
+}
+
+// quoteLiteral quotes a string as an SQL literal, for the statements such as
+// CREATE ROLE that take no bind parameters
+func quoteLiteral(value string) string {
+	return pq.QuoteLiteral(strings.ReplaceAll(value, "\x00", ""))
+}
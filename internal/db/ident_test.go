@@ -0,0 +1,46 @@
+package db
+
+import "testing"
+
+func TestIdentifierSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		id   Identifier
+		want string
+	}{
+		{name: "simple", id: Identifier{"orders"}, want: `"orders"`},
+		{name: "qualified", id: Identifier{"public", "orders"}, want: `"public"."orders"`},
+		{name: "keeps case", id: Identifier{"Orders"}, want: `"Orders"`},
+		{name: "double quote", id: Identifier{`a"b`}, want: `"a""b"`},
+		{name: "injection", id: Identifier{`x"; DROP TABLE t; --`}, want: `"x""; DROP TABLE t; --"`},
+		{name: "dot in part", id: Identifier{"a.b"}, want: `"a.b"`},
+		{name: "nul byte", id: Identifier{"a\x00b"}, want: `"ab"`},
+		{name: "unicode", id: Identifier{"straße"}, want: `"straße"`},
+		{name: "empty part", id: Identifier{""}, want: `""`},
+		{name: "empty", id: Identifier{}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.id.Sanitize(); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteLiteral(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "secret", want: `'secret'`},
+		{value: "it's", want: `'it''s'`},
+		{value: `a\b`, want: ` E'a\\b'`},
+		{value: "a\x00b", want: `'ab'`},
+	}
+	for _, tt := range tests {
+		if got := quoteLiteral(tt.value); got != tt.want {
+			t.Errorf("quoteLiteral(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// genericPlanVersion is the server_version_num from which EXPLAIN plans
+// queries with bind parameters generically
+const genericPlanVersion = 160000
+
+// maxIndexColumns bounds the number of columns of a suggested index
+const maxIndexColumns = 3
+
+var (
+	// filterColumnPattern matches "column op" comparisons in plan filters,
+	// optionally qualified with the relation alias
+	filterColumnPattern = regexp.MustCompile(`(?:[A-Za-z_][\w$]*\.)?"?([A-Za-z_][\w$]*)"?\s+(= ANY|=|<>|<=|>=|<|>|~~\*?|IS NULL|IS NOT NULL)[\s)]`)
+	// bindParamPattern matches $1 style bind parameters
+	bindParamPattern = regexp.MustCompile(`\$\d+`)
+)
+
+// IndexSuggestion is a candidate index for a query
+type IndexSuggestion struct {
+	Statement string   `json:"statement"`
+	Table     string   `json:"table"`
+	Columns   []string `json:"columns"`
+	// CostWithIndex and ImprovementPercent are estimated with HypoPG, when available
+	CostWithIndex      *float64 `json:"cost_with_index,omitempty"`
+	ImprovementPercent *float64 `json:"improvement_percent,omitempty"`
+}
+
+// IndexAdvice is the index analysis of a query
+type IndexAdvice struct {
+	Query       string            `json:"query"`
+	Cost        float64           `json:"cost,omitempty"`
+	Suggestions []IndexSuggestion `json:"suggestions"`
+	Note        string            `json:"note,omitempty"`
+}
+
+// explainNode is a node of an EXPLAIN (FORMAT JSON) plan
+type explainNode struct {
+	NodeType     string        `json:"Node Type"`
+	RelationName string        `json:"Relation Name"`
+	Schema       string        `json:"Schema"`
+	IndexName    string        `json:"Index Name"`
+	Filter       string        `json:"Filter"`
+	StartupCost  float64       `json:"Startup Cost"`
+	TotalCost    float64       `json:"Total Cost"`
+	PlanRows     float64       `json:"Plan Rows"`
+	Plans        []explainNode `json:"Plans"`
+}
+
+// TopStatements returns the read queries with the highest total execution
+// time from pg_stat_statements
+func (d *DB) TopStatements(limit int) ([]string, error) {
+	installed, err := d.HasExtension("pg_stat_statements")
+	if err != nil {
+		return nil, err
+	}
+	if !installed {
+		return nil, fmt.Errorf("the pg_stat_statements extension is not installed")
+	}
+
+	var queries []string
+	query := `SELECT query FROM pg_stat_statements
+		WHERE dbid = (SELECT oid FROM pg_catalog.pg_database WHERE datname = current_database())
+		AND query ~* '^\s*(select|with)\s'
+		ORDER BY total_exec_time DESC
+		LIMIT $1`
+	if err := d.conn.Select(&queries, query, limit); err != nil {
+		return nil, fmt.Errorf("failed to read pg_stat_statements: %w", err)
+	}
+	return queries, nil
+}
+
+// SuggestIndexes explains each query, derives candidate indexes from the
+// filters of its sequential scans and, when HypoPG is installed, estimates
+// the plan cost with each candidate as a hypothetical index. Candidates that
+// do not lower the cost are dropped when estimates are available.
+func (d *DB) SuggestIndexes(ctx context.Context, queries []string) ([]IndexAdvice, error) {
+	hypopg, err := d.HasExtension("hypopg")
+	if err != nil {
+		return nil, err
+	}
+	version, err := d.serverVersionNum(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, "SET TRANSACTION READ ONLY"); err != nil {
+		return nil, fmt.Errorf("failed to set transaction to read-only: %w", err)
+	}
+
+	advice := make([]IndexAdvice, 0, len(queries))
+	for _, query := range queries {
+		a := IndexAdvice{Query: query, Suggestions: []IndexSuggestion{}}
+
+		if len(SplitStatements(query)) != 1 {
+			a.Note = "only a single statement can be explained"
+			advice = append(advice, a)
+			continue
+		}
+		explain := "EXPLAIN (VERBOSE, FORMAT JSON) "
+		if bindParamPattern.MatchString(query) {
+			if version < genericPlanVersion {
+				a.Note = "query has bind parameters, explaining it requires PostgreSQL 16 or later"
+				advice = append(advice, a)
+				continue
+			}
+			explain = "EXPLAIN (GENERIC_PLAN, VERBOSE, FORMAT JSON) "
+		}
+
+		plan, err := explainPlan(ctx, tx, explain+query)
+		if err != nil {
+			a.Note = err.Error()
+			advice = append(advice, a)
+			continue
+		}
+		a.Cost = plan.TotalCost
+
+		candidates := indexCandidates(plan)
+		names := make([]tableName, len(candidates))
+		for i, candidate := range candidates {
+			names[i] = tableName{schema: candidate.Schema, table: candidate.RelationName}
+		}
+		refs, err := d.lookupTables(names)
+		if err != nil {
+			return nil, err
+		}
+		for i, candidate := range candidates {
+			ref, ok := refs[names[i]]
+			if !ok {
+				continue
+			}
+			columns := candidateColumns(candidate.Filter, ref.columns)
+			if len(columns) == 0 {
+				continue
+			}
+			quoted := make([]string, len(columns))
+			for i, col := range columns {
+				quoted[i] = quoteIdent(col)
+			}
+			suggestion := IndexSuggestion{
+				Statement: fmt.Sprintf("CREATE INDEX ON %s (%s)", ref.quoted(), strings.Join(quoted, ", ")),
+				Table:     ref.schema + "." + ref.table,
+				Columns:   columns,
+			}
+
+			if hypopg {
+				cost, err := hypotheticalCost(ctx, tx, suggestion.Statement, explain+query)
+				if err != nil {
+					return nil, err
+				}
+				if cost >= a.Cost {
+					continue
+				}
+				improvement := math.Round((a.Cost-cost)/a.Cost*1000) / 10
+				suggestion.CostWithIndex = &cost
+				suggestion.ImprovementPercent = &improvement
+			}
+			a.Suggestions = append(a.Suggestions, suggestion)
+		}
+
+		if hypopg {
+			sort.SliceStable(a.Suggestions, func(i, j int) bool {
+				return *a.Suggestions[i].ImprovementPercent > *a.Suggestions[j].ImprovementPercent
+			})
+		} else if len(a.Suggestions) > 0 {
+			a.Note = "install the hypopg extension to estimate the benefit of the suggested indexes"
+		}
+		advice = append(advice, a)
+	}
+	return advice, nil
+}
+
+// explainPlan returns the root node of an EXPLAIN (FORMAT JSON) statement.
+// Statements without bind parameters are prepared, which the server refuses
+// for more than one command; a generic plan leaves its parameters unbound,
+// so it is sent as a simple query and must be checked by the caller.
+func explainPlan(ctx context.Context, tx *sqlx.Tx, explain string) (*explainNode, error) {
+	// Explain errors must not abort the transaction for the other queries
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT explain"); err != nil {
+		return nil, fmt.Errorf("failed to create savepoint: %w", err)
+	}
+	var raw string
+	var err error
+	if bindParamPattern.MatchString(explain) {
+		err = tx.GetContext(ctx, &raw, explain)
+	} else {
+		err = getPrepared(ctx, tx, &raw, explain)
+	}
+	if err != nil {
+		tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT explain")
+		return nil, fmt.Errorf("failed to explain query: %w", err)
+	}
+
+	var plans []struct {
+		Plan explainNode `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(raw), &plans); err != nil || len(plans) == 0 {
+		return nil, fmt.Errorf("failed to decode query plan: %v", err)
+	}
+	return &plans[0].Plan, nil
+}
+
+// getPrepared scans the single row of a prepared statement into dest
+func getPrepared(ctx context.Context, tx *sqlx.Tx, dest interface{}, query string) error {
+	stmt, err := tx.PreparexContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	return stmt.GetContext(ctx, dest)
+}
+
+// explainStatement returns the plan of a single statement, explained with
+// options, e.g. "VERBOSE". Scripts are refused, so a query cannot end the
+// read-only transaction of tx and run other statements. Queries with bind
+// parameters are planned generically, which needs PostgreSQL 16 or later.
+func (d *DB) explainStatement(ctx context.Context, tx *sqlx.Tx, options, query string) (*explainNode, error) {
+	if len(SplitStatements(query)) != 1 {
+		return nil, fmt.Errorf("only a single statement can be explained")
+	}
+	if bindParamPattern.MatchString(query) {
+		version, err := d.serverVersionNum(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if version < genericPlanVersion {
+			return nil, fmt.Errorf("query has bind parameters, explaining it requires PostgreSQL 16 or later")
+		}
+		options += ", GENERIC_PLAN"
+	}
+	return explainPlan(ctx, tx, "EXPLAIN ("+options+", FORMAT JSON) "+query)
+}
+
+// hypotheticalCost returns the plan cost of the query with a hypothetical index
+func hypotheticalCost(ctx context.Context, tx *sqlx.Tx, createIndex, explain string) (float64, error) {
+	if _, err := tx.ExecContext(ctx, "SELECT * FROM hypopg_create_index($1)", createIndex); err != nil {
+		return 0, fmt.Errorf("failed to create hypothetical index: %w", err)
+	}
+	// Hypothetical indexes live in the backend, not the transaction
+	defer tx.ExecContext(ctx, "SELECT hypopg_reset()")
+
+	plan, err := explainPlan(ctx, tx, explain)
+	if err != nil {
+		return 0, err
+	}
+	return plan.TotalCost, nil
+}
+
+// indexCandidates returns the filtered sequential scans of a plan
+func indexCandidates(node *explainNode) []explainNode {
+	var result []explainNode
+	if node.NodeType == "Seq Scan" && node.Filter != "" && node.RelationName != "" {
+		result = append(result, *node)
+	}
+	for i := range node.Plans {
+		result = append(result, indexCandidates(&node.Plans[i])...)
+	}
+	return result
+}
+
+// candidateColumns returns the table columns compared in a filter, equality
+// comparisons first, as the key columns of a candidate index
+func candidateColumns(filter string, columns map[string]bool) []string {
+	var equality, other []string
+	seen := map[string]bool{}
+	for _, m := range filterColumnPattern.FindAllStringSubmatch(filter, -1) {
+		col, op := m[1], m[2]
+		if !columns[col] || seen[col] {
+			continue
+		}
+		seen[col] = true
+		switch op {
+		case "=", "= ANY", "IS NULL":
+			equality = append(equality, col)
+		default:
+			other = append(other, col)
+		}
+	}
+	result := append(equality, other...)
+	if len(result) > maxIndexColumns {
+		result = result[:maxIndexColumns]
+	}
+	return result
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// encodeInterval converts the text representation of an interval in the
+// default postgres IntervalStyle, e.g. "1 year 2 mons -3 days 04:05:06.5",
+// into its months, days and seconds, which PostgreSQL keeps apart because
+// their length varies, and the ISO 8601 duration. Intervals in other styles
+// are returned unchanged as a string.
+func encodeInterval(s string) interface{} {
+	var years, months, days int64
+	var micros int64
+	fields := strings.Fields(s)
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if strings.Contains(field, ":") {
+			us, ok := parseIntervalTime(field)
+			if !ok {
+				return s
+			}
+			micros += us
+			continue
+		}
+
+		n, err := strconv.ParseInt(field, 10, 64)
+		if err != nil || i+1 == len(fields) {
+			return s
+		}
+		i++
+		switch strings.TrimSuffix(fields[i], "s") {
+		case "year":
+			years += n
+		case "mon":
+			months += n
+		case "day":
+			days += n
+		default:
+			return s
+		}
+	}
+
+	months += years * 12
+	return map[string]interface{}{
+		"months":  months,
+		"days":    days,
+		"seconds": float64(micros) / 1e6,
+		"iso8601": isoDuration(months, days, micros),
+	}
+}
+
+// parseIntervalTime parses the [-]HH:MM:SS[.ffffff] part of an interval into microseconds
+func parseIntervalTime(s string) (int64, bool) {
+	sign := int64(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	hours, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	minutes, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	seconds, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, false
+	}
+	micros := (hours*3600+minutes*60)*1e6 + int64(math.Round(seconds*1e6))
+	return sign * micros, true
+}
+
+// isoDuration formats an interval as an ISO 8601 duration, e.g. P1Y2M3DT4H5M6.5S
+func isoDuration(months, days, micros int64) string {
+	var b strings.Builder
+	b.WriteString("P")
+	if y := months / 12; y != 0 {
+		fmt.Fprintf(&b, "%dY", y)
+	}
+	if m := months % 12; m != 0 {
+		fmt.Fprintf(&b, "%dM", m)
+	}
+	if days != 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+	if micros != 0 {
+		b.WriteString("T")
+		hours := micros / 3600e6
+		minutes := micros % 3600e6 / 60e6
+		seconds := micros % 60e6
+		if hours != 0 {
+			fmt.Fprintf(&b, "%dH", hours)
+		}
+		if minutes != 0 {
+			fmt.Fprintf(&b, "%dM", minutes)
+		}
+		if seconds != 0 {
+			b.WriteString(strconv.FormatFloat(float64(seconds)/1e6, 'f', -1, 64) + "S")
+		}
+	}
+	if b.Len() == 1 {
+		return "PT0S"
+	}
+	return b.String()
+}
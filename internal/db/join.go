@@ -0,0 +1,267 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// JoinCondition is an explicit equality between two "table.column" references
+type JoinCondition struct {
+	Left  string
+	Right string
+}
+
+// JoinParams describes a structured query over several related tables
+type JoinParams struct {
+	// Tables are "name" for the public schema or "schema.name"; each table is
+	// referenced by its name in columns, filters and joins
+	Tables []string
+	// Joins override the conditions inferred from foreign keys
+	Joins []JoinCondition
+	// Columns are "table.column" or "table.*", all columns of all tables by default
+	Columns []string
+	Filters []Filter
+	OrderBy []OrderBy
+	Limit   int
+}
+
+// foreignKey is a foreign key between two tables
+type foreignKey struct {
+	Name           string         `db:"name"`
+	Table          string         `db:"table_name"`
+	Columns        pq.StringArray `db:"columns"`
+	ForeignTable   string         `db:"foreign_table"`
+	ForeignColumns pq.StringArray `db:"foreign_columns"`
+}
+
+// joinEdge links two tables of a join with its conditions
+type joinEdge struct {
+	left, right string
+	conds       []string
+}
+
+// splitTableName splits "schema.name" into its parts. The schema of an
+// unqualified name is empty and resolved against the search_path.
+func splitTableName(name string) (string, string) {
+	if schema, table, ok := strings.Cut(name, "."); ok {
+		return schema, table
+	}
+	return "", name
+}
+
+// BuildJoin compiles a structured join to SQL. The tables are joined along
+// their foreign keys unless explicit joins are given for a pair of tables.
+func (d *DB) BuildJoin(params JoinParams) (string, []interface{}, error) {
+	if len(params.Tables) < 2 {
+		return "", nil, fmt.Errorf("at least two tables are required")
+	}
+
+	names := make([]tableName, len(params.Tables))
+	for i, name := range params.Tables {
+		names[i].schema, names[i].table = splitTableName(name)
+	}
+	found, err := d.lookupTables(names)
+	if err != nil {
+		return "", nil, err
+	}
+
+	refs := map[string]*tableRef{}
+	var aliases, qualified []string
+	for _, name := range names {
+		ref, ok := found[name]
+		if !ok {
+			return "", nil, name.notFound()
+		}
+		if _, ok := refs[ref.table]; ok {
+			return "", nil, fmt.Errorf("table %s is listed more than once", ref.table)
+		}
+		refs[ref.table] = ref
+		aliases = append(aliases, ref.table)
+		qualified = append(qualified, ref.schema+"."+ref.table)
+	}
+
+	// column resolves "table.column", or a bare column name that exists in a single table
+	column := func(name string) (string, error) {
+		if alias, col, ok := strings.Cut(name, "."); ok {
+			ref, exists := refs[alias]
+			if !exists {
+				return "", fmt.Errorf("table %s in %q is not part of the join", alias, name)
+			}
+			quoted, err := ref.column(col)
+			if err != nil {
+				return "", err
+			}
+			return quoteIdent(alias) + "." + quoted, nil
+		}
+		var matches []string
+		for _, alias := range aliases {
+			if refs[alias].columns[name] {
+				matches = append(matches, alias)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return "", fmt.Errorf("column %q does not exist in any of the tables", name)
+		case 1:
+			return quoteIdent(matches[0]) + "." + quoteIdent(name), nil
+		}
+		return "", fmt.Errorf("column %q is ambiguous, qualify it with one of %s", name, strings.Join(matches, ", "))
+	}
+
+	edges, err := d.joinEdges(params.Joins, qualified, column)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Join the tables in the given order, each along an edge to an already joined table
+	joined := map[string]bool{aliases[0]: true}
+	from := fmt.Sprintf("%s AS %s", refs[aliases[0]].quoted(), quoteIdent(aliases[0]))
+	pending := aliases[1:]
+	for len(pending) > 0 {
+		progress := false
+		for i, alias := range pending {
+			var conds []string
+			for _, e := range edges {
+				if (e.left == alias && joined[e.right]) || (e.right == alias && joined[e.left]) {
+					conds = e.conds
+					break
+				}
+			}
+			if conds == nil {
+				continue
+			}
+			from += fmt.Sprintf(" JOIN %s AS %s ON %s", refs[alias].quoted(), quoteIdent(alias), strings.Join(conds, " AND "))
+			joined[alias] = true
+			pending = append(pending[:i:i], pending[i+1:]...)
+			progress = true
+			break
+		}
+		if !progress {
+			return "", nil, fmt.Errorf("no foreign key links %s to the other tables, pass explicit joins", strings.Join(pending, ", "))
+		}
+	}
+
+	selectList := "*"
+	if len(params.Columns) > 0 {
+		cols := make([]string, 0, len(params.Columns))
+		for _, name := range params.Columns {
+			if alias, ok := strings.CutSuffix(name, ".*"); ok {
+				if _, exists := refs[alias]; !exists {
+					return "", nil, fmt.Errorf("table %s in %q is not part of the join", alias, name)
+				}
+				cols = append(cols, quoteIdent(alias)+".*")
+				continue
+			}
+			col, err := column(name)
+			if err != nil {
+				return "", nil, err
+			}
+			cols = append(cols, col)
+		}
+		selectList = strings.Join(cols, ", ")
+	}
+
+	var args []interface{}
+	where, err := buildWhere(params.Filters, column, &args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "SELECT %s FROM %s", selectList, from)
+	if where != "" {
+		sb.WriteString(" WHERE " + where)
+	}
+	if len(params.OrderBy) > 0 {
+		keys := make([]string, 0, len(params.OrderBy))
+		for _, o := range params.OrderBy {
+			col, err := column(o.Column)
+			if err != nil {
+				return "", nil, err
+			}
+			if o.Descending {
+				col += " DESC"
+			}
+			keys = append(keys, col)
+		}
+		sb.WriteString(" ORDER BY " + strings.Join(keys, ", "))
+	}
+
+	args = append(args, builderLimit(params.Limit))
+	fmt.Fprintf(&sb, " LIMIT $%d", len(args))
+
+	return sb.String(), args, nil
+}
+
+// joinEdges returns the possible joins between the tables, explicit joins
+// first followed by the foreign keys in name order
+func (d *DB) joinEdges(joins []JoinCondition, qualified []string, column func(string) (string, error)) ([]joinEdge, error) {
+	var edges []joinEdge
+	explicit := map[[2]string]int{}
+	for _, j := range joins {
+		left, right := strings.Split(j.Left, ".")[0], strings.Split(j.Right, ".")[0]
+		if !strings.Contains(j.Left, ".") || !strings.Contains(j.Right, ".") || left == right {
+			return nil, fmt.Errorf("join %s = %s must compare columns of two different tables as table.column", j.Left, j.Right)
+		}
+		l, err := column(j.Left)
+		if err != nil {
+			return nil, err
+		}
+		r, err := column(j.Right)
+		if err != nil {
+			return nil, err
+		}
+		// Conditions on the same pair of tables are combined
+		key := [2]string{left, right}
+		if left > right {
+			key = [2]string{right, left}
+		}
+		cond := l + " = " + r
+		if i, ok := explicit[key]; ok {
+			edges[i].conds = append(edges[i].conds, cond)
+			continue
+		}
+		explicit[key] = len(edges)
+		edges = append(edges, joinEdge{left: left, right: right, conds: []string{cond}})
+	}
+
+	var keys []foreignKey
+	query := `SELECT con.conname AS name, cl.relname AS table_name, fcl.relname AS foreign_table,
+		ARRAY(SELECT a.attname FROM unnest(con.conkey) WITH ORDINALITY AS k(attnum, ord)
+			JOIN pg_catalog.pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+			ORDER BY k.ord) AS columns,
+		ARRAY(SELECT a.attname FROM unnest(con.confkey) WITH ORDINALITY AS k(attnum, ord)
+			JOIN pg_catalog.pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum
+			ORDER BY k.ord) AS foreign_columns
+		FROM pg_catalog.pg_constraint con
+		JOIN pg_catalog.pg_class cl ON cl.oid = con.conrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = cl.relnamespace
+		JOIN pg_catalog.pg_class fcl ON fcl.oid = con.confrelid
+		JOIN pg_catalog.pg_namespace fn ON fn.oid = fcl.relnamespace
+		WHERE con.contype = 'f' AND con.conrelid <> con.confrelid
+		AND n.nspname || '.' || cl.relname = ANY($1)
+		AND fn.nspname || '.' || fcl.relname = ANY($1)
+		ORDER BY con.conname`
+	if err := d.conn.Select(&keys, query, pq.Array(qualified)); err != nil {
+		return nil, fmt.Errorf("failed to get foreign keys: %w", err)
+	}
+	for _, fk := range keys {
+		key := [2]string{fk.Table, fk.ForeignTable}
+		if fk.Table > fk.ForeignTable {
+			key = [2]string{fk.ForeignTable, fk.Table}
+		}
+		if _, ok := explicit[key]; ok {
+			continue
+		}
+		edge := joinEdge{left: fk.Table, right: fk.ForeignTable}
+		for i := range fk.Columns {
+			edge.conds = append(edge.conds, fmt.Sprintf("%s.%s = %s.%s",
+				quoteIdent(fk.Table), quoteIdent(fk.Columns[i]),
+				quoteIdent(fk.ForeignTable), quoteIdent(fk.ForeignColumns[i])))
+		}
+		edges = append(edges, edge)
+	}
+	return edges, nil
+}
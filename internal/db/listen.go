@@ -0,0 +1,88 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	listenerMinReconnect = 10 * time.Second
+	listenerMaxReconnect = time.Minute
+	// listenerPingInterval keeps the idle listener connection checked
+	listenerPingInterval = 90 * time.Second
+)
+
+// Notification is a payload received from NOTIFY
+type Notification struct {
+	Channel string `json:"channel"`
+	Payload string `json:"payload"`
+	PID     int    `json:"pid"`
+}
+
+// NotificationListener receives NOTIFY payloads on a dedicated connection
+type NotificationListener struct {
+	listener *pq.Listener
+	done     chan struct{}
+}
+
+// NewNotificationListener opens a dedicated connection for LISTEN. The handler
+// is called from a single goroutine for every notification received.
+func (d *DB) NewNotificationListener(handler func(Notification)) *NotificationListener {
+	l := &NotificationListener{
+		listener: pq.NewListener(d.databaseURL, listenerMinReconnect, listenerMaxReconnect, func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				log.Printf("notification listener error: %v", err)
+			}
+		}),
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		ticker := time.NewTicker(listenerPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case n, ok := <-l.listener.Notify:
+				if !ok {
+					return
+				}
+				// A nil notification signals a reconnect, notifications may have been lost
+				if n == nil {
+					continue
+				}
+				handler(Notification{Channel: n.Channel, Payload: n.Extra, PID: n.BePid})
+			case <-ticker.C:
+				go l.listener.Ping()
+			case <-l.done:
+				return
+			}
+		}
+	}()
+
+	return l
+}
+
+// Listen starts listening on the channel
+func (l *NotificationListener) Listen(channel string) error {
+	if err := l.listener.Listen(channel); err != nil && err != pq.ErrChannelAlreadyOpen {
+		return fmt.Errorf("failed to listen on channel %s: %w", channel, err)
+	}
+	return nil
+}
+
+// Unlisten stops listening on the channel
+func (l *NotificationListener) Unlisten(channel string) error {
+	if err := l.listener.Unlisten(channel); err != nil && err != pq.ErrChannelNotOpen {
+		return fmt.Errorf("failed to unlisten channel %s: %w", channel, err)
+	}
+	return nil
+}
+
+// Close closes the listener connection
+func (l *NotificationListener) Close() error {
+	close(l.done)
+	return l.listener.Close()
+}
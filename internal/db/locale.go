@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// DatabaseLocale is the encoding and locale of the database, which decide
+// how text is compared, sorted and case-folded
+type DatabaseLocale struct {
+	Encoding  string `db:"encoding" json:"encoding"`
+	LCCollate string `db:"lc_collate" json:"lc_collate"`
+	LCCtype   string `db:"lc_ctype" json:"lc_ctype"`
+	// Provider is libc, or icu (PostgreSQL 15) or builtin (PostgreSQL 17)
+	// with the locale in Locale
+	Provider string  `db:"locale_provider" json:"locale_provider"`
+	Locale   *string `db:"locale" json:"locale,omitempty"`
+}
+
+// databaseLocale returns the encoding and locale of the current database
+func (d *DB) databaseLocale(ctx context.Context, version int) (*DatabaseLocale, error) {
+	provider, locale := "'libc'", "NULL"
+	switch {
+	case version >= 170000:
+		provider, locale = "datlocprovider", "datlocale"
+	case version >= 150000:
+		provider, locale = "datlocprovider", "daticulocale"
+	}
+
+	loc := &DatabaseLocale{}
+	query := fmt.Sprintf(`SELECT pg_encoding_to_char(encoding) AS encoding,
+		datcollate AS lc_collate, datctype AS lc_ctype,
+		CASE %s WHEN 'c' THEN 'libc' WHEN 'i' THEN 'icu' WHEN 'b' THEN 'builtin' ELSE 'libc' END AS locale_provider,
+		%s AS locale
+		FROM pg_catalog.pg_database
+		WHERE datname = current_database()`, provider, locale)
+	if err := d.conn.GetContext(ctx, loc, query); err != nil {
+		return nil, fmt.Errorf("failed to get database locale: %w", err)
+	}
+	return loc, nil
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// maintenanceTablesLimit is the number of tables reported in the maintenance status
+const maintenanceTablesLimit = 50
+
+// TableMaintenance is the vacuum and analyze state of a table
+type TableMaintenance struct {
+	Schema           string     `db:"schema" json:"schema"`
+	Table            string     `db:"table_name" json:"table"`
+	LiveTuples       int64      `db:"live_tuples" json:"live_tuples"`
+	DeadTuples       int64      `db:"dead_tuples" json:"dead_tuples"`
+	DeadRatio        float64    `db:"dead_ratio" json:"dead_ratio"`
+	ModsSinceAnalyze int64      `db:"mods_since_analyze" json:"mods_since_analyze"`
+	LastVacuum       *time.Time `db:"last_vacuum" json:"last_vacuum"`
+	LastAutovacuum   *time.Time `db:"last_autovacuum" json:"last_autovacuum"`
+	LastAnalyze      *time.Time `db:"last_analyze" json:"last_analyze"`
+	LastAutoanalyze  *time.Time `db:"last_autoanalyze" json:"last_autoanalyze"`
+	AutovacuumCount  int64      `db:"autovacuum_count" json:"autovacuum_count"`
+	VacuumOverdue    bool       `db:"vacuum_overdue" json:"vacuum_overdue"`
+	AnalyzeOverdue   bool       `db:"analyze_overdue" json:"analyze_overdue"`
+	XIDAge           int64      `db:"xid_age" json:"xid_age"`
+}
+
+// AutovacuumWorker is a running autovacuum process
+type AutovacuumWorker struct {
+	PID             int    `db:"pid" json:"pid"`
+	Query           string `db:"query" json:"query"`
+	Phase           string `db:"phase" json:"phase"`
+	DurationSeconds int    `db:"duration_seconds" json:"duration_seconds"`
+}
+
+// WraparoundRisk is the transaction ID age of the current database
+type WraparoundRisk struct {
+	XIDAge       int64 `db:"xid_age" json:"xid_age"`
+	FreezeMaxAge int64 `db:"freeze_max_age" json:"autovacuum_freeze_max_age"`
+	// PercentToWraparound is the XID age relative to the 2^31 wraparound limit
+	PercentToWraparound float64 `db:"percent_to_wraparound" json:"percent_to_wraparound"`
+}
+
+// MaintenanceStatus is the vacuum, analyze and wraparound state of the database
+type MaintenanceStatus struct {
+	Tables     []TableMaintenance `json:"tables"`
+	Workers    []AutovacuumWorker `json:"autovacuum_workers"`
+	Wraparound WraparoundRisk     `json:"wraparound"`
+}
+
+// GetMaintenanceStatus returns the tables with the most dead tuples, the
+// running autovacuum workers and the transaction ID wraparound risk. Tables
+// are overdue when they pass the global autovacuum thresholds; per-table
+// storage parameters are not taken into account.
+func (d *DB) GetMaintenanceStatus() (*MaintenanceStatus, error) {
+	status := &MaintenanceStatus{
+		Tables:  []TableMaintenance{},
+		Workers: []AutovacuumWorker{},
+	}
+
+	query := `SELECT s.schemaname AS schema, s.relname AS table_name,
+		s.n_live_tup AS live_tuples, s.n_dead_tup AS dead_tuples,
+		round(s.n_dead_tup::numeric / GREATEST(s.n_live_tup + s.n_dead_tup, 1), 4)::float8 AS dead_ratio,
+		s.n_mod_since_analyze AS mods_since_analyze,
+		s.last_vacuum, s.last_autovacuum, s.last_analyze, s.last_autoanalyze,
+		s.autovacuum_count,
+		s.n_dead_tup > current_setting('autovacuum_vacuum_threshold')::float8
+			+ current_setting('autovacuum_vacuum_scale_factor')::float8 * GREATEST(c.reltuples, 0) AS vacuum_overdue,
+		s.n_mod_since_analyze > current_setting('autovacuum_analyze_threshold')::float8
+			+ current_setting('autovacuum_analyze_scale_factor')::float8 * GREATEST(c.reltuples, 0) AS analyze_overdue,
+		age(c.relfrozenxid) AS xid_age
+		FROM pg_catalog.pg_stat_user_tables s
+		JOIN pg_catalog.pg_class c ON c.oid = s.relid
+		ORDER BY s.n_dead_tup DESC, s.schemaname, s.relname
+		LIMIT $1`
+	if err := d.conn.Select(&status.Tables, query, maintenanceTablesLimit); err != nil {
+		return nil, fmt.Errorf("failed to get table maintenance status: %w", err)
+	}
+
+	query = `SELECT a.pid, a.query, COALESCE(p.phase, '') AS phase,
+		COALESCE(EXTRACT(EPOCH FROM now() - a.xact_start), 0)::int AS duration_seconds
+		FROM pg_catalog.pg_stat_activity a
+		LEFT JOIN pg_catalog.pg_stat_progress_vacuum p ON p.pid = a.pid
+		WHERE a.backend_type = 'autovacuum worker'
+		ORDER BY a.xact_start`
+	if err := d.conn.Select(&status.Workers, query); err != nil {
+		return nil, fmt.Errorf("failed to get autovacuum workers: %w", err)
+	}
+
+	query = `SELECT age(datfrozenxid) AS xid_age,
+		current_setting('autovacuum_freeze_max_age')::bigint AS freeze_max_age,
+		round(age(datfrozenxid)::numeric * 100 / 2147483648, 2)::float8 AS percent_to_wraparound
+		FROM pg_catalog.pg_database WHERE datname = current_database()`
+	if err := d.conn.Get(&status.Wraparound, query); err != nil {
+		return nil, fmt.Errorf("failed to get wraparound risk: %w", err)
+	}
+
+	return status, nil
+}
+
+// Analyze runs ANALYZE on a table
+func (d *DB) Analyze(ctx context.Context, schema, table string) error {
+	ref, err := d.lookupTable(schema, table)
+	if err != nil {
+		return err
+	}
+	if _, err := d.conn.ExecContext(ctx, "ANALYZE "+ref.quoted()); err != nil {
+		return fmt.Errorf("failed to analyze %s.%s: %w", ref.schema, ref.table, err)
+	}
+	return nil
+}
+
+// Vacuum runs a plain (non-FULL) VACUUM on a table, with ANALYZE when analyze is set
+func (d *DB) Vacuum(ctx context.Context, schema, table string, analyze bool) error {
+	ref, err := d.lookupTable(schema, table)
+	if err != nil {
+		return err
+	}
+	stmt := "VACUUM "
+	if analyze {
+		stmt += "(ANALYZE) "
+	}
+	// VACUUM cannot run inside a transaction block, it runs in autocommit mode
+	if _, err := d.conn.ExecContext(ctx, stmt+ref.quoted()); err != nil {
+		return fmt.Errorf("failed to vacuum %s.%s: %w", ref.schema, ref.table, err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"strings"
+)
+
+// encodeMoney converts the locale-dependent text representation of a money
+// value, e.g. $1,234.56, -1.234,56 € or ($5.00), into a numeric value
+// encoded like numeric columns. Values that cannot be parsed are returned
+// unchanged as a string.
+func (o *encodeOptions) encodeMoney(s string) interface{} {
+	negative := strings.Contains(s, "-") || strings.HasPrefix(strings.TrimSpace(s), "(")
+	var digits strings.Builder
+	for _, r := range s {
+		if (r >= '0' && r <= '9') || r == '.' || r == ',' {
+			digits.WriteRune(r)
+		}
+	}
+	amount := digits.String()
+	if amount == "" {
+		return s
+	}
+
+	// The last separator is the decimal one if a dot and a comma are used,
+	// a single separator followed by two digits otherwise
+	decimal := byte(0)
+	lastDot, lastComma := strings.LastIndexByte(amount, '.'), strings.LastIndexByte(amount, ',')
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		decimal = amount[max(lastDot, lastComma)]
+	case lastDot >= 0 && strings.Count(amount, ".") == 1 && len(amount)-lastDot-1 != 3:
+		decimal = '.'
+	case lastComma >= 0 && strings.Count(amount, ",") == 1 && len(amount)-lastComma-1 != 3:
+		decimal = ','
+	}
+
+	var number strings.Builder
+	if negative {
+		number.WriteByte('-')
+	}
+	for i := 0; i < len(amount); i++ {
+		switch c := amount[i]; {
+		case c == decimal:
+			number.WriteByte('.')
+		case c >= '0' && c <= '9':
+			number.WriteByte(c)
+		}
+	}
+	return o.encodeNumeric(number.String())
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Notice is a notice or warning raised while a query ran, e.g. by RAISE
+// NOTICE in a function
+type Notice struct {
+	Severity string `json:"severity"`
+	Code     string `json:"code,omitempty"`
+	Message  string `json:"message"`
+	Detail   string `json:"detail,omitempty"`
+	Hint     string `json:"hint,omitempty"`
+	Where    string `json:"where,omitempty"`
+}
+
+// maxNotices bounds the notices kept per query, a function raising a notice
+// per row would otherwise bloat the result
+const maxNotices = 100
+
+// noticeCollector collects the notices of a query
+type noticeCollector struct {
+	mu      sync.Mutex
+	notices []Notice
+	dropped int
+}
+
+// handle is the notice handler of the connection
+func (c *noticeCollector) handle(err *pq.Error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.notices) >= maxNotices {
+		c.dropped++
+		return
+	}
+	c.notices = append(c.notices, Notice{
+		Severity: err.Severity,
+		Code:     string(err.Code),
+		Message:  err.Message,
+		Detail:   err.Detail,
+		Hint:     err.Hint,
+		Where:    err.Where,
+	})
+}
+
+// collected returns the notices, with a last one counting the dropped notices
+func (c *noticeCollector) collected() []Notice {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dropped > 0 {
+		return append(c.notices, Notice{
+			Severity: "NOTICE",
+			Message:  fmt.Sprintf("%d more notices were dropped", c.dropped),
+		})
+	}
+	return c.notices
+}
+
+// setNoticeHandler sets the notice handler of a connection, nil unsets it
+func setNoticeHandler(conn *sqlx.Conn, handler func(*pq.Error)) error {
+	return conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(driver.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected driver connection %T", driverConn)
+		}
+		pq.SetNoticeHandler(c, handler)
+		return nil
+	})
+}
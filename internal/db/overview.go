@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// largestTablesLimit is the number of tables reported in the overview
+const largestTablesLimit = 10
+
+// TableSize represents the on-disk size of a table
+type TableSize struct {
+	Schema     string `db:"table_schema" json:"schema"`
+	Name       string `db:"table_name" json:"name"`
+	TotalBytes int64  `db:"total_bytes" json:"total_bytes"`
+	TotalSize  string `db:"total_size" json:"total_size"`
+}
+
+// Extension represents an installed PostgreSQL extension
+type Extension struct {
+	Name    string `db:"extname" json:"name"`
+	Version string `db:"extversion" json:"version"`
+}
+
+// ActivitySummary summarizes the connections to the current database
+type ActivitySummary struct {
+	MaxConnections      int `db:"max_connections" json:"max_connections"`
+	TotalConnections    int `db:"total_connections" json:"total_connections"`
+	Active              int `db:"active" json:"active"`
+	Idle                int `db:"idle" json:"idle"`
+	IdleInTransaction   int `db:"idle_in_transaction" json:"idle_in_transaction"`
+	WaitingOnLock       int `db:"waiting_on_lock" json:"waiting_on_lock"`
+	LongestQuerySeconds int `db:"longest_query_seconds" json:"longest_query_seconds"`
+}
+
+// DatabaseOverview is a summary of the whole database
+type DatabaseOverview struct {
+	ServerVersion string `json:"server_version"`
+	Database      string `json:"database"`
+	// Locale is the encoding, lc_collate and lc_ctype of the database
+	Locale        *DatabaseLocale `json:"locale"`
+	SizeBytes     int64           `json:"size_bytes"`
+	Size          string          `json:"size"`
+	TableCount    int             `json:"table_count"`
+	LargestTables []TableSize     `json:"largest_tables"`
+	Extensions    []Extension     `json:"extensions"`
+	Activity      ActivitySummary `json:"activity"`
+}
+
+// GetOverview returns a summary of the database: version, size, tables,
+// extensions and connection activity
+func (d *DB) GetOverview() (*DatabaseOverview, error) {
+	overview := &DatabaseOverview{
+		LargestTables: []TableSize{},
+		Extensions:    []Extension{},
+	}
+
+	// With Citus the sizes cover the shards on the workers
+	databaseSize, relationSize, err := d.sizeExpressions()
+	if err != nil {
+		return nil, err
+	}
+
+	var info struct {
+		ServerVersion string `db:"server_version"`
+		Database      string `db:"database"`
+		SizeBytes     int64  `db:"size_bytes"`
+		Size          string `db:"size"`
+	}
+	query := `SELECT current_setting('server_version') AS server_version,
+		current_database() AS database,
+		` + databaseSize + ` AS size_bytes,
+		pg_size_pretty(` + databaseSize + `) AS size`
+	if err := d.conn.Get(&info, query); err != nil {
+		return nil, fmt.Errorf("failed to get database info: %w", err)
+	}
+	overview.ServerVersion = info.ServerVersion
+	overview.Database = info.Database
+	overview.SizeBytes = info.SizeBytes
+	overview.Size = info.Size
+
+	version, err := d.serverVersionNum(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if overview.Locale, err = d.databaseLocale(context.Background(), version); err != nil {
+		return nil, err
+	}
+
+	query = `SELECT count(*) FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p')
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND n.nspname NOT LIKE 'pg_toast%'`
+	if err := d.conn.Get(&overview.TableCount, query); err != nil {
+		return nil, fmt.Errorf("failed to count tables: %w", err)
+	}
+
+	query = `SELECT n.nspname AS table_schema, c.relname AS table_name,
+		` + relationSize + ` AS total_bytes,
+		pg_size_pretty(` + relationSize + `) AS total_size
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p', 'm')
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		AND n.nspname NOT LIKE 'pg_toast%'
+		ORDER BY total_bytes DESC
+		LIMIT $1`
+	if err := d.conn.Select(&overview.LargestTables, query, largestTablesLimit); err != nil {
+		return nil, fmt.Errorf("failed to get largest tables: %w", err)
+	}
+
+	query = "SELECT extname, extversion FROM pg_catalog.pg_extension ORDER BY extname"
+	if err := d.conn.Select(&overview.Extensions, query); err != nil {
+		return nil, fmt.Errorf("failed to get extensions: %w", err)
+	}
+
+	query = `SELECT current_setting('max_connections')::int AS max_connections,
+		count(*) AS total_connections,
+		count(*) FILTER (WHERE state = 'active') AS active,
+		count(*) FILTER (WHERE state = 'idle') AS idle,
+		count(*) FILTER (WHERE state LIKE 'idle in transaction%') AS idle_in_transaction,
+		count(*) FILTER (WHERE wait_event_type = 'Lock') AS waiting_on_lock,
+		COALESCE(EXTRACT(EPOCH FROM max(now() - query_start) FILTER (WHERE state = 'active'))::int, 0) AS longest_query_seconds
+		FROM pg_catalog.pg_stat_activity
+		WHERE datname = current_database()`
+	if err := d.conn.Get(&overview.Activity, query); err != nil {
+		return nil, fmt.Errorf("failed to get connection activity: %w", err)
+	}
+
+	return overview, nil
+}
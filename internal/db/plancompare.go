@@ -0,0 +1,201 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PlanNode is a node of a query plan, flattened with its position in the tree
+type PlanNode struct {
+	// Path is the position of the node, e.g. "0.1" is the second child of the root
+	Path        string  `json:"path"`
+	NodeType    string  `json:"node_type"`
+	Relation    string  `json:"relation,omitempty"`
+	Index       string  `json:"index,omitempty"`
+	StartupCost float64 `json:"startup_cost"`
+	TotalCost   float64 `json:"total_cost"`
+	PlanRows    float64 `json:"plan_rows"`
+}
+
+// QueryPlan is the estimated plan of a query
+type QueryPlan struct {
+	TotalCost float64    `json:"total_cost"`
+	PlanRows  float64    `json:"plan_rows"`
+	Nodes     []PlanNode `json:"nodes"`
+}
+
+// PlanNodeChange is the difference of a plan node between two plans
+type PlanNodeChange struct {
+	Path string `json:"path"`
+	// Change is added, removed, replaced or estimate
+	Change string    `json:"change"`
+	Before *PlanNode `json:"before,omitempty"`
+	After  *PlanNode `json:"after,omitempty"`
+	// CostChangePercent is the change of the node's total cost
+	CostChangePercent *float64 `json:"cost_change_percent,omitempty"`
+	// RowsChangePercent is the change of the node's estimated rows
+	RowsChangePercent *float64 `json:"rows_change_percent,omitempty"`
+}
+
+// PlanComparison is the structured difference of two plans of a query
+type PlanComparison struct {
+	Before            *QueryPlan       `json:"before"`
+	After             *QueryPlan       `json:"after"`
+	ShapeChanged      bool             `json:"shape_changed"`
+	CostChangePercent float64          `json:"cost_change_percent"`
+	Changes           []PlanNodeChange `json:"changes"`
+}
+
+// ExplainQuery returns the estimated plan of a query. The query is not
+// executed. With the hypopg extension, hypotheticalIndexes are CREATE INDEX
+// statements planned as if the indexes existed.
+func (d *DB) ExplainQuery(ctx context.Context, query string, hypotheticalIndexes []string) (*QueryPlan, error) {
+	if len(hypotheticalIndexes) > 0 {
+		hypopg, err := d.HasExtension("hypopg")
+		if err != nil {
+			return nil, err
+		}
+		if !hypopg {
+			return nil, fmt.Errorf("hypothetical indexes require the hypopg extension")
+		}
+	}
+
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, "SET TRANSACTION READ ONLY"); err != nil {
+		return nil, fmt.Errorf("failed to set transaction to read-only: %w", err)
+	}
+
+	if len(hypotheticalIndexes) > 0 {
+		// Hypothetical indexes live in the backend, not the transaction
+		defer tx.ExecContext(context.WithoutCancel(ctx), "SELECT hypopg_reset()")
+		for _, createIndex := range hypotheticalIndexes {
+			if _, err := tx.ExecContext(ctx, "SELECT * FROM hypopg_create_index($1)", createIndex); err != nil {
+				return nil, fmt.Errorf("failed to create hypothetical index %q: %w", createIndex, err)
+			}
+		}
+	}
+
+	// Queries with bind parameters are planned generically (PostgreSQL 16+)
+	root, err := d.explainStatement(ctx, tx, "VERBOSE", query)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &QueryPlan{TotalCost: root.TotalCost, PlanRows: root.PlanRows}
+	flattenPlan(root, "0", &plan.Nodes)
+	return plan, nil
+}
+
+// flattenPlan appends a node and its children in depth-first order
+func flattenPlan(node *explainNode, path string, nodes *[]PlanNode) {
+	relation := node.RelationName
+	if relation != "" && node.Schema != "" {
+		relation = node.Schema + "." + relation
+	}
+	*nodes = append(*nodes, PlanNode{
+		Path:        path,
+		NodeType:    node.NodeType,
+		Relation:    relation,
+		Index:       node.IndexName,
+		StartupCost: node.StartupCost,
+		TotalCost:   node.TotalCost,
+		PlanRows:    node.PlanRows,
+	})
+	for i := range node.Plans {
+		flattenPlan(&node.Plans[i], path+"."+strconv.Itoa(i), nodes)
+	}
+}
+
+// ComparePlans returns the node by node difference of two plans. Nodes are
+// matched by their position in the tree; a node with a different type,
+// relation or index at the same position is reported as replaced.
+func ComparePlans(before, after *QueryPlan) *PlanComparison {
+	comparison := &PlanComparison{
+		Before:            before,
+		After:             after,
+		CostChangePercent: changePercent(before.TotalCost, after.TotalCost),
+		Changes:           []PlanNodeChange{},
+	}
+
+	beforeNodes := map[string]*PlanNode{}
+	for i := range before.Nodes {
+		beforeNodes[before.Nodes[i].Path] = &before.Nodes[i]
+	}
+	afterNodes := map[string]*PlanNode{}
+	for i := range after.Nodes {
+		afterNodes[after.Nodes[i].Path] = &after.Nodes[i]
+	}
+
+	paths := map[string]bool{}
+	for path := range beforeNodes {
+		paths[path] = true
+	}
+	for path := range afterNodes {
+		paths[path] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return comparePaths(sorted[i], sorted[j]) < 0 })
+
+	for _, path := range sorted {
+		b, a := beforeNodes[path], afterNodes[path]
+		change := PlanNodeChange{Path: path, Before: b, After: a}
+		switch {
+		case a == nil:
+			change.Change = "removed"
+		case b == nil:
+			change.Change = "added"
+		case b.NodeType != a.NodeType || b.Relation != a.Relation || b.Index != a.Index:
+			change.Change = "replaced"
+		case b.TotalCost != a.TotalCost || b.PlanRows != a.PlanRows:
+			change.Change = "estimate"
+		default:
+			continue
+		}
+		if a != nil && b != nil {
+			cost := changePercent(b.TotalCost, a.TotalCost)
+			rows := changePercent(b.PlanRows, a.PlanRows)
+			change.CostChangePercent = &cost
+			change.RowsChangePercent = &rows
+		}
+		if change.Change != "estimate" {
+			comparison.ShapeChanged = true
+		}
+		comparison.Changes = append(comparison.Changes, change)
+	}
+	return comparison
+}
+
+// comparePaths orders plan node paths depth-first
+func comparePaths(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, _ := strconv.Atoi(as[i])
+		y, _ := strconv.Atoi(bs[i])
+		if x != y {
+			return x - y
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// changePercent returns the relative change from before to after, rounded to 0.1%
+func changePercent(before, after float64) float64 {
+	if before == 0 {
+		if after == 0 {
+			return 0
+		}
+		return 100
+	}
+	return math.Round((after-before)/before*1000) / 10
+}
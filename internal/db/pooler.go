@@ -0,0 +1,50 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Pooler is the connection pooler between the server and PostgreSQL
+type Pooler string
+
+const (
+	// PoolerNone connects to PostgreSQL directly or through a session pooler
+	PoolerNone Pooler = "none"
+	// PoolerPgBouncer connects through PgBouncer in transaction pooling mode,
+	// where consecutive transactions may run on different server connections
+	PoolerPgBouncer Pooler = "pgbouncer"
+)
+
+// ParsePooler validates a pooler name
+func ParsePooler(s string) (Pooler, error) {
+	switch p := Pooler(s); p {
+	case PoolerNone, PoolerPgBouncer:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid pooler %q, must be one of none, pgbouncer", s)
+	}
+}
+
+// WithPooler adapts the connection to a connection pooler. With PgBouncer,
+// queries with parameters are sent in a single round trip instead of
+// preparing them first, since the prepared statement could land on another
+// server connection.
+func WithPooler(pooler Pooler) Option {
+	return func(d *DB) {
+		d.pooler = pooler
+	}
+}
+
+// connectionString returns the connection string adapted to the pooler
+func (d *DB) connectionString() string {
+	if d.pooler != PoolerPgBouncer {
+		return d.databaseURL
+	}
+	dsn := d.databaseURL
+	if converted, err := pq.ParseURL(dsn); err == nil && converted != "" {
+		dsn = converted
+	}
+	return dsn + " binary_parameters=yes"
+}
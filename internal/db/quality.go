@@ -0,0 +1,274 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Data quality checks
+const (
+	CheckNullRate     = "null_rate"
+	CheckDuplicateKey = "duplicate_key"
+	CheckOrphanedFK   = "orphaned_fk"
+	CheckDateRange    = "date_range"
+	CheckEmptyString  = "empty_string"
+)
+
+// QualityChecks are the available data quality checks
+var QualityChecks = []string{CheckNullRate, CheckDuplicateKey, CheckOrphanedFK, CheckDateRange, CheckEmptyString}
+
+// duplicateKeyNames are column names checked for duplicates when they have no unique index
+var duplicateKeyNames = map[string]bool{"id": true, "uuid": true, "email": true, "slug": true, "code": true}
+
+// QualityParams configures a data quality check of a table
+type QualityParams struct {
+	Schema string
+	Table  string
+	// Checks to run, all when empty
+	Checks []string
+	// SampleRows bounds the number of rows scanned
+	SampleRows int
+	// NullThreshold is the null ratio above which a column is reported
+	NullThreshold float64
+	// KeyColumns are checked for duplicates, in addition to id-like columns without unique index
+	KeyColumns []string
+	// MinDate and MaxDate bound the plausible values of date and timestamp columns
+	MinDate time.Time
+	MaxDate time.Time
+}
+
+// QualityFinding is a data quality problem found in a table
+type QualityFinding struct {
+	Check   string   `json:"check"`
+	Columns []string `json:"columns"`
+	Count   int64    `json:"count"`
+	Ratio   float64  `json:"ratio"`
+	Message string   `json:"message"`
+}
+
+// QualityReport is the result of a data quality check
+type QualityReport struct {
+	Table       string           `json:"table"`
+	RowsScanned int64            `json:"rows_scanned"`
+	Checks      []string         `json:"checks"`
+	Findings    []QualityFinding `json:"findings"`
+}
+
+// qualityColumn is a column of the checked table
+type qualityColumn struct {
+	Name     string `db:"attname"`
+	Type     string `db:"typname"`
+	Category string `db:"typcategory"`
+	NotNull  bool   `db:"attnotnull"`
+	Unique   bool   `db:"is_unique"`
+}
+
+// CheckDataQuality scans up to SampleRows rows of a table and reports null
+// rates above the threshold, duplicate values in key-like columns, foreign
+// key values without parent row, implausible dates and empty strings.
+func (d *DB) CheckDataQuality(ctx context.Context, params QualityParams) (*QualityReport, error) {
+	ref, err := d.lookupTable(params.Schema, params.Table)
+	if err != nil {
+		return nil, err
+	}
+	checks := map[string]bool{}
+	for _, c := range params.Checks {
+		checks[c] = true
+	}
+	if len(checks) == 0 {
+		for _, c := range QualityChecks {
+			checks[c] = true
+		}
+	}
+	for c := range checks {
+		if !slices.Contains(QualityChecks, c) {
+			return nil, fmt.Errorf("invalid check %q, must be one of %s", c, strings.Join(QualityChecks, ", "))
+		}
+	}
+	for _, col := range params.KeyColumns {
+		if _, err := ref.column(col); err != nil {
+			return nil, err
+		}
+	}
+
+	var columns []qualityColumn
+	query := `SELECT a.attname, t.typname, t.typcategory, a.attnotnull,
+		EXISTS (SELECT 1 FROM pg_catalog.pg_index i
+			WHERE i.indrelid = c.oid AND i.indisunique AND i.indnkeyatts = 1 AND i.indkey[0] = a.attnum) AS is_unique
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_type t ON t.oid = a.atttypid
+		WHERE n.nspname = $1 AND c.relname = $2
+		AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`
+	if err := d.conn.SelectContext(ctx, &columns, query, ref.schema, ref.table); err != nil {
+		return nil, fmt.Errorf("failed to get columns of %s.%s: %w", ref.schema, ref.table, err)
+	}
+
+	report := &QualityReport{
+		Table:    ref.schema + "." + ref.table,
+		Findings: []QualityFinding{},
+	}
+	for _, c := range QualityChecks {
+		if checks[c] {
+			report.Checks = append(report.Checks, c)
+		}
+	}
+	sample := fmt.Sprintf("(SELECT * FROM %s LIMIT %d) s", ref.quoted(), params.SampleRows)
+
+	// Column level checks share a single scan of the sample
+	type measure struct {
+		check  string
+		column string
+		expr   string
+	}
+	measures := []measure{{expr: "count(*)"}}
+	for _, col := range columns {
+		quoted := quoteIdent(col.Name)
+		if checks[CheckNullRate] && !col.NotNull {
+			measures = append(measures, measure{CheckNullRate, col.Name, fmt.Sprintf("count(*) - count(%s)", quoted)})
+		}
+		if checks[CheckDuplicateKey] && !col.Unique && (duplicateKeyNames[col.Name] || slices.Contains(params.KeyColumns, col.Name)) {
+			measures = append(measures, measure{CheckDuplicateKey, col.Name, fmt.Sprintf("count(%s) - count(DISTINCT %s)", quoted, quoted)})
+		}
+		if checks[CheckDateRange] && (col.Type == "date" || col.Type == "timestamp" || col.Type == "timestamptz") {
+			measures = append(measures, measure{CheckDateRange, col.Name, fmt.Sprintf("count(*) FILTER (WHERE %s < $1::timestamptz OR %s > $2::timestamptz)", quoted, quoted)})
+		}
+		if checks[CheckEmptyString] && col.Category == "S" {
+			measures = append(measures, measure{CheckEmptyString, col.Name, fmt.Sprintf("count(*) FILTER (WHERE btrim(%s::text) = '')", quoted)})
+		}
+	}
+
+	exprs := make([]string, len(measures))
+	for i, m := range measures {
+		exprs[i] = m.expr + "::bigint"
+	}
+	counts := make([]int64, len(measures))
+	dest := make([]interface{}, len(measures))
+	for i := range counts {
+		dest[i] = &counts[i]
+	}
+	query = fmt.Sprintf("SELECT %s FROM %s", strings.Join(exprs, ", "), sample)
+	var args []interface{}
+	if slices.ContainsFunc(measures, func(m measure) bool { return m.check == CheckDateRange }) {
+		args = append(args, params.MinDate.Format(time.RFC3339), params.MaxDate.Format(time.RFC3339))
+	}
+	if err := d.conn.QueryRowContext(ctx, query, args...).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", report.Table, err)
+	}
+	report.RowsScanned = counts[0]
+
+	ratio := func(count int64) float64 {
+		if report.RowsScanned == 0 {
+			return 0
+		}
+		return math.Round(float64(count)/float64(report.RowsScanned)*10000) / 10000
+	}
+	for i, m := range measures[1:] {
+		count := counts[i+1]
+		if count == 0 || (m.check == CheckNullRate && ratio(count) <= params.NullThreshold) {
+			continue
+		}
+		finding := QualityFinding{Check: m.check, Columns: []string{m.column}, Count: count, Ratio: ratio(count)}
+		switch m.check {
+		case CheckNullRate:
+			finding.Message = fmt.Sprintf("%.1f%% of the values are NULL", finding.Ratio*100)
+		case CheckDuplicateKey:
+			finding.Message = fmt.Sprintf("%d rows repeat a value of a key-like column without unique index", count)
+		case CheckDateRange:
+			finding.Message = fmt.Sprintf("%d values are before %s or after %s", count, params.MinDate.Format(time.DateOnly), params.MaxDate.Format(time.DateOnly))
+		case CheckEmptyString:
+			finding.Message = fmt.Sprintf("%d values are empty or whitespace-only strings", count)
+			if !columnNotNull(columns, m.column) {
+				finding.Message += "; NULL may be intended"
+			}
+		}
+		report.Findings = append(report.Findings, finding)
+	}
+
+	if checks[CheckOrphanedFK] {
+		findings, err := d.orphanedForeignKeys(ctx, ref, sample)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range findings {
+			f.Ratio = ratio(f.Count)
+			report.Findings = append(report.Findings, f)
+		}
+	}
+
+	return report, nil
+}
+
+// orphanedForeignKeys counts the sampled rows whose foreign key values have
+// no parent row, e.g. for constraints created NOT VALID
+func (d *DB) orphanedForeignKeys(ctx context.Context, ref *tableRef, sample string) ([]QualityFinding, error) {
+	var keys []struct {
+		Name           string         `db:"name"`
+		ForeignSchema  string         `db:"foreign_schema"`
+		ForeignTable   string         `db:"foreign_table"`
+		Columns        pq.StringArray `db:"columns"`
+		ForeignColumns pq.StringArray `db:"foreign_columns"`
+	}
+	query := `SELECT con.conname AS name, fn.nspname AS foreign_schema, fcl.relname AS foreign_table,
+		ARRAY(SELECT a.attname FROM unnest(con.conkey) WITH ORDINALITY AS k(attnum, ord)
+			JOIN pg_catalog.pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+			ORDER BY k.ord) AS columns,
+		ARRAY(SELECT a.attname FROM unnest(con.confkey) WITH ORDINALITY AS k(attnum, ord)
+			JOIN pg_catalog.pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum
+			ORDER BY k.ord) AS foreign_columns
+		FROM pg_catalog.pg_constraint con
+		JOIN pg_catalog.pg_class cl ON cl.oid = con.conrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = cl.relnamespace
+		JOIN pg_catalog.pg_class fcl ON fcl.oid = con.confrelid
+		JOIN pg_catalog.pg_namespace fn ON fn.oid = fcl.relnamespace
+		WHERE con.contype = 'f' AND n.nspname = $1 AND cl.relname = $2
+		ORDER BY con.conname`
+	if err := d.conn.SelectContext(ctx, &keys, query, ref.schema, ref.table); err != nil {
+		return nil, fmt.Errorf("failed to get foreign keys: %w", err)
+	}
+
+	var findings []QualityFinding
+	for _, fk := range keys {
+		var notNull, match []string
+		for i := range fk.Columns {
+			col := quoteIdent(fk.Columns[i])
+			notNull = append(notNull, "s."+col+" IS NOT NULL")
+			match = append(match, fmt.Sprintf("p.%s = s.%s", quoteIdent(fk.ForeignColumns[i]), col))
+		}
+		query := fmt.Sprintf("SELECT count(*) FROM %s WHERE %s AND NOT EXISTS (SELECT 1 FROM %s p WHERE %s)",
+			sample, strings.Join(notNull, " AND "),
+			Identifier{fk.ForeignSchema, fk.ForeignTable}.Sanitize(),
+			strings.Join(match, " AND "))
+		var count int64
+		if err := d.conn.GetContext(ctx, &count, query); err != nil {
+			return nil, fmt.Errorf("failed to check foreign key %s: %w", fk.Name, err)
+		}
+		if count > 0 {
+			findings = append(findings, QualityFinding{
+				Check:   CheckOrphanedFK,
+				Columns: fk.Columns,
+				Count:   count,
+				Message: fmt.Sprintf("%d rows reference a missing row of %s.%s (constraint %s)", count, fk.ForeignSchema, fk.ForeignTable, fk.Name),
+			})
+		}
+	}
+	return findings, nil
+}
+
+// columnNotNull reports whether a column has a NOT NULL constraint
+func columnNotNull(columns []qualityColumn, name string) bool {
+	for _, col := range columns {
+		if col.Name == name {
+			return col.NotNull
+		}
+	}
+	return false
+}
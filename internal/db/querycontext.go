@@ -0,0 +1,189 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// maxContextTables bounds the number of tables matched by a keyword
+	maxContextTables = 20
+	// maxEnumLikeValues is the number of distinct values above which a column
+	// is not considered enum-like
+	maxEnumLikeValues = 20
+	// maxSampleValueLength skips sample values that are too long to be useful
+	maxSampleValueLength = 64
+)
+
+// TableContext is a compact description of a table for query writing
+type TableContext struct {
+	Name        string              `json:"name"`
+	RowEstimate int64               `json:"rows"`
+	Columns     []string            `json:"columns"`
+	Values      map[string][]string `json:"values,omitempty"`
+}
+
+// QueryContext bundles everything needed to write queries against a set of tables
+type QueryContext struct {
+	Tables        []TableContext `json:"tables"`
+	Relationships []string       `json:"relationships"`
+}
+
+// FindTables returns the tables in the public schema whose name or column
+// names contain the keyword
+func (d *DB) FindTables(keyword string) ([]string, error) {
+	var tableNames []string
+	query := `SELECT DISTINCT table_name FROM information_schema.columns
+		WHERE table_schema = 'public' AND (table_name ILIKE $1 OR column_name ILIKE $1)
+		ORDER BY table_name
+		LIMIT $2`
+	pattern := "%" + escapeLike(keyword) + "%"
+	if err := d.conn.Select(&tableNames, query, pattern, maxContextTables); err != nil {
+		return nil, fmt.Errorf("failed to find tables: %w", err)
+	}
+	return tableNames, nil
+}
+
+// GetQueryContext returns compacted schema, relationships, enum-like sample
+// values and row estimates for the given tables in the public schema
+func (d *DB) GetQueryContext(tableNames []string) (*QueryContext, error) {
+	result := &QueryContext{
+		Tables:        []TableContext{},
+		Relationships: []string{},
+	}
+
+	var columns []struct {
+		TableName  string `db:"table_name"`
+		ColumnName string `db:"column_name"`
+		DataType   string `db:"data_type"`
+		NotNull    bool   `db:"not_null"`
+		PrimaryKey bool   `db:"primary_key"`
+		RowCount   int64  `db:"row_estimate"`
+	}
+	query := `SELECT c.relname AS table_name, a.attname AS column_name,
+		format_type(a.atttypid, a.atttypmod) AS data_type,
+		a.attnotnull AS not_null,
+		EXISTS (SELECT 1 FROM pg_catalog.pg_index i
+			WHERE i.indrelid = c.oid AND i.indisprimary AND a.attnum = ANY(i.indkey)) AS primary_key,
+		GREATEST(c.reltuples, 0)::bigint AS row_estimate
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = 'public' AND c.relname = ANY($1)
+		AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY c.relname, a.attnum`
+	if err := d.conn.Select(&columns, query, pq.Array(tableNames)); err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+
+	tables := map[string]*TableContext{}
+	var order []string
+	for _, col := range columns {
+		table, ok := tables[col.TableName]
+		if !ok {
+			table = &TableContext{Name: col.TableName, RowEstimate: col.RowCount}
+			tables[col.TableName] = table
+			order = append(order, col.TableName)
+		}
+		def := col.ColumnName + " " + col.DataType
+		if col.PrimaryKey {
+			def += " pk"
+		} else if col.NotNull {
+			def += " not null"
+		}
+		table.Columns = append(table.Columns, def)
+	}
+
+	// Enum-like values come from enum types and from low-cardinality columns
+	// in the planner statistics
+	var values []struct {
+		TableName  string         `db:"table_name"`
+		ColumnName string         `db:"column_name"`
+		Values     pq.StringArray `db:"vals"`
+	}
+	query = `SELECT c.relname AS table_name, a.attname AS column_name,
+		array_agg(e.enumlabel::text ORDER BY e.enumsortorder) AS vals
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_enum e ON e.enumtypid = a.atttypid
+		WHERE n.nspname = 'public' AND c.relname = ANY($1)
+		AND a.attnum > 0 AND NOT a.attisdropped
+		GROUP BY c.relname, a.attname
+		UNION ALL
+		SELECT s.tablename::text, s.attname::text, s.most_common_vals::text::text[]
+		FROM pg_catalog.pg_stats s
+		WHERE s.schemaname = 'public' AND s.tablename = ANY($1)
+		AND s.n_distinct > 0 AND s.n_distinct <= $2
+		AND s.most_common_vals IS NOT NULL`
+	if err := d.conn.Select(&values, query, pq.Array(tableNames), maxEnumLikeValues); err != nil {
+		return nil, fmt.Errorf("failed to get sample values: %w", err)
+	}
+	for _, v := range values {
+		table, ok := tables[v.TableName]
+		if !ok {
+			continue
+		}
+		if table.Values == nil {
+			table.Values = map[string][]string{}
+		}
+		if _, exists := table.Values[v.ColumnName]; exists {
+			continue
+		}
+		samples := []string{}
+		for _, s := range v.Values {
+			if len(s) <= maxSampleValueLength {
+				samples = append(samples, s)
+			}
+		}
+		if d.anonymizer != nil {
+			if kind, ok := d.anonymizer.Kind(v.ColumnName); ok {
+				for i, s := range samples {
+					samples[i] = d.anonymizer.Replace(kind, s)
+				}
+			}
+		}
+		if len(samples) > 0 {
+			table.Values[v.ColumnName] = samples
+		}
+	}
+
+	var relationships []struct {
+		TableName     string `db:"table_name"`
+		ColumnName    string `db:"column_name"`
+		ForeignTable  string `db:"foreign_table"`
+		ForeignColumn string `db:"foreign_column"`
+	}
+	query = `SELECT cl.relname AS table_name, a.attname AS column_name,
+		fcl.relname AS foreign_table, fa.attname AS foreign_column
+		FROM pg_catalog.pg_constraint con
+		JOIN pg_catalog.pg_class cl ON cl.oid = con.conrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = cl.relnamespace
+		JOIN pg_catalog.pg_class fcl ON fcl.oid = con.confrelid
+		CROSS JOIN LATERAL unnest(con.conkey, con.confkey) AS k(attnum, fattnum)
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+		JOIN pg_catalog.pg_attribute fa ON fa.attrelid = con.confrelid AND fa.attnum = k.fattnum
+		WHERE con.contype = 'f' AND n.nspname = 'public'
+		AND (cl.relname = ANY($1) OR fcl.relname = ANY($1))
+		ORDER BY cl.relname, a.attname`
+	if err := d.conn.Select(&relationships, query, pq.Array(tableNames)); err != nil {
+		return nil, fmt.Errorf("failed to get relationships: %w", err)
+	}
+	for _, r := range relationships {
+		result.Relationships = append(result.Relationships,
+			fmt.Sprintf("%s.%s -> %s.%s", r.TableName, r.ColumnName, r.ForeignTable, r.ForeignColumn))
+	}
+
+	for _, name := range order {
+		result.Tables = append(result.Tables, *tables[name])
+	}
+
+	return result, nil
+}
+
+// escapeLike escapes the LIKE wildcard characters in s
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
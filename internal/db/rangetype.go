@@ -0,0 +1,88 @@
+package db
+
+import (
+	"strconv"
+	"strings"
+)
+
+// rangeElementTypes maps the built-in range types to their element type
+var rangeElementTypes = map[string]string{
+	"INT4RANGE": "INT4",
+	"INT8RANGE": "INT8",
+	"NUMRANGE":  "NUMERIC",
+	"DATERANGE": "DATE",
+	"TSRANGE":   "TIMESTAMP",
+	"TSTZRANGE": "TIMESTAMPTZ",
+}
+
+// encodeRange converts the text representation of a range, e.g. [1,10) or
+// ["2024-01-01 00:00:00+00",), into {"lower", "upper", "bounds"}. Unbounded
+// ends are null; the empty range is {"empty": true}. Ranges that cannot be
+// parsed are returned unchanged as a string.
+func (o *encodeOptions) encodeRange(elemType, s string) interface{} {
+	if s == "empty" {
+		return map[string]interface{}{"empty": true}
+	}
+	if len(s) < 3 || !strings.ContainsAny(s[:1], "[(") || !strings.ContainsAny(s[len(s)-1:], "])") {
+		return s
+	}
+
+	lower, rest, ok := parseRangeBound(s[1:], ',')
+	if !ok {
+		return s
+	}
+	upper, rest, ok := parseRangeBound(rest, s[len(s)-1])
+	if !ok || rest != "" {
+		return s
+	}
+	return map[string]interface{}{
+		"lower":  o.encodeRangeBound(elemType, lower),
+		"upper":  o.encodeRangeBound(elemType, upper),
+		"bounds": s[:1] + s[len(s)-1:],
+	}
+}
+
+// parseRangeBound parses a possibly quoted bound up to the terminator and
+// returns it with the text after the terminator. An unbounded end is nil.
+func parseRangeBound(s string, terminator byte) (*string, string, bool) {
+	var b strings.Builder
+	quoted, inQuotes := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			i++
+			b.WriteByte(s[i])
+		case c == '"' && inQuotes && i+1 < len(s) && s[i+1] == '"':
+			i++
+			b.WriteByte('"')
+		case c == '"':
+			quoted, inQuotes = true, !inQuotes
+		case c == terminator && !inQuotes:
+			if b.Len() == 0 && !quoted {
+				return nil, s[i+1:], true
+			}
+			bound := b.String()
+			return &bound, s[i+1:], true
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return nil, "", false
+}
+
+// encodeRangeBound converts a range bound according to the element type
+func (o *encodeOptions) encodeRangeBound(elemType string, bound *string) interface{} {
+	if bound == nil {
+		return nil
+	}
+	switch elemType {
+	case "INT4", "INT8":
+		if i, err := strconv.ParseInt(*bound, 10, 64); err == nil {
+			return i
+		}
+	case "NUMERIC":
+		return o.encodeNumeric(*bound)
+	}
+	return *bound
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// ReferencedTables returns the "schema.table" names of the relations a query
+// reads, taken from the plans of its statements. Views are expanded to their
+// base tables. Queries with bind parameters are planned generically, which
+// needs PostgreSQL 16 or later.
+func (d *DB) ReferencedTables(ctx context.Context, query string) ([]string, error) {
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, "SET TRANSACTION READ ONLY"); err != nil {
+		return nil, fmt.Errorf("failed to set transaction to read-only: %w", err)
+	}
+
+	seen := map[string]bool{}
+	tables := []string{}
+	for _, stmt := range SplitStatements(query) {
+		root, err := d.explainStatement(ctx, tx, "VERBOSE", stmt)
+		if err != nil {
+			return nil, err
+		}
+		var nodes []PlanNode
+		flattenPlan(root, "0", &nodes)
+		for _, node := range nodes {
+			if node.Relation != "" && !seen[node.Relation] {
+				seen[node.Relation] = true
+				tables = append(tables, node.Relation)
+			}
+		}
+	}
+	sort.Strings(tables)
+	return tables, nil
+}
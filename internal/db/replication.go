@@ -0,0 +1,117 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// Replica is a standby connected to this server
+type Replica struct {
+	PID              int      `db:"pid" json:"pid"`
+	User             string   `db:"usename" json:"user"`
+	ApplicationName  string   `db:"application_name" json:"application_name"`
+	ClientAddr       *string  `db:"client_addr" json:"client_addr"`
+	State            string   `db:"state" json:"state"`
+	SyncState        string   `db:"sync_state" json:"sync_state"`
+	SentLSN          *string  `db:"sent_lsn" json:"sent_lsn"`
+	ReplayLSN        *string  `db:"replay_lsn" json:"replay_lsn"`
+	LagBytes         *int64   `db:"lag_bytes" json:"lag_bytes"`
+	WriteLagSeconds  *float64 `db:"write_lag_seconds" json:"write_lag_seconds"`
+	FlushLagSeconds  *float64 `db:"flush_lag_seconds" json:"flush_lag_seconds"`
+	ReplayLagSeconds *float64 `db:"replay_lag_seconds" json:"replay_lag_seconds"`
+}
+
+// WALReceiver is the connection of this standby to its upstream server
+type WALReceiver struct {
+	Status             string     `db:"status" json:"status"`
+	SenderHost         *string    `db:"sender_host" json:"sender_host"`
+	SenderPort         *int       `db:"sender_port" json:"sender_port"`
+	SlotName           *string    `db:"slot_name" json:"slot_name"`
+	ReceivedLSN        *string    `db:"received_lsn" json:"received_lsn"`
+	ReplayLSN          *string    `db:"replay_lsn" json:"replay_lsn"`
+	LagBytes           *int64     `db:"lag_bytes" json:"lag_bytes"`
+	ReplayLagSeconds   *float64   `db:"replay_lag_seconds" json:"replay_lag_seconds"`
+	LastMessageReceipt *time.Time `db:"last_msg_receipt_time" json:"last_message_receipt"`
+}
+
+// ReplicationSlot is a replication slot with the WAL it retains
+type ReplicationSlot struct {
+	Name          string  `db:"slot_name" json:"name"`
+	Type          string  `db:"slot_type" json:"type"`
+	Plugin        *string `db:"plugin" json:"plugin"`
+	Database      *string `db:"database" json:"database"`
+	Active        bool    `db:"active" json:"active"`
+	WALStatus     *string `db:"wal_status" json:"wal_status"`
+	RetainedBytes *int64  `db:"retained_bytes" json:"retained_bytes"`
+	SafeWALBytes  *int64  `db:"safe_wal_size" json:"safe_wal_bytes"`
+	// AtRisk is set for inactive slots holding back WAL, which grows until the disk fills
+	AtRisk bool `db:"at_risk" json:"at_risk"`
+}
+
+// ReplicationStatus is the replication state of the server
+type ReplicationStatus struct {
+	InRecovery  bool              `json:"in_recovery"`
+	Replicas    []Replica         `json:"replicas"`
+	WALReceiver *WALReceiver      `json:"wal_receiver,omitempty"`
+	Slots       []ReplicationSlot `json:"slots"`
+}
+
+// GetReplicationStatus returns the connected replicas with their lag, the WAL
+// receiver when the server is a standby and the replication slots with the
+// WAL they retain. Requires PostgreSQL 13 or later.
+func (d *DB) GetReplicationStatus() (*ReplicationStatus, error) {
+	status := &ReplicationStatus{
+		Replicas: []Replica{},
+		Slots:    []ReplicationSlot{},
+	}
+	if err := d.conn.Get(&status.InRecovery, "SELECT pg_is_in_recovery()"); err != nil {
+		return nil, fmt.Errorf("failed to get recovery state: %w", err)
+	}
+
+	// The current WAL position is only known on a primary, a standby uses its replay position
+	currentLSN := "pg_current_wal_lsn()"
+	if status.InRecovery {
+		currentLSN = "pg_last_wal_replay_lsn()"
+	}
+
+	query := fmt.Sprintf(`SELECT pid, usename, application_name, client_addr::text AS client_addr,
+		state, sync_state, sent_lsn::text AS sent_lsn, replay_lsn::text AS replay_lsn,
+		pg_wal_lsn_diff(%s, replay_lsn)::bigint AS lag_bytes,
+		EXTRACT(EPOCH FROM write_lag)::float8 AS write_lag_seconds,
+		EXTRACT(EPOCH FROM flush_lag)::float8 AS flush_lag_seconds,
+		EXTRACT(EPOCH FROM replay_lag)::float8 AS replay_lag_seconds
+		FROM pg_catalog.pg_stat_replication
+		ORDER BY application_name, pid`, currentLSN)
+	if err := d.conn.Select(&status.Replicas, query); err != nil {
+		return nil, fmt.Errorf("failed to get replicas: %w", err)
+	}
+
+	if status.InRecovery {
+		var receivers []WALReceiver
+		query := `SELECT status, sender_host, sender_port, slot_name,
+			latest_end_lsn::text AS received_lsn,
+			pg_last_wal_replay_lsn()::text AS replay_lsn,
+			pg_wal_lsn_diff(latest_end_lsn, pg_last_wal_replay_lsn())::bigint AS lag_bytes,
+			EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())::float8 AS replay_lag_seconds,
+			last_msg_receipt_time
+			FROM pg_catalog.pg_stat_wal_receiver`
+		if err := d.conn.Select(&receivers, query); err != nil {
+			return nil, fmt.Errorf("failed to get WAL receiver: %w", err)
+		}
+		if len(receivers) > 0 {
+			status.WALReceiver = &receivers[0]
+		}
+	}
+
+	query = fmt.Sprintf(`SELECT slot_name, slot_type, plugin, database, active, wal_status,
+		pg_wal_lsn_diff(%s, restart_lsn)::bigint AS retained_bytes,
+		safe_wal_size,
+		NOT active AND restart_lsn IS NOT NULL AS at_risk
+		FROM pg_catalog.pg_replication_slots
+		ORDER BY slot_name`, currentLSN)
+	if err := d.conn.Select(&status.Slots, query); err != nil {
+		return nil, fmt.Errorf("failed to get replication slots: %w", err)
+	}
+
+	return status, nil
+}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// TableCandidate is a table, view or foreign table with a given name
+type TableCandidate struct {
+	Schema string `db:"schema_name" json:"schema"`
+	Name   string `db:"table_name" json:"name"`
+	Kind   string `db:"kind" json:"kind"`
+	// SearchPathPosition is the position of the schema in the effective
+	// search_path, starting at 1, and 0 when it is not on it
+	SearchPathPosition int `db:"position" json:"search_path_position"`
+}
+
+// TableResolution is what a table name refers to under the effective
+// search_path of the connection
+type TableResolution struct {
+	Name string `json:"name"`
+	// SearchPath are the schemas searched in order, including the implicit
+	// pg_catalog and the session's temporary schema
+	SearchPath []string `json:"search_path"`
+	// Resolved is the table the name refers to, nil when there is none
+	Resolved *TableCandidate `json:"resolved"`
+	// Candidates are all tables of that name, the ones on the search_path
+	// first
+	Candidates []TableCandidate `json:"candidates"`
+	// Ambiguous is set when several schemas have a table of that name
+	Ambiguous bool     `json:"ambiguous"`
+	Warnings  []string `json:"warnings"`
+}
+
+// ResolveTable resolves a table name like PostgreSQL does: a qualified name
+// refers to its schema, an unqualified one to the first schema of the
+// search_path holding such a table. Tables of the same name elsewhere are
+// reported, as an unqualified name silently misses them.
+func (d *DB) ResolveTable(ctx context.Context, name string) (*TableResolution, error) {
+	var parts pq.StringArray
+	if err := d.conn.GetContext(ctx, &parts, "SELECT parse_ident($1)", name); err != nil {
+		return nil, fmt.Errorf("invalid table name %q: %w", name, err)
+	}
+	var schema, table string
+	switch len(parts) {
+	case 1:
+		table = parts[0]
+	case 2:
+		schema, table = parts[0], parts[1]
+	default:
+		return nil, fmt.Errorf("invalid table name %q, use table or schema.table", name)
+	}
+
+	res := &TableResolution{Name: name, Candidates: []TableCandidate{}, Warnings: []string{}}
+	var path pq.StringArray
+	if err := d.conn.GetContext(ctx, &path, "SELECT current_schemas(true)::text[]"); err != nil {
+		return nil, fmt.Errorf("failed to get the search_path: %w", err)
+	}
+	res.SearchPath = path
+
+	// Other sessions' temporary schemas are left out
+	query := `SELECT n.nspname AS schema_name, c.relname AS table_name,
+		CASE c.relkind WHEN 'v' THEN 'view' WHEN 'm' THEN 'materialized view'
+			WHEN 'f' THEN 'foreign table' WHEN 'p' THEN 'partitioned table' ELSE 'table' END AS kind,
+		COALESCE(p.position, 0) AS position
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN unnest($2::text[]) WITH ORDINALITY AS p(nspname, position) ON p.nspname = n.nspname
+		WHERE c.relname = $1 AND c.relkind IN ('r', 'p', 'v', 'm', 'f')
+		AND (p.position IS NOT NULL OR n.nspname NOT LIKE 'pg\_temp\_%')
+		ORDER BY p.position NULLS LAST, n.nspname`
+	if err := d.conn.SelectContext(ctx, &res.Candidates, query, table, path); err != nil {
+		return nil, fmt.Errorf("failed to look up table %s: %w", name, err)
+	}
+	res.Ambiguous = len(res.Candidates) > 1
+
+	for i, c := range res.Candidates {
+		if (schema != "" && c.Schema == schema) || (schema == "" && c.SearchPathPosition > 0) {
+			res.Resolved = &res.Candidates[i]
+			break
+		}
+	}
+
+	var others []string
+	for _, c := range res.Candidates {
+		if res.Resolved == nil || c.Schema != res.Resolved.Schema {
+			others = append(others, c.Schema+"."+c.Name)
+		}
+	}
+	switch {
+	case res.Resolved == nil && len(others) > 0 && schema == "":
+		res.Warnings = append(res.Warnings, fmt.Sprintf("%s is not on the search_path, qualify it as %s", table, strings.Join(others, " or ")))
+	case res.Resolved == nil && len(others) > 0:
+		res.Warnings = append(res.Warnings, fmt.Sprintf("%s.%s does not exist, but %s does", schema, table, strings.Join(others, " and ")))
+	case res.Resolved != nil && len(others) > 0 && schema == "":
+		res.Warnings = append(res.Warnings, fmt.Sprintf("%s resolves to %s.%s, not to %s; qualify the name to use another one", table, res.Resolved.Schema, res.Resolved.Name, strings.Join(others, ", ")))
+	}
+	return res, nil
+}
+
+// searchPathSchema returns the schema an unqualified table name resolves to
+// under the search_path
+func (d *DB) searchPathSchema(table string) (string, error) {
+	var schema string
+	query := `SELECT n.nspname FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.oid = to_regclass(quote_ident($1))`
+	if err := d.conn.Get(&schema, query, table); err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("table %s does not exist on the search_path", table)
+		}
+		return "", fmt.Errorf("failed to resolve table %s: %w", table, err)
+	}
+	return schema, nil
+}
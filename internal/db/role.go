@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ReadOnlyRole describes the least-privilege role created by CreateReadOnlyRole
+type ReadOnlyRole struct {
+	Name     string
+	Password string
+	// Schemas are the schemas whose tables the role can read
+	Schemas []string
+}
+
+// CreateReadOnlyRole creates a login role that can only read the tables of
+// the given schemas of the current database, or updates the password and
+// grants of an existing role. An existing role loses its superuser, create,
+// replication and bypass RLS attributes; one that is a member of other roles
+// or can write to tables is refused. Transactions of the role default to read-only
+// and the tables created later by the connected user are readable too.
+func (d *DB) CreateReadOnlyRole(ctx context.Context, role ReadOnlyRole) error {
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var existing struct {
+		Super       bool `db:"rolsuper"`
+		CreateDB    bool `db:"rolcreatedb"`
+		CreateRole  bool `db:"rolcreaterole"`
+		Replication bool `db:"rolreplication"`
+		BypassRLS   bool `db:"rolbypassrls"`
+	}
+	exists := true
+	query := `SELECT rolsuper, rolcreatedb, rolcreaterole, rolreplication, rolbypassrls
+		FROM pg_catalog.pg_roles WHERE rolname = $1`
+	if err := tx.GetContext(ctx, &existing, query, role.Name); errors.Is(err, sql.ErrNoRows) {
+		exists = false
+	} else if err != nil {
+		return fmt.Errorf("failed to look up role %s: %w", role.Name, err)
+	}
+	if exists {
+		if err := checkReusableRole(ctx, tx, role.Name); err != nil {
+			return err
+		}
+	}
+	var database string
+	if err := tx.GetContext(ctx, &database, "SELECT current_database()"); err != nil {
+		return fmt.Errorf("failed to get the database name: %w", err)
+	}
+
+	// Utility statements do not take bind parameters, the names and the
+	// password are quoted instead
+	name := quoteIdent(role.Name)
+	statements := []string{}
+	if exists {
+		// Only the attributes the role has are revoked, changing the others
+		// needs privileges the admin may lack
+		alter := fmt.Sprintf("ALTER ROLE %s LOGIN", name)
+		for _, attribute := range []struct {
+			set     bool
+			keyword string
+		}{
+			{existing.Super, "NOSUPERUSER"},
+			{existing.CreateDB, "NOCREATEDB"},
+			{existing.CreateRole, "NOCREATEROLE"},
+			{existing.Replication, "NOREPLICATION"},
+			{existing.BypassRLS, "NOBYPASSRLS"},
+		} {
+			if attribute.set {
+				alter += " " + attribute.keyword
+			}
+		}
+		statements = append(statements, alter+" PASSWORD "+quoteLiteral(role.Password))
+	} else {
+		statements = append(statements, fmt.Sprintf("CREATE ROLE %s LOGIN NOSUPERUSER NOCREATEDB NOCREATEROLE PASSWORD %s", name, quoteLiteral(role.Password)))
+	}
+	statements = append(statements,
+		fmt.Sprintf("ALTER ROLE %s SET default_transaction_read_only = on", name),
+		fmt.Sprintf("GRANT CONNECT ON DATABASE %s TO %s", quoteIdent(database), name),
+	)
+	for _, schema := range role.Schemas {
+		schema = quoteIdent(schema)
+		statements = append(statements,
+			fmt.Sprintf("GRANT USAGE ON SCHEMA %s TO %s", schema, name),
+			fmt.Sprintf("GRANT SELECT ON ALL TABLES IN SCHEMA %s TO %s", schema, name),
+			fmt.Sprintf("GRANT SELECT ON ALL SEQUENCES IN SCHEMA %s TO %s", schema, name),
+			fmt.Sprintf("ALTER DEFAULT PRIVILEGES IN SCHEMA %s GRANT SELECT ON TABLES TO %s", schema, name),
+		)
+	}
+
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to set up role %s: %w", role.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// readOnlyMemberships are the predefined roles a read-only role may be a
+// member of
+var readOnlyMemberships = []string{"pg_monitor", "pg_read_all_data", "pg_read_all_settings", "pg_read_all_stats", "pg_stat_scan_tables"}
+
+// checkReusableRole refuses an existing role that keeps write access after
+// its attributes are revoked: a member of other roles can switch to them with
+// SET ROLE, and granted or owned tables stay writable.
+func checkReusableRole(ctx context.Context, tx *sqlx.Tx, name string) error {
+	var memberships []string
+	query := `SELECT g.rolname FROM pg_catalog.pg_auth_members m
+		JOIN pg_catalog.pg_roles g ON g.oid = m.roleid
+		JOIN pg_catalog.pg_roles r ON r.oid = m.member
+		WHERE r.rolname = $1 AND g.rolname <> ALL ($2)
+		ORDER BY g.rolname`
+	if err := tx.SelectContext(ctx, &memberships, query, name, pq.Array(readOnlyMemberships)); err != nil {
+		return fmt.Errorf("failed to look up the memberships of role %s: %w", name, err)
+	}
+	if len(memberships) > 0 {
+		return fmt.Errorf("role %s is a member of %s and is not reused as a read-only role, revoke the memberships or choose another name", name, strings.Join(memberships, ", "))
+	}
+
+	var writable []string
+	query = `SELECT n.nspname || '.' || c.relname FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relkind IN ('r', 'p', 'f')
+			AND n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname NOT LIKE 'pg_toast%'
+			AND has_table_privilege($1, c.oid, 'INSERT, UPDATE, DELETE, TRUNCATE')
+		ORDER BY 1 LIMIT 5`
+	if err := tx.SelectContext(ctx, &writable, query, name); err != nil {
+		return fmt.Errorf("failed to look up the privileges of role %s: %w", name, err)
+	}
+	if len(writable) > 0 {
+		return fmt.Errorf("role %s can write to %s and is not reused as a read-only role, revoke its write privileges and ownership or choose another name", name, strings.Join(writable, ", "))
+	}
+	return nil
+}
+
+// ConnStringForRole returns a postgres:// URL connecting to the same database
+// as connString with the given user and password. The other connection
+// parameters, such as sslmode, are kept.
+func ConnStringForRole(connString, user, password string) (string, error) {
+	params, err := parseConnString(normalizeConnString(connString))
+	if err != nil {
+		return "", err
+	}
+	// Keep the database name, which defaults to the previous user name
+	params["dbname"] = params.databaseName()
+	params["user"] = user
+	params["password"] = password
+	return params.url(), nil
+}
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Role is a database role with its attributes and memberships
+type Role struct {
+	Name            string         `db:"rolname" json:"name"`
+	Superuser       bool           `db:"rolsuper" json:"superuser"`
+	CreateRole      bool           `db:"rolcreaterole" json:"create_role"`
+	CreateDB        bool           `db:"rolcreatedb" json:"create_db"`
+	CanLogin        bool           `db:"rolcanlogin" json:"can_login"`
+	Replication     bool           `db:"rolreplication" json:"replication"`
+	BypassRLS       bool           `db:"rolbypassrls" json:"bypass_rls"`
+	ConnectionLimit int            `db:"rolconnlimit" json:"connection_limit"`
+	ValidUntil      *time.Time     `db:"rolvaliduntil" json:"valid_until"`
+	MemberOf        pq.StringArray `db:"member_of" json:"member_of"`
+	Members         pq.StringArray `db:"members" json:"members"`
+}
+
+// PrivilegeGrant is a privilege granted on a table or column
+type PrivilegeGrant struct {
+	Schema    string  `db:"schema" json:"schema"`
+	Table     string  `db:"table_name" json:"table"`
+	Column    *string `db:"column_name" json:"column,omitempty"`
+	Grantee   string  `db:"grantee" json:"grantee"`
+	Grantor   string  `db:"grantor" json:"grantor"`
+	Privilege string  `db:"privilege" json:"privilege"`
+	Grantable bool    `db:"grantable" json:"grantable"`
+}
+
+// EffectivePrivileges are the table privileges a login role holds, directly,
+// through role membership or as a superuser
+type EffectivePrivileges struct {
+	Role      string `db:"role" json:"role"`
+	Superuser bool   `db:"superuser" json:"superuser"`
+	Select    bool   `db:"can_select" json:"select"`
+	Insert    bool   `db:"can_insert" json:"insert"`
+	Update    bool   `db:"can_update" json:"update"`
+	Delete    bool   `db:"can_delete" json:"delete"`
+	Truncate  bool   `db:"can_truncate" json:"truncate"`
+}
+
+// PrivilegeReport lists the grants matching a table and/or role. Owner,
+// row level security and effective privileges are reported for a single table.
+type PrivilegeReport struct {
+	Owner            string                `json:"owner,omitempty"`
+	RowLevelSecurity *bool                 `json:"row_level_security,omitempty"`
+	Grants           []PrivilegeGrant      `json:"grants"`
+	Effective        []EffectivePrivileges `json:"effective,omitempty"`
+}
+
+// ListRoles returns the roles with their attributes and memberships. The
+// predefined pg_* roles are skipped unless includeSystem is set.
+func (d *DB) ListRoles(includeSystem bool) ([]Role, error) {
+	roles := []Role{}
+	query := `SELECT r.rolname, r.rolsuper, r.rolcreaterole, r.rolcreatedb, r.rolcanlogin,
+		r.rolreplication, r.rolbypassrls, r.rolconnlimit, r.rolvaliduntil,
+		ARRAY(SELECT g.rolname FROM pg_catalog.pg_auth_members m
+			JOIN pg_catalog.pg_roles g ON g.oid = m.roleid
+			WHERE m.member = r.oid ORDER BY g.rolname) AS member_of,
+		ARRAY(SELECT u.rolname FROM pg_catalog.pg_auth_members m
+			JOIN pg_catalog.pg_roles u ON u.oid = m.member
+			WHERE m.roleid = r.oid ORDER BY u.rolname) AS members
+		FROM pg_catalog.pg_roles r
+		WHERE $1 OR r.rolname !~ '^pg_'
+		ORDER BY r.rolname`
+	if err := d.conn.Select(&roles, query, includeSystem); err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	return roles, nil
+}
+
+// GetPrivileges returns the table and column privileges granted on a table
+// and/or applying to a role, including grants to PUBLIC and to roles the role
+// is a member of. An empty table or role matches all.
+func (d *DB) GetPrivileges(schema, table, role string) (*PrivilegeReport, error) {
+	if schema == "" {
+		schema = "public"
+	}
+	if table == "" {
+		schema = ""
+	}
+	report := &PrivilegeReport{Grants: []PrivilegeGrant{}}
+
+	query := `WITH grants AS (
+			SELECT c.oid AS relid, NULL::text AS column_name, a.*
+			FROM pg_catalog.pg_class c
+			CROSS JOIN LATERAL aclexplode(COALESCE(c.relacl, acldefault('r', c.relowner))) a
+			WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f')
+			UNION ALL
+			SELECT c.oid, att.attname::text, a.*
+			FROM pg_catalog.pg_class c
+			JOIN pg_catalog.pg_attribute att ON att.attrelid = c.oid
+			CROSS JOIN LATERAL aclexplode(att.attacl) a
+			WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f') AND att.attacl IS NOT NULL
+		)
+		SELECT n.nspname AS schema, c.relname AS table_name, g.column_name,
+		CASE WHEN g.grantee = 0 THEN 'PUBLIC' ELSE pg_get_userbyid(g.grantee) END AS grantee,
+		pg_get_userbyid(g.grantor) AS grantor, g.privilege_type AS privilege, g.is_grantable AS grantable
+		FROM grants g
+		JOIN pg_catalog.pg_class c ON c.oid = g.relid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname NOT IN ('pg_catalog', 'information_schema') AND n.nspname !~ '^pg_toast'
+		AND ($1 = '' OR (n.nspname = $1 AND c.relname = $2))
+		AND ($3 = '' OR g.grantee = 0 OR pg_has_role($3::name, g.grantee, 'USAGE'))
+		ORDER BY n.nspname, c.relname, g.column_name NULLS FIRST, grantee, g.privilege_type`
+	if err := d.conn.Select(&report.Grants, query, schema, table, role); err != nil {
+		return nil, fmt.Errorf("failed to get privileges: %w", err)
+	}
+
+	if table == "" {
+		return report, nil
+	}
+
+	var info struct {
+		Owner string `db:"owner"`
+		RLS   bool   `db:"rls"`
+	}
+	query = `SELECT pg_get_userbyid(c.relowner) AS owner, c.relrowsecurity AS rls
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2`
+	if err := d.conn.Get(&info, query, schema, table); err != nil {
+		return nil, fmt.Errorf("failed to get table %s.%s: %w", schema, table, err)
+	}
+	report.Owner = info.Owner
+	report.RowLevelSecurity = &info.RLS
+
+	report.Effective = []EffectivePrivileges{}
+	query = `SELECT r.rolname AS role, r.rolsuper AS superuser,
+		has_table_privilege(r.oid, c.oid, 'SELECT') AS can_select,
+		has_table_privilege(r.oid, c.oid, 'INSERT') AS can_insert,
+		has_table_privilege(r.oid, c.oid, 'UPDATE') AS can_update,
+		has_table_privilege(r.oid, c.oid, 'DELETE') AS can_delete,
+		has_table_privilege(r.oid, c.oid, 'TRUNCATE') AS can_truncate
+		FROM pg_catalog.pg_roles r, pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2
+		AND r.rolcanlogin AND ($3 = '' OR r.rolname = $3)
+		ORDER BY r.rolname`
+	if err := d.conn.Select(&report.Effective, query, schema, table, role); err != nil {
+		return nil, fmt.Errorf("failed to get effective privileges: %w", err)
+	}
+	return report, nil
+}
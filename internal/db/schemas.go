@@ -0,0 +1,72 @@
+package db
+
+import (
+	"fmt"
+	"path"
+)
+
+// SchemaColumn is a column of a table in the bulk schema document
+type SchemaColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable,omitempty"`
+}
+
+// TableSchema is the schema of one table in the bulk schema document
+type TableSchema struct {
+	Schema  string         `json:"schema"`
+	Table   string         `json:"table"`
+	Remote  bool           `json:"remote,omitempty"`
+	Columns []SchemaColumn `json:"columns"`
+}
+
+// GetAllTableSchemas returns the columns of all tables and views in one
+// query. schema limits the result to one schema, all but the system schemas
+// by default; pattern is a glob (e.g. "order_*") matched against the table
+// names.
+func (d *DB) GetAllTableSchemas(schema, pattern string) ([]TableSchema, error) {
+	if pattern != "" {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid table name pattern %q: %w", pattern, err)
+		}
+	}
+
+	var rows []struct {
+		Schema   string `db:"table_schema"`
+		Table    string `db:"table_name"`
+		Remote   bool   `db:"remote"`
+		Column   string `db:"column_name"`
+		DataType string `db:"data_type"`
+		Nullable bool   `db:"nullable"`
+	}
+	query := `SELECT c.table_schema, c.table_name, t.table_type = 'FOREIGN' AS remote,
+			c.column_name, c.data_type, c.is_nullable = 'YES' AS nullable
+		FROM information_schema.columns c
+		JOIN information_schema.tables t USING (table_catalog, table_schema, table_name)
+		WHERE ($1 = '' AND c.table_schema NOT IN ('pg_catalog', 'information_schema')
+				AND c.table_schema NOT LIKE 'pg\_%') OR c.table_schema = $1
+		ORDER BY c.table_schema, c.table_name, c.ordinal_position`
+	if err := d.conn.Select(&rows, query, schema); err != nil {
+		return nil, fmt.Errorf("failed to get table schemas: %w", err)
+	}
+
+	tables := []TableSchema{}
+	for _, row := range rows {
+		if pattern != "" {
+			if ok, _ := path.Match(pattern, row.Table); !ok {
+				continue
+			}
+		}
+		n := len(tables)
+		if n == 0 || tables[n-1].Schema != row.Schema || tables[n-1].Table != row.Table {
+			tables = append(tables, TableSchema{Schema: row.Schema, Table: row.Table, Remote: row.Remote})
+			n++
+		}
+		tables[n-1].Columns = append(tables[n-1].Columns, SchemaColumn{
+			Name:     row.Column,
+			Type:     row.DataType,
+			Nullable: row.Nullable,
+		})
+	}
+	return tables, nil
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ScratchPrefix starts the names of the scratch databases, so they cannot be
+// mistaken for, or replace, other databases of the server
+const ScratchPrefix = "scratch_"
+
+// ScratchDatabaseURL returns the connection string of a scratch database on
+// the same server, with the user, password and other settings of this
+// database
+func (d *DB) ScratchDatabaseURL(name string) (string, error) {
+	conn, err := d.scratchConn(name)
+	if err != nil {
+		return "", err
+	}
+	return conn.url(), nil
+}
+
+// scratchConn returns the connection settings of a scratch database
+func (d *DB) scratchConn(name string) (connParams, error) {
+	if err := checkScratchName(name); err != nil {
+		return nil, err
+	}
+	if d.databaseURL == "" {
+		return nil, fmt.Errorf("scratch databases need the server to be started with a connection string")
+	}
+	conn, err := parseConnString(d.databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	conn["dbname"] = name
+	return conn, nil
+}
+
+// CreateScratchDatabase creates an empty scratch database, or a copy of the
+// template database. PostgreSQL refuses to copy a database other sessions are
+// connected to, which includes this server's own database.
+func (d *DB) CreateScratchDatabase(ctx context.Context, name, template string) error {
+	if err := checkScratchName(name); err != nil {
+		return err
+	}
+	stmt := "CREATE DATABASE " + quoteIdent(name)
+	if template != "" {
+		stmt += " TEMPLATE " + quoteIdent(template)
+	}
+	if _, err := d.conn.ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("failed to create database %s: %w", name, err)
+	}
+	return nil
+}
+
+// DropScratchDatabase drops a scratch database. Connections to it must be
+// closed first.
+func (d *DB) DropScratchDatabase(ctx context.Context, name string) error {
+	if err := checkScratchName(name); err != nil {
+		return err
+	}
+	if _, err := d.conn.ExecContext(ctx, "DROP DATABASE "+quoteIdent(name)); err != nil {
+		return fmt.Errorf("failed to drop database %s: %w", name, err)
+	}
+	return nil
+}
+
+// RestoreDump restores a custom format dump into a scratch database with
+// pg_restore. Ownership and privileges are not restored, the objects belong
+// to this server's user.
+func (d *DB) RestoreDump(ctx context.Context, pgRestore, path, database string) error {
+	if filepath.Ext(path) == ".sql" {
+		return fmt.Errorf("%s is a plain SQL dump, pg_restore only restores custom format dumps", filepath.Base(path))
+	}
+	conn, err := d.scratchConn(database)
+	if err != nil {
+		return err
+	}
+	return runClientProgram(ctx, pgRestore, conn, "--no-owner", "--no-privileges", "--exit-on-error", path)
+}
+
+// checkScratchName returns an error if name is not a scratch database name
+func checkScratchName(name string) error {
+	if !strings.HasPrefix(name, ScratchPrefix) {
+		return fmt.Errorf("%s is not a scratch database, its name must start with %s", name, ScratchPrefix)
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// maxScriptRows is the number of rows returned per statement of a script
+const maxScriptRows = 100
+
+// StatementStatus is the outcome of a statement of a script
+type StatementStatus string
+
+const (
+	StatementOK      StatementStatus = "ok"
+	StatementFailed  StatementStatus = "error"
+	StatementSkipped StatementStatus = "skipped"
+)
+
+// StatementResult is the outcome of a statement of a script. Statements
+// returning rows, such as SELECT or INSERT ... RETURNING, report their first
+// rows; the others the number of rows they affected.
+type StatementResult struct {
+	SQL          string
+	Status       StatementStatus
+	RowsAffected *int64
+	Columns      []ResultColumn
+	Rows         []map[string]interface{}
+	Truncated    bool
+	// Backups are the tables the rows were copied to before the statement
+	// changed them
+	Backups  []string
+	Error    error
+	Duration time.Duration
+}
+
+// ScriptResult is the outcome of a script
+type ScriptResult struct {
+	Statements []StatementResult
+	// Committed is false when the transaction was rolled back, undoing the
+	// statements reported as ok
+	Committed bool
+	Notices   []Notice
+}
+
+// dollarTag matches the opening tag of a dollar-quoted string
+var dollarTag = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+// leadingComments matches the comments and whitespace a statement starts with
+var leadingComments = regexp.MustCompile(`^(\s+|--[^\n]*|(?s:/\*.*?\*/))*`)
+
+// rowStatement matches the statements returning rows
+var rowStatement = regexp.MustCompile(`(?is)^(SELECT|WITH|VALUES|TABLE|SHOW|EXPLAIN|FETCH)\b|\bRETURNING\b`)
+
+// transactionControl matches the statements ending or starting transactions,
+// which would break the atomicity of a script
+var transactionControl = regexp.MustCompile(`(?i)^(BEGIN|START\s+TRANSACTION|COMMIT|END|ABORT|ROLLBACK(\s+(WORK|TRANSACTION))?\s*$|ROLLBACK\s+AND\b|PREPARE\s+TRANSACTION)\b`)
+
+// SplitStatements splits a script into its statements at the semicolons
+// outside of string literals, quoted identifiers, dollar-quoted strings and
+// comments. Empty statements are dropped.
+func SplitStatements(script string) []string {
+	var statements []string
+	start := 0
+	flush := func(end int) {
+		stmt := strings.TrimSpace(script[start:end])
+		if leadingComments.ReplaceAllString(stmt, "") != "" {
+			statements = append(statements, stmt)
+		}
+	}
+
+	masked := maskSQL(script)
+	for i := 0; i < len(masked); i++ {
+		if masked[i] == ';' {
+			flush(i)
+			start = i + 1
+		}
+	}
+	flush(len(script))
+	return statements
+}
+
+// maskSQL returns the SQL with the string literals, quoted identifiers,
+// dollar-quoted strings and comments blanked out, keeping the offsets of
+// the remaining text
+func maskSQL(sql string) string {
+	masked := []byte(sql)
+	skipSQL(sql, func(from, to int, comment bool) {
+		for j := from; j < to; j++ {
+			if masked[j] != '\n' {
+				masked[j] = ' '
+			}
+		}
+	})
+	return string(masked)
+}
+
+// hasComments reports whether the SQL has comments outside of string
+// literals, quoted identifiers and dollar-quoted strings
+func hasComments(sql string) bool {
+	found := false
+	skipSQL(sql, func(from, to int, comment bool) {
+		found = found || comment
+	})
+	return found
+}
+
+// skipSQL calls skip with the ranges of the string literals, quoted
+// identifiers, dollar-quoted strings and comments of the SQL
+func skipSQL(sql string, skip func(from, to int, comment bool)) {
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		start := i
+		comment := false
+		switch {
+		case c == '\'':
+			// E'...' strings escape quotes with backslashes
+			escapes := i > 0 && (sql[i-1] == 'E' || sql[i-1] == 'e') && (i < 2 || !isIdentChar(sql[i-2]))
+			i = skipQuoted(sql, i, '\'', escapes)
+		case c == '"':
+			i = skipQuoted(sql, i, '"', false)
+		case strings.HasPrefix(sql[i:], "--"):
+			comment = true
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end
+			}
+		case strings.HasPrefix(sql[i:], "/*"):
+			comment = true
+			i = skipBlockComment(sql, i)
+		case c == '$' && (i == 0 || !isIdentChar(sql[i-1])):
+			tag := dollarTag.FindString(sql[i:])
+			if tag == "" {
+				i++
+				continue
+			}
+			end := strings.Index(sql[i+len(tag):], tag)
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += len(tag) + end + len(tag)
+			}
+		default:
+			i++
+			continue
+		}
+		skip(start, i, comment)
+	}
+}
+
+// skipQuoted returns the index after the quoted string starting at i, a
+// doubled quote being part of the string
+func skipQuoted(script string, i int, quote byte, escapes bool) int {
+	for i++; i < len(script); i++ {
+		switch {
+		case escapes && script[i] == '\\':
+			i++
+		case script[i] == quote:
+			if i+1 < len(script) && script[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(script)
+}
+
+// skipBlockComment returns the index after the block comment starting at i,
+// block comments nest in PostgreSQL
+func skipBlockComment(script string, i int) int {
+	depth := 0
+	for i < len(script) {
+		switch {
+		case strings.HasPrefix(script[i:], "/*"):
+			depth++
+			i += 2
+		case strings.HasPrefix(script[i:], "*/"):
+			depth--
+			i += 2
+			if depth == 0 {
+				return i
+			}
+		default:
+			i++
+		}
+	}
+	return len(script)
+}
+
+// isIdentChar reports whether c can be part of an unquoted identifier
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// RunScript executes the statements of a script in one read-write
+// transaction. By default the first failing statement rolls the whole script
+// back and the statements after it are skipped. With continueOnError every
+// statement runs in a savepoint, a failing statement only undoes itself and
+// the transaction is committed with the statements that succeeded.
+func (d *DB) RunScript(ctx context.Context, statements []string, continueOnError bool) (*ScriptResult, error) {
+	for _, stmt := range statements {
+		if transactionControl.MatchString(leadingComments.ReplaceAllString(stmt, "")) {
+			return nil, fmt.Errorf("transaction control statements are not allowed in a script, it runs in one transaction: %s", stmt)
+		}
+	}
+
+	// The script runs on a connection of its own to collect its notices
+	conn, err := d.conn.Connx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a connection: %w", err)
+	}
+	defer conn.Close()
+
+	notices := &noticeCollector{}
+	if err := setNoticeHandler(conn, notices.handle); err != nil {
+		return nil, fmt.Errorf("failed to set notice handler: %w", err)
+	}
+	defer setNoticeHandler(conn, nil)
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if timeZone := d.queryTimeZone(ctx); timeZone != "" {
+		if err := setTimeZone(ctx, tx, timeZone); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &ScriptResult{Statements: make([]StatementResult, len(statements))}
+	failed := false
+	for i, stmt := range statements {
+		res := &result.Statements[i]
+		res.SQL = stmt
+		if failed && !continueOnError {
+			res.Status = StatementSkipped
+			continue
+		}
+
+		if continueOnError {
+			if _, err := tx.ExecContext(ctx, "SAVEPOINT script_statement"); err != nil {
+				return nil, fmt.Errorf("failed to create savepoint: %w", err)
+			}
+		}
+
+		start := time.Now()
+		err := d.runStatement(ctx, tx, stmt, res)
+		res.Duration = time.Since(start)
+		if err != nil {
+			res.Status = StatementFailed
+			res.Error = err
+			failed = true
+			if continueOnError {
+				if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT script_statement"); err != nil {
+					return nil, fmt.Errorf("failed to roll back to savepoint: %w", err)
+				}
+			}
+			continue
+		}
+		res.Status = StatementOK
+
+		if continueOnError {
+			if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT script_statement"); err != nil {
+				return nil, fmt.Errorf("failed to release savepoint: %w", err)
+			}
+		}
+	}
+
+	if !failed || continueOnError {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		result.Committed = true
+	}
+	result.Notices = notices.collected()
+	return result, nil
+}
+
+// runStatement executes a statement of a script, reading the first rows of
+// the statements returning rows
+func (d *DB) runStatement(ctx context.Context, tx *sqlx.Tx, stmt string, res *StatementResult) error {
+	if d.backupSchema != "" {
+		backups, err := d.backupBeforeWrite(ctx, tx, stmt)
+		if err != nil {
+			return err
+		}
+		res.Backups = backups
+	}
+
+	if !rowStatement.MatchString(leadingComments.ReplaceAllString(stmt, "")) {
+		r, err := tx.ExecContext(ctx, stmt)
+		if err != nil {
+			return err
+		}
+		if n, err := r.RowsAffected(); err == nil {
+			res.RowsAffected = &n
+		}
+		return nil
+	}
+
+	rows, err := tx.QueryxContext(ctx, stmt)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// One row more than returned tells whether rows were left out
+	read, err := d.readRows(rows, d.queryTimeZone(ctx), maxScriptRows+1)
+	if err != nil {
+		return err
+	}
+	if len(read.Rows) > maxScriptRows {
+		read.Rows = read.Rows[:maxScriptRows]
+		res.Truncated = true
+	}
+	res.Columns = read.Columns
+	res.Rows = read.Rows
+	// The rest of the rows is read for errors raised by later rows
+	for rows.Next() {
+	}
+	return rows.Err()
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"fmt"
+)
+
+// Setting is a server configuration parameter from pg_settings
+type Setting struct {
+	Name           string  `db:"name" json:"name"`
+	Value          string  `db:"setting" json:"value"`
+	Unit           *string `db:"unit" json:"unit"`
+	Category       string  `db:"category" json:"category"`
+	Description    string  `db:"short_desc" json:"description"`
+	Context        string  `db:"context" json:"context"`
+	Source         string  `db:"source" json:"source"`
+	SourceFile     *string `db:"sourcefile" json:"source_file,omitempty"`
+	SourceLine     *int    `db:"sourceline" json:"source_line,omitempty"`
+	BootValue      *string `db:"boot_val" json:"boot_value"`
+	ResetValue     *string `db:"reset_val" json:"reset_value"`
+	PendingRestart bool    `db:"pending_restart" json:"pending_restart"`
+}
+
+// SettingsFilter selects configuration parameters
+type SettingsFilter struct {
+	// Pattern matches parameter names, with % and _ as LIKE wildcards
+	Pattern string
+	// Category matches a prefix of the parameter category
+	Category string
+	// ChangedOnly skips the parameters at their default value
+	ChangedOnly bool
+}
+
+// GetSettings returns the configuration parameters matching the filter.
+// The source file and line are only visible to superusers and members of
+// pg_read_all_settings.
+func (d *DB) GetSettings(filter SettingsFilter) ([]Setting, error) {
+	settings := []Setting{}
+	query := `SELECT name, setting, unit, category, short_desc, context, source,
+		sourcefile, sourceline, boot_val, reset_val, pending_restart
+		FROM pg_catalog.pg_settings
+		WHERE ($1 = '' OR name ILIKE $1)
+		AND ($2 = '' OR category ILIKE $2 || '%')
+		AND (NOT $3 OR source NOT IN ('default', 'override'))
+		ORDER BY category, name`
+	if err := d.conn.Select(&settings, query, filter.Pattern, filter.Category, filter.ChangedOnly); err != nil {
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+	return settings, nil
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"fmt"
+)
+
+// SlotChange is a raw change read from a logical replication slot
+type SlotChange struct {
+	LSN  string `db:"lsn"`
+	XID  string `db:"xid"`
+	Data string `db:"data"`
+}
+
+// EnsureLogicalSlot creates the logical replication slot with the given
+// output plugin unless it already exists
+func (d *DB) EnsureLogicalSlot(slot, plugin string) error {
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM pg_catalog.pg_replication_slots WHERE slot_name = $1)"
+	if err := d.conn.Get(&exists, query, slot); err != nil {
+		return fmt.Errorf("failed to check replication slot %s: %w", slot, err)
+	}
+	if exists {
+		return nil
+	}
+
+	if _, err := d.conn.Exec("SELECT pg_catalog.pg_create_logical_replication_slot($1, $2)", slot, plugin); err != nil {
+		return fmt.Errorf("failed to create replication slot %s: %w", slot, err)
+	}
+	return nil
+}
+
+// ConsumeSlotChanges reads and consumes up to limit changes from a logical
+// replication slot. options are the output plugin options as name/value pairs.
+func (d *DB) ConsumeSlotChanges(slot string, limit int, options ...string) ([]SlotChange, error) {
+	args := []interface{}{slot, limit}
+	placeholders := ""
+	for _, opt := range options {
+		args = append(args, opt)
+		placeholders += fmt.Sprintf(", $%d", len(args))
+	}
+
+	changes := []SlotChange{}
+	query := fmt.Sprintf(`SELECT lsn::text AS lsn, xid::text AS xid, data
+		FROM pg_catalog.pg_logical_slot_get_changes($1, NULL, $2%s)`, placeholders)
+	if err := d.conn.Select(&changes, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to read changes from slot %s: %w", slot, err)
+	}
+	return changes, nil
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"fmt"
+)
+
+// MaxTopQueries is the largest number of queries returned by TopQueries
+const MaxTopQueries = 100
+
+// topQueryOrders maps the orders of TopQueries to their pg_stat_statements
+// columns
+var topQueryOrders = map[string]string{
+	"total_time": "total_exec_time",
+	"mean_time":  "mean_exec_time",
+	"calls":      "calls",
+	"rows":       "rows",
+}
+
+// StatementStats are the statistics of a normalized query from
+// pg_stat_statements
+type StatementStats struct {
+	Query          string  `db:"query" json:"query"`
+	Calls          int64   `db:"calls" json:"calls"`
+	TotalMS        float64 `db:"total_ms" json:"total_ms"`
+	MeanMS         float64 `db:"mean_ms" json:"mean_ms"`
+	MaxMS          float64 `db:"max_ms" json:"max_ms"`
+	Rows           int64   `db:"rows" json:"rows"`
+	SharedBlksHit  int64   `db:"shared_blks_hit" json:"shared_blks_hit"`
+	SharedBlksRead int64   `db:"shared_blks_read" json:"shared_blks_read"`
+	// HitRatio is the share of shared blocks found in the buffer cache
+	HitRatio *float64 `db:"hit_ratio" json:"hit_ratio"`
+}
+
+// TopQueries returns the queries of this database from pg_stat_statements
+// ordered by total_time, mean_time, calls or rows
+func (d *DB) TopQueries(orderBy string, limit int) ([]StatementStats, error) {
+	column, ok := topQueryOrders[orderBy]
+	if !ok {
+		return nil, fmt.Errorf("invalid order %q, use total_time, mean_time, calls or rows", orderBy)
+	}
+	if limit <= 0 || limit > MaxTopQueries {
+		limit = MaxTopQueries
+	}
+
+	stats := []StatementStats{}
+	query := fmt.Sprintf(`SELECT query, calls,
+		total_exec_time AS total_ms, mean_exec_time AS mean_ms, max_exec_time AS max_ms,
+		rows, shared_blks_hit, shared_blks_read,
+		shared_blks_hit::float8 / NULLIF(shared_blks_hit + shared_blks_read, 0) AS hit_ratio
+		FROM pg_stat_statements
+		WHERE dbid = (SELECT oid FROM pg_catalog.pg_database WHERE datname = current_database())
+		ORDER BY %s DESC
+		LIMIT $1`, column)
+	if err := d.conn.Select(&stats, query, limit); err != nil {
+		return nil, fmt.Errorf("failed to read pg_stat_statements: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// TablespaceUsage is the disk usage of a tablespace
+type TablespaceUsage struct {
+	Name      string `db:"name" json:"name"`
+	Location  string `db:"location" json:"location"`
+	SizeBytes int64  `db:"size_bytes" json:"size_bytes"`
+	Size      string `db:"size" json:"size"`
+}
+
+// StorageReport is the disk usage of the database and the server
+type StorageReport struct {
+	Database    string            `json:"database"`
+	SizeBytes   int64             `json:"size_bytes"`
+	Size        string            `json:"size"`
+	Tablespaces []TablespaceUsage `json:"tablespaces"`
+	WALFiles    *int              `json:"wal_files,omitempty"`
+	WALBytes    *int64            `json:"wal_bytes,omitempty"`
+	WALSize     *string           `json:"wal_size,omitempty"`
+	// TempFiles and TempBytes are cumulative since the statistics were reset
+	TempFiles  int64      `json:"temp_files"`
+	TempBytes  int64      `json:"temp_bytes"`
+	TempSize   string     `json:"temp_size"`
+	StatsReset *time.Time `json:"stats_reset"`
+	Notes      []string   `json:"notes,omitempty"`
+}
+
+// DatabaseSize returns the size of the current database in bytes
+func (d *DB) DatabaseSize() (int64, error) {
+	databaseSize, _, err := d.sizeExpressions()
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	if err := d.conn.Get(&size, "SELECT "+databaseSize); err != nil {
+		return 0, fmt.Errorf("failed to get database size: %w", err)
+	}
+	return size, nil
+}
+
+// GetStorageReport returns the database size, the usage of each tablespace,
+// the WAL size and the temporary file usage. The WAL size requires superuser
+// or pg_monitor and is left out otherwise.
+func (d *DB) GetStorageReport() (*StorageReport, error) {
+	report := &StorageReport{Tablespaces: []TablespaceUsage{}}
+
+	databaseSize, _, err := d.sizeExpressions()
+	if err != nil {
+		return nil, err
+	}
+	if databaseSize == clusterDatabaseSize {
+		report.Notes = append(report.Notes, "the database size includes the Citus workers, tablespace, WAL and temporary file usage are of the coordinator")
+	}
+
+	var info struct {
+		Database   string     `db:"database"`
+		SizeBytes  int64      `db:"size_bytes"`
+		Size       string     `db:"size"`
+		TempFiles  int64      `db:"temp_files"`
+		TempBytes  int64      `db:"temp_bytes"`
+		TempSize   string     `db:"temp_size"`
+		StatsReset *time.Time `db:"stats_reset"`
+	}
+	query := `SELECT d.datname AS database,
+		` + databaseSize + ` AS size_bytes,
+		pg_size_pretty(` + databaseSize + `) AS size,
+		s.temp_files, s.temp_bytes, pg_size_pretty(s.temp_bytes) AS temp_size,
+		s.stats_reset
+		FROM pg_catalog.pg_database d
+		JOIN pg_catalog.pg_stat_database s ON s.datid = d.oid
+		WHERE d.datname = current_database()`
+	if err := d.conn.Get(&info, query); err != nil {
+		return nil, fmt.Errorf("failed to get database size: %w", err)
+	}
+	report.Database = info.Database
+	report.SizeBytes = info.SizeBytes
+	report.Size = info.Size
+	report.TempFiles = info.TempFiles
+	report.TempBytes = info.TempBytes
+	report.TempSize = info.TempSize
+	report.StatsReset = info.StatsReset
+
+	query = `SELECT spcname AS name, pg_tablespace_location(oid) AS location,
+		pg_tablespace_size(oid) AS size_bytes,
+		pg_size_pretty(pg_tablespace_size(oid)) AS size
+		FROM pg_catalog.pg_tablespace
+		WHERE has_tablespace_privilege(oid, 'CREATE') OR spcname IN ('pg_default', 'pg_global')
+		ORDER BY spcname`
+	if err := d.conn.Select(&report.Tablespaces, query); err != nil {
+		report.Notes = append(report.Notes, fmt.Sprintf("tablespace usage unavailable: %v", err))
+	}
+
+	var wal struct {
+		Files int    `db:"files"`
+		Bytes int64  `db:"bytes"`
+		Size  string `db:"size"`
+	}
+	query = `SELECT count(*) AS files, COALESCE(sum(size), 0)::bigint AS bytes,
+		pg_size_pretty(COALESCE(sum(size), 0)) AS size
+		FROM pg_ls_waldir()`
+	if err := d.conn.Get(&wal, query); err != nil {
+		report.Notes = append(report.Notes, fmt.Sprintf("WAL size unavailable, it requires superuser or pg_monitor: %v", err))
+	} else {
+		report.WALFiles = &wal.Files
+		report.WALBytes = &wal.Bytes
+		report.WALSize = &wal.Size
+	}
+
+	return report, nil
+}
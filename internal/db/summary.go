@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// ColumnSummary is a column in the schema summary
+type ColumnSummary struct {
+	Name       string
+	Type       string
+	Comment    string
+	NotNull    bool
+	PrimaryKey bool
+	// Default is the default expression, empty when there is none
+	Default string
+	// Identity is ALWAYS or BY DEFAULT for identity columns
+	Identity string
+	// Generated is the expression of a generated column
+	Generated string
+	// References is the table.column referenced by a foreign key on the column
+	References string
+}
+
+// TableSummary is a table in the schema summary
+type TableSummary struct {
+	Schema      string
+	Name        string
+	Kind        string
+	Comment     string
+	RowEstimate int64
+	Columns     []ColumnSummary
+}
+
+// GetSchemaSummary returns the tables, views and their columns with the
+// comments, keys and foreign keys needed to describe the database. schema
+// limits the result to one schema, all but the system schemas by default.
+// Partitions are left out, their parent table describes them.
+func (d *DB) GetSchemaSummary(schema string) ([]TableSummary, error) {
+	version, err := d.serverVersionNum(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	// Generated columns (PostgreSQL 12) keep their expression in pg_attrdef
+	generated := "''"
+	if version >= 120000 {
+		generated = "a.attgenerated"
+	}
+
+	var rows []struct {
+		Schema       string         `db:"schema_name"`
+		Table        string         `db:"table_name"`
+		Kind         string         `db:"kind"`
+		TableComment sql.NullString `db:"table_comment"`
+		RowEstimate  int64          `db:"row_estimate"`
+		Column       string         `db:"column_name"`
+		DataType     string         `db:"data_type"`
+		NotNull      bool           `db:"not_null"`
+		PrimaryKey   bool           `db:"primary_key"`
+		Comment      sql.NullString `db:"column_comment"`
+		Default      sql.NullString `db:"column_default"`
+		Identity     string         `db:"identity"`
+		Generated    sql.NullString `db:"generated_expr"`
+		References   sql.NullString `db:"refs"`
+	}
+	query := fmt.Sprintf(`SELECT n.nspname AS schema_name, c.relname AS table_name,
+		CASE c.relkind WHEN 'v' THEN 'view' WHEN 'm' THEN 'materialized view'
+			WHEN 'f' THEN 'foreign table' ELSE 'table' END AS kind,
+		obj_description(c.oid, 'pg_class') AS table_comment,
+		GREATEST(c.reltuples, 0)::bigint AS row_estimate,
+		a.attname AS column_name, format_type(a.atttypid, a.atttypmod) AS data_type,
+		a.attnotnull AS not_null,
+		EXISTS (SELECT 1 FROM pg_catalog.pg_index i
+			WHERE i.indrelid = c.oid AND i.indisprimary AND a.attnum = ANY(i.indkey)) AS primary_key,
+		col_description(c.oid, a.attnum) AS column_comment,
+		CASE WHEN %[1]s = '' THEN pg_get_expr(ad.adbin, ad.adrelid) END AS column_default,
+		CASE a.attidentity WHEN 'a' THEN 'ALWAYS' WHEN 'd' THEN 'BY DEFAULT' ELSE '' END AS identity,
+		CASE WHEN %[1]s <> '' THEN pg_get_expr(ad.adbin, ad.adrelid) END AS generated_expr,
+		(SELECT fcl.relname || '.' || fa.attname
+			FROM pg_catalog.pg_constraint con
+			CROSS JOIN LATERAL unnest(con.conkey, con.confkey) AS k(attnum, fattnum)
+			JOIN pg_catalog.pg_class fcl ON fcl.oid = con.confrelid
+			JOIN pg_catalog.pg_attribute fa ON fa.attrelid = con.confrelid AND fa.attnum = k.fattnum
+			WHERE con.contype = 'f' AND con.conrelid = c.oid AND k.attnum = a.attnum
+			LIMIT 1) AS refs
+		FROM pg_catalog.pg_class c
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = c.oid
+		LEFT JOIN pg_catalog.pg_attrdef ad ON ad.adrelid = c.oid AND ad.adnum = a.attnum
+		WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f') AND NOT c.relispartition
+		AND a.attnum > 0 AND NOT a.attisdropped
+		AND (($1 = '' AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+				AND n.nspname NOT LIKE 'pg\_%%') OR n.nspname = $1)
+		ORDER BY n.nspname, c.relname, a.attnum`, generated)
+	if err := d.conn.Select(&rows, query, schema); err != nil {
+		return nil, fmt.Errorf("failed to get schema summary: %w", err)
+	}
+
+	tables := []TableSummary{}
+	for _, row := range rows {
+		n := len(tables)
+		if n == 0 || tables[n-1].Schema != row.Schema || tables[n-1].Name != row.Table {
+			tables = append(tables, TableSummary{
+				Schema:      row.Schema,
+				Name:        row.Table,
+				Kind:        row.Kind,
+				Comment:     row.TableComment.String,
+				RowEstimate: row.RowEstimate,
+			})
+			n++
+		}
+		tables[n-1].Columns = append(tables[n-1].Columns, ColumnSummary{
+			Name:       row.Column,
+			Type:       row.DataType,
+			Comment:    row.Comment.String,
+			NotNull:    row.NotNull,
+			PrimaryKey: row.PrimaryKey,
+			Default:    row.Default.String,
+			Identity:   row.Identity,
+			Generated:  row.Generated.String,
+			References: row.References.String,
+		})
+	}
+	return tables, nil
+}
+
+// GetColumnSamples returns up to maxSamples of the most common values of the
+// columns from the planner statistics, keyed by schema.table.column. Long
+// values are left out and columns without statistics are missing; no table
+// is scanned. schema limits the result as in GetSchemaSummary. Values of
+// anonymized columns are replaced like query results.
+func (d *DB) GetColumnSamples(schema string, maxSamples int) (map[string][]string, error) {
+	var rows []struct {
+		Schema string         `db:"schemaname"`
+		Table  string         `db:"tablename"`
+		Column string         `db:"attname"`
+		Values pq.StringArray `db:"vals"`
+	}
+	query := `SELECT s.schemaname, s.tablename, s.attname, s.most_common_vals::text::text[] AS vals
+		FROM pg_catalog.pg_stats s
+		WHERE s.most_common_vals IS NOT NULL
+		AND (($1 = '' AND s.schemaname NOT IN ('pg_catalog', 'information_schema')
+				AND s.schemaname NOT LIKE 'pg\_%') OR s.schemaname = $1)`
+	if err := d.conn.Select(&rows, query, schema); err != nil {
+		return nil, fmt.Errorf("failed to get column samples: %w", err)
+	}
+
+	samples := map[string][]string{}
+	for _, row := range rows {
+		key := row.Schema + "." + row.Table + "." + row.Column
+		for _, v := range row.Values {
+			if len(samples[key]) == maxSamples {
+				break
+			}
+			if len(v) <= maxSampleValueLength {
+				samples[key] = append(samples[key], v)
+			}
+		}
+		if d.anonymizer != nil {
+			if kind, ok := d.anonymizer.Kind(row.Column); ok {
+				for i, v := range samples[key] {
+					samples[key][i] = d.anonymizer.Replace(kind, v)
+				}
+			}
+		}
+	}
+	return samples, nil
+}
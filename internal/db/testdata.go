@@ -0,0 +1,347 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// MaxTestDataRows bounds the number of rows inserted per call
+	MaxTestDataRows = 10000
+	// parentKeySample is the number of parent keys sampled per foreign key
+	parentKeySample = 1000
+	// maxInsertParams stays below the limit of 65535 bind parameters per statement
+	maxInsertParams = 60000
+)
+
+// sampleNames are used for name-like text columns
+var sampleNames = []string{"Alice", "Bob", "Carol", "Dave", "Erin", "Frank", "Grace", "Heidi", "Ivan", "Judy", "Mallory", "Niaj", "Olivia", "Peggy", "Rupert", "Sybil", "Trent", "Victor", "Walter", "Yvonne"}
+
+// sampleWords are used for other text columns
+var sampleWords = []string{"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel", "india", "juliet", "kilo", "lima", "mike", "november", "oscar", "papa"}
+
+// TestDataResult reports the rows generated by GenerateTestData
+type TestDataResult struct {
+	Table     string   `json:"table"`
+	Requested int      `json:"requested"`
+	Inserted  int64    `json:"inserted"`
+	Columns   []string `json:"columns"`
+	// Defaulted columns are left to their default, identity or generated value
+	Defaulted []string `json:"defaulted"`
+}
+
+// testDataColumn is a column of the table to fill
+type testDataColumn struct {
+	Name       string         `db:"attname"`
+	Type       string         `db:"typname"`
+	TypMod     int            `db:"atttypmod"`
+	NotNull    bool           `db:"attnotnull"`
+	HasDefault bool           `db:"has_default"`
+	EnumValues pq.StringArray `db:"enum_values"`
+}
+
+// GenerateTestData inserts rows of synthetic data into a table in a single
+// transaction. Values follow the column types, enum labels and NOT NULL
+// constraints; foreign key columns get keys sampled from the parent table.
+// Columns with defaults, identity and generated columns are left to the
+// database. Rows conflicting with unique constraints are skipped.
+func (d *DB) GenerateTestData(ctx context.Context, schema, table string, rows int) (*TestDataResult, error) {
+	if rows <= 0 || rows > MaxTestDataRows {
+		return nil, fmt.Errorf("rows must be between 1 and %d", MaxTestDataRows)
+	}
+	ref, err := d.lookupTable(schema, table)
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []testDataColumn
+	query := `SELECT a.attname, t.typname, a.atttypmod, a.attnotnull,
+		a.atthasdef OR a.attidentity <> '' OR a.attgenerated <> '' AS has_default,
+		ARRAY(SELECT e.enumlabel::text FROM pg_catalog.pg_enum e
+			WHERE e.enumtypid = t.oid ORDER BY e.enumsortorder) AS enum_values
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_type t ON t.oid = a.atttypid
+		WHERE n.nspname = $1 AND c.relname = $2 AND c.relkind IN ('r', 'p')
+		AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`
+	if err := d.conn.SelectContext(ctx, &columns, query, ref.schema, ref.table); err != nil {
+		return nil, fmt.Errorf("failed to get columns of %s.%s: %w", ref.schema, ref.table, err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%s.%s is not a table", ref.schema, ref.table)
+	}
+
+	parents, err := d.sampleParentKeys(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TestDataResult{
+		Table:     ref.schema + "." + ref.table,
+		Requested: rows,
+		Columns:   []string{},
+		Defaulted: []string{},
+	}
+	var filled []testDataColumn
+	for _, col := range columns {
+		if _, isFK := parents[col.Name]; col.HasDefault && !isFK {
+			result.Defaulted = append(result.Defaulted, col.Name)
+			continue
+		}
+		filled = append(filled, col)
+		result.Columns = append(result.Columns, col.Name)
+	}
+	if len(filled) == 0 {
+		return nil, fmt.Errorf("all columns of %s have defaults, nothing to generate", result.Table)
+	}
+
+	quoted := make([]string, len(filled))
+	for i, col := range filled {
+		quoted[i] = quoteIdent(col.Name)
+	}
+
+	tx, err := d.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	batchSize := maxInsertParams / len(filled)
+	for start := 0; start < rows; start += batchSize {
+		end := min(start+batchSize, rows)
+		var args []interface{}
+		var tuples []string
+		for i := start; i < end; i++ {
+			// Foreign keys spanning several columns take all values from the same parent row
+			picked := map[*parentKeys][]interface{}{}
+			placeholders := make([]string, len(filled))
+			for j, col := range filled {
+				value, err := testValue(col, i, parents, picked)
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, value)
+				placeholders[j] = fmt.Sprintf("$%d", len(args))
+			}
+			tuples = append(tuples, "("+strings.Join(placeholders, ", ")+")")
+		}
+
+		stmt := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s ON CONFLICT DO NOTHING",
+			ref.quoted(), strings.Join(quoted, ", "), strings.Join(tuples, ", "))
+		res, err := tx.ExecContext(ctx, stmt, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert test data into %s: %w", result.Table, err)
+		}
+		affected, _ := res.RowsAffected()
+		result.Inserted += affected
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit test data: %w", err)
+	}
+	return result, nil
+}
+
+// parentKeys are key tuples sampled from the parent table of a foreign key
+type parentKeys struct {
+	columns []string
+	rows    [][]interface{}
+}
+
+// sampleParentKeys samples existing parent keys for each foreign key of the
+// table, mapped by referencing column
+func (d *DB) sampleParentKeys(ctx context.Context, ref *tableRef) (map[string]*parentKeys, error) {
+	var keys []struct {
+		Name           string         `db:"name"`
+		ForeignSchema  string         `db:"foreign_schema"`
+		ForeignTable   string         `db:"foreign_table"`
+		Columns        pq.StringArray `db:"columns"`
+		ForeignColumns pq.StringArray `db:"foreign_columns"`
+	}
+	query := `SELECT con.conname AS name, fn.nspname AS foreign_schema, fcl.relname AS foreign_table,
+		ARRAY(SELECT a.attname FROM unnest(con.conkey) WITH ORDINALITY AS k(attnum, ord)
+			JOIN pg_catalog.pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+			ORDER BY k.ord) AS columns,
+		ARRAY(SELECT a.attname FROM unnest(con.confkey) WITH ORDINALITY AS k(attnum, ord)
+			JOIN pg_catalog.pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum
+			ORDER BY k.ord) AS foreign_columns
+		FROM pg_catalog.pg_constraint con
+		JOIN pg_catalog.pg_class cl ON cl.oid = con.conrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = cl.relnamespace
+		JOIN pg_catalog.pg_class fcl ON fcl.oid = con.confrelid
+		JOIN pg_catalog.pg_namespace fn ON fn.oid = fcl.relnamespace
+		WHERE con.contype = 'f' AND n.nspname = $1 AND cl.relname = $2
+		ORDER BY con.conname`
+	if err := d.conn.SelectContext(ctx, &keys, query, ref.schema, ref.table); err != nil {
+		return nil, fmt.Errorf("failed to get foreign keys: %w", err)
+	}
+
+	parents := map[string]*parentKeys{}
+	for _, fk := range keys {
+		cols := make([]string, len(fk.ForeignColumns))
+		for i, col := range fk.ForeignColumns {
+			cols[i] = quoteIdent(col)
+		}
+		query := fmt.Sprintf("SELECT %s FROM %s ORDER BY random() LIMIT %d",
+			strings.Join(cols, ", "), Identifier{fk.ForeignSchema, fk.ForeignTable}.Sanitize(), parentKeySample)
+		rows, err := d.conn.QueryxContext(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sample keys of %s.%s: %w", fk.ForeignSchema, fk.ForeignTable, err)
+		}
+		keys := &parentKeys{columns: fk.Columns}
+		for rows.Next() {
+			values, err := rows.SliceScan()
+			if err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to sample keys of %s.%s: %w", fk.ForeignSchema, fk.ForeignTable, err)
+			}
+			// Text values are passed back as strings, []byte would be sent as binary
+			for i, v := range values {
+				if b, ok := v.([]byte); ok {
+					values[i] = string(b)
+				}
+			}
+			keys.rows = append(keys.rows, values)
+		}
+		rows.Close()
+		for _, col := range fk.Columns {
+			if _, exists := parents[col]; !exists {
+				parents[col] = keys
+			}
+		}
+	}
+	return parents, nil
+}
+
+// testValue generates the value of a column for the n-th generated row
+func testValue(col testDataColumn, n int, parents map[string]*parentKeys, picked map[*parentKeys][]interface{}) (interface{}, error) {
+	if keys, ok := parents[col.Name]; ok {
+		if len(keys.rows) == 0 {
+			if col.NotNull {
+				return nil, fmt.Errorf("column %s references an empty table, generate its parent rows first", col.Name)
+			}
+			return nil, nil
+		}
+		row, ok := picked[keys]
+		if !ok {
+			row = keys.rows[randInt(len(keys.rows))]
+			picked[keys] = row
+		}
+		for i, name := range keys.columns {
+			if name == col.Name {
+				return row[i], nil
+			}
+		}
+	}
+
+	if len(col.EnumValues) > 0 {
+		return col.EnumValues[randInt(len(col.EnumValues))], nil
+	}
+
+	name := strings.ToLower(col.Name)
+	switch col.Type {
+	case "bool":
+		return randInt(2) == 1, nil
+	case "int2":
+		return 1 + randInt(math.MaxInt16-1), nil
+	case "int4":
+		return 1 + randInt(1000000), nil
+	case "int8":
+		return 1 + randInt(1000000000), nil
+	case "float4", "float8":
+		return float64(randInt(100000)) / 100, nil
+	case "numeric":
+		return testNumeric(col.TypMod), nil
+	case "text", "varchar", "bpchar", "citext", "name":
+		return testText(name, n, col.TypMod), nil
+	case "date":
+		return testTime().Format(time.DateOnly), nil
+	case "timestamp", "timestamptz":
+		return testTime().Format(time.RFC3339), nil
+	case "time", "timetz":
+		return testTime().Format("15:04:05"), nil
+	case "uuid":
+		return testUUID(), nil
+	case "json", "jsonb":
+		return "{}", nil
+	case "inet":
+		return fmt.Sprintf("10.%d.%d.%d", randInt(256), randInt(256), 1+randInt(254)), nil
+	}
+	if !col.NotNull {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("cannot generate values for NOT NULL column %s of type %s", col.Name, col.Type)
+}
+
+// testText returns a plausible value for a text column based on its name
+func testText(name string, n, typMod int) string {
+	suffix := fmt.Sprintf("%04x", randInt(0x10000))
+	var value string
+	switch {
+	case strings.Contains(name, "email"):
+		value = fmt.Sprintf("user%d.%s@example.com", n, suffix)
+	case strings.Contains(name, "name"):
+		value = sampleNames[randInt(len(sampleNames))]
+	case strings.Contains(name, "phone"):
+		value = fmt.Sprintf("+1555%07d", randInt(10000000))
+	case strings.Contains(name, "url"):
+		value = fmt.Sprintf("https://example.com/%s", suffix)
+	default:
+		value = fmt.Sprintf("%s %s", sampleWords[randInt(len(sampleWords))], suffix)
+	}
+	// varchar(n) and char(n) store the length plus 4 in the type modifier
+	if typMod > 4 && len(value) > typMod-4 {
+		value = value[:typMod-4]
+	}
+	return value
+}
+
+// testNumeric returns a value fitting a numeric(precision, scale) column
+func testNumeric(typMod int) string {
+	precision, scale := 8, 2
+	if typMod >= 4 {
+		precision = ((typMod - 4) >> 16) & 0xffff
+		scale = (typMod - 4) & 0xffff
+	}
+	digits := min(precision-scale, 6)
+	whole := 0
+	if digits > 0 {
+		whole = randInt(int(math.Pow10(digits)))
+	}
+	if scale == 0 {
+		return fmt.Sprint(whole)
+	}
+	return fmt.Sprintf("%d.%0*d", whole, scale, randInt(int(math.Pow10(min(scale, 6)))))
+}
+
+// testTime returns a random time within the last two years
+func testTime() time.Time {
+	return time.Now().Add(-time.Duration(randInt(2*365*24*3600)) * time.Second).UTC()
+}
+
+// testUUID returns a random version 4 UUID
+func testUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// randInt returns a random int in [0, n)
+func randInt(n int) int {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0
+	}
+	return int(v.Int64())
+}
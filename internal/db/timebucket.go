@@ -0,0 +1,100 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// bucketIntervalPattern matches the bucket widths accepted by time bucket queries
+var bucketIntervalPattern = regexp.MustCompile(`^(\d+)\s*(second|minute|hour|day|week|month|year)s?$`)
+
+// TimeBucketParams describes a structured time series aggregation on a single table
+type TimeBucketParams struct {
+	Schema     string
+	Table      string
+	TimeColumn string
+	// Interval is the bucket width, e.g. "1 hour" or "15 minutes"
+	Interval    string
+	Aggregation Aggregation
+	Filters     []Filter
+	// From and To bound the time column, inclusive and exclusive, when set
+	From  string
+	To    string
+	Limit int
+}
+
+// BuildTimeBucket compiles a time series aggregation to SQL returning bucket
+// and value columns ordered by bucket. It uses time_bucket when TimescaleDB
+// is installed, date_trunc for single calendar units and date_bin otherwise.
+// When the limit cuts the series the most recent buckets are kept.
+func (d *DB) BuildTimeBucket(params TimeBucketParams) (string, []interface{}, error) {
+	m := bucketIntervalPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(params.Interval)))
+	if m == nil {
+		return "", nil, fmt.Errorf("invalid interval %q, expected e.g. \"1 hour\" or \"15 minutes\"", params.Interval)
+	}
+	count, err := strconv.Atoi(m[1])
+	if err != nil || count < 1 {
+		return "", nil, fmt.Errorf("invalid interval %q, the count must be positive", params.Interval)
+	}
+	unit := m[2]
+
+	ref, err := d.lookupTable(params.Schema, params.Table)
+	if err != nil {
+		return "", nil, err
+	}
+	timeCol, err := ref.column(params.TimeColumn)
+	if err != nil {
+		return "", nil, err
+	}
+	agg := params.Aggregation
+	agg.Alias = "value"
+	value, _, err := aggregateExpr(ref, agg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	timescale, err := d.HasExtension("timescaledb")
+	if err != nil {
+		return "", nil, err
+	}
+
+	var args []interface{}
+	var bucket string
+	switch {
+	case timescale:
+		args = append(args, fmt.Sprintf("%d %s", count, unit))
+		bucket = fmt.Sprintf("time_bucket($%d::interval, %s)", len(args), timeCol)
+	case count == 1:
+		args = append(args, unit)
+		bucket = fmt.Sprintf("date_trunc($%d, %s)", len(args), timeCol)
+	case unit == "month" || unit == "year":
+		return "", nil, fmt.Errorf("intervals of several months or years require TimescaleDB, use 1 %s", unit)
+	default:
+		args = append(args, fmt.Sprintf("%d %s", count, unit))
+		bucket = fmt.Sprintf("date_bin($%d::interval, %s, TIMESTAMP '2000-01-01')", len(args), timeCol)
+	}
+
+	where, err := buildWhere(params.Filters, ref.column, &args)
+	if err != nil {
+		return "", nil, err
+	}
+	conditions := []string{timeCol + " IS NOT NULL"}
+	if where != "" {
+		conditions = append(conditions, where)
+	}
+	if params.From != "" {
+		args = append(args, params.From)
+		conditions = append(conditions, fmt.Sprintf("%s >= $%d", timeCol, len(args)))
+	}
+	if params.To != "" {
+		args = append(args, params.To)
+		conditions = append(conditions, fmt.Sprintf("%s < $%d", timeCol, len(args)))
+	}
+	args = append(args, builderLimit(params.Limit))
+
+	query := fmt.Sprintf("SELECT * FROM (SELECT %s AS bucket, %s AS value FROM %s WHERE %s GROUP BY 1 ORDER BY 1 DESC LIMIT $%d) AS buckets ORDER BY bucket",
+		bucket, value, ref.quoted(), strings.Join(conditions, " AND "), len(args))
+	return query, args, nil
+}
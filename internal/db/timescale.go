@@ -0,0 +1,132 @@
+package db
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxChunks is the number of chunks listed per hypertable
+const maxChunks = 100
+
+// Hypertable is a TimescaleDB hypertable with its partitioning and compression
+type Hypertable struct {
+	Schema             string `db:"schema" json:"schema"`
+	Table              string `db:"table_name" json:"table"`
+	TimeColumn         string `db:"time_column" json:"time_column"`
+	ChunkInterval      string `db:"chunk_interval" json:"chunk_interval"`
+	Chunks             int64  `db:"num_chunks" json:"chunks"`
+	CompressionEnabled bool   `db:"compression_enabled" json:"compression_enabled"`
+	// TotalBytes includes all chunks, their indexes and TOAST
+	TotalBytes int64  `db:"total_bytes" json:"total_bytes"`
+	TotalSize  string `db:"total_size" json:"total_size"`
+	// The compression statistics are only set when compression is enabled
+	CompressedChunks       *int64 `db:"compressed_chunks" json:"compressed_chunks,omitempty"`
+	BytesBeforeCompression *int64 `db:"before_compression_bytes" json:"bytes_before_compression,omitempty"`
+	BytesAfterCompression  *int64 `db:"after_compression_bytes" json:"bytes_after_compression,omitempty"`
+}
+
+// Chunk is a chunk of a hypertable
+type Chunk struct {
+	Schema       string `db:"schema" json:"schema"`
+	Name         string `db:"name" json:"name"`
+	RangeStart   string `db:"range_start" json:"range_start"`
+	RangeEnd     string `db:"range_end" json:"range_end"`
+	IsCompressed bool   `db:"is_compressed" json:"is_compressed"`
+	TotalBytes   int64  `db:"total_bytes" json:"total_bytes"`
+	TotalSize    string `db:"total_size" json:"total_size"`
+}
+
+// ContinuousAggregate is a TimescaleDB continuous aggregate with its refresh policy
+type ContinuousAggregate struct {
+	Schema             string     `db:"schema" json:"schema"`
+	View               string     `db:"view_name" json:"view"`
+	HypertableSchema   string     `db:"hypertable_schema" json:"hypertable_schema"`
+	Hypertable         string     `db:"hypertable_name" json:"hypertable"`
+	MaterializedOnly   bool       `db:"materialized_only" json:"materialized_only"`
+	CompressionEnabled bool       `db:"compression_enabled" json:"compression_enabled"`
+	TotalBytes         int64      `db:"total_bytes" json:"total_bytes"`
+	TotalSize          string     `db:"total_size" json:"total_size"`
+	RefreshInterval    *string    `db:"refresh_interval" json:"refresh_interval"`
+	LastRefresh        *time.Time `db:"last_refresh" json:"last_refresh"`
+	Definition         string     `db:"definition" json:"definition"`
+}
+
+// ListHypertables returns the TimescaleDB hypertables with their time
+// dimension, chunk count, size and compression ratio
+func (d *DB) ListHypertables() ([]Hypertable, error) {
+	tables := []Hypertable{}
+	query := `SELECT h.hypertable_schema AS schema, h.hypertable_name AS table_name,
+		COALESCE(dim.column_name, '') AS time_column,
+		COALESCE(dim.time_interval::text, dim.integer_interval::text, '') AS chunk_interval,
+		h.num_chunks, h.compression_enabled,
+		COALESCE(hypertable_size(format('%I.%I', h.hypertable_schema, h.hypertable_name)::regclass), 0) AS total_bytes,
+		pg_size_pretty(COALESCE(hypertable_size(format('%I.%I', h.hypertable_schema, h.hypertable_name)::regclass), 0)) AS total_size,
+		cs.number_compressed_chunks AS compressed_chunks,
+		cs.before_compression_total_bytes AS before_compression_bytes,
+		cs.after_compression_total_bytes AS after_compression_bytes
+		FROM timescaledb_information.hypertables h
+		LEFT JOIN LATERAL (
+			SELECT d.column_name, d.time_interval, d.integer_interval
+			FROM timescaledb_information.dimensions d
+			WHERE d.hypertable_schema = h.hypertable_schema AND d.hypertable_name = h.hypertable_name
+			ORDER BY d.dimension_number LIMIT 1
+		) dim ON true
+		LEFT JOIN LATERAL hypertable_compression_stats(format('%I.%I', h.hypertable_schema, h.hypertable_name)::regclass) cs
+			ON h.compression_enabled
+		ORDER BY h.hypertable_schema, h.hypertable_name`
+	if err := d.conn.Select(&tables, query); err != nil {
+		return nil, fmt.Errorf("failed to list hypertables: %w", err)
+	}
+	return tables, nil
+}
+
+// ListChunks returns the most recent chunks of a hypertable
+func (d *DB) ListChunks(schema, table string) ([]Chunk, error) {
+	ref, err := d.lookupTable(schema, table)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := []Chunk{}
+	query := `SELECT c.chunk_schema AS schema, c.chunk_name AS name,
+		COALESCE(c.range_start::text, c.range_start_integer::text, '') AS range_start,
+		COALESCE(c.range_end::text, c.range_end_integer::text, '') AS range_end,
+		c.is_compressed,
+		COALESCE(s.total_bytes, 0) AS total_bytes,
+		pg_size_pretty(COALESCE(s.total_bytes, 0)) AS total_size
+		FROM timescaledb_information.chunks c
+		LEFT JOIN chunks_detailed_size($1::regclass) s
+			ON s.chunk_schema = c.chunk_schema AND s.chunk_name = c.chunk_name
+		WHERE c.hypertable_schema = $2 AND c.hypertable_name = $3
+		ORDER BY c.range_start DESC NULLS LAST, c.range_start_integer DESC NULLS LAST
+		LIMIT $4`
+	if err := d.conn.Select(&chunks, query, ref.quoted(), ref.schema, ref.table, maxChunks); err != nil {
+		return nil, fmt.Errorf("failed to list chunks of %s.%s: %w", ref.schema, ref.table, err)
+	}
+	return chunks, nil
+}
+
+// ListContinuousAggregates returns the TimescaleDB continuous aggregates with
+// their size and refresh policy
+func (d *DB) ListContinuousAggregates() ([]ContinuousAggregate, error) {
+	aggregates := []ContinuousAggregate{}
+	query := `SELECT ca.view_schema AS schema, ca.view_name,
+		ca.hypertable_schema, ca.hypertable_name,
+		ca.materialized_only, ca.compression_enabled,
+		COALESCE(hypertable_size(format('%I.%I', ca.materialization_hypertable_schema, ca.materialization_hypertable_name)::regclass), 0) AS total_bytes,
+		pg_size_pretty(COALESCE(hypertable_size(format('%I.%I', ca.materialization_hypertable_schema, ca.materialization_hypertable_name)::regclass), 0)) AS total_size,
+		j.schedule_interval::text AS refresh_interval,
+		js.last_successful_finish AS last_refresh,
+		ca.view_definition AS definition
+		FROM timescaledb_information.continuous_aggregates ca
+		LEFT JOIN timescaledb_information.jobs j
+			ON j.proc_name = 'policy_refresh_continuous_aggregate'
+			AND j.hypertable_schema = ca.materialization_hypertable_schema
+			AND j.hypertable_name = ca.materialization_hypertable_name
+		LEFT JOIN timescaledb_information.job_stats js ON js.job_id = j.job_id
+		ORDER BY ca.view_schema, ca.view_name`
+	if err := d.conn.Select(&aggregates, query); err != nil {
+		return nil, fmt.Errorf("failed to list continuous aggregates: %w", err)
+	}
+	return aggregates, nil
+}
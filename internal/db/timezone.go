@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// timeZoneKey is the context key of the time zone of a query
+type timeZoneKey struct{}
+
+// WithTimeZone sets the session TimeZone of the queries, e.g. UTC or
+// Europe/Berlin. timestamptz values are returned in RFC 3339 format with the
+// offset of that zone, or with a Z suffix for UTC.
+func WithTimeZone(name string) Option {
+	return func(d *DB) {
+		d.timeZone = name
+	}
+}
+
+// ContextWithTimeZone overrides the time zone of the queries run with the
+// returned context
+func ContextWithTimeZone(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, timeZoneKey{}, name)
+}
+
+// queryTimeZone returns the time zone of a query, empty for the server default
+func (d *DB) queryTimeZone(ctx context.Context) string {
+	if name, ok := ctx.Value(timeZoneKey{}).(string); ok && name != "" {
+		return name
+	}
+	return d.timeZone
+}
+
+// setTimeZone sets the TimeZone of the transaction
+func setTimeZone(ctx context.Context, tx *sqlx.Tx, name string) error {
+	if _, err := tx.ExecContext(ctx, "SELECT set_config('TimeZone', $1, true)", name); err != nil {
+		return fmt.Errorf("failed to set time zone %q: %w", name, err)
+	}
+	return nil
+}
+
+// inTimeZone returns a timestamptz value in the time zone of the query; the
+// driver parses it with a fixed offset, which UTC normalizes to a Z suffix
+func inTimeZone(t time.Time, name string) time.Time {
+	if name == "UTC" || name == "Etc/UTC" {
+		return t.UTC()
+	}
+	if loc, err := time.LoadLocation(name); err == nil {
+		return t.In(loc)
+	}
+	return t
+}
@@ -0,0 +1,176 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// transactionIdleTimeout ends transactions left idle, which would hold
+// their locks until the session ends
+const transactionIdleTimeout = 5 * time.Minute
+
+// savepointControl matches the savepoint statements, which have tools of
+// their own so the savepoints of a transaction are known
+var savepointControl = regexp.MustCompile(`(?i)^(SAVEPOINT|RELEASE|ROLLBACK\s+((WORK|TRANSACTION)\s+)?TO)\b`)
+
+// Transaction is a read-write transaction kept open across tool calls on a
+// dedicated connection. Savepoints let a statement be tried and undone
+// without giving up the rest of the transaction.
+type Transaction struct {
+	d *DB
+	// mu serializes the statements of concurrent tool calls on the transaction
+	mu         sync.Mutex
+	conn       *sqlx.Conn
+	tx         *sqlx.Tx
+	savepoints []string
+}
+
+// BeginTransaction takes a connection out of the pool and begins a
+// read-write transaction on it. The connection is returned by Commit or
+// Rollback.
+func (d *DB) BeginTransaction(ctx context.Context) (*Transaction, error) {
+	conn, err := d.conn.Connx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a transaction connection: %w", err)
+	}
+	// The transaction outlives the request that began it
+	tx, err := conn.BeginTxx(context.WithoutCancel(ctx), nil)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	timeout := fmt.Sprintf("SET LOCAL idle_in_transaction_session_timeout = %d", transactionIdleTimeout.Milliseconds())
+	if _, err := tx.ExecContext(ctx, timeout); err != nil {
+		tx.Rollback()
+		conn.Close()
+		return nil, fmt.Errorf("failed to set idle timeout: %w", err)
+	}
+	return &Transaction{d: d, conn: conn, tx: tx}, nil
+}
+
+// Exec executes a single statement in the transaction. Statements returning
+// rows report their first rows. A failing statement aborts the transaction
+// until it is rolled back to a savepoint.
+func (t *Transaction) Exec(ctx context.Context, stmt string) (*StatementResult, error) {
+	statements := SplitStatements(stmt)
+	if len(statements) != 1 {
+		return nil, fmt.Errorf("expected one statement, got %d", len(statements))
+	}
+	command := leadingComments.ReplaceAllString(statements[0], "")
+	if transactionControl.MatchString(command) {
+		return nil, fmt.Errorf("use commit_transaction or rollback_transaction to end the transaction")
+	}
+	if savepointControl.MatchString(command) {
+		return nil, fmt.Errorf("use the savepoint, rollback_to_savepoint and release_savepoint tools for savepoints")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	res := &StatementResult{SQL: statements[0]}
+	start := time.Now()
+	err := t.d.runStatement(ctx, t.tx, statements[0], res)
+	res.Duration = time.Since(start)
+	if err != nil {
+		res.Status = StatementFailed
+		res.Error = err
+		return res, nil
+	}
+	res.Status = StatementOK
+	return res, nil
+}
+
+// Savepoint creates a savepoint; a savepoint of the same name replaces the
+// earlier one for later rollbacks, like in PostgreSQL
+func (t *Transaction) Savepoint(ctx context.Context, name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := t.tx.ExecContext(ctx, "SAVEPOINT "+quoteIdent(name)); err != nil {
+		return fmt.Errorf("failed to create savepoint %s: %w", name, err)
+	}
+	t.savepoints = append(t.savepoints, name)
+	return nil
+}
+
+// RollbackToSavepoint undoes the statements run since a savepoint, which is
+// kept, and drops the savepoints created after it
+func (t *Transaction) RollbackToSavepoint(ctx context.Context, name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	i := t.savepointIndex(name)
+	if i < 0 {
+		return fmt.Errorf("savepoint %s does not exist", name)
+	}
+	if _, err := t.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+quoteIdent(name)); err != nil {
+		return fmt.Errorf("failed to roll back to savepoint %s: %w", name, err)
+	}
+	t.savepoints = t.savepoints[:i+1]
+	return nil
+}
+
+// ReleaseSavepoint drops a savepoint and the savepoints created after it,
+// keeping the changes made since
+func (t *Transaction) ReleaseSavepoint(ctx context.Context, name string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	i := t.savepointIndex(name)
+	if i < 0 {
+		return fmt.Errorf("savepoint %s does not exist", name)
+	}
+	if _, err := t.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+quoteIdent(name)); err != nil {
+		return fmt.Errorf("failed to release savepoint %s: %w", name, err)
+	}
+	t.savepoints = t.savepoints[:i]
+	return nil
+}
+
+// Savepoints returns the names of the savepoints, oldest first
+func (t *Transaction) Savepoints() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string{}, t.savepoints...)
+}
+
+// savepointIndex returns the index of the latest savepoint of a name, -1 if
+// there is none; the caller holds mu
+func (t *Transaction) savepointIndex(name string) int {
+	for i := len(t.savepoints) - 1; i >= 0; i-- {
+		if t.savepoints[i] == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Commit commits the transaction and returns the connection to the pool
+func (t *Transaction) Commit() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	err := t.tx.Commit()
+	if closeErr := t.conn.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Rollback rolls the transaction back and returns the connection to the pool
+func (t *Transaction) Rollback() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	err := t.tx.Rollback()
+	if closeErr := t.conn.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to roll back transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// VectorMetric is a pgvector distance metric
+type VectorMetric string
+
+const (
+	// VectorL2 is the Euclidean distance (<->)
+	VectorL2 VectorMetric = "l2"
+	// VectorCosine is the cosine distance (<=>)
+	VectorCosine VectorMetric = "cosine"
+	// VectorInnerProduct is the negative inner product (<#>)
+	VectorInnerProduct VectorMetric = "inner_product"
+	// VectorL1 is the taxicab distance (<+>), available since pgvector 0.7
+	VectorL1 VectorMetric = "l1"
+)
+
+// vectorOperators maps the metrics to their pgvector operators
+var vectorOperators = map[VectorMetric]string{
+	VectorL2:           "<->",
+	VectorCosine:       "<=>",
+	VectorInnerProduct: "<#>",
+	VectorL1:           "<+>",
+}
+
+// VectorColumn represents a pgvector column
+type VectorColumn struct {
+	Schema     string         `db:"table_schema" json:"schema"`
+	Table      string         `db:"table_name" json:"table"`
+	Column     string         `db:"column_name" json:"column"`
+	Type       string         `db:"type_name" json:"type"`
+	Dimensions *int           `db:"dimensions" json:"dimensions"`
+	Indexes    pq.StringArray `db:"indexes" json:"indexes"`
+}
+
+// ListVectorColumns returns the pgvector columns with their dimensions and index definitions
+func (d *DB) ListVectorColumns() ([]VectorColumn, error) {
+	installed, err := d.HasExtension("vector")
+	if err != nil {
+		return nil, err
+	}
+	if !installed {
+		return nil, fmt.Errorf("the vector extension is not installed")
+	}
+
+	columns := []VectorColumn{}
+	query := `SELECT n.nspname AS table_schema, c.relname AS table_name, a.attname AS column_name,
+		t.typname AS type_name,
+		CASE WHEN a.atttypmod > 0 THEN a.atttypmod END AS dimensions,
+		ARRAY(SELECT pg_get_indexdef(i.indexrelid) FROM pg_catalog.pg_index i
+			WHERE i.indrelid = c.oid AND a.attnum = ANY(i.indkey)
+			ORDER BY i.indexrelid) AS indexes
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_type t ON t.oid = a.atttypid
+		WHERE t.typname IN ('vector', 'halfvec', 'sparsevec')
+		AND c.relkind IN ('r', 'p', 'm')
+		AND a.attnum > 0 AND NOT a.attisdropped
+		AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		ORDER BY n.nspname, c.relname, a.attnum`
+	if err := d.conn.Select(&columns, query); err != nil {
+		return nil, fmt.Errorf("failed to list vector columns: %w", err)
+	}
+	return columns, nil
+}
+
+// VectorSearchParams describes a nearest-neighbor search
+type VectorSearchParams struct {
+	Schema  string
+	Table   string
+	Column  string
+	Vector  []float64
+	Metric  VectorMetric
+	Limit   int
+	Columns []string
+}
+
+// VectorSearch returns the rows nearest to the given vector, closest first,
+// with their distance in a "distance" column. When no columns are requested
+// all columns except the vector column are returned.
+func (d *DB) VectorSearch(params VectorSearchParams) (*QueryResult, error) {
+	op, ok := vectorOperators[params.Metric]
+	if !ok {
+		return nil, fmt.Errorf("invalid metric %q, must be one of l2, cosine, inner_product, l1", params.Metric)
+	}
+	if len(params.Vector) == 0 {
+		return nil, fmt.Errorf("vector must not be empty")
+	}
+	if params.Schema == "" {
+		params.Schema = "public"
+	}
+
+	var tableColumns []struct {
+		Name string `db:"attname"`
+		Type string `db:"typname"`
+	}
+	query := `SELECT a.attname, t.typname
+		FROM pg_catalog.pg_attribute a
+		JOIN pg_catalog.pg_class c ON c.oid = a.attrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_catalog.pg_type t ON t.oid = a.atttypid
+		WHERE n.nspname = $1 AND c.relname = $2
+		AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`
+	if err := d.conn.Select(&tableColumns, query, params.Schema, params.Table); err != nil {
+		return nil, fmt.Errorf("failed to get columns of %s.%s: %w", params.Schema, params.Table, err)
+	}
+
+	var vectorType string
+	var otherColumns []string
+	for _, col := range tableColumns {
+		if col.Name == params.Column {
+			vectorType = col.Type
+		} else {
+			otherColumns = append(otherColumns, col.Name)
+		}
+	}
+	if vectorType != "vector" && vectorType != "halfvec" {
+		return nil, fmt.Errorf("column %s.%s.%s is not a vector or halfvec column", params.Schema, params.Table, params.Column)
+	}
+
+	selected := params.Columns
+	if len(selected) == 0 {
+		selected = otherColumns
+	}
+	var selectList []string
+	for _, col := range selected {
+		selectList = append(selectList, quoteIdent(col))
+	}
+
+	elems := make([]string, len(params.Vector))
+	for i, v := range params.Vector {
+		elems[i] = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+
+	distance := fmt.Sprintf("%s %s $1::%s", quoteIdent(params.Column), op, vectorType)
+	query = fmt.Sprintf("SELECT %s FROM %s ORDER BY %s LIMIT $2",
+		strings.Join(append(selectList, distance+" AS distance"), ", "),
+		Identifier{params.Schema, params.Table}.Sanitize(),
+		distance)
+
+	return d.QueryContext(context.Background(), query, "["+strings.Join(elems, ",")+"]", params.Limit)
+}
@@ -0,0 +1,232 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Workspace is a dedicated connection on which temporary tables live across
+// queries. Queries still run in read-only transactions, which PostgreSQL
+// allows to modify temporary tables but no other table.
+type Workspace struct {
+	d *DB
+	// mu serializes the statements of concurrent tool calls on the connection
+	mu   sync.Mutex
+	conn *sqlx.Conn
+}
+
+// maxNumericPrecision is the largest precision of a constrained numeric
+const maxNumericPrecision = 1000
+
+// TempTable is a temporary table of a workspace
+type TempTable struct {
+	Name          string `db:"name" json:"name"`
+	EstimatedRows int64  `db:"estimated_rows" json:"estimated_rows"`
+	Size          string `db:"size" json:"size"`
+}
+
+// NewWorkspace takes a connection out of the pool for the workspace. The
+// connection is returned by Close, which also drops its temporary tables.
+func (d *DB) NewWorkspace(ctx context.Context) (*Workspace, error) {
+	conn, err := d.conn.Connx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a workspace connection: %w", err)
+	}
+	return &Workspace{d: d, conn: conn}, nil
+}
+
+// Query executes a query on the workspace connection in a read-only
+// transaction. Changes to temporary tables are committed.
+func (w *Workspace) Query(ctx context.Context, query string, args ...interface{}) (*QueryResult, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.d.readOnlyQuery(ctx, w.conn, true, query, args...)
+}
+
+// CreateTempTable creates a temporary table from the result of a query. The
+// query only runs in read-only transactions: its columns are described
+// through a cursor, the table is created from their names and types, and it
+// is then filled by an INSERT, so the query itself cannot modify the database.
+func (w *Workspace) CreateTempTable(ctx context.Context, name, query string) (int64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	columns, err := w.describeQuery(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+
+	table := quoteIdent(name)
+	stmt := fmt.Sprintf("CREATE TEMP TABLE %s (%s)", table, strings.Join(columns, ", "))
+	if _, err := w.conn.ExecContext(ctx, stmt); err != nil {
+		return 0, fmt.Errorf("failed to create temporary table %s: %w", name, err)
+	}
+
+	tx, err := w.readOnlyTx(ctx)
+	if err != nil {
+		return 0, w.dropAfter(ctx, name, err)
+	}
+	defer tx.Rollback()
+
+	result, err := execSingle(ctx, tx, fmt.Sprintf("INSERT INTO pg_temp.%s %s", table, query))
+	if err != nil {
+		// The failed transaction must end before the table can be dropped
+		tx.Rollback()
+		return 0, w.dropAfter(ctx, name, fmt.Errorf("failed to fill temporary table %s: %w", name, err))
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, w.dropAfter(ctx, name, fmt.Errorf("failed to commit transaction: %w", err))
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get row count: %w", err)
+	}
+	return rows, nil
+}
+
+// describeQuery returns the column definitions of the result of a query. A
+// cursor is declared for the query in a read-only transaction, which only
+// accepts a single SELECT, and FETCH 0 returns its columns without running it.
+func (w *Workspace) describeQuery(ctx context.Context, query string) ([]string, error) {
+	tx, err := w.readOnlyTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := execSingle(ctx, tx, "DECLARE pgmcp_describe NO SCROLL CURSOR FOR "+query); err != nil {
+		return nil, fmt.Errorf("failed to describe query: %w", err)
+	}
+	rows, err := tx.QueryContext(ctx, "FETCH 0 FROM pgmcp_describe")
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe query: %w", err)
+	}
+	defer rows.Close()
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get column types: %w", err)
+	}
+
+	names := uniqueColumnNames(columnNames(columnTypes))
+	columns := make([]string, len(columnTypes))
+	for i, ct := range columnTypes {
+		columns[i] = quoteIdent(names[i]) + " " + columnType(ct)
+	}
+	return columns, nil
+}
+
+// columnType returns the type of a column definition for a result column.
+// Types without a built-in name, e.g. of extensions, enums or domains, are
+// stored as text, which PostgreSQL converts any value to on INSERT.
+func columnType(ct *sql.ColumnType) string {
+	typeName := strings.ToLower(ct.DatabaseTypeName())
+	if typeName == "" {
+		return "text"
+	}
+	// Quoted, e.g. "char" is not char(1), and qualified against shadowing types
+	typ := "pg_catalog." + quoteIdent(typeName)
+	switch typeName {
+	case "varchar", "bpchar":
+		if length, ok := ct.Length(); ok && length > 0 {
+			typ += fmt.Sprintf("(%d)", length)
+		}
+	case "numeric":
+		// An unconstrained numeric has no typmod, which reads as a huge precision
+		if precision, scale, ok := ct.DecimalSize(); ok && precision > 0 && precision <= maxNumericPrecision {
+			typ += fmt.Sprintf("(%d,%d)", precision, scale)
+		}
+	}
+	return typ
+}
+
+// readOnlyTx begins a read-only transaction on the workspace connection
+func (w *Workspace) readOnlyTx(ctx context.Context) (*sqlx.Tx, error) {
+	tx, err := w.conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "SET TRANSACTION READ ONLY"); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to set transaction to read-only: %w", err)
+	}
+	return tx, nil
+}
+
+// preparer prepares statements on a connection or transaction
+type preparer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// execSingle executes a statement through a prepared statement, which
+// PostgreSQL rejects when the text contains more than one command
+func execSingle(ctx context.Context, conn preparer, stmt string) (sql.Result, error) {
+	prepared, err := conn.PrepareContext(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer prepared.Close()
+	return prepared.ExecContext(ctx)
+}
+
+// dropAfter drops a temporary table that could not be filled and returns err
+func (w *Workspace) dropAfter(ctx context.Context, name string, err error) error {
+	if dropErr := w.dropTempTable(context.WithoutCancel(ctx), name); dropErr != nil {
+		return fmt.Errorf("%w (%v)", err, dropErr)
+	}
+	return err
+}
+
+// DropTempTable drops a temporary table of the workspace
+func (w *Workspace) DropTempTable(ctx context.Context, name string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.dropTempTable(ctx, name)
+}
+
+// dropTempTable drops a temporary table, the caller holds mu
+func (w *Workspace) dropTempTable(ctx context.Context, name string) error {
+	stmt := "DROP TABLE pg_temp." + quoteIdent(name)
+	if _, err := w.conn.ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("failed to drop temporary table %s: %w", name, err)
+	}
+	return nil
+}
+
+// ListTempTables returns the temporary tables of the workspace
+func (w *Workspace) ListTempTables(ctx context.Context) ([]TempTable, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	tables := []TempTable{}
+	query := `SELECT c.relname AS name, GREATEST(c.reltuples, 0)::bigint AS estimated_rows,
+		pg_size_pretty(pg_total_relation_size(c.oid)) AS size
+		FROM pg_catalog.pg_class c
+		WHERE c.relnamespace = pg_my_temp_schema() AND c.relkind = 'r'
+		ORDER BY c.relname`
+	if err := w.conn.SelectContext(ctx, &tables, query); err != nil {
+		return nil, fmt.Errorf("failed to list temporary tables: %w", err)
+	}
+	return tables, nil
+}
+
+// Close drops the temporary tables and returns the connection to the pool
+func (w *Workspace) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// DISCARD TEMP keeps the pooled connection from leaking tables to other users
+	_, err := w.conn.ExecContext(context.Background(), "DISCARD TEMP")
+	if closeErr := w.conn.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to close workspace: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package pglog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Format is the PostgreSQL log destination format
+type Format string
+
+const (
+	// Stderr is the plain text format of log_destination = 'stderr'
+	Stderr Format = "stderr"
+	// CSV is the format of log_destination = 'csvlog'
+	CSV Format = "csvlog"
+)
+
+// ParseFormat parses a log format name
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case Stderr, CSV:
+		return f, nil
+	}
+	return "", fmt.Errorf("invalid log format %q, must be one of stderr, csvlog", s)
+}
+
+// Entry is an error reported in the server log
+type Entry struct {
+	// Time is the log time for csvlog, the log_line_prefix for stderr
+	Time      string `json:"time,omitempty"`
+	Severity  string `json:"severity"`
+	SQLState  string `json:"sql_state,omitempty"`
+	User      string `json:"user,omitempty"`
+	Database  string `json:"database,omitempty"`
+	Message   string `json:"message"`
+	Detail    string `json:"detail,omitempty"`
+	Hint      string `json:"hint,omitempty"`
+	Context   string `json:"context,omitempty"`
+	Statement string `json:"statement,omitempty"`
+	Deadlock  bool   `json:"deadlock"`
+}
+
+// errorSeverities are the severities reported as errors
+var errorSeverities = map[string]bool{"ERROR": true, "FATAL": true, "PANIC": true}
+
+// severityPattern matches the severity tag of a stderr log line
+var severityPattern = regexp.MustCompile(`^(.*?)\b(DEBUG\d?|LOG|INFO|NOTICE|WARNING|ERROR|FATAL|PANIC|DETAIL|HINT|CONTEXT|STATEMENT|QUERY|LOCATION):  (.*)$`)
+
+// ReadRecent returns the errors in the last maxBytes of a log file, oldest first,
+// keeping at most limit entries
+func ReadRecent(path string, format Format, maxBytes int64, limit int) ([]Entry, error) {
+	data, err := readTail(path, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	if format == CSV {
+		entries = parseCSV(data)
+	} else {
+		entries = parseStderr(data)
+	}
+	if len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+// readTail reads the last maxBytes of a file, starting at a line boundary
+func readTail(path string, maxBytes int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat log file: %w", err)
+	}
+	offset := info.Size() - maxBytes
+	if offset < 0 {
+		offset = 0
+	}
+	data, err := io.ReadAll(io.NewSectionReader(f, offset, info.Size()-offset))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+	if offset > 0 {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	return data, nil
+}
+
+// parseStderr extracts the errors from stderr format lines. Detail, hint,
+// context and statement lines following an error are attached to it.
+func parseStderr(data []byte) []Entry {
+	var entries []Entry
+	var current *Entry
+	// last is the field continuation lines are appended to
+	var last *string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		m := severityPattern.FindStringSubmatch(line)
+		if m == nil {
+			// Continuation lines of multi-line messages and statements
+			if last != nil && strings.HasPrefix(line, "\t") {
+				*last += "\n" + strings.TrimPrefix(line, "\t")
+			}
+			continue
+		}
+
+		prefix, tag, text := strings.TrimSpace(m[1]), m[2], m[3]
+		switch {
+		case errorSeverities[tag]:
+			entries = append(entries, Entry{
+				Time:     prefix,
+				Severity: tag,
+				Message:  text,
+				Deadlock: strings.Contains(text, "deadlock detected"),
+			})
+			current = &entries[len(entries)-1]
+			last = &current.Message
+		case current != nil && tag == "DETAIL":
+			current.Detail = text
+			last = &current.Detail
+		case current != nil && tag == "HINT":
+			current.Hint = text
+			last = &current.Hint
+		case current != nil && tag == "CONTEXT":
+			current.Context = text
+			last = &current.Context
+		case current != nil && (tag == "STATEMENT" || tag == "QUERY"):
+			current.Statement = text
+			last = &current.Statement
+		case tag != "LOCATION":
+			current, last = nil, nil
+		}
+	}
+	return entries
+}
+
+// csvlog column positions
+const (
+	csvLogTime  = 0
+	csvUser     = 1
+	csvDatabase = 2
+	csvSeverity = 11
+	csvSQLState = 12
+	csvMessage  = 13
+	csvDetail   = 14
+	csvHint     = 15
+	csvContext  = 18
+	csvQuery    = 19
+)
+
+// parseCSV extracts the errors from csvlog records. Records cut by the start
+// of the tail are skipped.
+func parseCSV(data []byte) []Entry {
+	var entries []Entry
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil || len(record) <= csvQuery || !errorSeverities[record[csvSeverity]] {
+			continue
+		}
+		entries = append(entries, Entry{
+			Time:      record[csvLogTime],
+			Severity:  record[csvSeverity],
+			SQLState:  record[csvSQLState],
+			User:      record[csvUser],
+			Database:  record[csvDatabase],
+			Message:   record[csvMessage],
+			Detail:    record[csvDetail],
+			Hint:      record[csvHint],
+			Context:   record[csvContext],
+			Statement: record[csvQuery],
+			Deadlock:  record[csvSQLState] == "40P01",
+		})
+	}
+	return entries
+}
@@ -0,0 +1,382 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultTestDataRows is the number of rows inserted by generate_test_data
+const defaultTestDataRows = 10
+
+// defaultTopStatements is the number of pg_stat_statements queries analyzed by suggest_indexes
+const defaultTopStatements = 5
+
+// defaultTopQueries is the number of queries listed by top_queries
+const defaultTopQueries = 10
+
+// addAdminTools registers the database administration tools
+func (s *PostgresMCPServer) addAdminTools() {
+	// Without free-form SQL only the top queries of pg_stat_statements are analyzed
+	suggestIndexesDescription := "Suggest CREATE INDEX statements for a query, or for the slowest queries in pg_stat_statements, from the filtered sequential scans of their plans."
+	if s.restrictSQL {
+		suggestIndexesDescription = "Suggest CREATE INDEX statements for the slowest queries in pg_stat_statements, from the filtered sequential scans of their plans."
+	}
+	suggestIndexesOptions := []mcp.ToolOption{
+		mcp.WithDescription(suggestIndexesDescription + " With the hypopg extension the plan cost with each index is estimated and only beneficial indexes are kept."),
+		mcp.WithNumber("limit",
+			mcp.Description("The number of pg_stat_statements queries to analyze"),
+			mcp.DefaultNumber(defaultTopStatements),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	}
+	if !s.restrictSQL {
+		suggestIndexesOptions = append(suggestIndexesOptions, mcp.WithString("sql",
+			mcp.Description("The query to analyze; omit to analyze the top queries from pg_stat_statements"),
+		))
+	}
+	s.addTool(mcp.NewTool("suggest_indexes", suggestIndexesOptions...), s.handleSuggestIndexes)
+
+	maintenanceStatusTool := mcp.NewTool("maintenance_status",
+		mcp.WithDescription("Report per-table dead tuple ratios and last vacuum/analyze times, tables overdue for vacuum or analyze, running autovacuum workers and the transaction ID wraparound risk"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(maintenanceStatusTool, s.handleMaintenanceStatus)
+
+	replicationStatusTool := mcp.NewTool("replication_status",
+		mcp.WithDescription("Report replication health: connected replicas with lag in bytes and seconds, the WAL receiver when this server is a standby, and replication slots with the WAL they retain. Inactive slots retaining WAL are flagged at_risk."),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(replicationStatusTool, s.handleReplicationStatus)
+
+	showSettingsTool := mcp.NewTool("show_settings",
+		mcp.WithDescription("Show server configuration parameters from pg_settings with their value, unit, source (and configuration file when visible) and whether a restart is pending"),
+		mcp.WithString("pattern",
+			mcp.Description("Parameter name pattern, % and _ are wildcards, e.g. work_mem or autovacuum%"),
+		),
+		mcp.WithString("category",
+			mcp.Description("Category prefix, e.g. \"Resource Usage\" or \"Write-Ahead Log\""),
+		),
+		mcp.WithBoolean("changed_only",
+			mcp.Description("Only show parameters that are not at their default value"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(showSettingsTool, s.handleShowSettings)
+
+	connectionInfoTool := mcp.NewTool("connection_info",
+		mcp.WithDescription("Show the PostgreSQL server version (to choose syntax, e.g. MERGE needs 15), the current database, the session user and effective role, whether the connection uses SSL, whether the server is a standby, and session parameters such as TimeZone, search_path and statement_timeout"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(connectionInfoTool, s.handleConnectionInfo)
+
+	listRolesTool := mcp.NewTool("list_roles",
+		mcp.WithDescription("List the database roles with their attributes (superuser, login, create role/db, replication, bypass RLS), the roles they are members of and their members"),
+		mcp.WithBoolean("include_system",
+			mcp.Description("Include the predefined pg_* roles"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(listRolesTool, s.handleListRoles)
+
+	getPrivilegesTool := mcp.NewTool("get_privileges",
+		mcp.WithDescription("List table and column privileges granted on a table and/or applying to a role, including grants to PUBLIC and inherited through role membership. For a single table also report its owner, row level security and the effective privileges of each login role, e.g. to answer who can write to a table or debug permission denied errors."),
+		mcp.WithString("table",
+			mcp.Description("The table to inspect"),
+		),
+		mcp.WithString("schema",
+			mcp.Description("The schema of the table"),
+			mcp.DefaultString("public"),
+		),
+		mcp.WithString("role",
+			mcp.Description("Only show privileges applying to this role"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(getPrivilegesTool, s.handleGetPrivileges)
+
+	listForeignDataTool := mcp.NewTool("list_foreign_data",
+		mcp.WithDescription("List the foreign servers with their wrapper, the user mappings and the foreign tables. Queries on foreign tables are sent to the remote server and can be much slower than local tables. Secret options such as passwords are masked."),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(listForeignDataTool, s.handleListForeignData)
+
+	if !s.allowWrite {
+		return
+	}
+
+	runAnalyzeTool := mcp.NewTool("run_analyze",
+		mcp.WithDescription("Run ANALYZE on a table to refresh its planner statistics"),
+		mcp.WithString("table",
+			mcp.Required(),
+			mcp.Description("The table to analyze"),
+		),
+		mcp.WithString("schema",
+			mcp.Description("The schema of the table"),
+			mcp.DefaultString("public"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+	s.addTool(runAnalyzeTool, s.handleRunAnalyze)
+
+	runVacuumTool := mcp.NewTool("run_vacuum",
+		mcp.WithDescription("Run a plain VACUUM (never VACUUM FULL) on a table to reclaim dead tuples"),
+		mcp.WithString("table",
+			mcp.Required(),
+			mcp.Description("The table to vacuum"),
+		),
+		mcp.WithString("schema",
+			mcp.Description("The schema of the table"),
+			mcp.DefaultString("public"),
+		),
+		mcp.WithBoolean("analyze",
+			mcp.Description("Also refresh the planner statistics"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+	s.addTool(runVacuumTool, s.handleRunVacuum)
+
+	generateTestDataTool := mcp.NewTool("generate_test_data",
+		mcp.WithDescription("Insert synthetic rows into a table for development databases. Values follow the column types, enum labels and NOT NULL constraints, foreign keys reference existing parent rows, and columns with defaults are left to the database."),
+		mcp.WithString("table",
+			mcp.Required(),
+			mcp.Description("The table to fill"),
+		),
+		mcp.WithString("schema",
+			mcp.Description("The schema of the table"),
+			mcp.DefaultString("public"),
+		),
+		mcp.WithNumber("rows",
+			mcp.Description(fmt.Sprintf("The number of rows to insert, at most %d", db.MaxTestDataRows)),
+			mcp.DefaultNumber(defaultTestDataRows),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+	s.addTool(generateTestDataTool, s.handleGenerateTestData)
+}
+
+// handleSuggestIndexes handles the suggest_indexes tool
+func (s *PostgresMCPServer) handleSuggestIndexes(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var queries []string
+	if sql := stringArg(request, "sql", ""); sql != "" {
+		if s.restrictSQL {
+			return mcp.NewToolResultError("Free-form SQL is disabled, omit sql to analyze the top queries from pg_stat_statements"), nil
+		}
+		queries = []string{sql}
+	} else {
+		var err error
+		queries, err = s.db.TopStatements(intArg(request, "limit", defaultTopStatements))
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to get top queries", err), nil
+		}
+	}
+
+	advice, err := s.db.SuggestIndexes(ctx, queries)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to suggest indexes", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(advice, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleMaintenanceStatus handles the maintenance_status tool
+func (s *PostgresMCPServer) handleMaintenanceStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	status, err := s.db.GetMaintenanceStatus()
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to get maintenance status", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleReplicationStatus handles the replication_status tool
+func (s *PostgresMCPServer) handleReplicationStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	status, err := s.db.GetReplicationStatus()
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to get replication status", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleShowSettings handles the show_settings tool
+func (s *PostgresMCPServer) handleShowSettings(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	filter := db.SettingsFilter{
+		Pattern:  stringArg(request, "pattern", ""),
+		Category: stringArg(request, "category", ""),
+	}
+	filter.ChangedOnly, _ = request.Params.Arguments["changed_only"].(bool)
+
+	settings, err := s.db.GetSettings(filter)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to get settings", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// addStatementTools registers the pg_stat_statements tools
+func (s *PostgresMCPServer) addStatementTools() {
+	topQueriesTool := mcp.NewTool("top_queries",
+		mcp.WithDescription("List the normalized queries of this database from pg_stat_statements with their calls, total, mean and maximum execution time, rows and buffer cache hit ratio"),
+		mcp.WithString("order_by",
+			mcp.Enum("total_time", "mean_time", "calls", "rows"),
+			mcp.DefaultString("total_time"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("The number of queries, at most %d", db.MaxTopQueries)),
+			mcp.DefaultNumber(defaultTopQueries),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(topQueriesTool, s.handleTopQueries)
+}
+
+// handleTopQueries handles the top_queries tool
+func (s *PostgresMCPServer) handleTopQueries(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	stats, err := s.db.TopQueries(stringArg(request, "order_by", "total_time"), intArg(request, "limit", defaultTopQueries))
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list queries", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleConnectionInfo handles the connection_info tool
+func (s *PostgresMCPServer) handleConnectionInfo(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	info, err := s.db.GetConnectionInfo(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to get connection info", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleListRoles handles the list_roles tool
+func (s *PostgresMCPServer) handleListRoles(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	includeSystem, _ := request.Params.Arguments["include_system"].(bool)
+	roles, err := s.db.ListRoles(includeSystem)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list roles", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(roles, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleGetPrivileges handles the get_privileges tool
+func (s *PostgresMCPServer) handleGetPrivileges(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	table, role := stringArg(request, "table", ""), stringArg(request, "role", "")
+	if table == "" && role == "" {
+		return mcp.NewToolResultError("Either table or role is required"), nil
+	}
+
+	report, err := s.db.GetPrivileges(stringArg(request, "schema", "public"), table, role)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to get privileges", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleListForeignData handles the list_foreign_data tool
+func (s *PostgresMCPServer) handleListForeignData(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	data, err := s.db.GetForeignData()
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list foreign data", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleRunAnalyze handles the run_analyze tool
+func (s *PostgresMCPServer) handleRunAnalyze(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	schema, table := stringArg(request, "schema", "public"), stringArg(request, "table", "")
+	if table == "" {
+		return mcp.NewToolResultError("table is required"), nil
+	}
+	log.Printf("run_analyze called on %s.%s", schema, table)
+
+	if err := s.db.Analyze(ctx, schema, table); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to analyze table", err), nil
+	}
+	return mcp.NewToolResultText("ANALYZE completed on " + schema + "." + table), nil
+}
+
+// handleRunVacuum handles the run_vacuum tool
+func (s *PostgresMCPServer) handleRunVacuum(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	schema, table := stringArg(request, "schema", "public"), stringArg(request, "table", "")
+	if table == "" {
+		return mcp.NewToolResultError("table is required"), nil
+	}
+	analyze, _ := request.Params.Arguments["analyze"].(bool)
+	log.Printf("run_vacuum called on %s.%s (analyze: %v)", schema, table, analyze)
+
+	if err := s.db.Vacuum(ctx, schema, table, analyze); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to vacuum table", err), nil
+	}
+	return mcp.NewToolResultText("VACUUM completed on " + schema + "." + table), nil
+}
+
+// handleGenerateTestData handles the generate_test_data tool
+func (s *PostgresMCPServer) handleGenerateTestData(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	schema, table := stringArg(request, "schema", "public"), stringArg(request, "table", "")
+	if table == "" {
+		return mcp.NewToolResultError("table is required"), nil
+	}
+	rows := intArg(request, "rows", defaultTestDataRows)
+	log.Printf("generate_test_data called on %s.%s (%d rows)", schema, table, rows)
+
+	result, err := s.db.GenerateTestData(ctx, schema, table, rows)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to generate test data", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
@@ -0,0 +1,337 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// writeProposalTTL is how long a write proposal can be approved
+	writeProposalTTL = time.Hour
+	// impactCountTimeout bounds the count of the rows a write affects
+	impactCountTimeout = 5 * time.Second
+)
+
+// writeApproval keeps the write proposals waiting for approval
+type writeApproval struct {
+	// requireOtherApprover rejects approvals by the identity that proposed
+	requireOtherApprover bool
+	// byUser compares the users of a trusted proxy instead of the sessions
+	byUser bool
+
+	mu        sync.Mutex
+	proposals map[string]*writeProposal
+}
+
+// writeProposal is a write script waiting for approval
+type writeProposal struct {
+	ID         string              `json:"id"`
+	SQL        string              `json:"sql"`
+	Statements []statementEstimate `json:"statements"`
+	ProposedBy string              `json:"proposed_by"`
+	ProposedAt time.Time           `json:"proposed_at"`
+	ExpiresAt  time.Time           `json:"expires_at"`
+
+	proposer   Identity
+	statements []string
+}
+
+// statementEstimate is the impact of a write statement
+type statementEstimate struct {
+	SQL string `json:"sql"`
+	// Target is the table written by INSERT, UPDATE, DELETE and MERGE
+	Target string `json:"target,omitempty"`
+	// EstimatedRows is the planner's estimate of the rows written, null for
+	// statements that cannot be explained, such as DDL or statements
+	// depending on earlier statements of the script
+	EstimatedRows *int64 `json:"estimated_rows"`
+	// AffectedRows is the count of the rows an UPDATE or DELETE matches now,
+	// null when it could not be counted in time
+	AffectedRows *int64 `json:"affected_rows,omitempty"`
+	Impact       string `json:"impact,omitempty"`
+}
+
+// WithWriteApproval makes the write tools running free-form SQL two-phase:
+// propose_write returns a proposal with the estimated affected rows, and
+// the statements only run once approve_write is called. With
+// requireOtherApprover the proposal must be approved by another user of an
+// authenticating proxy trusted with WithForwardedHeaders, or else by another
+// session. Approvers without a user or session are refused. run_script and
+// the transaction tools are not registered.
+func WithWriteApproval(requireOtherApprover bool) Option {
+	return func(s *PostgresMCPServer) {
+		s.writeApproval = &writeApproval{requireOtherApprover: requireOtherApprover}
+	}
+}
+
+// addWriteApprovalTools registers the write proposal tools
+func (s *PostgresMCPServer) addWriteApprovalTools() {
+	proposeTool := mcp.NewTool("propose_write",
+		mcp.WithDescription("Propose a write script of one or more SQL statements without running it. Returns a proposal ID with the estimated rows each statement affects; the statements run in one transaction once approve_write is called with the ID."),
+		mcp.WithString("sql",
+			mcp.Required(),
+			mcp.Description("The statements separated by semicolons. BEGIN, COMMIT and ROLLBACK are not allowed."),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(proposeTool, s.handleProposeWrite)
+
+	description := "Run the statements of a write proposal in one transaction, rolled back at the first error"
+	if s.writeApproval.requireOtherApprover {
+		description += ". The proposal must be approved by another identity than the one that proposed it."
+	}
+	approveTool := mcp.NewTool("approve_write",
+		mcp.WithDescription(description),
+		mcp.WithString("proposal_id",
+			mcp.Required(),
+			mcp.Description("The proposal ID returned by propose_write"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+	)
+	s.addTool(approveTool, s.handleApproveWrite)
+
+	rejectTool := mcp.NewTool("reject_write",
+		mcp.WithDescription("Discard a write proposal without running it"),
+		mcp.WithString("proposal_id",
+			mcp.Required(),
+			mcp.Description("The proposal ID returned by propose_write"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(rejectTool, s.handleRejectWrite)
+
+	listTool := mcp.NewTool("list_write_proposals",
+		mcp.WithDescription("List the write proposals waiting for approval"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(listTool, s.handleListWriteProposals)
+}
+
+// handleProposeWrite handles the propose_write tool
+func (s *PostgresMCPServer) handleProposeWrite(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	script := stringArg(request, "sql", "")
+	statements := db.SplitStatements(script)
+	if len(statements) == 0 {
+		return mcp.NewToolResultError("SQL script is required"), nil
+	}
+	if len(statements) > maxScriptStatements {
+		return mcp.NewToolResultError(fmt.Sprintf("The script has %d statements, at most %d are allowed", len(statements), maxScriptStatements)), nil
+	}
+
+	id, err := newRandomID()
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to create proposal", err), nil
+	}
+	identity := identityFromContext(ctx)
+	now := time.Now()
+	proposal := &writeProposal{
+		ID:         id,
+		SQL:        script,
+		Statements: make([]statementEstimate, len(statements)),
+		ProposedBy: identity.String(),
+		ProposedAt: now,
+		ExpiresAt:  now.Add(writeProposalTTL),
+		proposer:   identity,
+		statements: statements,
+	}
+	for i, stmt := range statements {
+		proposal.Statements[i] = s.estimateWrite(ctx, stmt)
+	}
+	s.writeApproval.add(proposal)
+	log.Printf("write proposal %s by %s: %s", id, proposal.ProposedBy, script)
+
+	resultJSON, err := json.MarshalIndent(proposal, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// estimateWrite returns the impact of a write statement: the planner's
+// estimate of the rows written, taken from the input of the ModifyTable node
+// of its plan, and for UPDATE and DELETE the count of the rows their WHERE
+// clause matches
+func (s *PostgresMCPServer) estimateWrite(ctx context.Context, stmt string) statementEstimate {
+	estimate := statementEstimate{SQL: stmt}
+	plan, err := s.db.ExplainQuery(ctx, stmt, nil)
+	if err == nil && len(plan.Nodes) > 0 && plan.Nodes[0].NodeType == "ModifyTable" {
+		estimate.Target = plan.Nodes[0].Relation
+		if len(plan.Nodes) > 1 {
+			rows := int64(plan.Nodes[1].PlanRows)
+			estimate.EstimatedRows = &rows
+		}
+	}
+
+	if count, ok := s.countAffectedRows(ctx, stmt); ok {
+		estimate.AffectedRows = &count
+		estimate.Impact = fmt.Sprintf("this will affect ~%d rows", count)
+	} else if estimate.EstimatedRows != nil {
+		estimate.Impact = fmt.Sprintf("the planner estimates ~%d rows", *estimate.EstimatedRows)
+	}
+	return estimate
+}
+
+// countAffectedRows counts the rows an UPDATE or DELETE statement matches
+// with a SELECT count(*) of its tables and WHERE clause. Counting gives up
+// after impactCountTimeout, leaving the planner's estimate.
+func (s *PostgresMCPServer) countAffectedRows(ctx context.Context, stmt string) (int64, bool) {
+	query, ok := db.CountAffectedRowsQuery(stmt)
+	if !ok {
+		return 0, false
+	}
+	ctx, cancel := context.WithTimeout(ctx, impactCountTimeout)
+	defer cancel()
+	run, err := s.readOnlyQuery(ctx, query)
+	if err != nil || len(run.Rows) != 1 {
+		return 0, false
+	}
+	switch count := run.Rows[0]["affected_rows"].(type) {
+	case int64:
+		return count, true
+	case float64:
+		return int64(count), true
+	}
+	return 0, false
+}
+
+// handleApproveWrite handles the approve_write tool
+func (s *PostgresMCPServer) handleApproveWrite(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	proposal, err := s.writeApproval.approve(stringArg(request, "proposal_id", ""), identityFromContext(ctx))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	log.Printf("write proposal %s approved by %s", proposal.ID, identityFromContext(ctx))
+
+	result, err := s.db.RunScript(ctx, proposal.statements, false)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to run script", err), nil
+	}
+
+	return s.scriptResult(result)
+}
+
+// handleRejectWrite handles the reject_write tool
+func (s *PostgresMCPServer) handleRejectWrite(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id := stringArg(request, "proposal_id", "")
+	if !s.writeApproval.remove(id) {
+		return mcp.NewToolResultError(fmt.Sprintf("Proposal %q not found", id)), nil
+	}
+	log.Printf("write proposal %s rejected by %s", id, identityFromContext(ctx))
+	return mcp.NewToolResultText(fmt.Sprintf("Rejected proposal %s", id)), nil
+}
+
+// handleListWriteProposals handles the list_write_proposals tool
+func (s *PostgresMCPServer) handleListWriteProposals(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	resultJSON, err := json.MarshalIndent(s.writeApproval.list(), "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// String names the identity in proposals and logs
+func (i Identity) String() string {
+	if i.User != "" {
+		return "user " + i.User
+	}
+	if i.SessionID != "" {
+		return "session " + i.SessionID
+	}
+	return "local client"
+}
+
+// caller returns what tells the proposer and the approver apart: the user of
+// a trusted proxy, or else the session, empty when unknown
+func (w *writeApproval) caller(identity Identity) string {
+	if w.byUser {
+		return identity.User
+	}
+	return identity.SessionID
+}
+
+// add stores a proposal
+func (w *writeApproval) add(proposal *writeProposal) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.expire()
+	if w.proposals == nil {
+		w.proposals = map[string]*writeProposal{}
+	}
+	w.proposals[proposal.ID] = proposal
+}
+
+// approve removes a proposal for the approver to run it, so that it runs at
+// most once
+func (w *writeApproval) approve(id string, approver Identity) (*writeProposal, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.expire()
+	proposal, ok := w.proposals[id]
+	if !ok {
+		return nil, fmt.Errorf("proposal %q not found, it may have expired", id)
+	}
+	if w.requireOtherApprover {
+		caller := w.caller(approver)
+		if caller == "" {
+			return nil, fmt.Errorf("proposal %s cannot be approved by an anonymous caller", id)
+		}
+		if caller == w.caller(proposal.proposer) {
+			return nil, fmt.Errorf("proposal %s must be approved by another identity than %s", id, proposal.ProposedBy)
+		}
+	}
+	delete(w.proposals, id)
+	return proposal, nil
+}
+
+// get returns a pending proposal without removing it
+func (w *writeApproval) get(id string) (*writeProposal, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.expire()
+	proposal, ok := w.proposals[id]
+	return proposal, ok
+}
+
+// remove drops a proposal, reporting whether it existed
+func (w *writeApproval) remove(id string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.expire()
+	_, ok := w.proposals[id]
+	delete(w.proposals, id)
+	return ok
+}
+
+// list returns the pending proposals, oldest first
+func (w *writeApproval) list() []*writeProposal {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.expire()
+	proposals := make([]*writeProposal, 0, len(w.proposals))
+	for _, proposal := range w.proposals {
+		proposals = append(proposals, proposal)
+	}
+	sort.Slice(proposals, func(i, j int) bool {
+		return proposals[i].ProposedAt.Before(proposals[j].ProposedAt)
+	})
+	return proposals
+}
+
+// expire drops the proposals past their expiry, w.mu must be held
+func (w *writeApproval) expire() {
+	now := time.Now()
+	for id, proposal := range w.proposals {
+		if now.After(proposal.ExpiresAt) {
+			delete(w.proposals, id)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWriteApprovalApprove(t *testing.T) {
+	tests := []struct {
+		name     string
+		byUser   bool
+		proposer Identity
+		approver Identity
+		wantErr  bool
+	}{
+		{
+			name:     "other session",
+			proposer: Identity{SessionID: "a"},
+			approver: Identity{SessionID: "b"},
+		},
+		{
+			name:     "same session",
+			proposer: Identity{SessionID: "a"},
+			approver: Identity{SessionID: "a"},
+			wantErr:  true,
+		},
+		{
+			name:     "user ignored without a trusted proxy",
+			proposer: Identity{SessionID: "a", User: "alice"},
+			approver: Identity{SessionID: "a", User: "bob"},
+			wantErr:  true,
+		},
+		{
+			name:     "anonymous session",
+			proposer: Identity{SessionID: "a"},
+			approver: Identity{},
+			wantErr:  true,
+		},
+		{
+			name:     "other user",
+			byUser:   true,
+			proposer: Identity{SessionID: "a", User: "alice"},
+			approver: Identity{SessionID: "a", User: "bob"},
+		},
+		{
+			name:     "same user in another session",
+			byUser:   true,
+			proposer: Identity{SessionID: "a", User: "alice"},
+			approver: Identity{SessionID: "b", User: "alice"},
+			wantErr:  true,
+		},
+		{
+			name:     "approver without a user",
+			byUser:   true,
+			proposer: Identity{SessionID: "a", User: "alice"},
+			approver: Identity{SessionID: "b"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &writeApproval{requireOtherApprover: true, byUser: tt.byUser}
+			w.add(&writeProposal{ID: "p1", proposer: tt.proposer, ProposedBy: tt.proposer.String(), ExpiresAt: time.Now().Add(time.Hour)})
+			_, err := w.approve("p1", tt.approver)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
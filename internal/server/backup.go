@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// WithWriteBackups copies the rows changed by UPDATE, DELETE and TRUNCATE
+// statements of the write tools into timestamped tables of the schema before
+// they run, and registers the list_backups and restore_backup tools
+func WithWriteBackups(schema string) Option {
+	return func(s *PostgresMCPServer) {
+		s.backupSchema = schema
+		s.dbOptions = append(s.dbOptions, db.WithBackupSchema(schema))
+	}
+}
+
+// addBackupTools registers the tools of the backups taken before writes
+func (s *PostgresMCPServer) addBackupTools() {
+	if !s.allowWrite || s.backupSchema == "" {
+		return
+	}
+
+	listTool := mcp.NewTool("list_backups",
+		mcp.WithDescription(fmt.Sprintf("List the backups of rows taken in schema %s before UPDATE, DELETE and TRUNCATE statements changed them, newest first", s.backupSchema)),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(listTool, s.handleListBackups)
+
+	restoreTool := mcp.NewTool("restore_backup",
+		mcp.WithDescription("Undo a write by putting the rows of a backup back: deleted and truncated rows are inserted again, updated rows get their old values back by primary key. A backup can be restored once."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The backup name from list_backups or the backups of a statement result"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+	)
+	s.addTool(restoreTool, s.handleRestoreBackup)
+}
+
+// handleListBackups handles the list_backups tool
+func (s *PostgresMCPServer) handleListBackups(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	backups, err := s.db.ListBackups(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list backups", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(backups, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleRestoreBackup handles the restore_backup tool
+func (s *PostgresMCPServer) handleRestoreBackup(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := stringArg(request, "name", "")
+	if name == "" {
+		return mcp.NewToolResultError("Backup name is required"), nil
+	}
+	log.Printf("restoring backup %s", name)
+
+	rows, err := s.db.RestoreBackup(ctx, name)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to restore backup", err), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Restored %d rows from backup %s", rows, name)), nil
+}
@@ -0,0 +1,417 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// filterItems is the JSON schema of a structured query filter
+var filterItems = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"column": map[string]any{"type": "string"},
+		"op":     map[string]any{"type": "string", "enum": db.FilterOperators},
+		"value":  map[string]any{"description": "A string, number or boolean; a list of them for in/not in; omitted for is null/is not null"},
+	},
+	"required": []string{"column", "op"},
+}
+
+// orderByItems is the JSON schema of a structured query sort key
+var orderByItems = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"column":    map[string]any{"type": "string"},
+		"direction": map[string]any{"type": "string", "enum": []string{"asc", "desc"}},
+	},
+	"required": []string{"column"},
+}
+
+// aggregationItems is the JSON schema of a structured aggregation
+var aggregationItems = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"func":   map[string]any{"type": "string", "enum": db.AggregateFunctions},
+		"column": map[string]any{"type": "string", "description": "The aggregated column, omit for count(*)"},
+		"alias":  map[string]any{"type": "string", "description": "The result column name, defaults to func_column"},
+	},
+	"required": []string{"func"},
+}
+
+// builtQueryResponse is the result of a structured query with the SQL it compiled to
+type builtQueryResponse struct {
+	SQL  string        `json:"sql"`
+	Args []interface{} `json:"args,omitempty"`
+	*queryResponse
+}
+
+// addBuilderTools registers the structured query tools
+func (s *PostgresMCPServer) addBuilderTools() {
+	selectRowsTool := mcp.NewTool("select_rows",
+		mcp.WithDescription("Look up rows of a table without writing SQL. Filters are combined with AND; table and column names are validated and values are bound as parameters."),
+		mcp.WithString("table",
+			mcp.Required(),
+			mcp.Description("The table to read"),
+		),
+		mcp.WithString("schema",
+			mcp.Description("The schema of the table"),
+			mcp.DefaultString("public"),
+		),
+		mcp.WithArray("columns",
+			mcp.Description("The columns to return, all by default"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithArray("filters",
+			mcp.Description("Conditions on columns, e.g. {\"column\": \"status\", \"op\": \"=\", \"value\": \"active\"}"),
+			mcp.Items(filterItems),
+		),
+		mcp.WithArray("order_by",
+			mcp.Description("Sort keys, e.g. {\"column\": \"created_at\", \"direction\": \"desc\"}"),
+			mcp.Items(orderByItems),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("The maximum number of rows to return, at most %d", db.MaxBuilderLimit)),
+			mcp.DefaultNumber(db.DefaultBuilderLimit),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(selectRowsTool, s.handleSelectRows)
+
+	aggregateTool := mcp.NewTool("aggregate",
+		mcp.WithDescription("Compute counts, sums, averages, minimums and maximums of a table, optionally grouped by columns, without writing SQL"),
+		mcp.WithString("table",
+			mcp.Required(),
+			mcp.Description("The table to aggregate"),
+		),
+		mcp.WithString("schema",
+			mcp.Description("The schema of the table"),
+			mcp.DefaultString("public"),
+		),
+		mcp.WithArray("group_by",
+			mcp.Description("The columns to group by"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithArray("aggregations",
+			mcp.Required(),
+			mcp.Description("The aggregates to compute, e.g. {\"func\": \"sum\", \"column\": \"amount\"}"),
+			mcp.Items(aggregationItems),
+		),
+		mcp.WithArray("filters",
+			mcp.Description("Conditions on table columns applied before grouping"),
+			mcp.Items(filterItems),
+		),
+		mcp.WithArray("having",
+			mcp.Description("Conditions on aggregation aliases applied after grouping, e.g. {\"column\": \"count\", \"op\": \">\", \"value\": 10}"),
+			mcp.Items(filterItems),
+		),
+		mcp.WithArray("order_by",
+			mcp.Description("Sort keys on group by columns or aggregation aliases"),
+			mcp.Items(orderByItems),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("The maximum number of groups to return, at most %d", db.MaxBuilderLimit)),
+			mcp.DefaultNumber(db.DefaultBuilderLimit),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(aggregateTool, s.handleAggregate)
+
+	joinQueryTool := mcp.NewTool("join_query",
+		mcp.WithDescription("Read rows from several related tables joined along their foreign keys, without writing the join conditions. Columns, filters and sort keys are referenced as table.column."),
+		mcp.WithArray("tables",
+			mcp.Required(),
+			mcp.Description("The tables to join, \"name\" resolved against the search_path like resolve_table or \"schema.name\"; each table is joined to an earlier one it has a foreign key with"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithArray("joins",
+			mcp.Description("Explicit join conditions replacing the foreign keys between a pair of tables, e.g. {\"left\": \"orders.customer_id\", \"right\": \"customers.id\"}"),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"left":  map[string]any{"type": "string"},
+					"right": map[string]any{"type": "string"},
+				},
+				"required": []string{"left", "right"},
+			}),
+		),
+		mcp.WithArray("columns",
+			mcp.Description("The columns to return as table.column or table.*, all by default"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithArray("filters",
+			mcp.Description("Conditions on table.column, combined with AND"),
+			mcp.Items(filterItems),
+		),
+		mcp.WithArray("order_by",
+			mcp.Description("Sort keys on table.column"),
+			mcp.Items(orderByItems),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("The maximum number of rows to return, at most %d", db.MaxBuilderLimit)),
+			mcp.DefaultNumber(db.DefaultBuilderLimit),
+		),
+		mcp.WithBoolean("sql_only",
+			mcp.Description("Return the generated SQL without running it"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(joinQueryTool, s.handleJoinQuery)
+
+	timeBucketTool := mcp.NewTool("time_bucket_query",
+		mcp.WithDescription("Aggregate a table into time buckets for charting, without writing SQL. Returns rows of {bucket, value} ordered by bucket; uses time_bucket when TimescaleDB is installed and date_trunc/date_bin otherwise."),
+		mcp.WithString("table",
+			mcp.Required(),
+			mcp.Description("The table to aggregate"),
+		),
+		mcp.WithString("schema",
+			mcp.Description("The schema of the table"),
+			mcp.DefaultString("public"),
+		),
+		mcp.WithString("time_column",
+			mcp.Required(),
+			mcp.Description("The timestamp column to bucket"),
+		),
+		mcp.WithString("interval",
+			mcp.Required(),
+			mcp.Description("The bucket width, e.g. \"1 hour\", \"15 minutes\" or \"1 day\""),
+		),
+		mcp.WithObject("aggregation",
+			mcp.Description("The aggregate computed per bucket, e.g. {\"func\": \"sum\", \"column\": \"amount\"}; count(*) by default"),
+			mcp.Properties(aggregationItems["properties"].(map[string]any)),
+		),
+		mcp.WithArray("filters",
+			mcp.Description("Conditions on table columns, combined with AND"),
+			mcp.Items(filterItems),
+		),
+		mcp.WithString("from",
+			mcp.Description("Only rows at or after this time, e.g. 2024-01-01 or 2024-01-01T00:00:00Z"),
+		),
+		mcp.WithString("to",
+			mcp.Description("Only rows before this time"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("The maximum number of buckets to return, the most recent are kept, at most %d", db.MaxBuilderLimit)),
+			mcp.DefaultNumber(db.DefaultBuilderLimit),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(timeBucketTool, s.handleTimeBucketQuery)
+}
+
+// handleSelectRows handles the select_rows tool
+func (s *PostgresMCPServer) handleSelectRows(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	params := db.SelectParams{
+		Schema:  stringArg(request, "schema", "public"),
+		Table:   stringArg(request, "table", ""),
+		Columns: stringSliceArg(request, "columns"),
+		Limit:   intArg(request, "limit", db.DefaultBuilderLimit),
+	}
+	if params.Table == "" {
+		return mcp.NewToolResultError("table is required"), nil
+	}
+
+	var err error
+	if params.Filters, err = filtersArg(request, "filters"); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if params.OrderBy, err = orderByArg(request, "order_by"); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	query, args, err := s.db.BuildSelect(params)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to build query", err), nil
+	}
+	return s.runBuiltQuery(ctx, query, args)
+}
+
+// handleAggregate handles the aggregate tool
+func (s *PostgresMCPServer) handleAggregate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	params := db.AggregateParams{
+		Schema:  stringArg(request, "schema", "public"),
+		Table:   stringArg(request, "table", ""),
+		GroupBy: stringSliceArg(request, "group_by"),
+		Limit:   intArg(request, "limit", db.DefaultBuilderLimit),
+	}
+	if params.Table == "" {
+		return mcp.NewToolResultError("table is required"), nil
+	}
+
+	items, _ := request.Params.Arguments["aggregations"].([]any)
+	for _, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("aggregations must be an array of objects"), nil
+		}
+		agg := db.Aggregation{}
+		agg.Func, _ = obj["func"].(string)
+		agg.Column, _ = obj["column"].(string)
+		agg.Alias, _ = obj["alias"].(string)
+		params.Aggregations = append(params.Aggregations, agg)
+	}
+
+	var err error
+	if params.Filters, err = filtersArg(request, "filters"); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if params.Having, err = filtersArg(request, "having"); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if params.OrderBy, err = orderByArg(request, "order_by"); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	query, args, err := s.db.BuildAggregate(params)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to build query", err), nil
+	}
+	return s.runBuiltQuery(ctx, query, args)
+}
+
+// handleJoinQuery handles the join_query tool
+func (s *PostgresMCPServer) handleJoinQuery(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	params := db.JoinParams{
+		Tables:  stringSliceArg(request, "tables"),
+		Columns: stringSliceArg(request, "columns"),
+		Limit:   intArg(request, "limit", db.DefaultBuilderLimit),
+	}
+
+	items, _ := request.Params.Arguments["joins"].([]any)
+	for _, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("joins must be an array of objects"), nil
+		}
+		join := db.JoinCondition{}
+		join.Left, _ = obj["left"].(string)
+		join.Right, _ = obj["right"].(string)
+		params.Joins = append(params.Joins, join)
+	}
+
+	var err error
+	if params.Filters, err = filtersArg(request, "filters"); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if params.OrderBy, err = orderByArg(request, "order_by"); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	query, args, err := s.db.BuildJoin(params)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to build query", err), nil
+	}
+
+	if sqlOnly, _ := request.Params.Arguments["sql_only"].(bool); sqlOnly {
+		resultJSON, err := json.MarshalIndent(builtQueryResponse{SQL: query, Args: args}, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+		}
+		return mcp.NewToolResultText(string(resultJSON)), nil
+	}
+	return s.runBuiltQuery(ctx, query, args)
+}
+
+// handleTimeBucketQuery handles the time_bucket_query tool
+func (s *PostgresMCPServer) handleTimeBucketQuery(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	params := db.TimeBucketParams{
+		Schema:      stringArg(request, "schema", "public"),
+		Table:       stringArg(request, "table", ""),
+		TimeColumn:  stringArg(request, "time_column", ""),
+		Interval:    stringArg(request, "interval", ""),
+		Aggregation: db.Aggregation{Func: "count"},
+		From:        stringArg(request, "from", ""),
+		To:          stringArg(request, "to", ""),
+		Limit:       intArg(request, "limit", db.DefaultBuilderLimit),
+	}
+	if params.Table == "" || params.TimeColumn == "" || params.Interval == "" {
+		return mcp.NewToolResultError("table, time_column and interval are required"), nil
+	}
+	if obj, ok := request.Params.Arguments["aggregation"].(map[string]any); ok {
+		if fn, _ := obj["func"].(string); fn != "" {
+			params.Aggregation.Func = fn
+		}
+		params.Aggregation.Column, _ = obj["column"].(string)
+	}
+
+	var err error
+	if params.Filters, err = filtersArg(request, "filters"); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	query, args, err := s.db.BuildTimeBucket(params)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to build query", err), nil
+	}
+	return s.runBuiltQuery(ctx, query, args)
+}
+
+// runBuiltQuery executes a compiled structured query and returns its rows with the SQL
+func (s *PostgresMCPServer) runBuiltQuery(ctx context.Context, query string, args []interface{}) (*mcp.CallToolResult, error) {
+	start := time.Now()
+	run, err := s.readOnlyQuery(ctx, query, args...)
+	s.history.record(sessionIDFromContext(ctx), query, args, start, run.Rows, err)
+	if err != nil {
+		return s.queryError("Failed to execute query", query, err), nil
+	}
+
+	resp, err := s.runResponse(ctx, run)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to build response", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(builtQueryResponse{SQL: query, Args: args, queryResponse: resp}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// filtersArg returns the structured filters of an array argument
+func filtersArg(request mcp.CallToolRequest, name string) ([]db.Filter, error) {
+	items, _ := request.Params.Arguments[name].([]any)
+	var filters []db.Filter
+	for _, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s must be an array of objects", name)
+		}
+		column, _ := obj["column"].(string)
+		op, _ := obj["op"].(string)
+		if column == "" || op == "" {
+			return nil, fmt.Errorf("each of %s needs a column and an op", name)
+		}
+		filters = append(filters, db.Filter{Column: column, Op: op, Value: obj["value"]})
+	}
+	return filters, nil
+}
+
+// orderByArg returns the sort keys of an array argument
+func orderByArg(request mcp.CallToolRequest, name string) ([]db.OrderBy, error) {
+	items, _ := request.Params.Arguments[name].([]any)
+	var keys []db.OrderBy
+	for _, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s must be an array of objects", name)
+		}
+		column, _ := obj["column"].(string)
+		if column == "" {
+			return nil, fmt.Errorf("each of %s needs a column", name)
+		}
+		direction, _ := obj["direction"].(string)
+		switch strings.ToLower(direction) {
+		case "", "asc":
+			keys = append(keys, db.OrderBy{Column: column})
+		case "desc":
+			keys = append(keys, db.OrderBy{Column: column, Descending: true})
+		default:
+			return nil, fmt.Errorf("invalid direction %q for %s, must be asc or desc", direction, column)
+		}
+	}
+	return keys, nil
+}
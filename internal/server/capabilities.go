@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// capabilitiesPath is the path component of the capabilities resource
+const capabilitiesPath = "capabilities"
+
+// extensionTools registers the tools of an extension
+type extensionTools struct {
+	extension string
+	add       func()
+}
+
+// capabilityState is the last probe of the server capabilities and the tools
+// registered for the installed extensions
+type capabilityState struct {
+	mu      sync.Mutex
+	current *db.Capabilities
+	// tools maps the extensions whose tools are registered to the tool names
+	tools map[string][]string
+}
+
+// capabilitiesResponse is the capabilities resource and the result of
+// refresh_capabilities
+type capabilitiesResponse struct {
+	*db.Capabilities
+	// ExtensionTools maps the installed extensions to the tools registered
+	// for them
+	ExtensionTools map[string][]string `json:"extension_tools"`
+	Added          []string            `json:"added_tools,omitempty"`
+	Removed        []string            `json:"removed_tools,omitempty"`
+}
+
+// extensionToolGroups returns the extensions with tools of their own
+func (s *PostgresMCPServer) extensionToolGroups() []extensionTools {
+	return []extensionTools{
+		{extension: "pg_stat_statements", add: s.addStatementTools},
+		{extension: "postgis", add: s.addSpatialTools},
+		{extension: "vector", add: s.addVectorTools},
+		{extension: "timescaledb", add: s.addTimescaleTools},
+		{extension: "citus", add: s.addCitusTools},
+		{extension: "pg_cron", add: s.addCronTools},
+	}
+}
+
+// addCapabilityTools registers the capabilities resource and the tool
+// probing them again
+func (s *PostgresMCPServer) addCapabilityTools() {
+	resource := mcp.NewResource(
+		fmt.Sprintf("%s/%s", s.db.ResourceBaseURL(), capabilitiesPath),
+		"Server capabilities",
+		mcp.WithResourceDescription("Server version, installed extensions, version-dependent features such as MERGE, and the tools registered for the extensions, as probed at startup or by refresh_capabilities"),
+		mcp.WithMIMEType("application/json"),
+	)
+	s.server.AddResource(resource, s.handleCapabilitiesResource)
+
+	refreshTool := mcp.NewTool("refresh_capabilities",
+		mcp.WithDescription("Probe the server version and installed extensions again, e.g. after CREATE EXTENSION, registering the tools of new extensions and removing those of dropped ones"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(refreshTool, s.handleRefreshCapabilities)
+}
+
+// addExtensionTools probes the capabilities and registers the tools of the
+// installed extensions
+func (s *PostgresMCPServer) addExtensionTools() {
+	if _, err := s.probeCapabilities(context.Background()); err != nil {
+		log.Printf("failed to probe server capabilities: %v", err)
+	}
+}
+
+// probeCapabilities probes the server and syncs the extension tools with the
+// installed extensions. Clients are notified of the changed tools.
+func (s *PostgresMCPServer) probeCapabilities(ctx context.Context) (*capabilitiesResponse, error) {
+	caps, err := s.db.GetCapabilities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.capabilities.mu.Lock()
+	defer s.capabilities.mu.Unlock()
+	s.capabilities.current = caps
+	if s.capabilities.tools == nil {
+		s.capabilities.tools = map[string][]string{}
+	}
+
+	resp := &capabilitiesResponse{Capabilities: caps}
+	for _, group := range s.extensionToolGroups() {
+		names, registered := s.capabilities.tools[group.extension]
+		switch installed := caps.HasExtension(group.extension); {
+		case installed && !registered:
+			added := s.registerTools(group.add)
+			s.capabilities.tools[group.extension] = added
+			resp.Added = append(resp.Added, added...)
+		case !installed && registered:
+			s.server.DeleteTools(names...)
+			for _, name := range names {
+				delete(s.toolNames, name)
+			}
+			delete(s.capabilities.tools, group.extension)
+			resp.Removed = append(resp.Removed, names...)
+		}
+	}
+	resp.ExtensionTools = s.extensionToolNames()
+	return resp, nil
+}
+
+// registerTools calls add and returns the names of the tools it registered
+func (s *PostgresMCPServer) registerTools(add func()) []string {
+	before := make(map[string]bool, len(s.toolNames))
+	for name := range s.toolNames {
+		before[name] = true
+	}
+	add()
+
+	added := []string{}
+	for name := range s.toolNames {
+		if !before[name] {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	return added
+}
+
+// extensionToolNames returns a copy of the tools registered per extension,
+// the caller holds the lock
+func (s *PostgresMCPServer) extensionToolNames() map[string][]string {
+	tools := make(map[string][]string, len(s.capabilities.tools))
+	for ext, names := range s.capabilities.tools {
+		tools[ext] = append([]string{}, names...)
+	}
+	return tools
+}
+
+// handleCapabilitiesResource returns the last probe of the capabilities,
+// probing them when the database was not reachable before
+func (s *PostgresMCPServer) handleCapabilitiesResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	s.capabilities.mu.Lock()
+	resp := &capabilitiesResponse{Capabilities: s.capabilities.current, ExtensionTools: s.extensionToolNames()}
+	s.capabilities.mu.Unlock()
+	if resp.Capabilities == nil {
+		var err error
+		if resp, err = s.probeCapabilities(ctx); err != nil {
+			return nil, fmt.Errorf("failed to probe server capabilities: %w", err)
+		}
+		resp.Added, resp.Removed = nil, nil
+	}
+
+	capsJSON, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal capabilities to JSON: %w", err)
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "application/json",
+			Text:     string(capsJSON),
+		},
+	}, nil
+}
+
+// handleRefreshCapabilities handles the refresh_capabilities tool
+func (s *PostgresMCPServer) handleRefreshCapabilities(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	resp, err := s.probeCapabilities(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to probe server capabilities", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/cdc"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// The changes path component for resource URIs
+const changesPath = "changes"
+
+// cdcConfig configures change data capture from a logical replication slot
+type cdcConfig struct {
+	slot       string
+	interval   time.Duration
+	retention  time.Duration
+	createSlot bool
+}
+
+// WithCDC enables change data capture from a wal2json logical replication
+// slot. The slot is polled every interval and changes are kept for the
+// retention period. When createSlot is set the slot is created if missing.
+func WithCDC(slot string, interval, retention time.Duration, createSlot bool) Option {
+	return func(s *PostgresMCPServer) {
+		s.cdcConfig = &cdcConfig{
+			slot:       slot,
+			interval:   interval,
+			retention:  retention,
+			createSlot: createSlot,
+		}
+	}
+}
+
+// setupCDC starts the slot poller and registers the change resources and tool
+func (s *PostgresMCPServer) setupCDC() error {
+	if s.cdcConfig.createSlot {
+		if err := s.db.EnsureLogicalSlot(s.cdcConfig.slot, cdc.Plugin); err != nil {
+			return err
+		}
+	}
+
+	s.changes = cdc.NewBuffer(s.cdcConfig.retention)
+	poller := cdc.NewPoller(s.db, s.cdcConfig.slot, s.cdcConfig.interval, s.changes, s.notifyChanges)
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopCDC = cancel
+	go poller.Run(ctx)
+
+	template := mcp.NewResourceTemplate(
+		fmt.Sprintf("%s/{table}/%s", s.db.ResourceBaseURL(), changesPath),
+		"Recent table changes",
+		mcp.WithTemplateDescription(fmt.Sprintf("Row changes captured from replication slot %s during the last %s. table is \"name\" for the public schema or \"schema.name\".", s.cdcConfig.slot, s.cdcConfig.retention)),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+	s.server.AddResourceTemplate(template, s.handleChangesResource)
+
+	recentChangesTool := mcp.NewTool("recent_changes",
+		mcp.WithDescription(fmt.Sprintf("Get the row changes (insert, update, delete, truncate) of a table captured from the replication slot, kept for %s", s.cdcConfig.retention)),
+		mcp.WithString("table",
+			mcp.Description("The table as \"name\" for the public schema or \"schema.name\". Omit to list the tables with recent changes."),
+		),
+		mcp.WithNumber("minutes",
+			mcp.Description("Only return changes from the last N minutes"),
+		),
+	)
+	s.addTool(recentChangesTool, s.handleRecentChanges)
+
+	return nil
+}
+
+// handleChangesResource returns the recent changes of the table in the URI
+func (s *PostgresMCPServer) handleChangesResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	var table string
+	switch v := request.Params.Arguments["table"].(type) {
+	case []string:
+		if len(v) > 0 {
+			table = v[0]
+		}
+	case string:
+		table = v
+	}
+	if table == "" {
+		return nil, fmt.Errorf("table is required")
+	}
+
+	changes := s.changes.Recent(qualifyTable(table), time.Time{})
+	changesJSON, err := json.MarshalIndent(changes, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal changes to JSON: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "application/json",
+			Text:     string(changesJSON),
+		},
+	}, nil
+}
+
+// handleRecentChanges handles the recent_changes tool
+func (s *PostgresMCPServer) handleRecentChanges(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	table := stringArg(request, "table", "")
+
+	var result interface{}
+	if table == "" {
+		result = map[string]interface{}{"tables": s.changes.Tables()}
+	} else {
+		var since time.Time
+		if minutes := intArg(request, "minutes", 0); minutes > 0 {
+			since = time.Now().Add(-time.Duration(minutes) * time.Minute)
+		}
+		result = s.changes.Recent(qualifyTable(table), since)
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// notifyChanges tells the clients that the change resources of the tables were updated
+func (s *PostgresMCPServer) notifyChanges(tables []string) {
+	for _, table := range tables {
+		uri := fmt.Sprintf("%s/%s/%s", s.db.ResourceBaseURL(), strings.TrimPrefix(table, "public."), changesPath)
+		s.server.SendNotificationToAllClients(mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+	}
+	log.Printf("cdc: captured changes for %s", strings.Join(tables, ", "))
+}
+
+// qualifyTable returns the table name qualified with the public schema if needed
+func qualifyTable(table string) string {
+	if strings.Contains(table, ".") {
+		return table
+	}
+	return "public." + table
+}
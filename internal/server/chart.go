@@ -0,0 +1,319 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// maxChartRows bounds the rows of a chart, more are dropped
+	maxChartRows = 5000
+	// vegaLiteSchema is the Vega-Lite version of the generated specs
+	vegaLiteSchema = "https://vega.github.io/schema/vega-lite/v5.json"
+)
+
+// chartTypes are the chart types of chart_data
+var chartTypes = []string{"line", "bar", "area", "scatter", "pie"}
+
+// chartAxis is the x column of a chart with its Vega-Lite field type
+type chartAxis struct {
+	Column string `json:"column"`
+	Type   string `json:"type"`
+}
+
+// chartSeries is a named series of y values aligned with the labels
+type chartSeries struct {
+	Name string     `json:"name"`
+	Data []*float64 `json:"data"`
+}
+
+// chartSpec is the simple chart format of chart_data
+type chartSpec struct {
+	Type      string        `json:"type"`
+	Title     string        `json:"title,omitempty"`
+	X         chartAxis     `json:"x"`
+	Labels    []any         `json:"labels"`
+	Series    []chartSeries `json:"series"`
+	Truncated bool          `json:"truncated,omitempty"`
+}
+
+// addChartTools registers the chart_data tool, which runs free-form SQL
+func (s *PostgresMCPServer) addChartTools() {
+	if s.restrictSQL {
+		return
+	}
+
+	chartTool := mcp.NewTool("chart_data",
+		mcp.WithDescription("Run a read-only query and shape its rows into chart data for plotting: a simple spec of labels and series, or a Vega-Lite specification with the data inlined"),
+		mcp.WithString("sql",
+			mcp.Required(),
+			mcp.Description("The SQL query to chart"),
+		),
+		mcp.WithString("x",
+			mcp.Required(),
+			mcp.Description("The column of the x axis, or the slice labels of a pie chart"),
+		),
+		mcp.WithArray("y",
+			mcp.Required(),
+			mcp.Description("The numeric columns plotted as series"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithString("series",
+			mcp.Description("A column whose values split a single y column into one series each, e.g. region for SELECT day, region, sum(amount)"),
+		),
+		mcp.WithString("chart",
+			mcp.Enum(chartTypes...),
+			mcp.DefaultString("line"),
+		),
+		mcp.WithString("format",
+			mcp.Description("simple for {labels, series}, vega-lite for a Vega-Lite v5 specification"),
+			mcp.Enum("simple", "vega-lite"),
+			mcp.DefaultString("simple"),
+		),
+		mcp.WithString("title",
+			mcp.Description("The chart title"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(chartTool, s.handleChartData)
+}
+
+// handleChartData handles the chart_data tool
+func (s *PostgresMCPServer) handleChartData(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	sql := stringArg(request, "sql", "")
+	x := stringArg(request, "x", "")
+	ys := stringSliceArg(request, "y")
+	seriesColumn := stringArg(request, "series", "")
+	chart := stringArg(request, "chart", "line")
+	format := stringArg(request, "format", "simple")
+	if sql == "" || x == "" || len(ys) == 0 {
+		return mcp.NewToolResultError("sql, x and y are required"), nil
+	}
+	if !slices.Contains(chartTypes, chart) {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid chart %q, must be one of %s", chart, strings.Join(chartTypes, ", "))), nil
+	}
+	if format != "simple" && format != "vega-lite" {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid format %q, use simple or vega-lite", format)), nil
+	}
+	if seriesColumn != "" && len(ys) > 1 {
+		return mcp.NewToolResultError("series splits a single y column, pass one y column with it"), nil
+	}
+	if chart == "pie" && (len(ys) > 1 || seriesColumn != "") {
+		return mcp.NewToolResultError("A pie chart takes one y column and no series"), nil
+	}
+
+	start := time.Now()
+	run, err := s.readOnlyQuery(ctx, sql)
+	s.history.record(sessionIDFromContext(ctx), sql, nil, start, run.Rows, err)
+	if err != nil {
+		return s.queryError("Failed to execute query", sql, err), nil
+	}
+
+	types := map[string]string{}
+	for _, col := range run.Columns {
+		types[col.Name] = col.Type
+	}
+	for _, name := range append([]string{x, seriesColumn}, ys...) {
+		if _, ok := types[name]; name != "" && !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("The query has no column %q", name)), nil
+		}
+	}
+
+	rows := run.Rows
+	truncated := len(rows) > maxChartRows
+	if truncated {
+		rows = rows[:maxChartRows]
+	}
+	title := stringArg(request, "title", "")
+	axis := chartAxis{Column: x, Type: vegaFieldType(types[x])}
+	if chart == "pie" {
+		axis.Type = "nominal"
+	}
+
+	var out any
+	if format == "vega-lite" {
+		out, err = vegaLiteSpec(rows, axis, ys, seriesColumn, chart, title, truncated)
+	} else {
+		out, err = simpleChartSpec(rows, axis, ys, seriesColumn, chart, title, truncated)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// simpleChartSpec shapes rows into labels and series aligned with them. With
+// a series column, each of its values becomes a series and the points a
+// series has no row for are null.
+func simpleChartSpec(rows []map[string]any, axis chartAxis, ys []string, seriesColumn, chart, title string, truncated bool) (*chartSpec, error) {
+	spec := &chartSpec{Type: chart, Title: title, X: axis, Labels: []any{}, Series: []chartSeries{}}
+	labelIndex := map[string]int{}
+	seriesIndex := map[string]int{}
+	if seriesColumn == "" {
+		for _, y := range ys {
+			seriesIndex[y] = len(spec.Series)
+			spec.Series = append(spec.Series, chartSeries{Name: y})
+		}
+	}
+
+	for _, row := range rows {
+		label := chartValue(row[axis.Column])
+		key := fmt.Sprint(label)
+		i, ok := labelIndex[key]
+		if !ok {
+			i = len(spec.Labels)
+			labelIndex[key] = i
+			spec.Labels = append(spec.Labels, label)
+			for j := range spec.Series {
+				spec.Series[j].Data = append(spec.Series[j].Data, nil)
+			}
+		}
+
+		for _, y := range ys {
+			name := y
+			if seriesColumn != "" {
+				name = fmt.Sprint(chartValue(row[seriesColumn]))
+			}
+			j, ok := seriesIndex[name]
+			if !ok {
+				j = len(spec.Series)
+				seriesIndex[name] = j
+				spec.Series = append(spec.Series, chartSeries{Name: name, Data: make([]*float64, len(spec.Labels))})
+			}
+			value, err := chartNumber(row[y])
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", y, err)
+			}
+			spec.Series[j].Data[i] = value
+		}
+	}
+	spec.Truncated = truncated
+	return spec, nil
+}
+
+// vegaLiteSpec returns a Vega-Lite specification with the rows inlined.
+// Several y columns are folded into a series field to color them.
+func vegaLiteSpec(rows []map[string]any, axis chartAxis, ys []string, seriesColumn, chart, title string, truncated bool) (map[string]any, error) {
+	values := make([]map[string]any, 0, len(rows))
+	for _, row := range rows {
+		value := map[string]any{axis.Column: chartValue(row[axis.Column])}
+		if seriesColumn != "" {
+			value[seriesColumn] = chartValue(row[seriesColumn])
+		}
+		for _, y := range ys {
+			n, err := chartNumber(row[y])
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", y, err)
+			}
+			value[y] = n
+		}
+		values = append(values, value)
+	}
+
+	spec := map[string]any{
+		"$schema": vegaLiteSchema,
+		"data":    map[string]any{"values": values},
+	}
+	if title != "" {
+		spec["title"] = title
+	}
+	if truncated {
+		spec["description"] = fmt.Sprintf("The first %d rows of the query", maxChartRows)
+	}
+
+	if chart == "pie" {
+		spec["mark"] = map[string]any{"type": "arc", "tooltip": true}
+		spec["encoding"] = map[string]any{
+			"theta": map[string]any{"field": ys[0], "type": "quantitative"},
+			"color": map[string]any{"field": axis.Column, "type": "nominal"},
+		}
+		return spec, nil
+	}
+
+	mark := chart
+	if chart == "scatter" {
+		mark = "point"
+	}
+	spec["mark"] = map[string]any{"type": mark, "tooltip": true}
+	y := map[string]any{"field": ys[0], "type": "quantitative"}
+	encoding := map[string]any{
+		"x": map[string]any{"field": axis.Column, "type": axis.Type},
+		"y": y,
+	}
+	switch {
+	case seriesColumn != "":
+		encoding["color"] = map[string]any{"field": seriesColumn, "type": "nominal"}
+	case len(ys) > 1:
+		spec["transform"] = []any{map[string]any{"fold": ys, "as": []string{"series", "value"}}}
+		y["field"] = "value"
+		encoding["color"] = map[string]any{"field": "series", "type": "nominal"}
+	}
+	spec["encoding"] = encoding
+	return spec, nil
+}
+
+// vegaFieldType maps a column type to the Vega-Lite type of its axis
+func vegaFieldType(typeName string) string {
+	switch strings.ToLower(typeName) {
+	case "date", "timestamp", "timestamptz", "time", "timetz":
+		return "temporal"
+	case "int2", "int4", "int8", "float4", "float8", "numeric", "money", "oid":
+		return "quantitative"
+	default:
+		return "nominal"
+	}
+}
+
+// chartValue renders times as RFC 3339 strings, other values are kept
+func chartValue(value any) any {
+	if t, ok := value.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+	return value
+}
+
+// chartNumber converts a y value to a number, NULL to nil
+func chartNumber(value any) (*float64, error) {
+	var n float64
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case int64:
+		n = float64(v)
+	case int32:
+		n = float64(v)
+	case int:
+		n = float64(v)
+	case float64:
+		n = v
+	case float32:
+		n = float64(v)
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("value %q is not a number", v)
+		}
+		n = f
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("value %q is not a number", v)
+		}
+		n = f
+	default:
+		return nil, fmt.Errorf("value %v of type %T is not a number", v, v)
+	}
+	return &n, nil
+}
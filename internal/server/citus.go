@@ -0,0 +1,31 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// addCitusTools registers the Citus tools
+func (s *PostgresMCPServer) addCitusTools() {
+	listDistributedTablesTool := mcp.NewTool("list_distributed_tables",
+		mcp.WithDescription("List the Citus distributed and reference tables with their distribution column, shard count, cluster-wide size and colocation group, and the shards held by each worker. Tables in the same colocation group can be joined on their distribution columns without moving data between workers."),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(listDistributedTablesTool, s.handleListDistributedTables)
+}
+
+// handleListDistributedTables handles the list_distributed_tables tool
+func (s *PostgresMCPServer) handleListDistributedTables(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	cluster, err := s.db.GetCitusCluster()
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list distributed tables", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(cluster, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
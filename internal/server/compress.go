@@ -0,0 +1,195 @@
+package server
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultCompressionLevel is the gzip and deflate level of HTTP responses
+	DefaultCompressionLevel = gzip.DefaultCompression
+	// compressMinBytes is the size below which complete responses are sent
+	// uncompressed, as compression would not pay off
+	compressMinBytes = 1024
+)
+
+// WithCompression compresses the HTTP responses of the SSE server with gzip
+// or deflate, whichever the client accepts, at the given level from 1 (best
+// speed) to 9 (best compression), -1 for the default. 0 disables compression.
+func WithCompression(level int) Option {
+	return func(s *PostgresMCPServer) {
+		s.compressionLevel = level
+	}
+}
+
+// compressHandler compresses the responses of next when the client accepts
+// gzip or deflate. The event stream is compressed too: every flush of the
+// SSE server flushes the compressor, so events are not held back.
+func compressHandler(next http.Handler, level int) http.Handler {
+	if level == gzip.NoCompression {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := acceptedEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, level: level}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// acceptedEncoding returns the encoding of an Accept-Encoding header the
+// responses are compressed with, gzip before deflate, empty for none. The
+// wildcard only stands for the encodings not refused with q=0.
+func acceptedEncoding(header string) string {
+	accepted := map[string]bool{}
+	refused := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				refused[name] = true
+				continue
+			}
+		}
+		accepted[name] = true
+	}
+	for _, encoding := range []string{"gzip", "deflate"} {
+		if !refused[encoding] && (accepted[encoding] || accepted["*"]) {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// compressWriter compresses a response. The first bytes are buffered until
+// there are enough of them to compress, the handler flushes or the response
+// ends; small complete responses are sent as they are.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	level    int
+	status   int
+	buf      []byte
+	started  bool
+	// compressor is set once the response is compressed
+	compressor interface {
+		io.WriteCloser
+		Flush() error
+	}
+}
+
+// WriteHeader implements http.ResponseWriter, the header is sent once the
+// response is known to be compressed or not
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.status == 0 {
+		cw.status = status
+	}
+}
+
+// Write implements http.ResponseWriter
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.started {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) < compressMinBytes {
+			return len(p), nil
+		}
+		if err := cw.start(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if cw.compressor != nil {
+		return cw.compressor.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush implements http.Flusher. A flushed response is a stream, which is
+// compressed whatever the size of its first bytes.
+func (cw *compressWriter) Flush() {
+	if !cw.started {
+		if err := cw.start(true); err != nil {
+			return
+		}
+	}
+	if cw.compressor != nil {
+		if err := cw.compressor.Flush(); err != nil {
+			return
+		}
+	}
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// start sends the header and the buffered bytes, compressed if compress is
+// set and the response has a body that is not encoded yet
+func (cw *compressWriter) start(compress bool) error {
+	cw.started = true
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	header := cw.ResponseWriter.Header()
+	if cw.status < http.StatusOK || cw.status == http.StatusNoContent || cw.status == http.StatusNotModified || header.Get("Content-Encoding") != "" {
+		compress = false
+	}
+
+	if compress {
+		header.Set("Content-Encoding", cw.encoding)
+		header.Del("Content-Length")
+		var err error
+		if cw.encoding == "gzip" {
+			cw.compressor, err = gzip.NewWriterLevel(cw.ResponseWriter, cw.level)
+		} else {
+			cw.compressor, err = flate.NewWriter(cw.ResponseWriter, cw.level)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if cw.compressor != nil {
+		_, err := cw.compressor.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+// close ends the response, sending a small one uncompressed
+func (cw *compressWriter) close() {
+	if !cw.started {
+		if cw.status == 0 && len(cw.buf) == 0 {
+			// The handler wrote nothing, net/http sends the default response
+			return
+		}
+		if err := cw.start(false); err != nil {
+			return
+		}
+	}
+	if cw.compressor != nil {
+		cw.compressor.Close()
+	}
+}
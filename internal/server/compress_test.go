@@ -0,0 +1,143 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAcceptedEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "", want: ""},
+		{header: "gzip", want: "gzip"},
+		{header: "deflate", want: "deflate"},
+		{header: "deflate, gzip;q=0.5", want: "gzip"},
+		{header: "GZIP", want: "gzip"},
+		{header: "br", want: ""},
+		{header: "*", want: "gzip"},
+		{header: "gzip;q=0", want: ""},
+		{header: "gzip;q=0, deflate", want: "deflate"},
+		{header: "gzip;q=0, *", want: "deflate"},
+		{header: "gzip;q=0, deflate;q=0, *", want: ""},
+		{header: "*;q=0", want: ""},
+		{header: "identity", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := acceptedEncoding(tt.header); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// serveCompressed runs handler behind compressHandler for a request
+// accepting gzip
+func serveCompressed(method string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	compressHandler(handler, DefaultCompressionLevel).ServeHTTP(w, r)
+	return w
+}
+
+func TestCompressHandler(t *testing.T) {
+	t.Run("small response sent as is", func(t *testing.T) {
+		w := serveCompressed(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "small")
+		})
+		if got := w.Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("got Content-Encoding %q, want none", got)
+		}
+		if got := w.Body.String(); got != "small" {
+			t.Errorf("got body %q, want %q", got, "small")
+		}
+	})
+
+	t.Run("large response compressed", func(t *testing.T) {
+		body := strings.Repeat("row ", compressMinBytes)
+		w := serveCompressed(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", "4096")
+			io.WriteString(w, body)
+		})
+		if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+			t.Fatalf("got Content-Encoding %q, want gzip", got)
+		}
+		if got := w.Header().Get("Content-Length"); got != "" {
+			t.Errorf("got Content-Length %q, want none", got)
+		}
+		if got := gunzip(t, w.Body.Bytes()); got != body {
+			t.Errorf("got %d decompressed bytes, want %d", len(got), len(body))
+		}
+	})
+
+	t.Run("event stream flushed", func(t *testing.T) {
+		event := "event: message\ndata: {}\n\n"
+		var flushed []byte
+		serveCompressed(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(w, event)
+			w.(http.Flusher).Flush()
+			flushed = bytes.Clone(w.(*compressWriter).ResponseWriter.(*httptest.ResponseRecorder).Body.Bytes())
+		})
+		if len(flushed) == 0 {
+			t.Fatal("got nothing sent on flush, want the compressed event")
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(flushed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]byte, len(event))
+		if _, err := io.ReadFull(zr, got); err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != event {
+			t.Errorf("got %q, want %q", got, event)
+		}
+	})
+
+	t.Run("no content", func(t *testing.T) {
+		w := serveCompressed(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+		if w.Code != http.StatusNoContent {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusNoContent)
+		}
+		if got := w.Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("got Content-Encoding %q, want none", got)
+		}
+	})
+
+	t.Run("head", func(t *testing.T) {
+		w := serveCompressed(http.MethodHead, func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := w.(*compressWriter); ok {
+				t.Error("got a compressing writer for HEAD")
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+		if got := w.Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("got Content-Encoding %q, want none", got)
+		}
+	})
+}
+
+// gunzip decompresses a gzip body
+func gunzip(t *testing.T, body []byte) string {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(got)
+}
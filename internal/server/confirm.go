@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// destructiveStatement is a statement waiting for the client's confirmation
+type destructiveStatement struct {
+	statementEstimate
+	Reason string `json:"reason"`
+}
+
+// confirmationRequest is returned instead of running destructive statements
+type confirmationRequest struct {
+	ConfirmationRequired bool                   `json:"confirmation_required"`
+	Statements           []destructiveStatement `json:"statements"`
+	ConfirmationToken    string                 `json:"confirmation_token"`
+	Hint                 string                 `json:"hint"`
+}
+
+// confirmations signs the confirmation tokens of destructive statements
+type confirmations struct {
+	mu  sync.Mutex
+	key []byte
+}
+
+// WithoutDestructiveConfirmation runs DROP, TRUNCATE, and DELETE or UPDATE
+// without a WHERE clause without asking the client to confirm them first.
+//
+// The confirmation is a token returned to the calling client, not a prompt
+// shown to the user: an agent can repeat the call with the token without a
+// human seeing it. It guards against accidents, not against a client that
+// runs without supervision.
+func WithoutDestructiveConfirmation() Option {
+	return func(s *PostgresMCPServer) {
+		s.skipConfirmation = true
+	}
+}
+
+// confirmDestructive returns a confirmation request when the SQL holds
+// destructive statements and the call does not carry the confirmation
+// token of that SQL, nil when the SQL can run. The token is bound to the
+// session and the exact SQL, so the confirmed statements are the ones run.
+//
+// The token goes back to the caller, so it gives no guarantee that a human
+// confirmed: MCP elicitation would let the server ask the user directly,
+// but the client library in use does not support it yet.
+func (s *PostgresMCPServer) confirmDestructive(ctx context.Context, request mcp.CallToolRequest, sql string) *mcp.CallToolResult {
+	if s.skipConfirmation {
+		return nil
+	}
+
+	var destructive []destructiveStatement
+	for _, stmt := range db.SplitStatements(sql) {
+		if reason := db.DestructiveReason(stmt); reason != "" {
+			destructive = append(destructive, destructiveStatement{
+				statementEstimate: s.estimateWrite(ctx, stmt),
+				Reason:            reason,
+			})
+		}
+	}
+	if len(destructive) == 0 {
+		return nil
+	}
+
+	token, err := s.confirmations.token(sessionIDFromContext(ctx), sql)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to create confirmation token", err)
+	}
+	if given := stringArg(request, "confirmation_token", ""); given != "" && hmac.Equal([]byte(given), []byte(token)) {
+		return nil
+	}
+
+	resultJSON, err := json.MarshalIndent(confirmationRequest{
+		ConfirmationRequired: true,
+		Statements:           destructive,
+		ConfirmationToken:    token,
+		Hint:                 "Nothing was run. Show these statements and their impact to the user and, once confirmed, repeat the call with the same sql and this confirmation_token.",
+	}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err)
+	}
+	return mcp.NewToolResultError(string(resultJSON))
+}
+
+// token returns the confirmation token of SQL run by a session, signed with
+// a key generated on first use
+func (c *confirmations) token(sessionID, sql string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.key == nil {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return "", fmt.Errorf("failed to generate confirmation key: %w", err)
+		}
+		c.key = key
+	}
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(sessionID))
+	mac.Write([]byte{0})
+	mac.Write([]byte(sql))
+	return hex.EncodeToString(mac.Sum(nil))[:32], nil
+}
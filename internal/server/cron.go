@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultCronRuns is the number of runs listed by list_cron_job_runs
+const defaultCronRuns = 20
+
+// addCronTools registers the pg_cron tools. Scheduling runs SQL later as
+// the job's user, so it needs write access and free-form SQL, and is left
+// out when writes need approval.
+func (s *PostgresMCPServer) addCronTools() {
+	listJobsTool := mcp.NewTool("list_cron_jobs",
+		mcp.WithDescription("List the pg_cron jobs with their schedule, command, database, user, whether they are active, their run and failure counts and the status, message and duration of their last run"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(listJobsTool, s.handleListCronJobs)
+
+	listRunsTool := mcp.NewTool("list_cron_job_runs",
+		mcp.WithDescription("List the most recent runs of a pg_cron job with their status, return message, start time and duration"),
+		mcp.WithNumber("job_id",
+			mcp.Required(),
+			mcp.Description("The job ID from list_cron_jobs"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description(fmt.Sprintf("The number of runs, at most %d", db.MaxCronRuns)),
+			mcp.DefaultNumber(defaultCronRuns),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(listRunsTool, s.handleListCronJobRuns)
+
+	if !s.allowWrite {
+		return
+	}
+	if !s.restrictSQL && s.writeApproval == nil {
+		scheduleTool := mcp.NewTool("schedule_cron_job",
+			mcp.WithDescription("Schedule an SQL command with pg_cron; a job of the same name is replaced. The command runs in this database as the current user."),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("The job name"),
+			),
+			mcp.WithString("schedule",
+				mcp.Required(),
+				mcp.Description("A cron schedule in UTC, e.g. \"0 3 * * *\" for 3am daily, or an interval such as \"30 seconds\""),
+			),
+			mcp.WithString("command",
+				mcp.Required(),
+				mcp.Description("The SQL command to run"),
+			),
+			mcp.WithString("confirmation_token",
+				mcp.Description("The token returned when the command is a DROP, TRUNCATE, or DELETE or UPDATE without WHERE, passed once the user confirmed it"),
+			),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(true),
+		)
+		s.addTool(scheduleTool, s.handleScheduleCronJob)
+	}
+
+	for _, active := range []bool{true, false} {
+		name, verb := "enable_cron_job", "Enable"
+		if !active {
+			name, verb = "disable_cron_job", "Disable"
+		}
+		tool := mcp.NewTool(name,
+			mcp.WithDescription(verb+" a pg_cron job, keeping its schedule and run history"),
+			mcp.WithNumber("job_id",
+				mcp.Required(),
+				mcp.Description("The job ID from list_cron_jobs"),
+			),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+		)
+		s.addTool(tool, s.cronJobActivator(active))
+	}
+}
+
+// handleListCronJobs handles the list_cron_jobs tool
+func (s *PostgresMCPServer) handleListCronJobs(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobs, err := s.db.ListCronJobs()
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list pg_cron jobs", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleListCronJobRuns handles the list_cron_job_runs tool
+func (s *PostgresMCPServer) handleListCronJobRuns(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobID := intArg(request, "job_id", 0)
+	if jobID <= 0 {
+		return mcp.NewToolResultError("job_id is required"), nil
+	}
+	runs, err := s.db.CronJobRuns(int64(jobID), intArg(request, "limit", defaultCronRuns))
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list pg_cron job runs", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleScheduleCronJob handles the schedule_cron_job tool
+func (s *PostgresMCPServer) handleScheduleCronJob(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := stringArg(request, "name", "")
+	schedule := stringArg(request, "schedule", "")
+	command := stringArg(request, "command", "")
+	if name == "" || schedule == "" || command == "" {
+		return mcp.NewToolResultError("name, schedule and command are required"), nil
+	}
+	if confirm := s.confirmDestructive(ctx, request, command); confirm != nil {
+		return confirm, nil
+	}
+	log.Printf("scheduling pg_cron job %s (%s): %s", name, schedule, command)
+
+	jobID, err := s.db.ScheduleCronJob(ctx, name, schedule, command)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to schedule job", err), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Scheduled pg_cron job %s with ID %d", name, jobID)), nil
+}
+
+// cronJobActivator returns the handler of enable_cron_job or disable_cron_job
+func (s *PostgresMCPServer) cronJobActivator(active bool) func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		jobID := intArg(request, "job_id", 0)
+		if jobID <= 0 {
+			return mcp.NewToolResultError("job_id is required"), nil
+		}
+		state := "enabled"
+		if !active {
+			state = "disabled"
+		}
+		log.Printf("pg_cron job %d %s", jobID, state)
+
+		if err := s.db.SetCronJobActive(ctx, int64(jobID), active); err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to change job", err), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf("pg_cron job %d %s", jobID, state)), nil
+	}
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// columnItems is the JSON schema of a column of the DDL tools
+var columnItems = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"name":        map[string]any{"type": "string"},
+		"type":        map[string]any{"type": "string", "description": "A PostgreSQL type, e.g. bigint, text, varchar(100), timestamptz, numeric(12,2), jsonb"},
+		"not_null":    map[string]any{"type": "boolean"},
+		"primary_key": map[string]any{"type": "boolean", "description": "Part of the primary key; several columns make a composite key"},
+		"unique":      map[string]any{"type": "boolean"},
+		"default":     map[string]any{"type": "string", "description": "An SQL expression, e.g. now() or 'pending'"},
+		"references":  map[string]any{"type": "string", "description": "The referenced primary key or unique column, table.column or schema.table.column"},
+	},
+	"required": []string{"name", "type"},
+}
+
+// ddlResponse is the result of the DDL tools
+type ddlResponse struct {
+	SQL      string          `json:"sql"`
+	Warnings []string        `json:"warnings,omitempty"`
+	Executed bool            `json:"executed"`
+	Result   *scriptResponse `json:"result,omitempty"`
+	Hint     string          `json:"hint,omitempty"`
+}
+
+// addDDLTools registers the tools generating DDL from typed arguments. The
+// DDL is returned for review and only runs with execute in write mode.
+func (s *PostgresMCPServer) addDDLTools() {
+	executeDescription := "Run the statement instead of only returning it for review; needs -allow_write"
+	if s.writeApproval != nil {
+		executeDescription = "Not available: writes need approval, pass the returned sql to propose_write"
+	}
+
+	createTableTool := mcp.NewTool("create_table",
+		mcp.WithDescription("Generate a CREATE TABLE statement from typed column definitions, checked against the schema: the table must not exist, types must exist, defaults must be valid expressions of the column type and referenced columns must be primary keys or unique. Returns the DDL with warnings for review."),
+		mcp.WithString("table",
+			mcp.Required(),
+			mcp.Description("The table to create"),
+		),
+		mcp.WithString("schema",
+			mcp.Description("The schema of the table"),
+			mcp.DefaultString("public"),
+		),
+		mcp.WithArray("columns",
+			mcp.Required(),
+			mcp.Description("The columns, e.g. {\"name\": \"id\", \"type\": \"bigint\", \"primary_key\": true}"),
+			mcp.Items(columnItems),
+		),
+		mcp.WithBoolean("execute",
+			mcp.Description(executeDescription),
+		),
+		mcp.WithReadOnlyHintAnnotation(!s.allowWrite),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+	s.addTool(createTableTool, s.handleCreateTable)
+
+	addColumnTool := mcp.NewTool("add_column",
+		mcp.WithDescription("Generate an ALTER TABLE ... ADD COLUMN statement, checked against the schema: the table must exist without the column, and a NOT NULL column without a default can only be added to an empty table. Returns the DDL with warnings for review."),
+		mcp.WithString("table",
+			mcp.Required(),
+			mcp.Description("The table to alter"),
+		),
+		mcp.WithString("schema",
+			mcp.Description("The schema of the table"),
+			mcp.DefaultString("public"),
+		),
+		mcp.WithObject("column",
+			mcp.Required(),
+			mcp.Description("The column to add, e.g. {\"name\": \"archived_at\", \"type\": \"timestamptz\"}"),
+			mcp.Properties(columnItems["properties"].(map[string]any)),
+		),
+		mcp.WithBoolean("execute",
+			mcp.Description(executeDescription),
+		),
+		mcp.WithReadOnlyHintAnnotation(!s.allowWrite),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+	s.addTool(addColumnTool, s.handleAddColumn)
+
+	createIndexTool := mcp.NewTool("create_index",
+		mcp.WithDescription("Generate a CREATE INDEX statement on existing columns, warning about existing indexes that already cover them. Returns the DDL with warnings for review."),
+		mcp.WithString("table",
+			mcp.Required(),
+			mcp.Description("The table to index"),
+		),
+		mcp.WithString("schema",
+			mcp.Description("The schema of the table"),
+			mcp.DefaultString("public"),
+		),
+		mcp.WithArray("columns",
+			mcp.Required(),
+			mcp.Description("The indexed columns, in order"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithString("name",
+			mcp.Description("The index name, defaults to table_columns_idx"),
+		),
+		mcp.WithBoolean("unique",
+			mcp.Description("Create a unique index"),
+		),
+		mcp.WithString("method",
+			mcp.Description("The index access method"),
+			mcp.Enum(db.IndexMethods...),
+			mcp.DefaultString("btree"),
+		),
+		mcp.WithBoolean("execute",
+			mcp.Description(executeDescription),
+		),
+		mcp.WithReadOnlyHintAnnotation(!s.allowWrite),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+	s.addTool(createIndexTool, s.handleCreateIndex)
+}
+
+// handleCreateTable handles the create_table tool
+func (s *PostgresMCPServer) handleCreateTable(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	params := db.CreateTableParams{
+		Schema: stringArg(request, "schema", "public"),
+		Table:  stringArg(request, "table", ""),
+	}
+	items, _ := request.Params.Arguments["columns"].([]any)
+	for _, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			return mcp.NewToolResultError("columns must be an array of objects"), nil
+		}
+		params.Columns = append(params.Columns, columnDefArg(obj))
+	}
+
+	ddl, err := s.db.BuildCreateTable(ctx, params)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to generate DDL", err), nil
+	}
+	return s.ddlResult(ctx, request, ddl)
+}
+
+// handleAddColumn handles the add_column tool
+func (s *PostgresMCPServer) handleAddColumn(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	obj, ok := request.Params.Arguments["column"].(map[string]any)
+	if !ok {
+		return mcp.NewToolResultError("column must be an object"), nil
+	}
+	params := db.AddColumnParams{
+		Schema: stringArg(request, "schema", "public"),
+		Table:  stringArg(request, "table", ""),
+		Column: columnDefArg(obj),
+	}
+	if params.Table == "" {
+		return mcp.NewToolResultError("table is required"), nil
+	}
+
+	ddl, err := s.db.BuildAddColumn(ctx, params)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to generate DDL", err), nil
+	}
+	return s.ddlResult(ctx, request, ddl)
+}
+
+// handleCreateIndex handles the create_index tool
+func (s *PostgresMCPServer) handleCreateIndex(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	params := db.CreateIndexParams{
+		Schema:  stringArg(request, "schema", "public"),
+		Table:   stringArg(request, "table", ""),
+		Name:    stringArg(request, "name", ""),
+		Columns: stringSliceArg(request, "columns"),
+		Method:  stringArg(request, "method", "btree"),
+	}
+	params.Unique, _ = request.Params.Arguments["unique"].(bool)
+	if params.Table == "" {
+		return mcp.NewToolResultError("table is required"), nil
+	}
+
+	ddl, err := s.db.BuildCreateIndex(ctx, params)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to generate DDL", err), nil
+	}
+	return s.ddlResult(ctx, request, ddl)
+}
+
+// ddlResult returns generated DDL for review, or runs it first when execute
+// is set and writes are allowed without approval
+func (s *PostgresMCPServer) ddlResult(ctx context.Context, request mcp.CallToolRequest, ddl *db.DDL) (*mcp.CallToolResult, error) {
+	resp := ddlResponse{SQL: ddl.SQL, Warnings: ddl.Warnings}
+	execute, _ := request.Params.Arguments["execute"].(bool)
+	switch {
+	case !execute && s.writeApproval != nil:
+		resp.Hint = "Review the statement, then pass it to propose_write to run it"
+	case !execute && s.allowWrite:
+		resp.Hint = "Review the statement, then repeat the call with execute=true to run it"
+	case !execute:
+		resp.Hint = "Review the statement; the server is read-only, so it has to be run elsewhere"
+	case !s.allowWrite:
+		return mcp.NewToolResultError("execute needs a server started with -allow_write"), nil
+	case s.writeApproval != nil:
+		return mcp.NewToolResultError("Writes need approval, pass the statement to propose_write"), nil
+	default:
+		log.Printf("executing generated DDL: %s", ddl.SQL)
+		result, err := s.db.RunScript(ctx, []string{ddl.SQL}, false)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to run DDL", err), nil
+		}
+		script := s.newScriptResponse(result)
+		resp.Executed = result.Committed
+		resp.Result = &script
+	}
+
+	resultJSON, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// columnDefArg returns the column definition of an object argument
+func columnDefArg(obj map[string]any) db.ColumnDef {
+	col := db.ColumnDef{}
+	col.Name, _ = obj["name"].(string)
+	col.Type, _ = obj["type"].(string)
+	col.NotNull, _ = obj["not_null"].(bool)
+	col.PrimaryKey, _ = obj["primary_key"].(bool)
+	col.Unique, _ = obj["unique"].(bool)
+	col.Default, _ = obj["default"].(string)
+	col.References, _ = obj["references"].(string)
+	return col
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"log"
+	"sort"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// WithDDLNotifications listens for the notifications of the DDL event
+// triggers installed by install_ddl_trigger. Each DDL command then drops the
+// cached schema of the tables it touched at once and notifies clients of
+// their schema resources, without waiting for the schema cache TTL or the
+// next schema watch poll.
+func WithDDLNotifications() Option {
+	return func(s *PostgresMCPServer) {
+		s.ddlNotifications = true
+	}
+}
+
+// ddlNotificationsAvailable reports whether DDL notifications can be
+// received: LISTEN needs a server session and its own connection from the
+// database URL
+func (s *PostgresMCPServer) ddlNotificationsAvailable() bool {
+	return s.ddlNotifications && s.pooler != db.PoolerPgBouncer && s.databaseURL != ""
+}
+
+// startDDLListener listens on the DDL channel until the server is closed
+func (s *PostgresMCPServer) startDDLListener() {
+	if !s.ddlNotificationsAvailable() {
+		return
+	}
+	s.ddlListener = s.db.NewNotificationListener(s.handleDDLNotification)
+	if err := s.ddlListener.Listen(db.DDLChannel); err != nil {
+		log.Printf("DDL notifications: %v", err)
+		return
+	}
+
+	installed, err := s.db.DDLTriggerInstalled(context.Background())
+	if err != nil {
+		log.Printf("DDL notifications: %v", err)
+	} else if !installed {
+		log.Printf("DDL notifications: the event triggers are not installed, install them with install_ddl_trigger (-allow_write) as a superuser")
+	}
+}
+
+// handleDDLNotification handles a notification of the DDL event triggers
+func (s *PostgresMCPServer) handleDDLNotification(n db.Notification) {
+	event, err := db.ParseDDLEvent(n.Payload)
+	if err != nil {
+		log.Printf("DDL notifications: %v", err)
+		return
+	}
+
+	tables := event.Tables
+	if tables == nil {
+		// Too many tables to list, all of them may have changed
+		s.tableResourcesMu.Lock()
+		for name := range s.tableResources {
+			tables = append(tables, name)
+		}
+		s.tableResourcesMu.Unlock()
+	}
+	sort.Strings(tables)
+	// Any DDL command may create, drop or rename tables
+	s.schemaChanged(tables, true)
+}
+
+// addDDLTriggerTools registers the tools installing and removing the DDL
+// event triggers. They need write access, as they change the database.
+func (s *PostgresMCPServer) addDDLTriggerTools() {
+	if !s.ddlNotificationsAvailable() || !s.allowWrite {
+		return
+	}
+
+	installTool := mcp.NewTool("install_ddl_trigger",
+		mcp.WithDescription("Install event triggers notifying this server of every DDL command, so cached schemas and schema resources are updated at once. Creates the public.pgmcp_ddl_notify() function and two event triggers; needs superuser."),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+	s.addTool(installTool, s.handleInstallDDLTrigger)
+
+	uninstallTool := mcp.NewTool("uninstall_ddl_trigger",
+		mcp.WithDescription("Drop the event triggers and the function installed by install_ddl_trigger"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+	)
+	s.addTool(uninstallTool, s.handleUninstallDDLTrigger)
+}
+
+// handleInstallDDLTrigger handles the install_ddl_trigger tool
+func (s *PostgresMCPServer) handleInstallDDLTrigger(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	log.Printf("installing the DDL event triggers")
+	if err := s.db.InstallDDLTrigger(ctx); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to install the DDL event triggers", err), nil
+	}
+	return mcp.NewToolResultText("Installed the DDL event triggers, DDL commands are notified on channel " + db.DDLChannel), nil
+}
+
+// handleUninstallDDLTrigger handles the uninstall_ddl_trigger tool
+func (s *PostgresMCPServer) handleUninstallDDLTrigger(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	log.Printf("removing the DDL event triggers")
+	if err := s.db.UninstallDDLTrigger(ctx); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to remove the DDL event triggers", err), nil
+	}
+	return mcp.NewToolResultText("Removed the DDL event triggers"), nil
+}
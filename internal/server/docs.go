@@ -0,0 +1,310 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// docsPath is the path component of the generated documentation resources
+	docsPath = "docs"
+	// maxDocSamples is the number of sample values listed per column
+	maxDocSamples = 5
+)
+
+// docFormats maps the formats of generate_docs to file extension and MIME type
+var docFormats = map[string][2]string{
+	"markdown": {"md", "text/markdown"},
+	"html":     {"html", "text/html"},
+}
+
+// generatedDocs keeps the documents written as resources by generate_docs
+type generatedDocs struct {
+	mu   sync.Mutex
+	docs map[string]string
+}
+
+// docsResponse is the result of generate_docs with output=resource
+type docsResponse struct {
+	URI      string `json:"uri"`
+	MIMEType string `json:"mime_type"`
+	Tables   int    `json:"tables"`
+	Bytes    int    `json:"bytes"`
+}
+
+// docTable is a table of the data dictionary with the tables referencing it
+type docTable struct {
+	db.TableSummary
+	referencedBy []string
+}
+
+// addDocsTools registers the generate_docs tool
+func (s *PostgresMCPServer) addDocsTools() {
+	docsTool := mcp.NewTool("generate_docs",
+		mcp.WithDescription("Generate a data dictionary of the database as Markdown or HTML: every table with its comment, row estimate, columns with types, keys, defaults, comments and sample values, and the foreign key relationships in both directions. Returned inline or stored as a resource to read or save as a file."),
+		mcp.WithString("schema",
+			mcp.Description("Only document the tables of this schema, all but the system schemas by default"),
+		),
+		mcp.WithString("pattern",
+			mcp.Description("Only document the tables whose name matches this glob pattern, e.g. order_*"),
+		),
+		mcp.WithString("format",
+			mcp.Enum("markdown", "html"),
+			mcp.DefaultString("markdown"),
+		),
+		mcp.WithString("output",
+			mcp.Description("inline returns the document, resource stores it as a resource and returns its URI"),
+			mcp.Enum("inline", "resource"),
+			mcp.DefaultString("inline"),
+		),
+		mcp.WithBoolean("sample_values",
+			mcp.Description("List the most common values of each column from the planner statistics; no table is scanned"),
+			mcp.DefaultBool(true),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(docsTool, s.handleGenerateDocs)
+}
+
+// handleGenerateDocs handles the generate_docs tool
+func (s *PostgresMCPServer) handleGenerateDocs(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	schema := stringArg(request, "schema", "")
+	pattern := stringArg(request, "pattern", "")
+	if _, err := path.Match(pattern, ""); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid table name pattern %q", pattern)), nil
+	}
+	format := stringArg(request, "format", "markdown")
+	formatInfo, ok := docFormats[format]
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid format %q, use markdown or html", format)), nil
+	}
+	output := stringArg(request, "output", "inline")
+	if output != "inline" && output != "resource" {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid output %q, use inline or resource", output)), nil
+	}
+
+	summaries, err := s.db.GetSchemaSummary(schema)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to read the schema", err), nil
+	}
+	samples := map[string][]string{}
+	if withSamples, ok := request.Params.Arguments["sample_values"].(bool); !ok || withSamples {
+		if samples, err = s.db.GetColumnSamples(schema, maxDocSamples); err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to read sample values", err), nil
+		}
+	}
+
+	tables := docTables(summaries, pattern)
+	var doc string
+	if format == "html" {
+		doc = renderHTMLDocs(tables, samples, time.Now())
+	} else {
+		doc = renderMarkdownDocs(tables, samples, time.Now())
+	}
+	if output == "inline" {
+		return mcp.NewToolResultText(doc), nil
+	}
+
+	name := schema
+	if name == "" {
+		name = "all"
+	}
+	uri := fmt.Sprintf("%s/%s/%s.%s", s.db.ResourceBaseURL(), docsPath, name, formatInfo[0])
+	s.putDocsResource(uri, formatInfo[1], doc)
+
+	resultJSON, err := json.MarshalIndent(docsResponse{URI: uri, MIMEType: formatInfo[1], Tables: len(tables), Bytes: len(doc)}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// putDocsResource stores a document and registers its resource, replacing
+// the document generated earlier for the same schema and format
+func (s *PostgresMCPServer) putDocsResource(uri, mimeType, doc string) {
+	s.docs.mu.Lock()
+	defer s.docs.mu.Unlock()
+	if s.docs.docs == nil {
+		s.docs.docs = map[string]string{}
+	}
+	_, registered := s.docs.docs[uri]
+	s.docs.docs[uri] = doc
+	if registered {
+		return
+	}
+
+	resource := mcp.NewResource(
+		uri,
+		"Data dictionary "+path.Base(uri),
+		mcp.WithResourceDescription("Schema documentation written by generate_docs"),
+		mcp.WithMIMEType(mimeType),
+	)
+	s.server.AddResource(resource, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		s.docs.mu.Lock()
+		doc := s.docs.docs[uri]
+		s.docs.mu.Unlock()
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: mimeType,
+				Text:     doc,
+			},
+		}, nil
+	})
+}
+
+// docTables keeps the tables matching the pattern, in schema and name order,
+// and collects the foreign keys referencing each of them
+func docTables(summaries []db.TableSummary, pattern string) []docTable {
+	referencedBy := map[string][]string{}
+	for _, table := range summaries {
+		for _, col := range table.Columns {
+			if col.References != "" {
+				target := strings.SplitN(col.References, ".", 2)[0]
+				referencedBy[target] = append(referencedBy[target], table.Name+"."+col.Name)
+			}
+		}
+	}
+
+	var tables []docTable
+	for _, table := range summaries {
+		if pattern != "" {
+			if ok, _ := path.Match(pattern, table.Name); !ok {
+				continue
+			}
+		}
+		tables = append(tables, docTable{TableSummary: table, referencedBy: referencedBy[table.Name]})
+	}
+	return tables
+}
+
+// columnKeys describes the keys and constraints of a column
+func columnKeys(col db.ColumnSummary) string {
+	var keys []string
+	if col.PrimaryKey {
+		keys = append(keys, "PK")
+	}
+	if col.References != "" {
+		keys = append(keys, "FK → "+col.References)
+	}
+	if col.NotNull && !col.PrimaryKey {
+		keys = append(keys, "NOT NULL")
+	}
+	return strings.Join(keys, ", ")
+}
+
+// columnDefault describes how a column gets its value when none is given
+func columnDefault(col db.ColumnSummary) string {
+	switch {
+	case col.Identity != "":
+		return "GENERATED " + col.Identity + " AS IDENTITY"
+	case col.Generated != "":
+		return "GENERATED ALWAYS AS (" + col.Generated + ")"
+	default:
+		return col.Default
+	}
+}
+
+// renderMarkdownDocs renders the data dictionary as Markdown
+func renderMarkdownDocs(tables []docTable, samples map[string][]string, generatedAt time.Time) string {
+	var b strings.Builder
+	b.WriteString("# Data dictionary\n\n")
+	fmt.Fprintf(&b, "Generated %s, %d tables.\n\n", generatedAt.UTC().Format(time.RFC3339), len(tables))
+	for _, table := range tables {
+		fmt.Fprintf(&b, "- [%s](#%s)\n", qualifiedTableName(table.TableSummary), markdownAnchor(qualifiedTableName(table.TableSummary)))
+	}
+
+	for _, table := range tables {
+		fmt.Fprintf(&b, "\n## %s\n\n", qualifiedTableName(table.TableSummary))
+		fmt.Fprintf(&b, "*%s, ~%s rows*\n\n", table.Kind, approxCount(table.RowEstimate))
+		if comment := strings.TrimSpace(table.Comment); comment != "" {
+			b.WriteString(comment + "\n\n")
+		}
+
+		b.WriteString("| Column | Type | Keys | Default | Description | Sample values |\n")
+		b.WriteString("|---|---|---|---|---|---|\n")
+		for _, col := range table.Columns {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n",
+				markdownCell(col.Name),
+				markdownCell(col.Type),
+				markdownCell(columnKeys(col)),
+				markdownCode(columnDefault(col)),
+				markdownCell(col.Comment),
+				markdownCell(strings.Join(samples[table.Schema+"."+table.Name+"."+col.Name], ", ")))
+		}
+
+		if len(table.referencedBy) > 0 {
+			b.WriteString("\nReferenced by: " + markdownCell(strings.Join(table.referencedBy, ", ")) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// markdownCell escapes a value for a Markdown table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// markdownCode renders a value as inline code, empty values stay empty
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + strings.ReplaceAll(markdownCell(s), "`", "'") + "`"
+}
+
+// markdownAnchor is the heading anchor GitHub generates for a title
+func markdownAnchor(title string) string {
+	return strings.ToLower(strings.NewReplacer(".", "", " ", "-").Replace(title))
+}
+
+// renderHTMLDocs renders the data dictionary as a standalone HTML page
+func renderHTMLDocs(tables []docTable, samples map[string][]string, generatedAt time.Time) string {
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Data dictionary</title>\n")
+	b.WriteString("<style>body{font-family:sans-serif}table{border-collapse:collapse}th,td{border:1px solid #ccc;padding:4px 8px;text-align:left;vertical-align:top}</style>\n")
+	b.WriteString("</head>\n<body>\n<h1>Data dictionary</h1>\n")
+	fmt.Fprintf(&b, "<p>Generated %s, %d tables.</p>\n<ul>\n", generatedAt.UTC().Format(time.RFC3339), len(tables))
+	for _, table := range tables {
+		name := html.EscapeString(qualifiedTableName(table.TableSummary))
+		fmt.Fprintf(&b, "<li><a href=\"#%s\">%s</a></li>\n", name, name)
+	}
+	b.WriteString("</ul>\n")
+
+	for _, table := range tables {
+		name := html.EscapeString(qualifiedTableName(table.TableSummary))
+		fmt.Fprintf(&b, "<h2 id=\"%s\">%s</h2>\n", name, name)
+		fmt.Fprintf(&b, "<p><em>%s, ~%s rows</em></p>\n", html.EscapeString(table.Kind), approxCount(table.RowEstimate))
+		if comment := strings.TrimSpace(table.Comment); comment != "" {
+			fmt.Fprintf(&b, "<p>%s</p>\n", html.EscapeString(comment))
+		}
+
+		b.WriteString("<table>\n<tr><th>Column</th><th>Type</th><th>Keys</th><th>Default</th><th>Description</th><th>Sample values</th></tr>\n")
+		for _, col := range table.Columns {
+			fmt.Fprintf(&b, "<tr><td>%s</td><td>%s</td><td>%s</td><td><code>%s</code></td><td>%s</td><td>%s</td></tr>\n",
+				html.EscapeString(col.Name),
+				html.EscapeString(col.Type),
+				html.EscapeString(columnKeys(col)),
+				html.EscapeString(columnDefault(col)),
+				html.EscapeString(col.Comment),
+				html.EscapeString(strings.Join(samples[table.Schema+"."+table.Name+"."+col.Name], ", ")))
+		}
+		b.WriteString("</table>\n")
+
+		if len(table.referencedBy) > 0 {
+			fmt.Fprintf(&b, "<p>Referenced by: %s</p>\n", html.EscapeString(strings.Join(table.referencedBy, ", ")))
+		}
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// dumpsPath is the path component of the dump file resources
+	dumpsPath = "dumps"
+	// maxDumpResourceBytes bounds the dump files returned as resources
+	maxDumpResourceBytes = 64 * 1024 * 1024
+)
+
+// dumpConfig is the pg_dump binary and the directory dumps are written to
+type dumpConfig struct {
+	pgDump string
+	dir    string
+}
+
+// dumpResponse is the result of backup_database
+type dumpResponse struct {
+	URI string `json:"uri"`
+	*db.DumpResult
+	ExecutionMS float64 `json:"execution_ms"`
+}
+
+// WithDumps registers the backup_database tool, which runs the pg_dump
+// binary and writes the dumps to dir. The dumps can be read back as
+// resources.
+func WithDumps(pgDump, dir string) Option {
+	return func(s *PostgresMCPServer) {
+		s.dumps = &dumpConfig{pgDump: pgDump, dir: dir}
+	}
+}
+
+// addDumpTools registers the backup_database tool and the dump resources
+func (s *PostgresMCPServer) addDumpTools() {
+	if s.dumps == nil {
+		return
+	}
+
+	dumpTool := mcp.NewTool("backup_database",
+		mcp.WithDescription(fmt.Sprintf("Make a logical backup of the database with pg_dump into %s and return the resource URI and size of the dump file", s.dumps.dir)),
+		mcp.WithString("format",
+			mcp.Description("custom for a compressed archive restored with pg_restore, plain for an SQL script"),
+			mcp.Enum(db.DumpCustom, db.DumpPlain),
+			mcp.DefaultString(db.DumpCustom),
+		),
+		mcp.WithBoolean("schema_only",
+			mcp.Description("Dump the schema without the data"),
+		),
+		mcp.WithArray("schemas",
+			mcp.Description("Only dump these schemas (pg_dump patterns)"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithArray("tables",
+			mcp.Description("Only dump these tables (pg_dump patterns, e.g. public.orders)"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(dumpTool, s.handleBackupDatabase)
+
+	template := mcp.NewResourceTemplate(
+		fmt.Sprintf("%s/%s/{name}", s.db.ResourceBaseURL(), dumpsPath),
+		"Database dumps",
+		mcp.WithTemplateDescription("Dump files written by backup_database: plain dumps as SQL text, custom dumps as binary archives"),
+	)
+	s.server.AddResourceTemplate(template, s.handleDumpResource)
+}
+
+// handleBackupDatabase handles the backup_database tool
+func (s *PostgresMCPServer) handleBackupDatabase(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	params := db.DumpParams{
+		PgDump:  s.dumps.pgDump,
+		Dir:     s.dumps.dir,
+		Format:  stringArg(request, "format", db.DumpCustom),
+		Schemas: stringSliceArg(request, "schemas"),
+		Tables:  stringSliceArg(request, "tables"),
+	}
+	params.SchemaOnly, _ = request.Params.Arguments["schema_only"].(bool)
+	log.Printf("dumping the database to %s", s.dumps.dir)
+
+	result, err := s.db.Dump(ctx, params)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to back up the database", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(dumpResponse{
+		URI:         fmt.Sprintf("%s/%s/%s", s.db.ResourceBaseURL(), dumpsPath, result.Name),
+		DumpResult:  result,
+		ExecutionMS: milliseconds(result.Duration),
+	}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleDumpResource returns a dump file of the dump directory
+func (s *PostgresMCPServer) handleDumpResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	name := templateArgument(request, "name")
+	path, info, err := s.dumpFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxDumpResourceBytes {
+		return nil, fmt.Errorf("dump %s has %d bytes, more than the %d bytes returned as a resource; copy it from %s instead", name, info.Size(), maxDumpResourceBytes, s.dumps.dir)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dump %s: %w", name, err)
+	}
+
+	if filepath.Ext(name) == ".sql" {
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: "application/sql",
+				Text:     string(data),
+			},
+		}, nil
+	}
+	return []mcp.ResourceContents{
+		mcp.BlobResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "application/octet-stream",
+			Blob:     base64.StdEncoding.EncodeToString(data),
+		},
+	}, nil
+}
+
+// dumpFile returns the path of a file of the dump directory, rejecting names
+// that point outside of it
+func (s *PostgresMCPServer) dumpFile(name string) (string, os.FileInfo, error) {
+	if name == "" || name != filepath.Base(name) || name[0] == '.' {
+		return "", nil, fmt.Errorf("invalid dump name %q", name)
+	}
+	path := filepath.Join(s.dumps.dir, name)
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return "", nil, fmt.Errorf("dump %s not found", name)
+	}
+	return path, info, nil
+}
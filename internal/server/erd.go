@@ -0,0 +1,221 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// mermaidUnsafe matches the characters not allowed in Mermaid entity names
+// and attribute types
+var mermaidUnsafe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// erdRelationship is a foreign key column between two diagram tables
+type erdRelationship struct {
+	from, to       db.TableSummary
+	column, target string
+	optional       bool
+}
+
+// addERDTools registers the generate_erd tool
+func (s *PostgresMCPServer) addERDTools() {
+	erdTool := mcp.NewTool("generate_erd",
+		mcp.WithDescription("Generate an entity relationship diagram of tables and their foreign keys as a Mermaid erDiagram, which chat clients render directly, or as Graphviz DOT"),
+		mcp.WithArray("tables",
+			mcp.Description("The tables to draw, \"name\" or \"schema.name\"; all tables of the schema by default. Only the foreign keys between drawn tables are shown."),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithString("schema",
+			mcp.Description("Only draw the tables of this schema, all but the system schemas by default"),
+		),
+		mcp.WithString("format",
+			mcp.Enum("mermaid", "dot"),
+			mcp.DefaultString("mermaid"),
+		),
+		mcp.WithBoolean("columns",
+			mcp.Description("List the columns of each table; false draws the tables with their keys only"),
+			mcp.DefaultBool(true),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(erdTool, s.handleGenerateERD)
+}
+
+// handleGenerateERD handles the generate_erd tool
+func (s *PostgresMCPServer) handleGenerateERD(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	format := stringArg(request, "format", "mermaid")
+	if format != "mermaid" && format != "dot" {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid format %q, use mermaid or dot", format)), nil
+	}
+	allColumns := true
+	if v, ok := request.Params.Arguments["columns"].(bool); ok {
+		allColumns = v
+	}
+
+	summaries, err := s.db.GetSchemaSummary(stringArg(request, "schema", ""))
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to read the schema", err), nil
+	}
+
+	tables := summaries
+	if names := stringSliceArg(request, "tables"); len(names) > 0 {
+		tables = nil
+		for _, name := range names {
+			i := slices.IndexFunc(summaries, func(t db.TableSummary) bool {
+				return qualifiedTableName(t) == name || t.Schema+"."+t.Name == name
+			})
+			if i < 0 {
+				return mcp.NewToolResultError(fmt.Sprintf("Table %q not found", name)), nil
+			}
+			tables = append(tables, summaries[i])
+		}
+	}
+	if len(tables) == 0 {
+		return mcp.NewToolResultError("No tables to draw"), nil
+	}
+
+	relationships := erdRelationships(tables)
+	if format == "dot" {
+		return mcp.NewToolResultText(renderDOT(tables, relationships, allColumns)), nil
+	}
+	return mcp.NewToolResultText(renderMermaid(tables, relationships, allColumns)), nil
+}
+
+// erdRelationships returns the foreign keys between the drawn tables.
+// References name the target table without its schema, a table of the same
+// schema is preferred.
+func erdRelationships(tables []db.TableSummary) []erdRelationship {
+	var relationships []erdRelationship
+	for _, table := range tables {
+		for _, col := range table.Columns {
+			if col.References == "" {
+				continue
+			}
+			targetName, targetColumn, _ := strings.Cut(col.References, ".")
+			target := -1
+			for i, t := range tables {
+				if t.Name == targetName && (target < 0 || t.Schema == table.Schema) {
+					target = i
+				}
+			}
+			if target < 0 {
+				continue
+			}
+			relationships = append(relationships, erdRelationship{
+				from:     table,
+				to:       tables[target],
+				column:   col.Name,
+				target:   targetColumn,
+				optional: !col.NotNull,
+			})
+		}
+	}
+	return relationships
+}
+
+// drawnColumn reports whether a column is drawn, keys are always drawn
+func drawnColumn(col db.ColumnSummary, allColumns bool) bool {
+	return allColumns || col.PrimaryKey || col.References != ""
+}
+
+// renderMermaid renders the tables as a Mermaid erDiagram
+func renderMermaid(tables []db.TableSummary, relationships []erdRelationship, allColumns bool) string {
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+	for _, table := range tables {
+		fmt.Fprintf(&b, "    %s {\n", mermaidName(qualifiedTableName(table)))
+		for _, col := range table.Columns {
+			if !drawnColumn(col, allColumns) {
+				continue
+			}
+			fmt.Fprintf(&b, "        %s %s", mermaidName(col.Type), mermaidName(col.Name))
+			var keys []string
+			if col.PrimaryKey {
+				keys = append(keys, "PK")
+			}
+			if col.References != "" {
+				keys = append(keys, "FK")
+			}
+			if len(keys) > 0 {
+				b.WriteString(" " + strings.Join(keys, ", "))
+			}
+			if comment := strings.TrimSpace(col.Comment); comment != "" {
+				fmt.Fprintf(&b, " %q", strings.ReplaceAll(strings.Join(strings.Fields(comment), " "), `"`, "'"))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("    }\n")
+	}
+
+	// Many rows reference one row; a nullable foreign key references zero or one
+	for _, rel := range relationships {
+		parent := "||"
+		if rel.optional {
+			parent = "o|"
+		}
+		fmt.Fprintf(&b, "    %s }o--%s %s : %q\n", mermaidName(qualifiedTableName(rel.from)), parent, mermaidName(qualifiedTableName(rel.to)), rel.column)
+	}
+	return b.String()
+}
+
+// mermaidName replaces the characters Mermaid does not accept in names and
+// types, e.g. "character varying(20)" becomes character_varying_20
+func mermaidName(name string) string {
+	return strings.Trim(mermaidUnsafe.ReplaceAllString(name, "_"), "_")
+}
+
+// renderDOT renders the tables as a Graphviz digraph of record nodes with an
+// edge from each foreign key column to the referenced column
+func renderDOT(tables []db.TableSummary, relationships []erdRelationship, allColumns bool) string {
+	var b strings.Builder
+	b.WriteString("digraph erd {\n    rankdir=LR;\n    node [shape=record, fontname=\"Helvetica\"];\n")
+	for _, table := range tables {
+		fields := []string{dotRecordEscape(qualifiedTableName(table))}
+		for _, col := range table.Columns {
+			if !drawnColumn(col, allColumns) {
+				continue
+			}
+			field := col.Name + " : " + col.Type
+			if col.PrimaryKey {
+				field += " (PK)"
+			}
+			if col.References != "" {
+				field += " (FK)"
+			}
+			fields = append(fields, fmt.Sprintf("<%s> %s\\l", dotPort(col.Name), dotRecordEscape(field)))
+		}
+		fmt.Fprintf(&b, "    %s [label=\"{%s}\"];\n", dotID(qualifiedTableName(table)), strings.Join(fields, "|"))
+	}
+	for _, rel := range relationships {
+		style := ""
+		if rel.optional {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "    %s:%s -> %s:%s [label=%s%s];\n",
+			dotID(qualifiedTableName(rel.from)), dotPort(rel.column),
+			dotID(qualifiedTableName(rel.to)), dotPort(rel.target),
+			dotID(rel.column), style)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotID quotes a DOT identifier
+func dotID(name string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(name) + `"`
+}
+
+// dotPort names the record field of a column
+func dotPort(column string) string {
+	return "c_" + mermaidName(column)
+}
+
+// dotRecordEscape escapes the characters with a meaning in record labels
+func dotRecordEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "{", `\{`, "}", `\}`, "|", `\|`, "<", `\<`, ">", `\>`).Replace(s)
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/iwanbk/postgres-mcp-go/internal/pglog"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// logTailBytes is how much of the end of the log file is scanned for errors
+	logTailBytes = 1024 * 1024
+	// defaultRecentErrors is the number of log errors returned by recent_errors
+	defaultRecentErrors = 50
+)
+
+// errorLogConfig locates the PostgreSQL server log
+type errorLogConfig struct {
+	path   string
+	format pglog.Format
+}
+
+// WithErrorLog lets recent_errors read the errors from a PostgreSQL log file
+// (stderr or csvlog format) readable by this process
+func WithErrorLog(path string, format pglog.Format) Option {
+	return func(s *PostgresMCPServer) {
+		s.errorLog = &errorLogConfig{path: path, format: format}
+	}
+}
+
+// addErrorLogTools registers the error surfacing tools
+func (s *PostgresMCPServer) addErrorLogTools() {
+	description := "Report the error, deadlock and recovery conflict counters of the database from pg_stat_database"
+	if s.errorLog != nil {
+		description += ", and the most recent errors (with detail, hint and statement) and deadlocks from the server log"
+	}
+	recentErrorsTool := mcp.NewTool("recent_errors",
+		mcp.WithDescription(description),
+		mcp.WithNumber("limit",
+			mcp.Description("The maximum number of log errors to return, most recent last"),
+			mcp.DefaultNumber(defaultRecentErrors),
+		),
+		mcp.WithBoolean("deadlocks_only",
+			mcp.Description("Only return deadlocks from the log"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(recentErrorsTool, s.handleRecentErrors)
+}
+
+// handleRecentErrors handles the recent_errors tool
+func (s *PostgresMCPServer) handleRecentErrors(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	counters, err := s.db.GetErrorCounters()
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to get error counters", err), nil
+	}
+
+	result := struct {
+		Counters *db.ErrorCounters `json:"counters"`
+		Errors   []pglog.Entry     `json:"errors,omitempty"`
+		Note     string            `json:"note,omitempty"`
+	}{Counters: counters}
+
+	if s.errorLog == nil {
+		result.Note = "no server log is configured, start the server with -log_file to see individual errors"
+	} else {
+		entries, err := pglog.ReadRecent(s.errorLog.path, s.errorLog.format, logTailBytes, math.MaxInt)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to read the server log", err), nil
+		}
+		if deadlocksOnly, _ := request.Params.Arguments["deadlocks_only"].(bool); deadlocksOnly {
+			var deadlocks []pglog.Entry
+			for _, e := range entries {
+				if e.Deadlock {
+					deadlocks = append(deadlocks, e)
+				}
+			}
+			entries = deadlocks
+		}
+		if limit := intArg(request, "limit", defaultRecentErrors); limit > 0 && len(entries) > limit {
+			entries = entries[len(entries)-limit:]
+		}
+		result.Errors = append([]pglog.Entry{}, entries...)
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
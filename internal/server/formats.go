@@ -0,0 +1,250 @@
+package server
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// formatArgument is the tool and resources/read argument selecting the
+	// format of the content, a format name or MIME type
+	formatArgument = "format"
+
+	mimeJSON     = "application/json"
+	mimeCSV      = "text/csv"
+	mimeMarkdown = "text/markdown"
+)
+
+// contentFormats maps the format names and resource URI suffixes to MIME types
+var contentFormats = map[string]string{
+	"json":     mimeJSON,
+	"csv":      mimeCSV,
+	"markdown": mimeMarkdown,
+	"md":       mimeMarkdown,
+}
+
+// formatExtensions are the resource URI suffixes of the MIME types
+var formatExtensions = map[string]string{
+	mimeJSON:     "json",
+	mimeCSV:      "csv",
+	mimeMarkdown: "md",
+}
+
+// parseFormat returns the MIME type of a format name or MIME type, JSON when
+// format is empty
+func parseFormat(format string) (string, error) {
+	if format == "" {
+		return mimeJSON, nil
+	}
+	if mimeType, ok := contentFormats[strings.ToLower(format)]; ok {
+		return mimeType, nil
+	}
+	// A MIME type, possibly with parameters such as text/csv; charset=utf-8
+	if mimeType, _, err := mime.ParseMediaType(format); err == nil {
+		switch mimeType {
+		case mimeJSON, mimeCSV, mimeMarkdown:
+			return mimeType, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported format %q, use json, csv or markdown", format)
+}
+
+// formatSuffix splits a known format suffix, e.g. .csv, off a name or URI
+func formatSuffix(name string) (string, string, bool) {
+	ext := path.Ext(name)
+	if mimeType, ok := contentFormats[strings.TrimPrefix(ext, ".")]; ok && ext != "" {
+		return strings.TrimSuffix(name, ext), mimeType, true
+	}
+	return name, "", false
+}
+
+// resourceFormat returns the MIME type a resource is read as: the format of
+// its URI suffix, else the format argument of the request, else JSON
+func resourceFormat(request mcp.ReadResourceRequest) (string, error) {
+	if _, mimeType, ok := formatSuffix(request.Params.URI); ok {
+		return mimeType, nil
+	}
+	format, _ := request.Params.Arguments[formatArgument].(string)
+	return parseFormat(format)
+}
+
+// toolFormat returns the MIME type of the format argument of a tool
+func toolFormat(request mcp.CallToolRequest) (string, error) {
+	return parseFormat(stringArg(request, formatArgument, ""))
+}
+
+// formatOption is the format argument of the tools returning tables
+func formatOption(rows string) mcp.ToolOption {
+	return mcp.WithString(formatArgument,
+		mcp.Description("Return the result as json, or as a csv or markdown table with "+rows),
+		mcp.Enum("json", "csv", "markdown"),
+		mcp.DefaultString("json"),
+	)
+}
+
+// table is tabular content encoded as CSV or Markdown
+type table struct {
+	columns []string
+	rows    []map[string]interface{}
+}
+
+// rowsTable returns the table of query result rows in column order
+func rowsTable(columns []db.ResultColumn, rows []map[string]interface{}) table {
+	t := table{rows: rows}
+	for _, col := range columns {
+		t.columns = append(t.columns, col.Name)
+	}
+	if len(t.columns) == 0 && len(rows) > 0 {
+		for name := range rows[0] {
+			t.columns = append(t.columns, name)
+		}
+		sort.Strings(t.columns)
+	}
+	return t
+}
+
+// tableColumnsTable returns the table of the columns of a table schema
+func tableColumnsTable(columns []db.TableColumn) table {
+	t := table{columns: []string{"column_name", "data_type", "remote"}}
+	for _, col := range columns {
+		t.rows = append(t.rows, map[string]interface{}{
+			"column_name": col.ColumnName,
+			"data_type":   col.DataType,
+			"remote":      col.Remote,
+		})
+	}
+	return t
+}
+
+// tableSchemasTable returns the table of the columns of several tables, one
+// row per column
+func tableSchemasTable(tables []db.TableSchema) table {
+	t := table{columns: []string{"schema", "table", "column", "type", "nullable", "remote"}}
+	for _, tbl := range tables {
+		for _, col := range tbl.Columns {
+			t.rows = append(t.rows, map[string]interface{}{
+				"schema":   tbl.Schema,
+				"table":    tbl.Table,
+				"column":   col.Name,
+				"type":     col.Type,
+				"nullable": col.Nullable,
+				"remote":   tbl.Remote,
+			})
+		}
+	}
+	return t
+}
+
+// encodeTable encodes a table as CSV with a header row or as a Markdown table
+func encodeTable(mimeType string, t table) (string, error) {
+	switch mimeType {
+	case mimeCSV:
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		if err := w.Write(t.columns); err != nil {
+			return "", err
+		}
+		record := make([]string, len(t.columns))
+		for _, row := range t.rows {
+			for i, col := range t.columns {
+				record[i] = formatCell(row[col])
+			}
+			if err := w.Write(record); err != nil {
+				return "", err
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return "", fmt.Errorf("failed to write CSV: %w", err)
+		}
+		return buf.String(), nil
+	case mimeMarkdown:
+		var b strings.Builder
+		header := make([]string, len(t.columns))
+		rule := make([]string, len(t.columns))
+		for i, col := range t.columns {
+			header[i] = markdownCell(col)
+			rule[i] = "---"
+		}
+		b.WriteString("| " + strings.Join(header, " | ") + " |\n")
+		b.WriteString("| " + strings.Join(rule, " | ") + " |\n")
+		cells := make([]string, len(t.columns))
+		for _, row := range t.rows {
+			for i, col := range t.columns {
+				cells[i] = markdownCell(formatCell(row[col]))
+			}
+			b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		}
+		return b.String(), nil
+	}
+	return "", fmt.Errorf("%s content is not tabular", mimeType)
+}
+
+// formatCell formats a value for a CSV or Markdown cell. NULL is empty,
+// arrays and objects are JSON.
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []interface{}, map[string]interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+	return fmt.Sprint(value)
+}
+
+// formattedToolResult returns a table as a CSV or Markdown tool result, with
+// the hint of a truncated result as a second text content
+func formattedToolResult(mimeType string, t table, hint string) (*mcp.CallToolResult, error) {
+	text, err := encodeTable(mimeType, t)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to encode result", err), nil
+	}
+	result := mcp.NewToolResultText(text)
+	if hint != "" {
+		result.Content = append(result.Content, mcp.NewTextContent(hint))
+	}
+	return result, nil
+}
+
+// formattedResource returns the contents of a resource read as a MIME type:
+// v as indented JSON, or t as a CSV or Markdown table
+func formattedResource(uri, mimeType string, v interface{}, t table) ([]mcp.ResourceContents, error) {
+	var text string
+	if mimeType == mimeJSON {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal result to JSON: %w", err)
+		}
+		text = string(data)
+	} else {
+		var err error
+		if text, err = encodeTable(mimeType, t); err != nil {
+			return nil, err
+		}
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: mimeType,
+			Text:     text,
+		},
+	}, nil
+}
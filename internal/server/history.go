@@ -0,0 +1,228 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// maxHistoryEntries is the number of queries remembered per session
+	maxHistoryEntries = 100
+	// maxHistoryRows is the largest result kept for diffing on rerun
+	maxHistoryRows = 1000
+	// maxDiffRows is the number of added/removed rows listed in a diff
+	maxDiffRows = 20
+)
+
+// historyEntry is a query executed during a session
+type historyEntry struct {
+	ID         int           `json:"id"`
+	SQL        string        `json:"sql"`
+	Args       []interface{} `json:"args,omitempty"`
+	ExecutedAt time.Time     `json:"executed_at"`
+	Duration   string        `json:"duration"`
+	RowCount   int           `json:"row_count"`
+	Error      string        `json:"error,omitempty"`
+
+	// rows is kept for diffing when the result is small enough
+	rows []map[string]interface{}
+}
+
+// queryHistory keeps the executed queries per session
+type queryHistory struct {
+	mu       sync.Mutex
+	nextID   int
+	sessions map[string][]*historyEntry
+}
+
+// rowDiff is the difference between two results of the same query
+type rowDiff struct {
+	Added          []map[string]interface{} `json:"added"`
+	Removed        []map[string]interface{} `json:"removed"`
+	AddedCount     int                      `json:"added_count"`
+	RemovedCount   int                      `json:"removed_count"`
+	UnchangedCount int                      `json:"unchanged_count"`
+}
+
+// addHistoryTools registers the query history tools
+func (s *PostgresMCPServer) addHistoryTools() {
+	listHistoryTool := mcp.NewTool("list_query_history",
+		mcp.WithDescription("List the queries executed with the query tool and named queries during this session, most recent last"),
+	)
+	s.addTool(listHistoryTool, s.handleListQueryHistory)
+
+	rerunTool := mcp.NewTool("rerun_query",
+		mcp.WithDescription("Run a query from the session history again and report which rows were added or removed compared to the previous run"),
+		mcp.WithNumber("id",
+			mcp.Required(),
+			mcp.Description("The history entry ID from list_query_history"),
+		),
+	)
+	s.addTool(rerunTool, s.handleRerunQuery)
+}
+
+// handleListQueryHistory handles the list_query_history tool
+func (s *PostgresMCPServer) handleListQueryHistory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	entries := s.history.list(sessionIDFromContext(ctx))
+
+	resultJSON, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleRerunQuery handles the rerun_query tool
+func (s *PostgresMCPServer) handleRerunQuery(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	sessionID := sessionIDFromContext(ctx)
+	previous, ok := s.history.get(sessionID, intArg(request, "id", 0))
+	if !ok {
+		return mcp.NewToolResultError("Query not found in the session history"), nil
+	}
+
+	start := time.Now()
+	run, err := s.readOnlyQuery(ctx, previous.SQL, previous.Args...)
+	entry := s.history.record(sessionID, previous.SQL, previous.Args, start, run.Rows, err)
+	if err != nil {
+		return s.queryError("Failed to execute query", previous.SQL, err), nil
+	}
+
+	resp, err := s.runResponse(ctx, run)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to build response", err), nil
+	}
+
+	rerun := struct {
+		ID         int           `json:"id"`
+		PreviousID int           `json:"previous_id"`
+		Diff       *rowDiff      `json:"diff,omitempty"`
+		DiffNote   string        `json:"diff_note,omitempty"`
+		Result     queryResponse `json:"result"`
+	}{
+		ID:         entry.ID,
+		PreviousID: previous.ID,
+		Result:     *resp,
+	}
+	if previous.rows != nil && entry.rows != nil {
+		rerun.Diff = diffRows(previous.rows, run.Rows)
+	} else {
+		rerun.DiffNote = fmt.Sprintf("No diff available, results larger than %d rows or failed runs are not kept", maxHistoryRows)
+	}
+
+	resultJSON, err := json.MarshalIndent(rerun, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// record adds an executed query to the session history and returns the entry
+func (h *queryHistory) record(sessionID, sql string, args []interface{}, start time.Time, rows []map[string]interface{}, err error) historyEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	entry := &historyEntry{
+		ID:         h.nextID,
+		SQL:        sql,
+		Args:       args,
+		ExecutedAt: start,
+		Duration:   time.Since(start).Round(time.Millisecond).String(),
+		RowCount:   len(rows),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else if len(rows) <= maxHistoryRows {
+		entry.rows = rows
+	}
+
+	if h.sessions == nil {
+		h.sessions = map[string][]*historyEntry{}
+	}
+	entries := append(h.sessions[sessionID], entry)
+	if len(entries) > maxHistoryEntries {
+		entries = entries[len(entries)-maxHistoryEntries:]
+	}
+	h.sessions[sessionID] = entries
+	return *entry
+}
+
+// list returns the history of a session
+func (h *queryHistory) list(sessionID string) []historyEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	entries := make([]historyEntry, 0, len(h.sessions[sessionID]))
+	for _, entry := range h.sessions[sessionID] {
+		entries = append(entries, *entry)
+	}
+	return entries
+}
+
+// get returns a history entry of a session
+func (h *queryHistory) get(sessionID string, id int) (historyEntry, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, entry := range h.sessions[sessionID] {
+		if entry.ID == id {
+			return *entry, true
+		}
+	}
+	return historyEntry{}, false
+}
+
+// forget drops the history of a session
+func (h *queryHistory) forget(sessionID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.sessions, sessionID)
+}
+
+// diffRows compares two results as multisets of rows
+func diffRows(before, after []map[string]interface{}) *rowDiff {
+	remaining := map[string]int{}
+	for _, row := range before {
+		remaining[rowKey(row)]++
+	}
+
+	diff := &rowDiff{
+		Added:   []map[string]interface{}{},
+		Removed: []map[string]interface{}{},
+	}
+	for _, row := range after {
+		key := rowKey(row)
+		if remaining[key] > 0 {
+			remaining[key]--
+			diff.UnchangedCount++
+			continue
+		}
+		diff.AddedCount++
+		if len(diff.Added) < maxDiffRows {
+			diff.Added = append(diff.Added, row)
+		}
+	}
+	for _, row := range before {
+		key := rowKey(row)
+		if remaining[key] > 0 {
+			remaining[key]--
+			diff.RemovedCount++
+			if len(diff.Removed) < maxDiffRows {
+				diff.Removed = append(diff.Removed, row)
+			}
+		}
+	}
+	return diff
+}
+
+// rowKey returns a canonical encoding of a row; map keys are sorted by the encoder
+func rowKey(row map[string]interface{}) string {
+	b, err := json.Marshal(row)
+	if err != nil {
+		return fmt.Sprint(row)
+	}
+	return string(b)
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// ForwardedPrefixHeader is the path prefix a reverse proxy serves the
+	// server under and strips from the requests it forwards
+	ForwardedPrefixHeader = "X-Forwarded-Prefix"
+	// corsAllowHeaders are the request headers browsers may send by default
+	corsAllowHeaders = "Content-Type, Authorization, Traceparent, Tracestate, " + TenantHeader
+	// corsMaxAge is how long browsers cache a preflight response, in seconds
+	corsMaxAge = "600"
+	// shutdownTimeout bounds the wait for the requests in flight when the
+	// server stops
+	shutdownTimeout = 5 * time.Second
+)
+
+// httpConfig configures the HTTP server of the SSE transport
+type httpConfig struct {
+	// basePath prefixes the paths of all endpoints, empty or e.g. /postgres
+	basePath string
+	// corsOrigins are the origins browsers may call the server from, * for any
+	corsOrigins []string
+	// trustForwarded applies the X-Forwarded-* headers of a reverse proxy
+	trustForwarded bool
+}
+
+// WithBasePath serves the SSE and message endpoints under a path prefix,
+// e.g. /postgres/sse, for a reverse proxy forwarding a path of a shared host
+func WithBasePath(basePath string) Option {
+	return func(s *PostgresMCPServer) {
+		s.http.basePath = cleanBasePath(basePath)
+	}
+}
+
+// WithCORS allows browser-based clients of the given origins, e.g.
+// https://app.example.com, or * for any origin, to call the HTTP server.
+// Preflight requests are answered and other origins are refused.
+func WithCORS(origins ...string) Option {
+	return func(s *PostgresMCPServer) {
+		s.http.corsOrigins = origins
+	}
+}
+
+// WithForwardedHeaders trusts the X-Forwarded-* headers of a reverse proxy.
+// The message endpoint sent to clients is then a path, prefixed with
+// X-Forwarded-Prefix, which clients resolve against the public URL they
+// connected to, i.e. the scheme and host of X-Forwarded-Proto and
+// X-Forwarded-Host. The caller identity of policies and write approval
+// takes the user of X-Forwarded-User. Only enable it behind a proxy that
+// sets these headers.
+func WithForwardedHeaders() Option {
+	return func(s *PostgresMCPServer) {
+		s.http.trustForwarded = true
+	}
+}
+
+// cleanBasePath returns a path prefix with a leading and without a trailing
+// slash, empty for the root
+func cleanBasePath(p string) string {
+	p = path.Clean("/" + p)
+	if p == "/" {
+		return ""
+	}
+	return p
+}
+
+// sseHandler returns the compressed handler of the SSE and message endpoints
+// of the server under prefix, whose event streams can be resumed
+func (s *PostgresMCPServer) sseHandler(baseURL, prefix string) http.Handler {
+	opts := []server.SSEOption{
+		server.WithBaseURL(baseURL),
+		server.WithSSEContextFunc(s.requestContext),
+	}
+	if !s.http.trustForwarded {
+		sseServer := server.NewSSEServer(s.server, append(opts, server.WithStaticBasePath(prefix))...)
+		return compressHandler(resumableHandler(sseServer, prefix+"/sse", s.sseIdleTimeout), s.compressionLevel)
+	}
+
+	// The public URL is only known per request, so the message endpoint is
+	// sent as a path resolved by the client
+	sseServer := server.NewSSEServer(s.server, append(opts,
+		server.WithUseFullURLForMessageEndpoint(false),
+		server.WithDynamicBasePath(func(r *http.Request, sessionID string) string {
+			return forwardedPrefix(r) + prefix
+		}),
+	)...)
+	mux := http.NewServeMux()
+	mux.Handle(prefix+"/sse", sseServer.SSEHandler())
+	mux.Handle(prefix+"/message", sseServer.MessageHandler())
+	return compressHandler(resumableHandler(mux, prefix+"/sse", s.sseIdleTimeout), s.compressionLevel)
+}
+
+// serveHTTP serves handler on addr until ctx is done, e.g. on SIGTERM. The
+// event streams never end by themselves, so they are ended at once, and the
+// other requests in flight get shutdownTimeout to complete.
+func serveHTTP(ctx context.Context, addr string, handler http.Handler) error {
+	baseCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := &http.Server{
+		Addr:        addr,
+		Handler:     handler,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	cancel()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return srv.Close()
+	}
+	return nil
+}
+
+// forwardedPrefix returns the path prefix of X-Forwarded-Prefix
+func forwardedPrefix(r *http.Request) string {
+	prefix, _, _ := strings.Cut(r.Header.Get(ForwardedPrefixHeader), ",")
+	if prefix = strings.TrimSpace(prefix); prefix == "" {
+		return ""
+	}
+	return cleanBasePath(prefix)
+}
+
+// corsHandler adds the CORS headers of the allowed origins to the responses
+// of next and answers preflight requests. Without origins the responses are
+// left as they are.
+func corsHandler(next http.Handler, origins []string) http.Handler {
+	if len(origins) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		allowed := corsAllowedOrigin(origins, origin)
+		w.Header().Add("Vary", "Origin")
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if allowed == "" {
+				http.Error(w, "origin not allowed", http.StatusForbidden)
+				return
+			}
+			header := w.Header()
+			header.Set("Access-Control-Allow-Origin", allowed)
+			header.Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+				header.Set("Access-Control-Allow-Headers", requested)
+			} else {
+				header.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			}
+			header.Set("Access-Control-Max-Age", corsMaxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(&corsWriter{ResponseWriter: w, origin: allowed}, r)
+	})
+}
+
+// corsAllowedOrigin returns the Access-Control-Allow-Origin of a request
+// origin, empty when it is not allowed
+func corsAllowedOrigin(origins []string, origin string) string {
+	if origin == "" {
+		return ""
+	}
+	for _, allowed := range origins {
+		if allowed == "*" {
+			return "*"
+		}
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// corsWriter sets Access-Control-Allow-Origin when the header is sent,
+// replacing the header the SSE server sets for any origin
+type corsWriter struct {
+	http.ResponseWriter
+	origin      string
+	wroteHeader bool
+}
+
+// WriteHeader implements http.ResponseWriter
+func (cw *corsWriter) WriteHeader(status int) {
+	if !cw.wroteHeader {
+		cw.wroteHeader = true
+		if cw.origin != "" {
+			cw.Header().Set("Access-Control-Allow-Origin", cw.origin)
+		} else {
+			cw.Header().Del("Access-Control-Allow-Origin")
+		}
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter
+func (cw *corsWriter) Write(p []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush implements http.Flusher for the event stream
+func (cw *corsWriter) Flush() {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (cw *corsWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
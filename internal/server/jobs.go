@@ -0,0 +1,322 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// maxRunningJobs bounds the number of concurrently running query jobs
+	maxRunningJobs = 4
+	// jobRetention is how long finished jobs and their results are kept
+	jobRetention = time.Hour
+)
+
+// jobStatus is the state of a query job
+type jobStatus string
+
+const (
+	jobRunning   jobStatus = "running"
+	jobSucceeded jobStatus = "succeeded"
+	jobFailed    jobStatus = "failed"
+	jobCanceled  jobStatus = "canceled"
+)
+
+// queryJob is a query running asynchronously on the server
+type queryJob struct {
+	ID          string     `json:"id"`
+	SQL         string     `json:"sql"`
+	Status      jobStatus  `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	RowCount    int        `json:"row_count"`
+	SubmittedAt time.Time  `json:"submitted_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Duration    string     `json:"duration"`
+
+	sessionID string
+	result    *db.QueryResult
+	elapsed   time.Duration
+	cancel    context.CancelFunc
+}
+
+// jobManager keeps track of the query jobs
+type jobManager struct {
+	mu   sync.Mutex
+	jobs map[string]*queryJob
+}
+
+// addJobTools registers the asynchronous query job tools
+func (s *PostgresMCPServer) addJobTools() {
+	submitTool := mcp.NewTool("submit_query_job",
+		mcp.WithDescription("Start a read-only SQL query in the background and return a job ID immediately. Use for heavy analytical queries that may take minutes, then poll get_job_status and fetch get_job_result."),
+		mcp.WithString("sql",
+			mcp.Required(),
+			mcp.Description("The SQL query to execute"),
+		),
+	)
+	s.addTool(submitTool, s.handleSubmitQueryJob)
+
+	statusTool := mcp.NewTool("get_job_status",
+		mcp.WithDescription("Get the status of a query job: running, succeeded, failed or canceled"),
+		mcp.WithString("job_id",
+			mcp.Required(),
+			mcp.Description("The job ID returned by submit_query_job"),
+		),
+	)
+	s.addTool(statusTool, s.handleGetJobStatus)
+
+	resultTool := mcp.NewTool("get_job_result",
+		mcp.WithDescription("Get the rows of a succeeded query job"),
+		mcp.WithString("job_id",
+			mcp.Required(),
+			mcp.Description("The job ID returned by submit_query_job"),
+		),
+	)
+	s.addTool(resultTool, s.handleGetJobResult)
+
+	cancelTool := mcp.NewTool("cancel_job",
+		mcp.WithDescription("Cancel a running query job"),
+		mcp.WithString("job_id",
+			mcp.Required(),
+			mcp.Description("The job ID returned by submit_query_job"),
+		),
+	)
+	s.addTool(cancelTool, s.handleCancelJob)
+}
+
+// handleSubmitQueryJob handles the submit_query_job tool
+func (s *PostgresMCPServer) handleSubmitQueryJob(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	sql := stringArg(request, "sql", "")
+	if sql == "" {
+		return mcp.NewToolResultError("SQL query is required"), nil
+	}
+
+	// The job outlives the request, its query is accounted to the session here
+	sessionID := sessionIDFromContext(ctx)
+	job, err := s.jobs.submit(sessionID, sql, func(ctx context.Context, sql string) (*db.QueryResult, error) {
+		start := time.Now()
+		result, err := s.runJob(ctx, sql)
+		if result != nil {
+			s.usage.recordQuery(sessionID, len(result.Rows), time.Since(start))
+		}
+		return result, err
+	})
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to submit job", err), nil
+	}
+	log.Printf("submitted query job %s: %s", job.ID, sql)
+
+	return jobResult(job)
+}
+
+// handleGetJobStatus handles the get_job_status tool
+func (s *PostgresMCPServer) handleGetJobStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	job, err := s.jobs.get(sessionIDFromContext(ctx), stringArg(request, "job_id", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return jobResult(job)
+}
+
+// handleGetJobResult handles the get_job_result tool
+func (s *PostgresMCPServer) handleGetJobResult(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	job, err := s.jobs.get(sessionIDFromContext(ctx), stringArg(request, "job_id", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if job.Status != jobSucceeded {
+		return mcp.NewToolResultError(fmt.Sprintf("Job %s has no result, its status is %s", job.ID, job.Status)), nil
+	}
+
+	resp, err := s.runResponse(ctx, queryRun{QueryResult: *job.result, Duration: job.elapsed})
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to build response", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleCancelJob handles the cancel_job tool
+func (s *PostgresMCPServer) handleCancelJob(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	job, err := s.jobs.cancel(sessionIDFromContext(ctx), stringArg(request, "job_id", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return jobResult(job)
+}
+
+// runJob executes the query of a job
+func (s *PostgresMCPServer) runJob(ctx context.Context, sql string) (*db.QueryResult, error) {
+	ctx, span := s.startQuerySpan(ctx, sql)
+	start := time.Now()
+	result, err := s.db.QueryContext(ctx, sql)
+	rows := 0
+	if result != nil {
+		rows = len(result.Rows)
+	}
+	endQuerySpan(span, rows, 0, err)
+	s.slowQueries.record(ctx, sql, nil, start, rows, err)
+	return result, err
+}
+
+// jobResult returns the job status as a tool result
+func jobResult(job queryJob) (*mcp.CallToolResult, error) {
+	jobJSON, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal job to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(jobJSON)), nil
+}
+
+// sessionIDFromContext returns the MCP session ID of the request, if any
+func sessionIDFromContext(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+// submit starts a job running fn in the background and returns a snapshot of it
+func (m *jobManager) submit(sessionID, sql string, fn func(ctx context.Context, sql string) (*db.QueryResult, error)) (queryJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expire()
+
+	running := 0
+	for _, job := range m.jobs {
+		if job.Status == jobRunning {
+			running++
+		}
+	}
+	if running >= maxRunningJobs {
+		return queryJob{}, fmt.Errorf("too many running jobs, wait for one to finish or cancel one")
+	}
+
+	id, err := newRandomID()
+	if err != nil {
+		return queryJob{}, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &queryJob{
+		ID:          id,
+		SQL:         sql,
+		Status:      jobRunning,
+		SubmittedAt: time.Now(),
+		sessionID:   sessionID,
+		cancel:      cancel,
+	}
+	if m.jobs == nil {
+		m.jobs = map[string]*queryJob{}
+	}
+	m.jobs[id] = job
+
+	go func() {
+		result, err := fn(ctx, sql)
+		m.finish(job, result, err)
+	}()
+
+	return m.snapshot(job), nil
+}
+
+// finish records the outcome of a job
+func (m *jobManager) finish(job *queryJob, result *db.QueryResult, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job.cancel()
+	now := time.Now()
+	job.FinishedAt = &now
+	job.elapsed = now.Sub(job.SubmittedAt)
+	switch {
+	case job.Status == jobCanceled:
+	case err != nil:
+		job.Status = jobFailed
+		job.Error = err.Error()
+	default:
+		job.Status = jobSucceeded
+		job.result = result
+		job.RowCount = len(result.Rows)
+	}
+}
+
+// get returns a snapshot of a job of the session
+func (m *jobManager) get(sessionID, id string) (queryJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expire()
+	job, ok := m.jobs[id]
+	if !ok || job.sessionID != sessionID {
+		return queryJob{}, fmt.Errorf("job %q not found", id)
+	}
+	return m.snapshot(job), nil
+}
+
+// cancel cancels a running job of the session
+func (m *jobManager) cancel(sessionID, id string) (queryJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok || job.sessionID != sessionID {
+		return queryJob{}, fmt.Errorf("job %q not found", id)
+	}
+	if job.Status == jobRunning {
+		job.Status = jobCanceled
+		job.cancel()
+	}
+	return m.snapshot(job), nil
+}
+
+// cancelAll cancels every running job
+func (m *jobManager) cancelAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, job := range m.jobs {
+		if job.Status == jobRunning {
+			job.Status = jobCanceled
+			job.cancel()
+		}
+	}
+}
+
+// snapshot copies a job with its duration filled in, m.mu must be held
+func (m *jobManager) snapshot(job *queryJob) queryJob {
+	snapshot := *job
+	end := time.Now()
+	if job.FinishedAt != nil {
+		end = *job.FinishedAt
+	}
+	snapshot.Duration = end.Sub(job.SubmittedAt).Round(time.Millisecond).String()
+	return snapshot
+}
+
+// expire drops finished jobs past the retention period, m.mu must be held
+func (m *jobManager) expire() {
+	cutoff := time.Now().Add(-jobRetention)
+	for id, job := range m.jobs {
+		if job.FinishedAt != nil && job.FinishedAt.Before(cutoff) {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// newRandomID returns a random ID of jobs and write proposals
+func newRandomID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// lazyConnectRetryInterval is how often an unreachable database is retried
+const lazyConnectRetryInterval = 5 * time.Second
+
+// WithLazyConnect starts the server even when the database is unreachable.
+// Tools return an error until the database can be reached; the table schema
+// resources, the extension-specific tools and change data capture are set
+// up once it is.
+func WithLazyConnect() Option {
+	return func(s *PostgresMCPServer) {
+		s.lazyConnect = true
+		s.dbOptions = append(s.dbOptions, db.WithLazyConnect())
+	}
+}
+
+// requireDatabase wraps a tool handler to fail with a clear error until the
+// database has been reached once
+func (s *PostgresMCPServer) requireDatabase(handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	if !s.lazyConnect {
+		return handler
+	}
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !s.connected.Load() {
+			if err := s.db.Ping(ctx); err != nil {
+				return mcp.NewToolResultErrorFromErr("The database is not reachable yet, try again later", err), nil
+			}
+			s.connected.Store(true)
+		}
+		return handler(ctx, request)
+	}
+}
+
+// startLazySetup finishes the setup in the background once the database is
+// reachable. Clients are notified of the added resources and tools.
+func (s *PostgresMCPServer) startLazySetup() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopLazySetup = cancel
+
+	go func() {
+		ticker := time.NewTicker(lazyConnectRetryInterval)
+		defer ticker.Stop()
+		for {
+			if err := s.db.Ping(ctx); err == nil {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+		s.connected.Store(true)
+		log.Printf("database reachable, finishing setup")
+
+		if err := s.addTableResources(); err != nil {
+			log.Printf("lazy setup: %v", err)
+		}
+		s.addExtensionTools()
+		if s.cdcConfig != nil {
+			if err := s.setupCDC(); err != nil {
+				log.Printf("lazy setup: failed to set up change data capture: %v", err)
+			}
+		}
+		if s.schemaWatchInterval > 0 {
+			s.startSchemaWatch()
+		}
+		s.startDDLListener()
+	}()
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleLocal sends a JSON-RPC request to the MCP server without a transport
+// and returns its result, so the command line sees what a client receives
+func (s *PostgresMCPServer) handleLocal(ctx context.Context, method mcp.MCPMethod, params any) (any, error) {
+	message, err := json.Marshal(map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s request: %w", method, err)
+	}
+
+	switch response := s.server.HandleMessage(ctx, message).(type) {
+	case mcp.JSONRPCResponse:
+		return response.Result, nil
+	case mcp.JSONRPCError:
+		return nil, fmt.Errorf("%s failed: %s", method, response.Error.Message)
+	default:
+		return nil, fmt.Errorf("unexpected %s response %T", method, response)
+	}
+}
+
+// ListTools returns the registered tools as listed to MCP clients
+func (s *PostgresMCPServer) ListTools(ctx context.Context) ([]mcp.Tool, error) {
+	result, err := s.handleLocal(ctx, mcp.MethodToolsList, map[string]any{})
+	if err != nil {
+		return nil, err
+	}
+	list, ok := result.(mcp.ListToolsResult)
+	if !ok {
+		return nil, fmt.Errorf("unexpected tools/list result %T", result)
+	}
+	return list.Tools, nil
+}
+
+// CallTool calls a tool like an MCP client would and returns its result
+func (s *PostgresMCPServer) CallTool(ctx context.Context, name string, arguments map[string]any) (*mcp.CallToolResult, error) {
+	result, err := s.handleLocal(ctx, mcp.MethodToolsCall, map[string]any{
+		"name":      name,
+		"arguments": arguments,
+	})
+	if err != nil {
+		return nil, err
+	}
+	callResult, ok := result.(mcp.CallToolResult)
+	if !ok {
+		return nil, fmt.Errorf("unexpected tools/call result %T", result)
+	}
+	return &callResult, nil
+}
+
+// DumpSchema returns the columns of each table of the public schema, the
+// content of the table schema resources
+func (s *PostgresMCPServer) DumpSchema() (map[string][]db.TableColumn, error) {
+	tableNames, err := s.tableNames()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table names: %w", err)
+	}
+
+	return s.tableSchemas(tableNames)
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// TenantHeader names the database of a request to the root endpoints
+const TenantHeader = "X-Postgres-Database"
+
+// tenantPathPrefix is the path under which each named database is served
+const tenantPathPrefix = "/mcp/"
+
+// ServeSSETenants serves several databases over SSE on one address. Each
+// tenant is reachable under /mcp/{name}/sse with its own connection pool and
+// sessions. The root /sse and /message endpoints serve the tenant named by
+// the X-Postgres-Database header, or root when the header is absent. root
+// may be nil. The base path and CORS origins of root, or of any tenant when
+// root is nil, apply to all endpoints. The server stops when ctx is done.
+func ServeSSETenants(ctx context.Context, addr, baseURL string, root *PostgresMCPServer, tenants map[string]*PostgresMCPServer) error {
+	var config httpConfig
+	if root != nil {
+		config = root.http
+	} else {
+		for _, tenant := range tenants {
+			config = tenant.http
+			break
+		}
+	}
+	mux := http.NewServeMux()
+
+	handlers := map[string]http.Handler{}
+	for name, tenant := range tenants {
+		prefix := config.basePath + tenantPathPrefix + name
+		handler := tenant.sseHandler(baseURL, prefix)
+		handlers[name] = handler
+		mux.Handle(prefix+"/", handler)
+	}
+
+	var rootHandler http.Handler
+	if root != nil {
+		rootHandler = root.sseHandler(baseURL, config.basePath)
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if name := r.Header.Get(TenantHeader); name != "" {
+			handler, ok := handlers[name]
+			if !ok {
+				http.Error(w, "unknown database "+name, http.StatusNotFound)
+				return
+			}
+			// Route to the tenant as if its path had been used
+			tenantRequest := r.Clone(r.Context())
+			tenantRequest.URL.Path = config.basePath + tenantPathPrefix + name + strings.TrimPrefix(r.URL.Path, config.basePath)
+			handler.ServeHTTP(w, tenantRequest)
+			return
+		}
+		if rootHandler == nil {
+			http.NotFound(w, r)
+			return
+		}
+		rootHandler.ServeHTTP(w, r)
+	})
+
+	return serveHTTP(ctx, addr, corsHandler(mux, config.corsOrigins))
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/config"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// addNamedQueryTools registers a tool for each configured named query
+func (s *PostgresMCPServer) addNamedQueryTools() error {
+	for _, q := range s.namedQueries {
+		if s.toolNames[q.Name] {
+			return fmt.Errorf("named query %q clashes with an existing tool", q.Name)
+		}
+
+		description := q.Description
+		if description == "" {
+			description = fmt.Sprintf("Run the pre-approved query %s", q.Name)
+		}
+		opts := []mcp.ToolOption{
+			mcp.WithDescription(description),
+			mcp.WithReadOnlyHintAnnotation(true),
+		}
+		for _, p := range q.Parameters {
+			opts = append(opts, namedQueryParameter(p))
+		}
+
+		query := q
+		s.addTool(mcp.NewTool(q.Name, opts...), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return s.handleNamedQuery(ctx, request, query)
+		})
+	}
+	return nil
+}
+
+// namedQueryParameter returns the tool option declaring a named query parameter
+func namedQueryParameter(p config.Parameter) mcp.ToolOption {
+	propOpts := []mcp.PropertyOption{mcp.Description(p.Description)}
+	if p.Required {
+		propOpts = append(propOpts, mcp.Required())
+	}
+	switch p.Type {
+	case config.ParameterNumber, config.ParameterInteger:
+		return mcp.WithNumber(p.Name, propOpts...)
+	case config.ParameterBoolean:
+		return mcp.WithBoolean(p.Name, propOpts...)
+	default:
+		return mcp.WithString(p.Name, propOpts...)
+	}
+}
+
+// handleNamedQuery runs a named query with the arguments bound as $1, $2, ...
+func (s *PostgresMCPServer) handleNamedQuery(ctx context.Context, request mcp.CallToolRequest, q config.NamedQuery) (*mcp.CallToolResult, error) {
+	args := make([]interface{}, 0, len(q.Parameters))
+	for _, p := range q.Parameters {
+		arg, err := namedQueryArg(p, request.Params.Arguments[p.Name])
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		args = append(args, arg)
+	}
+	log.Printf("named query %s called with arguments: %v", q.Name, args)
+
+	start := time.Now()
+	run, err := s.readOnlyQuery(ctx, q.SQL, args...)
+	s.history.record(sessionIDFromContext(ctx), q.SQL, args, start, run.Rows, err)
+	if err != nil {
+		return s.queryError("Failed to execute query", q.SQL, err), nil
+	}
+
+	resp, err := s.runResponse(ctx, run)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to build response", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// namedQueryArg converts a tool argument to the bind value of a parameter.
+// Missing optional parameters are bound as NULL.
+func namedQueryArg(p config.Parameter, value interface{}) (interface{}, error) {
+	if value == nil {
+		if p.Required {
+			return nil, fmt.Errorf("%s is required", p.Name)
+		}
+		return nil, nil
+	}
+
+	switch p.Type {
+	case config.ParameterString:
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+	case config.ParameterNumber:
+		if v, ok := value.(float64); ok {
+			return v, nil
+		}
+	case config.ParameterInteger:
+		if v, ok := value.(float64); ok && v == math.Trunc(v) {
+			return int64(v), nil
+		}
+	case config.ParameterBoolean:
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("%s must be of type %s", p.Name, p.Type)
+}
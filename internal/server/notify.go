@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// notificationMethod is the MCP notification used to forward NOTIFY payloads.
+// Logging notifications are understood by every client.
+const notificationMethod = "notifications/message"
+
+// channelSubscriptions tracks which MCP sessions listen on which channels
+type channelSubscriptions struct {
+	mu       sync.Mutex
+	listener *db.NotificationListener
+	// channels maps a channel name to the subscribed session IDs
+	channels map[string]map[string]struct{}
+}
+
+// addNotifyTools registers the LISTEN/NOTIFY tools
+func (s *PostgresMCPServer) addNotifyTools() {
+	subscribeTool := mcp.NewTool("subscribe_channel",
+		mcp.WithDescription("LISTEN on a PostgreSQL notification channel. Payloads sent with NOTIFY or pg_notify are forwarded to this session as notifications/message log notifications with logger \"postgres\"."),
+		mcp.WithString("channel",
+			mcp.Required(),
+			mcp.Description("The notification channel"),
+		),
+	)
+	s.addTool(subscribeTool, s.handleSubscribeChannel)
+
+	unsubscribeTool := mcp.NewTool("unsubscribe_channel",
+		mcp.WithDescription("Stop forwarding notifications of a PostgreSQL notification channel to this session"),
+		mcp.WithString("channel",
+			mcp.Required(),
+			mcp.Description("The notification channel"),
+		),
+	)
+	s.addTool(unsubscribeTool, s.handleUnsubscribeChannel)
+}
+
+// handleSubscribeChannel handles the subscribe_channel tool
+func (s *PostgresMCPServer) handleSubscribeChannel(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	channel := stringArg(request, "channel", "")
+	if channel == "" {
+		return mcp.NewToolResultError("channel is required"), nil
+	}
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return mcp.NewToolResultError("Subscriptions require a client session"), nil
+	}
+
+	if err := s.subscribe(session.SessionID(), channel); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to subscribe", err), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Subscribed to channel %s", channel)), nil
+}
+
+// handleUnsubscribeChannel handles the unsubscribe_channel tool
+func (s *PostgresMCPServer) handleUnsubscribeChannel(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	channel := stringArg(request, "channel", "")
+	if channel == "" {
+		return mcp.NewToolResultError("channel is required"), nil
+	}
+	session := server.ClientSessionFromContext(ctx)
+	if session == nil {
+		return mcp.NewToolResultError("Subscriptions require a client session"), nil
+	}
+
+	if err := s.unsubscribe(session.SessionID(), channel); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to unsubscribe", err), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Unsubscribed from channel %s", channel)), nil
+}
+
+// subscribe adds a session to a channel, listening on it if needed
+func (s *PostgresMCPServer) subscribe(sessionID, channel string) error {
+	subs := &s.subscriptions
+	subs.mu.Lock()
+	defer subs.mu.Unlock()
+
+	if subs.listener == nil {
+		subs.listener = s.db.NewNotificationListener(s.forwardNotification)
+		subs.channels = map[string]map[string]struct{}{}
+	}
+
+	sessions, ok := subs.channels[channel]
+	if !ok {
+		if err := subs.listener.Listen(channel); err != nil {
+			return err
+		}
+		sessions = map[string]struct{}{}
+		subs.channels[channel] = sessions
+	}
+	sessions[sessionID] = struct{}{}
+	return nil
+}
+
+// unsubscribe removes a session from a channel, unlistening when it was the last one
+func (s *PostgresMCPServer) unsubscribe(sessionID, channel string) error {
+	subs := &s.subscriptions
+	subs.mu.Lock()
+	defer subs.mu.Unlock()
+
+	sessions, ok := subs.channels[channel]
+	if !ok {
+		return nil
+	}
+	delete(sessions, sessionID)
+	if len(sessions) > 0 {
+		return nil
+	}
+	delete(subs.channels, channel)
+	return subs.listener.Unlisten(channel)
+}
+
+// unsubscribeSession removes a closed session from all channels
+func (s *PostgresMCPServer) unsubscribeSession(ctx context.Context, session server.ClientSession) {
+	s.subscriptions.mu.Lock()
+	var channels []string
+	for channel := range s.subscriptions.channels {
+		channels = append(channels, channel)
+	}
+	s.subscriptions.mu.Unlock()
+
+	for _, channel := range channels {
+		if err := s.unsubscribe(session.SessionID(), channel); err != nil {
+			log.Printf("failed to unsubscribe session %s: %v", session.SessionID(), err)
+		}
+	}
+}
+
+// forwardNotification sends a NOTIFY payload to the subscribed sessions
+func (s *PostgresMCPServer) forwardNotification(n db.Notification) {
+	s.subscriptions.mu.Lock()
+	var sessionIDs []string
+	for sessionID := range s.subscriptions.channels[n.Channel] {
+		sessionIDs = append(sessionIDs, sessionID)
+	}
+	s.subscriptions.mu.Unlock()
+	sort.Strings(sessionIDs)
+
+	params := map[string]any{
+		"level":  mcp.LoggingLevelInfo,
+		"logger": "postgres",
+		"data":   n,
+	}
+	for _, sessionID := range sessionIDs {
+		if err := s.server.SendNotificationToSpecificClient(sessionID, notificationMethod, params); err != nil {
+			log.Printf("failed to forward notification on channel %s to session %s: %v", n.Channel, sessionID, err)
+		}
+	}
+}
+
+// closeSubscriptions closes the listener connection
+func (s *PostgresMCPServer) closeSubscriptions() error {
+	s.subscriptions.mu.Lock()
+	defer s.subscriptions.mu.Unlock()
+	if s.subscriptions.listener == nil {
+		return nil
+	}
+	err := s.subscriptions.listener.Close()
+	s.subscriptions.listener = nil
+	s.subscriptions.channels = nil
+	return err
+}
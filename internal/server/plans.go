@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// planDatabases are the other databases compare_plans can explain queries on,
+// the configured ones and the scratch databases. Connections are opened on
+// first use.
+type planDatabases struct {
+	mu    sync.Mutex
+	urls  map[string]string
+	conns map[string]*db.DB
+	// options are the database options of the server's own connection
+	options []db.Option
+}
+
+// WithPlanDatabases lets compare_plans explain queries on other databases,
+// e.g. staging and production, given by name. Only these databases, the
+// scratch databases and the server's own database can be explained.
+func WithPlanDatabases(urls map[string]string) Option {
+	return func(s *PostgresMCPServer) {
+		s.planDatabases.urls = make(map[string]string, len(urls))
+		for name, url := range urls {
+			s.planDatabases.urls[name] = url
+		}
+	}
+}
+
+// addPlanTools registers the query plan comparison tool, which takes
+// free-form SQL and is left out when it is disabled
+func (s *PostgresMCPServer) addPlanTools() {
+	if s.restrictSQL {
+		return
+	}
+	description := "Compare the estimated plans of a query, without running it, before and after hypothetical indexes (requires the hypopg extension)"
+	others := s.planDatabases.names()
+	if s.scratch != nil && s.allowWrite {
+		others = append(others, "a scratch database of create_scratch_database")
+	}
+	if len(others) > 0 {
+		description += " or between this database and another one: " + strings.Join(others, ", ")
+	}
+	description += ". Returns both plans and a node by node diff of node types, costs and estimated rows."
+
+	comparePlansTool := mcp.NewTool("compare_plans",
+		mcp.WithDescription(description),
+		mcp.WithString("sql",
+			mcp.Required(),
+			mcp.Description("The query to explain"),
+		),
+		mcp.WithString("database",
+			mcp.Description("The database whose plan is compared with this database's plan"),
+		),
+		mcp.WithArray("hypothetical_indexes",
+			mcp.Description("CREATE INDEX statements planned as if the indexes existed for the second plan"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(comparePlansTool, s.handleComparePlans)
+}
+
+// handleComparePlans handles the compare_plans tool
+func (s *PostgresMCPServer) handleComparePlans(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	sql := stringArg(request, "sql", "")
+	if sql == "" {
+		return mcp.NewToolResultError("SQL query is required"), nil
+	}
+	database := stringArg(request, "database", "")
+	indexes := stringSliceArg(request, "hypothetical_indexes")
+	if database == "" && len(indexes) == 0 {
+		return mcp.NewToolResultError("Either database or hypothetical_indexes is required"), nil
+	}
+
+	before, err := s.db.ExplainQuery(ctx, sql, nil)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to explain query", err), nil
+	}
+
+	target := s.db
+	if database != "" {
+		target, err = s.otherDatabase(database)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to connect to database", err), nil
+		}
+	}
+	after, err := target.ExplainQuery(ctx, sql, indexes)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to explain query", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(db.ComparePlans(before, after), "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// names returns the sorted names of the databases
+func (p *planDatabases) names() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	names := make([]string, 0, len(p.urls))
+	for name := range p.urls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// get returns the connection to a database, opening it on first use
+func (p *planDatabases) get(name string) (*db.DB, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if conn, ok := p.conns[name]; ok {
+		return conn, nil
+	}
+	url, ok := p.urls[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown database %s", name)
+	}
+
+	conn, err := db.New(url, p.options...)
+	if err != nil {
+		return nil, err
+	}
+	if p.conns == nil {
+		p.conns = map[string]*db.DB{}
+	}
+	p.conns[name] = conn
+	return conn, nil
+}
+
+// has reports whether a database is registered
+func (p *planDatabases) has(name string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.urls[name]
+	return ok
+}
+
+// add registers a database, replacing one of the same name
+func (p *planDatabases) add(name, url string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.urls == nil {
+		p.urls = map[string]string{}
+	}
+	p.urls[name] = url
+}
+
+// remove forgets a database and closes its connection
+func (p *planDatabases) remove(name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.urls, name)
+	conn, ok := p.conns[name]
+	if !ok {
+		return nil
+	}
+	delete(p.conns, name)
+	return conn.Close()
+}
+
+// close closes the opened connections
+func (p *planDatabases) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var errs []error
+	for _, conn := range p.conns {
+		errs = append(errs, conn.Close())
+	}
+	p.conns = nil
+	return errors.Join(errs...)
+}
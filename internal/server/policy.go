@@ -0,0 +1,279 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/config"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// UserHeader names the authenticated user of a request, as set by an
+// authenticating reverse proxy in front of the server. It is only trusted
+// with WithForwardedHeaders.
+const UserHeader = "X-Forwarded-User"
+
+// Identity is the caller of a tool
+type Identity struct {
+	SessionID string
+	// User is the UserHeader of the HTTP request, empty unless forwarded
+	// headers are trusted
+	User string
+}
+
+// Invocation is a tool call submitted to the policy before it runs
+type Invocation struct {
+	Tool      string
+	Arguments map[string]interface{}
+	// SQL is the query of the tools taking an sql argument, of named
+	// queries and the stored query re-run by rerun_query, compare_snapshots
+	// and approve_write, empty for the other tools
+	SQL      string
+	Identity Identity
+
+	tablesOnce sync.Once
+	tables     []string
+	tablesErr  error
+	resolve    func(ctx context.Context) ([]string, error)
+}
+
+// Tables returns the "schema.table" names the call reads. For SQL they are
+// taken from the query plan, with views expanded to their base tables; for
+// the other tools from the table, schema and tables arguments. The tables
+// are only looked up when the policy asks for them.
+func (inv *Invocation) Tables(ctx context.Context) ([]string, error) {
+	inv.tablesOnce.Do(func() {
+		inv.tables, inv.tablesErr = inv.resolve(ctx)
+	})
+	return inv.tables, inv.tablesErr
+}
+
+// Policy authorizes tool calls. An error denies the call and is returned to
+// the client. Implementations can evaluate CEL or Rego rules on the
+// invocation.
+type Policy interface {
+	Authorize(ctx context.Context, inv *Invocation) error
+}
+
+// PolicyFunc adapts a function to a Policy
+type PolicyFunc func(ctx context.Context, inv *Invocation) error
+
+// Authorize calls f
+func (f PolicyFunc) Authorize(ctx context.Context, inv *Invocation) error {
+	return f(ctx, inv)
+}
+
+// WithPolicy evaluates every tool call against the policy before it runs.
+// Several policies must all allow a call.
+func WithPolicy(policy Policy) Option {
+	return func(s *PostgresMCPServer) {
+		s.policies = append(s.policies, policy)
+	}
+}
+
+// identityKey is the context key of the request's user
+type identityKey struct{}
+
+// withRequestUser stores the UserHeader of an HTTP request in the context.
+// Clients can set the header themselves, so it is ignored unless it comes
+// from a trusted proxy.
+func withRequestUser(ctx context.Context, r *http.Request, trusted bool) context.Context {
+	if !trusted {
+		return ctx
+	}
+	return context.WithValue(ctx, identityKey{}, r.Header.Get(UserHeader))
+}
+
+// identityFromContext returns the identity of the caller of a tool
+func identityFromContext(ctx context.Context) Identity {
+	var identity Identity
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		identity.SessionID = session.SessionID()
+	}
+	identity.User, _ = ctx.Value(identityKey{}).(string)
+	return identity
+}
+
+// authorize wraps a tool handler to evaluate the policies first
+func (s *PostgresMCPServer) authorize(name string, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	if len(s.policies) == 0 {
+		return handler
+	}
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		inv := &Invocation{
+			Tool:      name,
+			Arguments: request.Params.Arguments,
+			SQL:       s.invocationSQL(ctx, name, request),
+			Identity:  identityFromContext(ctx),
+		}
+		inv.resolve = func(ctx context.Context) ([]string, error) {
+			if inv.SQL != "" {
+				return s.db.ReferencedTables(ctx, inv.SQL)
+			}
+			tables, err := argumentTables(request)
+			if err != nil {
+				return nil, err
+			}
+			if len(tables) == 0 && !tablelessTools[name] {
+				return nil, fmt.Errorf("tool %s names no tables", name)
+			}
+			return tables, nil
+		}
+
+		for _, policy := range s.policies {
+			if err := policy.Authorize(ctx, inv); err != nil {
+				return mcp.NewToolResultErrorFromErr("Denied by policy", err), nil
+			}
+		}
+		return handler(ctx, request)
+	}
+}
+
+// tablelessTools are the tools reading no table data, which are allowed
+// without naming tables when table rules are set
+var tablelessTools = map[string]bool{
+	"begin_transaction":     true,
+	"cancel_job":            true,
+	"commit_transaction":    true,
+	"connection_info":       true,
+	"drop_temp_table":       true,
+	"get_job_result":        true,
+	"get_job_status":        true,
+	"list_query_history":    true,
+	"list_roles":            true,
+	"list_temp_tables":      true,
+	"list_write_proposals":  true,
+	"refresh_capabilities":  true,
+	"reject_write":          true,
+	"release_savepoint":     true,
+	"replication_status":    true,
+	"rollback_to_savepoint": true,
+	"rollback_transaction":  true,
+	"savepoint":             true,
+	"session_usage":         true,
+	"show_settings":         true,
+	"subscribe_channel":     true,
+	"unsubscribe_channel":   true,
+}
+
+// invocationSQL returns the SQL a tool call runs: its sql argument, the
+// query of a named query or the stored query a tool re-runs
+func (s *PostgresMCPServer) invocationSQL(ctx context.Context, name string, request mcp.CallToolRequest) string {
+	if sql := stringArg(request, "sql", ""); sql != "" {
+		return sql
+	}
+	switch name {
+	case "rerun_query":
+		if entry, ok := s.history.get(sessionIDFromContext(ctx), intArg(request, "id", 0)); ok {
+			return entry.SQL
+		}
+	case "compare_snapshots":
+		var queries []string
+		for _, arg := range []string{"name", "other"} {
+			if snapshot, ok := s.snapshots.get(stringArg(request, arg, "")); ok {
+				queries = append(queries, snapshot.SQL)
+			}
+		}
+		return strings.Join(queries, "\n;\n")
+	case "approve_write":
+		if s.writeApproval != nil {
+			if proposal, ok := s.writeApproval.get(stringArg(request, "proposal_id", "")); ok {
+				return proposal.SQL
+			}
+		}
+	}
+	return s.namedQuerySQL(name)
+}
+
+// namedQuerySQL returns the query of a named query tool
+func (s *PostgresMCPServer) namedQuerySQL(name string) string {
+	for _, q := range s.namedQueries {
+		if q.Name == name {
+			return q.SQL
+		}
+	}
+	return ""
+}
+
+// argumentTables returns the tables named by the table, schema and tables
+// arguments, in the public schema unless qualified. Schema lists and table
+// patterns cannot be resolved to tables.
+func argumentTables(request mcp.CallToolRequest) ([]string, error) {
+	if len(stringSliceArg(request, "schemas")) > 0 {
+		return nil, fmt.Errorf("the schemas argument names no tables")
+	}
+	schema := stringArg(request, "schema", "public")
+	qualify := func(table string) string {
+		if strings.Contains(table, ".") {
+			return table
+		}
+		return schema + "." + table
+	}
+
+	tables := []string{}
+	if table := stringArg(request, "table", ""); table != "" {
+		tables = append(tables, qualify(table))
+	}
+	for _, table := range stringSliceArg(request, "tables") {
+		tables = append(tables, qualify(table))
+	}
+	for _, table := range tables {
+		if strings.ContainsAny(table, "*?[") {
+			return nil, fmt.Errorf("table pattern %s names no tables", table)
+		}
+	}
+	return tables, nil
+}
+
+// rulesPolicy is the policy of the configuration file
+type rulesPolicy struct {
+	rules *config.Policy
+}
+
+// NewRulesPolicy returns the policy of the allowed and denied tables and
+// tools of the configuration file. When table rules are set, calls whose
+// tables cannot be determined are denied, as are the calls of tools that
+// name no tables, such as those listing all tables, unless they read no
+// table data.
+func NewRulesPolicy(rules *config.Policy) Policy {
+	return &rulesPolicy{rules: rules}
+}
+
+// Authorize implements Policy
+func (p *rulesPolicy) Authorize(ctx context.Context, inv *Invocation) error {
+	if matchAny(p.rules.DeniedTools, inv.Tool) {
+		return fmt.Errorf("tool %s is denied", inv.Tool)
+	}
+	if len(p.rules.AllowedTables) == 0 && len(p.rules.DeniedTables) == 0 {
+		return nil
+	}
+
+	tables, err := inv.Tables(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot determine the tables of the call: %w", err)
+	}
+	for _, table := range tables {
+		if matchAny(p.rules.DeniedTables, table) {
+			return fmt.Errorf("table %s is denied", table)
+		}
+		if len(p.rules.AllowedTables) > 0 && !matchAny(p.rules.AllowedTables, table) {
+			return fmt.Errorf("table %s is not allowed", table)
+		}
+	}
+	return nil
+}
+
+// matchAny reports whether name matches one of the glob patterns
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestArgumentTables(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name:      "table in public",
+			arguments: map[string]interface{}{"table": "orders"},
+			want:      []string{"public.orders"},
+		},
+		{
+			name:      "table in schema",
+			arguments: map[string]interface{}{"table": "orders", "schema": "sales"},
+			want:      []string{"sales.orders"},
+		},
+		{
+			name:      "qualified tables",
+			arguments: map[string]interface{}{"tables": []any{"sales.orders", "customers"}},
+			want:      []string{"sales.orders", "public.customers"},
+		},
+		{
+			name:      "no tables",
+			arguments: map[string]interface{}{"schema": "sales"},
+			want:      []string{},
+		},
+		{
+			name:      "table pattern",
+			arguments: map[string]interface{}{"tables": []any{"public.*"}},
+			wantErr:   true,
+		},
+		{
+			name:      "schemas",
+			arguments: map[string]interface{}{"schemas": []any{"sales"}, "tables": []any{"public.orders"}},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tt.arguments
+			got, err := argumentTables(request)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
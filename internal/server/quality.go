@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// defaultQualitySampleRows is the number of rows scanned by check_data_quality
+	defaultQualitySampleRows = 100000
+	// defaultNullThreshold is the null ratio above which columns are reported
+	defaultNullThreshold = 0.5
+	// defaultMaxDateYears is how far in the future dates are still plausible
+	defaultMaxDateYears = 10
+)
+
+// addQualityTools registers the data quality tools
+func (s *PostgresMCPServer) addQualityTools() {
+	checkDataQualityTool := mcp.NewTool("check_data_quality",
+		mcp.WithDescription("Run data quality checks on a table and return structured findings: null rates above a threshold, duplicate values in key-like columns without unique index, foreign key values without parent row, implausible dates and empty strings"),
+		mcp.WithString("table",
+			mcp.Required(),
+			mcp.Description("The table to check"),
+		),
+		mcp.WithString("schema",
+			mcp.Description("The schema of the table"),
+			mcp.DefaultString("public"),
+		),
+		mcp.WithArray("checks",
+			mcp.Description("The checks to run, all by default"),
+			mcp.Items(map[string]any{"type": "string", "enum": db.QualityChecks}),
+		),
+		mcp.WithNumber("null_threshold",
+			mcp.Description("Report columns with a null ratio above this value (0 to 1)"),
+			mcp.DefaultNumber(defaultNullThreshold),
+		),
+		mcp.WithArray("key_columns",
+			mcp.Description("Columns to check for duplicates in addition to id, uuid, email, slug and code columns without unique index"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithString("min_date",
+			mcp.Description("Dates before this day (YYYY-MM-DD) are reported, 1900-01-01 by default"),
+		),
+		mcp.WithString("max_date",
+			mcp.Description("Dates after this day (YYYY-MM-DD) are reported, 10 years from now by default"),
+		),
+		mcp.WithNumber("sample_rows",
+			mcp.Description("The maximum number of rows scanned"),
+			mcp.DefaultNumber(defaultQualitySampleRows),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(checkDataQualityTool, s.handleCheckDataQuality)
+}
+
+// handleCheckDataQuality handles the check_data_quality tool
+func (s *PostgresMCPServer) handleCheckDataQuality(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	params := db.QualityParams{
+		Schema:        stringArg(request, "schema", "public"),
+		Table:         stringArg(request, "table", ""),
+		Checks:        stringSliceArg(request, "checks"),
+		SampleRows:    intArg(request, "sample_rows", defaultQualitySampleRows),
+		NullThreshold: defaultNullThreshold,
+		KeyColumns:    stringSliceArg(request, "key_columns"),
+		MinDate:       time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC),
+		MaxDate:       time.Now().AddDate(defaultMaxDateYears, 0, 0),
+	}
+	if params.Table == "" {
+		return mcp.NewToolResultError("table is required"), nil
+	}
+	if params.SampleRows <= 0 {
+		params.SampleRows = defaultQualitySampleRows
+	}
+	if threshold, ok := request.Params.Arguments["null_threshold"].(float64); ok {
+		params.NullThreshold = threshold
+	}
+	for name, date := range map[string]*time.Time{"min_date": &params.MinDate, "max_date": &params.MaxDate} {
+		if value := stringArg(request, name, ""); value != "" {
+			parsed, err := time.Parse(time.DateOnly, value)
+			if err != nil {
+				return mcp.NewToolResultError(name + " must be a date as YYYY-MM-DD"), nil
+			}
+			*date = parsed
+		}
+	}
+
+	report, err := s.db.CheckDataQuality(ctx, params)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to check data quality", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+)
+
+// responseOverheadBytes is reserved for the envelope around truncated rows
+const responseOverheadBytes = 512
+
+// queryRun is the result of a query run by a tool
+type queryRun struct {
+	db.QueryResult
+	// Retries is the number of times the query was retried after a transient error
+	Retries  int
+	Duration time.Duration
+}
+
+// queryResponse is the envelope returned by row-producing tools
+type queryResponse struct {
+	Columns   []db.ResultColumn `json:"columns,omitempty"`
+	Rows      orderedRows       `json:"rows"`
+	Truncated bool              `json:"truncated"`
+	TotalRows int               `json:"total_rows"`
+	Hint      string            `json:"hint,omitempty"`
+	// ResultURI is the resource holding the full result of a truncated response
+	ResultURI string `json:"result_uri,omitempty"`
+	// Notices are the notices and warnings raised by the query, e.g. by RAISE
+	// NOTICE in a function
+	Notices []db.Notice `json:"notices,omitempty"`
+	// ExecutionMS is the time the query took, including retries
+	ExecutionMS float64 `json:"execution_ms,omitempty"`
+	// Retries is the number of times the query was retried after a transient error
+	Retries int `json:"retries,omitempty"`
+}
+
+// newRunResponse builds the response envelope of a query run with its
+// columns, execution time and retries
+func newRunResponse(run queryRun, maxBytes int) (*queryResponse, error) {
+	resp, err := newQueryResponse(run.Rows, run.Columns, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	resp.Notices = run.Notices
+	resp.ExecutionMS = milliseconds(run.Duration)
+	resp.Retries = run.Retries
+	return resp, nil
+}
+
+// newQueryResponse builds the response envelope with the rows in column
+// order, dropping trailing rows when the encoded response would exceed
+// maxBytes
+func newQueryResponse(rows []map[string]interface{}, columns []db.ResultColumn, maxBytes int) (*queryResponse, error) {
+	kept, truncated, err := truncateRows(rows, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &queryResponse{
+		Columns:   columns,
+		Rows:      orderedRows{rows: kept},
+		Truncated: truncated,
+		TotalRows: len(rows),
+	}
+	for _, col := range columns {
+		resp.Rows.columns = append(resp.Rows.columns, col.Name)
+	}
+	if truncated {
+		resp.Hint = fmt.Sprintf("Result truncated to %d of %d rows to stay under %d bytes. Use LIMIT/OFFSET or a narrower column list to paginate.",
+			len(kept), len(rows), maxBytes)
+	}
+	return resp, nil
+}
+
+// orderedRows encodes rows as JSON objects whose keys follow the column
+// order of the query, instead of the sorted key order of Go maps
+type orderedRows struct {
+	columns []string
+	rows    []map[string]interface{}
+}
+
+// MarshalJSON implements json.Marshaler
+func (r orderedRows) MarshalJSON() ([]byte, error) {
+	if r.rows == nil {
+		return []byte("null"), nil
+	}
+	if len(r.columns) == 0 {
+		return json.Marshal(r.rows)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, row := range r.rows {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('{')
+		written := 0
+		for _, col := range r.columns {
+			value, ok := row[col]
+			if !ok {
+				continue
+			}
+			if err := writeMember(&buf, written > 0, col, value); err != nil {
+				return nil, err
+			}
+			written++
+		}
+		// Keys that are not result columns follow in sorted order
+		if written < len(row) {
+			known := make(map[string]bool, len(r.columns))
+			for _, col := range r.columns {
+				known[col] = true
+			}
+			extra := make([]string, 0, len(row)-written)
+			for key := range row {
+				if !known[key] {
+					extra = append(extra, key)
+				}
+			}
+			sort.Strings(extra)
+			for _, key := range extra {
+				if err := writeMember(&buf, written > 0, key, row[key]); err != nil {
+					return nil, err
+				}
+				written++
+			}
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+// writeMember writes a "key":value member of a JSON object
+func writeMember(buf *bytes.Buffer, comma bool, key string, value interface{}) error {
+	if comma {
+		buf.WriteByte(',')
+	}
+	keyJSON, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal value of column %s to JSON: %w", key, err)
+	}
+	buf.Write(keyJSON)
+	buf.WriteByte(':')
+	buf.Write(valueJSON)
+	return nil
+}
+
+// truncateRows returns the longest prefix of rows whose indented JSON encoding
+// fits into maxBytes. A non-positive maxBytes disables truncation.
+func truncateRows[T any](rows []T, maxBytes int) ([]T, bool, error) {
+	if maxBytes <= 0 {
+		return rows, false, nil
+	}
+
+	size := responseOverheadBytes
+	for i, row := range rows {
+		// Rows are nested two levels deep in the indented response
+		rowJSON, err := json.MarshalIndent(row, "    ", "  ")
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to marshal row to JSON: %w", err)
+		}
+		size += len(rowJSON) + len(",\n    ")
+		if size > maxBytes {
+			return rows[:i], true, nil
+		}
+	}
+	return rows, false, nil
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOrderedRowsMarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		rows orderedRows
+		want string
+	}{
+		{
+			name: "column order",
+			rows: orderedRows{columns: []string{"z", "a", "m"}, rows: []map[string]interface{}{{"a": 1, "m": "x", "z": nil}}},
+			want: `[{"z":null,"a":1,"m":"x"}]`,
+		},
+		{
+			name: "several rows",
+			rows: orderedRows{columns: []string{"b", "a"}, rows: []map[string]interface{}{{"a": 1, "b": 2}, {"a": 3, "b": 4}}},
+			want: `[{"b":2,"a":1},{"b":4,"a":3}]`,
+		},
+		{
+			name: "omitted column",
+			rows: orderedRows{columns: []string{"b", "a"}, rows: []map[string]interface{}{{"a": 1}}},
+			want: `[{"a":1}]`,
+		},
+		{
+			name: "extra keys follow sorted",
+			rows: orderedRows{columns: []string{"b"}, rows: []map[string]interface{}{{"b": 1, "y": 2, "x": 3}}},
+			want: `[{"b":1,"x":3,"y":2}]`,
+		},
+		{
+			name: "nested values",
+			rows: orderedRows{columns: []string{"tags", "doc"}, rows: []map[string]interface{}{{"doc": map[string]interface{}{"k": "v"}, "tags": []interface{}{"a", nil}}}},
+			want: `[{"tags":["a",null],"doc":{"k":"v"}}]`,
+		},
+		{
+			name: "escaped keys",
+			rows: orderedRows{columns: []string{`a"b`, "<c>"}, rows: []map[string]interface{}{{`a"b`: 1, "<c>": 2}}},
+			want: `[{"a\"b":1,"\u003cc\u003e":2}]`,
+		},
+		{
+			name: "without columns",
+			rows: orderedRows{rows: []map[string]interface{}{{"b": 1, "a": 2}}},
+			want: `[{"a":2,"b":1}]`,
+		},
+		{
+			name: "no rows",
+			rows: orderedRows{columns: []string{"a"}, rows: []map[string]interface{}{}},
+			want: `[]`,
+		},
+		{
+			name: "nil rows",
+			rows: orderedRows{columns: []string{"a"}},
+			want: `null`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.rows)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// resultsPath is the path component of the query result resources
+	resultsPath = "results"
+	// DefaultResultTTL is how long truncated query results are kept by default
+	DefaultResultTTL = 10 * time.Minute
+	// maxStoredResults bounds the results kept at once, the oldest are dropped first
+	maxStoredResults = 20
+	// resultPreviewRows is the number of rows returned inline with a stored result
+	resultPreviewRows = 20
+)
+
+// storedResult is the full result of a truncated query response, read back
+// in chunks of at most maxResponseBytes
+type storedResult struct {
+	id        string
+	sessionID string
+	columns   []db.ResultColumn
+	rows      []map[string]interface{}
+	// chunks are the index of the first row of each chunk
+	chunks  []int
+	created time.Time
+	expires time.Time
+}
+
+// resultStore keeps the full results of truncated query responses
+type resultStore struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	disabled bool
+	results  map[string]*storedResult
+}
+
+// resultInfo is the result resource, describing a stored result and its chunks
+type resultInfo struct {
+	URI       string            `json:"uri"`
+	Columns   []db.ResultColumn `json:"columns,omitempty"`
+	TotalRows int               `json:"total_rows"`
+	Chunks    []string          `json:"chunks"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// resultChunk is a chunk resource of a stored result
+type resultChunk struct {
+	Columns  []db.ResultColumn `json:"columns,omitempty"`
+	Rows     orderedRows       `json:"rows"`
+	Chunk    int               `json:"chunk"`
+	Chunks   int               `json:"chunks"`
+	FirstRow int               `json:"first_row"`
+	// Next is the URI of the next chunk, empty for the last one
+	Next string `json:"next,omitempty"`
+}
+
+// WithResultTTL sets how long the full results of truncated query responses
+// are kept as session resources. A non-positive ttl disables the result
+// resources, truncated responses only return the rows that fit.
+func WithResultTTL(ttl time.Duration) Option {
+	return func(s *PostgresMCPServer) {
+		s.results.ttl = ttl
+		s.results.disabled = ttl <= 0
+	}
+}
+
+// store keeps the rows of a result for the session, chunked to fit maxBytes
+func (r *resultStore) store(sessionID string, run queryRun, maxBytes int) (*storedResult, error) {
+	chunks, err := chunkRows(run.Rows, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	id, err := newRandomID()
+	if err != nil {
+		return nil, err
+	}
+	ttl := r.ttl
+	if ttl == 0 {
+		ttl = DefaultResultTTL
+	}
+	now := time.Now()
+	result := &storedResult{
+		id:        id,
+		sessionID: sessionID,
+		columns:   run.Columns,
+		rows:      run.Rows,
+		chunks:    chunks,
+		created:   now,
+		expires:   now.Add(ttl),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expire()
+	if r.results == nil {
+		r.results = map[string]*storedResult{}
+	}
+	for len(r.results) >= maxStoredResults {
+		var oldest *storedResult
+		for _, stored := range r.results {
+			if oldest == nil || stored.created.Before(oldest.created) {
+				oldest = stored
+			}
+		}
+		delete(r.results, oldest.id)
+	}
+	r.results[id] = result
+	return result, nil
+}
+
+// get returns a result stored for the session
+func (r *resultStore) get(sessionID, id string) (*storedResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expire()
+	result, ok := r.results[id]
+	if !ok || result.sessionID != sessionID {
+		return nil, fmt.Errorf("result %s not found, it may have expired", id)
+	}
+	return result, nil
+}
+
+// forget drops the results of a closed session
+func (r *resultStore) forget(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, result := range r.results {
+		if result.sessionID == sessionID {
+			delete(r.results, id)
+		}
+	}
+}
+
+// expire drops the results past their expiry, r.mu must be held
+func (r *resultStore) expire() {
+	now := time.Now()
+	for id, result := range r.results {
+		if now.After(result.expires) {
+			delete(r.results, id)
+		}
+	}
+}
+
+// chunkRows splits rows into chunks whose responses fit into maxBytes and
+// returns the index of the first row of each chunk. A row larger than
+// maxBytes is a chunk of its own.
+func chunkRows(rows []map[string]interface{}, maxBytes int) ([]int, error) {
+	var chunks []int
+	for start := 0; start < len(rows); {
+		chunks = append(chunks, start)
+		kept, _, err := truncateRows(rows[start:], maxBytes)
+		if err != nil {
+			return nil, err
+		}
+		start += max(len(kept), 1)
+	}
+	return chunks, nil
+}
+
+// runResponse builds the response envelope of a query run. When the rows do
+// not fit into the response, the full result is kept as a result resource of
+// the session and the response returns a preview of its first rows.
+func (s *PostgresMCPServer) runResponse(ctx context.Context, run queryRun) (*queryResponse, error) {
+	resp, err := newRunResponse(run, s.maxResponseBytes)
+	if err != nil || !resp.Truncated || s.results.disabled {
+		return resp, err
+	}
+
+	result, err := s.results.store(sessionIDFromContext(ctx), run, s.maxResponseBytes)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Rows.rows) > resultPreviewRows {
+		resp.Rows.rows = resp.Rows.rows[:resultPreviewRows]
+	}
+	resp.ResultURI = s.resultURI(result.id)
+	resp.Hint = fmt.Sprintf("Showing the first %d of %d rows. Read the full result in %d chunks from the resources %s/{chunk}, chunk 0 to %d, until %s.",
+		len(resp.Rows.rows), resp.TotalRows, len(result.chunks), resp.ResultURI, len(result.chunks)-1, result.expires.Format(time.RFC3339))
+	return resp, nil
+}
+
+// resultURI returns the URI of a result resource
+func (s *PostgresMCPServer) resultURI(id string) string {
+	return fmt.Sprintf("%s/%s/%s", s.db.ResourceBaseURL(), resultsPath, id)
+}
+
+// addResultResources registers the resource templates of the stored results
+func (s *PostgresMCPServer) addResultResources() {
+	if s.results.disabled {
+		return
+	}
+
+	resultTemplate := mcp.NewResourceTemplate(
+		fmt.Sprintf("%s/%s/{id}", s.db.ResourceBaseURL(), resultsPath),
+		"Query results",
+		mcp.WithTemplateDescription("Full results of truncated query responses of this session: their columns, row count, expiry and the URIs of their chunks. Append .csv or .md to the URI for a table of the chunks."),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+	s.server.AddResourceTemplate(resultTemplate, s.handleResultResource)
+
+	chunkTemplate := mcp.NewResourceTemplate(
+		fmt.Sprintf("%s/%s/{id}/{chunk}", s.db.ResourceBaseURL(), resultsPath),
+		"Query result chunks",
+		mcp.WithTemplateDescription("The rows of a query result, in chunks numbered from 0 that fit into a response. Append .csv or .md to the URI to read the rows as a CSV or Markdown table."),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+	s.server.AddResourceTemplate(chunkTemplate, s.handleResultChunkResource)
+}
+
+// handleResultResource describes a stored result
+func (s *PostgresMCPServer) handleResultResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	mimeType, err := resourceFormat(request)
+	if err != nil {
+		return nil, err
+	}
+	id, _, _ := formatSuffix(templateArgument(request, "id"))
+	result, err := s.results.get(sessionIDFromContext(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+
+	info := resultInfo{
+		URI:       s.resultURI(result.id),
+		Columns:   result.columns,
+		TotalRows: len(result.rows),
+		Chunks:    make([]string, len(result.chunks)),
+		ExpiresAt: result.expires,
+	}
+	chunks := table{columns: []string{"chunk", "uri", "first_row", "rows"}}
+	for i, first := range result.chunks {
+		info.Chunks[i] = fmt.Sprintf("%s/%d", info.URI, i)
+		chunks.rows = append(chunks.rows, map[string]interface{}{
+			"chunk":     i,
+			"uri":       info.Chunks[i],
+			"first_row": first,
+			"rows":      result.chunkEnd(i) - first,
+		})
+	}
+	return formattedResource(request.Params.URI, mimeType, info, chunks)
+}
+
+// handleResultChunkResource returns a chunk of the rows of a stored result
+func (s *PostgresMCPServer) handleResultChunkResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	mimeType, err := resourceFormat(request)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.results.get(sessionIDFromContext(ctx), templateArgument(request, "id"))
+	if err != nil {
+		return nil, err
+	}
+	chunkArg, _, _ := formatSuffix(templateArgument(request, "chunk"))
+	chunk, err := strconv.Atoi(chunkArg)
+	if err != nil || chunk < 0 || chunk >= len(result.chunks) {
+		return nil, fmt.Errorf("result %s has chunks 0 to %d", result.id, len(result.chunks)-1)
+	}
+
+	rows := result.rows[result.chunks[chunk]:result.chunkEnd(chunk)]
+	resp := resultChunk{
+		Columns:  result.columns,
+		Rows:     orderedRows{rows: rows},
+		Chunk:    chunk,
+		Chunks:   len(result.chunks),
+		FirstRow: result.chunks[chunk],
+	}
+	for _, col := range result.columns {
+		resp.Rows.columns = append(resp.Rows.columns, col.Name)
+	}
+	if chunk+1 < len(result.chunks) {
+		resp.Next = fmt.Sprintf("%s/%d", s.resultURI(result.id), chunk+1)
+	}
+	return formattedResource(request.Params.URI, mimeType, resp, rowsTable(result.columns, rows))
+}
+
+// chunkEnd returns the index after the last row of a chunk
+func (r *storedResult) chunkEnd(chunk int) int {
+	if chunk+1 < len(r.chunks) {
+		return r.chunks[chunk+1]
+	}
+	return len(r.rows)
+}
+
+// templateArgument returns a variable of a resource template URI
+func templateArgument(request mcp.ReadResourceRequest, name string) string {
+	switch v := request.Params.Arguments[name].(type) {
+	case []string:
+		if len(v) > 0 {
+			return v[0]
+		}
+	case string:
+		return v
+	}
+	return ""
+}
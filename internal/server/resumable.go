@@ -0,0 +1,336 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSSEIdleTimeout is how long the MCP session of a dropped event
+	// stream is kept for the client to resume it by default
+	DefaultSSEIdleTimeout = 2 * time.Minute
+	// sseReplayEvents and sseReplayBytes bound the events kept per stream
+	// for clients resuming it
+	sseReplayEvents = 256
+	sseReplayBytes  = 4 * 1024 * 1024
+	// ssePingInterval is how often an idle event stream is sent a comment,
+	// which detects dropped connections and keeps proxies from closing it
+	ssePingInterval = 30 * time.Second
+)
+
+// WithSSEIdleTimeout keeps the MCP session of an SSE client whose event
+// stream dropped for idleTimeout. Events are numbered, and a client
+// reconnecting with the Last-Event-ID header of the last event it received
+// resumes its session and is sent the events it missed. Sessions left for
+// longer are closed, releasing their transactions, workspaces and history.
+// A non-positive idleTimeout ends the session with its event stream.
+func WithSSEIdleTimeout(idleTimeout time.Duration) Option {
+	return func(s *PostgresMCPServer) {
+		s.sseIdleTimeout = idleTimeout
+	}
+}
+
+// sseEvent is an event of a stream with its sequence number
+type sseEvent struct {
+	seq  uint64
+	text string
+}
+
+// sseStream is the event stream of an MCP session, which outlives the
+// connections of the client reading it
+type sseStream struct {
+	id     string
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	events []sseEvent
+	size   int
+	next   uint64
+	closed bool
+	// notify wakes the attached client when events were added, kick makes
+	// it give way to a newer connection
+	notify chan struct{}
+	kick   chan struct{}
+	// attachment counts the connections, to tell whether the client came
+	// back before the idle timeout
+	attachment int
+}
+
+// add appends an event and wakes the attached client
+func (st *sseStream) add(text string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.next++
+	st.events = append(st.events, sseEvent{seq: st.next, text: text})
+	st.size += len(text)
+	for len(st.events) > 1 && (len(st.events) > sseReplayEvents || st.size > sseReplayBytes) {
+		st.size -= len(st.events[0].text)
+		st.events = st.events[1:]
+	}
+	st.wake()
+}
+
+// close marks the stream as ended once the MCP session ended
+func (st *sseStream) close() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.closed = true
+	st.wake()
+}
+
+// wake notifies the attached client, st.mu must be held
+func (st *sseStream) wake() {
+	if st.notify != nil {
+		select {
+		case st.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// attach makes a connection the reader of the stream, replacing the
+// previous one. Events up to last were received and are dropped.
+func (st *sseStream) attach(last uint64) (notify, kick chan struct{}, attachment int) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.kick != nil {
+		close(st.kick)
+	}
+	st.notify = make(chan struct{}, 1)
+	st.kick = make(chan struct{})
+	st.attachment++
+	st.acknowledge(last)
+	return st.notify, st.kick, st.attachment
+}
+
+// detach ends an attachment, unless a newer connection replaced it. It
+// reports whether the stream has no reader now.
+func (st *sseStream) detach(attachment int) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.attachment != attachment {
+		return false
+	}
+	st.notify = nil
+	st.kick = nil
+	return true
+}
+
+// since returns the events after seq and whether the stream ended
+func (st *sseStream) since(seq uint64) ([]sseEvent, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	var events []sseEvent
+	for _, event := range st.events {
+		if event.seq > seq {
+			events = append(events, event)
+		}
+	}
+	return events, st.closed
+}
+
+// acknowledge drops the events up to seq, st.mu must be held
+func (st *sseStream) acknowledge(seq uint64) {
+	i := 0
+	for i < len(st.events) && st.events[i].seq <= seq {
+		st.size -= len(st.events[i].text)
+		i++
+	}
+	st.events = st.events[i:]
+}
+
+// streamWriter is the ResponseWriter the SSE server writes an event stream
+// to. Every flush ends an event.
+type streamWriter struct {
+	stream *sseStream
+	header http.Header
+	buf    bytes.Buffer
+}
+
+// Header implements http.ResponseWriter
+func (w *streamWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *streamWriter) WriteHeader(status int) {}
+
+// Write implements http.ResponseWriter
+func (w *streamWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+// Flush implements http.Flusher
+func (w *streamWriter) Flush() {
+	if text := strings.TrimRight(w.buf.String(), "\r\n"); text != "" {
+		w.stream.add(text)
+	}
+	w.buf.Reset()
+}
+
+// sseBroker serves the event streams of an SSE server, keeping the streams
+// and their MCP sessions when the connections of their clients drop
+type sseBroker struct {
+	next        http.Handler
+	ssePath     string
+	idleTimeout time.Duration
+
+	mu      sync.Mutex
+	streams map[string]*sseStream
+}
+
+// resumableHandler serves the event streams at ssePath of next, the SSE
+// server, through an sseBroker
+func resumableHandler(next http.Handler, ssePath string, idleTimeout time.Duration) http.Handler {
+	if idleTimeout <= 0 {
+		return next
+	}
+	b := &sseBroker{next: next, ssePath: ssePath, idleTimeout: idleTimeout, streams: map[string]*sseStream{}}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != ssePath {
+			next.ServeHTTP(w, r)
+			return
+		}
+		b.serve(w, r)
+	})
+}
+
+// serve resumes the stream of the Last-Event-ID header or opens a new one,
+// and sends its events until the connection drops
+func (b *sseBroker) serve(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	stream, last := b.resume(r.Header.Get("Last-Event-ID"))
+	if stream == nil {
+		var err error
+		if stream, err = b.open(r); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	notify, kick, attachment := stream.attach(last)
+	defer func() {
+		if stream.detach(attachment) {
+			b.expireLater(stream, attachment)
+		}
+	}()
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("Access-Control-Allow-Origin", "*")
+	// Keep nginx from buffering the stream
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ping := time.NewTicker(ssePingInterval)
+	defer ping.Stop()
+	for {
+		events, closed := stream.since(last)
+		for _, event := range events {
+			if _, err := fmt.Fprintf(w, "id: %s:%d\n%s\n\n", stream.id, event.seq, event.text); err != nil {
+				return
+			}
+			last = event.seq
+		}
+		if len(events) > 0 {
+			flusher.Flush()
+		}
+		if closed {
+			return
+		}
+
+		select {
+		case <-notify:
+		case <-kick:
+			return
+		case <-r.Context().Done():
+			return
+		case <-ping.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// resume returns the stream of a Last-Event-ID and the sequence number of
+// the last event the client received, nil for unknown streams
+func (b *sseBroker) resume(lastEventID string) (*sseStream, uint64) {
+	id, seq, ok := strings.Cut(lastEventID, ":")
+	if !ok {
+		return nil, 0
+	}
+	last, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return nil, 0
+	}
+	b.mu.Lock()
+	stream := b.streams[id]
+	b.mu.Unlock()
+	if stream == nil {
+		log.Printf("SSE stream %s expired, opening a new session", id)
+		return nil, 0
+	}
+	if events, _ := stream.since(last); len(events) > 0 && events[0].seq > last+1 {
+		log.Printf("SSE stream %s resumed after event %d, events %d to %d were dropped", id, last, last+1, events[0].seq-1)
+	}
+	return stream, last
+}
+
+// open starts a new MCP session on the SSE server, whose event stream is
+// kept by the broker
+func (b *sseBroker) open(r *http.Request) (*sseStream, error) {
+	id, err := newRandomID()
+	if err != nil {
+		return nil, err
+	}
+	// The session ends when the stream expires, not with the connection
+	ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+	stream := &sseStream{id: id, cancel: cancel}
+
+	b.mu.Lock()
+	b.streams[id] = stream
+	b.mu.Unlock()
+
+	upstream := r.Clone(ctx)
+	upstream.Header.Del("Last-Event-ID")
+	go func() {
+		defer func() {
+			b.mu.Lock()
+			delete(b.streams, id)
+			b.mu.Unlock()
+			stream.close()
+		}()
+		b.next.ServeHTTP(&streamWriter{stream: stream, header: http.Header{}}, upstream)
+	}()
+	return stream, nil
+}
+
+// expireLater ends the stream and its MCP session unless a client attached
+// to it again within the idle timeout
+func (b *sseBroker) expireLater(stream *sseStream, attachment int) {
+	time.AfterFunc(b.idleTimeout, func() {
+		stream.mu.Lock()
+		idle := stream.attachment == attachment && !stream.closed
+		stream.mu.Unlock()
+		if idle {
+			log.Printf("SSE stream %s idle for %s, closing its session", stream.id, b.idleTimeout)
+			stream.cancel()
+		}
+	})
+}
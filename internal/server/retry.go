@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+)
+
+const (
+	// DefaultQueryRetries is how often a read-only query failing with a transient error is retried by default
+	DefaultQueryRetries = 2
+	// DefaultQueryRetryBackoff is the default wait before the first retry, it doubles with each retry
+	DefaultQueryRetryBackoff = 200 * time.Millisecond
+)
+
+// WithQueryRetries sets how often read-only queries failing with a transient
+// error, such as a serialization failure, a reset connection or too many
+// connections, are retried and the wait before the first retry, which
+// doubles with each retry. Queries of sessions with temporary tables are
+// not retried. Zero retries disables retrying.
+func WithQueryRetries(retries int, backoff time.Duration) Option {
+	return func(s *PostgresMCPServer) {
+		s.queryRetries = retries
+		s.queryRetryBackoff = backoff
+	}
+}
+
+// withRetries runs the read-only query in run until it succeeds, fails with
+// a permanent error or the retries are used up. It returns the number of
+// retries.
+func (s *PostgresMCPServer) withRetries(ctx context.Context, run func() (*db.QueryResult, error)) (*db.QueryResult, int, error) {
+	backoff := s.queryRetryBackoff
+	for retries := 0; ; retries++ {
+		result, err := run()
+		if err == nil || retries >= s.queryRetries || !db.IsTransient(err) || ctx.Err() != nil {
+			return result, retries, err
+		}
+		log.Printf("retrying query after transient error (retry %d of %d): %v", retries+1, s.queryRetries, err)
+
+		select {
+		case <-ctx.Done():
+			return nil, retries, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,244 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// DefaultSchemaCacheTTL is how long table names and columns are cached by default
+const DefaultSchemaCacheTTL = time.Minute
+
+const (
+	// schemaPreloadBatch is the number of tables whose columns are read by
+	// one catalog query when the schema is preloaded
+	schemaPreloadBatch = 500
+	// schemaPreloadWorkers is the number of catalog queries run at once
+	schemaPreloadWorkers = 4
+)
+
+// schemaCache caches the table names and columns read from the catalog
+type schemaCache struct {
+	mu  sync.Mutex
+	ttl time.Duration
+	// disabled is set by a non-positive TTL
+	disabled bool
+	names    []string
+	namesAt  time.Time
+	columns  map[string]cachedColumns
+}
+
+// cachedColumns are the columns of a table and when they were read
+type cachedColumns struct {
+	columns []db.TableColumn
+	at      time.Time
+}
+
+// WithSchemaCacheTTL sets how long table names and columns are cached, a
+// non-positive TTL disables the cache
+func WithSchemaCacheTTL(ttl time.Duration) Option {
+	return func(s *PostgresMCPServer) {
+		s.schemaCache.ttl = ttl
+		s.schemaCache.disabled = ttl <= 0
+	}
+}
+
+// fresh reports whether a value read at t is still valid
+func (c *schemaCache) fresh(t time.Time) bool {
+	ttl := c.ttl
+	if ttl == 0 {
+		ttl = DefaultSchemaCacheTTL
+	}
+	return !c.disabled && !t.IsZero() && time.Since(t) < ttl
+}
+
+// invalidate drops the cached schema
+func (c *schemaCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.names = nil
+	c.namesAt = time.Time{}
+	c.columns = nil
+}
+
+// invalidateTable drops the cached columns of a table
+func (c *schemaCache) invalidateTable(tableName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.columns, tableName)
+}
+
+// tableNames returns the tables of the public schema, cached
+func (s *PostgresMCPServer) tableNames() ([]string, error) {
+	c := &s.schemaCache
+	c.mu.Lock()
+	if c.fresh(c.namesAt) {
+		names := c.names
+		c.mu.Unlock()
+		return names, nil
+	}
+	c.mu.Unlock()
+
+	names, err := s.db.GetTableNames()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.names, c.namesAt = names, time.Now()
+	c.mu.Unlock()
+	return names, nil
+}
+
+// tableSchema returns the columns of a table, cached
+func (s *PostgresMCPServer) tableSchema(tableName string) ([]db.TableColumn, error) {
+	c := &s.schemaCache
+	c.mu.Lock()
+	if cached, ok := c.columns[tableName]; ok && c.fresh(cached.at) {
+		c.mu.Unlock()
+		return cached.columns, nil
+	}
+	c.mu.Unlock()
+
+	columns, err := s.db.GetTableSchema(tableName)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if c.columns == nil {
+		c.columns = map[string]cachedColumns{}
+	}
+	c.columns[tableName] = cachedColumns{columns: columns, at: time.Now()}
+	c.mu.Unlock()
+	return columns, nil
+}
+
+// tableSchemas returns the columns of several tables, reading the ones not
+// cached in one catalog query
+func (s *PostgresMCPServer) tableSchemas(tableNames []string) (map[string][]db.TableColumn, error) {
+	c := &s.schemaCache
+	schemas := make(map[string][]db.TableColumn, len(tableNames))
+	var missing []string
+	c.mu.Lock()
+	for _, name := range tableNames {
+		if cached, ok := c.columns[name]; ok && c.fresh(cached.at) {
+			schemas[name] = cached.columns
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	c.mu.Unlock()
+	if len(missing) == 0 {
+		return schemas, nil
+	}
+
+	loaded, err := s.db.GetTableSchemas(missing)
+	if err != nil {
+		return nil, err
+	}
+	s.cacheTableSchemas(missing, loaded)
+	for _, name := range missing {
+		schemas[name] = loaded[name]
+	}
+	return schemas, nil
+}
+
+// preloadTableSchemas reads the columns of the tables into the cache, in
+// batches of tables read by a bounded number of concurrent catalog queries
+// rather than one query per table
+func (s *PostgresMCPServer) preloadTableSchemas(tableNames []string) error {
+	if s.schemaCache.disabled || len(tableNames) == 0 {
+		return nil
+	}
+
+	batches := make(chan []string)
+	errs := make(chan error, schemaPreloadWorkers)
+	var wg sync.WaitGroup
+	for range min(schemaPreloadWorkers, (len(tableNames)+schemaPreloadBatch-1)/schemaPreloadBatch) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				schemas, err := s.db.GetTableSchemas(batch)
+				if err != nil {
+					select {
+					case errs <- err:
+					default:
+					}
+					continue
+				}
+				s.cacheTableSchemas(batch, schemas)
+			}
+		}()
+	}
+	for start := 0; start < len(tableNames); start += schemaPreloadBatch {
+		batches <- tableNames[start:min(start+schemaPreloadBatch, len(tableNames))]
+	}
+	close(batches)
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return nil
+	}
+}
+
+// cacheTableSchemas stores the columns of a batch of tables read at once
+func (s *PostgresMCPServer) cacheTableSchemas(tableNames []string, schemas map[string][]db.TableColumn) {
+	c := &s.schemaCache
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.columns == nil {
+		c.columns = make(map[string]cachedColumns, len(tableNames))
+	}
+	for _, name := range tableNames {
+		c.columns[name] = cachedColumns{columns: schemas[name], at: now}
+	}
+}
+
+// addSchemaCacheTools registers the refresh_schema tool
+func (s *PostgresMCPServer) addSchemaCacheTools() {
+	refreshTool := mcp.NewTool("refresh_schema",
+		mcp.WithDescription("Drop the cached table names and columns and update the table schema resources after tables were created, altered or dropped. The schema is otherwise cached for a short time."),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(refreshTool, s.handleRefreshSchema)
+}
+
+// handleRefreshSchema handles the refresh_schema tool
+func (s *PostgresMCPServer) handleRefreshSchema(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.schemaCache.invalidate()
+	added, removed, err := s.syncTableResources()
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to refresh the schema", err), nil
+	}
+	tables, err := s.tableNames()
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to refresh the schema", err), nil
+	}
+
+	result := struct {
+		Tables  int      `json:"tables"`
+		Added   []string `json:"added_tables"`
+		Removed []string `json:"removed_tables"`
+	}{Tables: len(tables), Added: added, Removed: removed}
+	if result.Added == nil {
+		result.Added = []string{}
+	}
+	if result.Removed == nil {
+		result.Removed = []string{}
+	}
+
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return mcp.NewToolResultText(string(out)), nil
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// addSchemasResource registers the resource holding the schemas of all tables
+// and the templates reading the schema resources in another format
+func (s *PostgresMCPServer) addSchemasResource() {
+	resource := mcp.NewResource(
+		fmt.Sprintf("%s/%s", s.db.ResourceBaseURL(), schemaPath),
+		"All tables database schema",
+		mcp.WithResourceDescription("Columns of all tables and views of the non-system schemas in one compact document"),
+		mcp.WithMIMEType("application/json"),
+	)
+
+	s.server.AddResource(resource, s.handleSchemasResource)
+
+	// The schema resources can also be read in another format by URI suffix,
+	// e.g. postgres://host/db/schema.csv or postgres://host/db/users/schema.md
+	s.server.AddResourceTemplate(mcp.NewResourceTemplate(
+		fmt.Sprintf("%s/%s.{format}", s.db.ResourceBaseURL(), schemaPath),
+		"All tables database schema in a format",
+		mcp.WithTemplateDescription("The all tables schema resource as json, csv or md (Markdown)"),
+	), s.handleSchemasResource)
+	s.server.AddResourceTemplate(mcp.NewResourceTemplate(
+		fmt.Sprintf("%s/{table}/%s.{format}", s.db.ResourceBaseURL(), schemaPath),
+		"Table database schema in a format",
+		mcp.WithTemplateDescription("The schema resource of a table as json, csv or md (Markdown)"),
+	), s.handleFormattedTableSchemaResource)
+}
+
+// handleSchemasResource reads the schemas of all tables
+func (s *PostgresMCPServer) handleSchemasResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	return s.readVersionedResource(ctx, request, nil, nil, func(mimeType string) (string, error) {
+		tables, err := s.db.GetAllTableSchemas("", "")
+		if err != nil {
+			return "", err
+		}
+		if mimeType != mimeJSON {
+			return encodeTable(mimeType, tableSchemasTable(tables))
+		}
+
+		// Use compact JSON to keep the document small
+		schemasJSON, err := json.Marshal(tables)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal schemas to JSON: %w", err)
+		}
+		return string(schemasJSON), nil
+	})
+}
+
+// addSchemasTools registers the get_all_schemas tool
+func (s *PostgresMCPServer) addSchemasTools() {
+	allSchemasTool := mcp.NewTool("get_all_schemas",
+		mcp.WithDescription("Get the columns of all tables and views in one compact JSON document instead of reading the table schema resources one by one"),
+		mcp.WithString("schema",
+			mcp.Description("Only describe the tables of this schema, all but the system schemas by default"),
+		),
+		mcp.WithString("pattern",
+			mcp.Description("Only describe the tables whose name matches this glob pattern, e.g. order_*"),
+		),
+		formatOption("one row per column"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(allSchemasTool, s.handleGetAllSchemas)
+
+	describeTableTool := mcp.NewTool("describe_table",
+		mcp.WithDescription("Describe a table in full: columns with their full default and generation expressions, identity, non-default collations and whether an INSERT must, may or cannot set them, the database encoding and locale, the partition key or parent, indexes (including partitioned indexes and NULLS NOT DISTINCT) and constraints (including EXCLUDE) with their definitions, columns and CHECK expressions, including the checks of domain types"),
+		mcp.WithString("table",
+			mcp.Required(),
+			mcp.Description("The table, view or foreign table"),
+		),
+		mcp.WithString("schema",
+			mcp.Description("The schema of the table. Omit to resolve the table against the search_path like resolve_table."),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(describeTableTool, s.handleDescribeTable)
+
+	resolveTableTool := mcp.NewTool("resolve_table",
+		mcp.WithDescription("Resolve a table name against the effective search_path like PostgreSQL does, and list the same-named tables in other schemas. Use it before querying an unqualified name to make sure it refers to the intended table."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The table as \"name\" or \"schema.name\", quoted identifiers are kept as is"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(resolveTableTool, s.handleResolveTable)
+}
+
+// handleGetAllSchemas handles the get_all_schemas tool
+func (s *PostgresMCPServer) handleGetAllSchemas(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	mimeType, err := toolFormat(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	tables, err := s.db.GetAllTableSchemas(stringArg(request, "schema", ""), stringArg(request, "pattern", ""))
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to get table schemas", err), nil
+	}
+	if mimeType != mimeJSON {
+		return formattedToolResult(mimeType, tableSchemasTable(tables), "")
+	}
+
+	// Use compact JSON to keep the response small
+	resultJSON, err := json.Marshal(tables)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleDescribeTable handles the describe_table tool
+func (s *PostgresMCPServer) handleDescribeTable(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	table := stringArg(request, "table", "")
+	if table == "" {
+		return mcp.NewToolResultError("table is required"), nil
+	}
+	desc, err := s.db.DescribeTable(ctx, stringArg(request, "schema", ""), table)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to describe table", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(desc, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleResolveTable handles the resolve_table tool
+func (s *PostgresMCPServer) handleResolveTable(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := stringArg(request, "name", "")
+	if name == "" {
+		return mcp.NewToolResultError("name is required"), nil
+	}
+	resolution, err := s.db.ResolveTable(ctx, name)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to resolve table", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(resolution, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// WithSchemaWatch polls the schema fingerprints of the tables every interval
+// and notifies clients with notifications/resources/updated of the schema
+// resources of the tables DDL changed, so clients holding them can read them
+// again. Created and dropped tables add and remove schema resources.
+func WithSchemaWatch(interval time.Duration) Option {
+	return func(s *PostgresMCPServer) {
+		s.schemaWatchInterval = interval
+	}
+}
+
+// startSchemaWatch polls the table fingerprints until the server is closed
+func (s *PostgresMCPServer) startSchemaWatch() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopSchemaWatch = cancel
+
+	go func() {
+		ticker := time.NewTicker(s.schemaWatchInterval)
+		defer ticker.Stop()
+		var previous map[string]string
+		for {
+			current, err := s.db.TableFingerprints(ctx)
+			if err != nil {
+				log.Printf("schema watch: %v", err)
+			} else {
+				if previous != nil {
+					s.notifySchemaChanges(previous, current)
+				}
+				previous = current
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// notifySchemaChanges compares two polls of the table fingerprints and
+// notifies clients of the schema resources that changed
+func (s *PostgresMCPServer) notifySchemaChanges(previous, current map[string]string) {
+	var changed []string
+	listChanged := false
+	for name, fingerprint := range current {
+		if old, ok := previous[name]; !ok {
+			listChanged = true
+		} else if old != fingerprint {
+			changed = append(changed, name)
+		}
+	}
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			listChanged = true
+		}
+	}
+	if len(changed) == 0 && !listChanged {
+		return
+	}
+	sort.Strings(changed)
+	s.schemaChanged(changed, listChanged)
+}
+
+// schemaChanged drops the cached schema of the changed tables, adds and
+// removes the resources of created and dropped tables when the list of
+// tables changed, and notifies clients of the updated schema resources
+func (s *PostgresMCPServer) schemaChanged(changed []string, listChanged bool) {
+	for _, name := range changed {
+		s.schemaCache.invalidateTable(name)
+	}
+	if listChanged {
+		// Adding and removing resources notifies clients of the changed list
+		s.schemaCache.invalidate()
+		if _, _, err := s.syncTableResources(); err != nil {
+			log.Printf("failed to update the table schema resources: %v", err)
+		}
+	}
+
+	s.tableResourcesMu.Lock()
+	var uris []string
+	for _, name := range changed {
+		if uri, ok := s.tableResources[name]; ok {
+			uris = append(uris, uri)
+		}
+	}
+	s.tableResourcesMu.Unlock()
+	uris = append(uris, fmt.Sprintf("%s/%s", s.db.ResourceBaseURL(), schemaPath))
+
+	for _, uri := range uris {
+		s.server.SendNotificationToAllClients(mcp.MethodNotificationResourceUpdated, map[string]any{"uri": uri})
+	}
+	if len(changed) > 0 {
+		log.Printf("schema of %s changed", strings.Join(changed, ", "))
+	}
+}
@@ -0,0 +1,268 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// scratchNamePattern matches the names of scratch databases without the
+// prefix, which together fit in an identifier
+var scratchNamePattern = regexp.MustCompile(`^[a-z0-9_]{1,55}$`)
+
+// Sources of a scratch database
+const (
+	scratchFromTemplate = "template"
+	scratchFromSchema   = "schema"
+	scratchFromDump     = "dump"
+)
+
+// scratchConfig is the pg_restore binary restoring dumps into scratch
+// databases
+type scratchConfig struct {
+	pgRestore string
+}
+
+// scratchResponse is the result of create_scratch_database
+type scratchResponse struct {
+	Database    string  `json:"database"`
+	Source      string  `json:"source"`
+	Dump        string  `json:"dump,omitempty"`
+	ExecutionMS float64 `json:"execution_ms"`
+}
+
+// WithScratchDatabases registers the scratch database tools, which create
+// copies of the database to experiment on. Dumps are restored with the
+// pg_restore binary; cloning the schema and restoring dumps need WithDumps.
+func WithScratchDatabases(pgRestore string) Option {
+	return func(s *PostgresMCPServer) {
+		s.scratch = &scratchConfig{pgRestore: pgRestore}
+	}
+}
+
+// addScratchTools registers the tools creating, changing and dropping
+// scratch databases. They need write access, as creating a database changes
+// the server.
+func (s *PostgresMCPServer) addScratchTools() {
+	if s.scratch == nil || !s.allowWrite {
+		return
+	}
+
+	sources := []string{scratchFromTemplate}
+	description := "Create a scratch database named " + db.ScratchPrefix + "<name> on the same server to experiment on safely: an empty database or a copy of a template database (template)"
+	if s.dumps != nil {
+		sources = append(sources, scratchFromSchema, scratchFromDump)
+		description += ", a copy of this database's schema without the data (schema), or a restored custom format dump of backup_database (dump)"
+	}
+	description += ". The database can be changed with run_scratch_script and compared with compare_plans, and is kept until drop_scratch_database."
+
+	createTool := mcp.NewTool("create_scratch_database",
+		mcp.WithDescription(description),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The name after the "+db.ScratchPrefix+" prefix (lower case letters, digits and underscores)"),
+		),
+		mcp.WithString("source",
+			mcp.Enum(sources...),
+			mcp.DefaultString(scratchFromTemplate),
+		),
+		mcp.WithString("template",
+			mcp.Description("With source=template, the database to copy; no other session may be connected to it, so this server's own database cannot be copied. Empty creates an empty database."),
+		),
+		mcp.WithString("dump",
+			mcp.Description("With source=dump, the dump file name returned by backup_database"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+	s.addTool(createTool, s.handleCreateScratchDatabase)
+
+	if !s.restrictSQL {
+		runTool := mcp.NewTool("run_scratch_script",
+			mcp.WithDescription("Run a multi-statement SQL script in one transaction on a scratch database and report the outcome of every statement, like run_script. Changes to the scratch database do not touch this database, so nothing needs confirmation."),
+			mcp.WithString("database",
+				mcp.Required(),
+				mcp.Description("The scratch database, e.g. "+db.ScratchPrefix+"experiment"),
+			),
+			mcp.WithString("sql",
+				mcp.Required(),
+				mcp.Description("The statements separated by semicolons. BEGIN, COMMIT and ROLLBACK are not allowed."),
+			),
+			mcp.WithString("on_error",
+				mcp.Description("stop to roll back the script at the first error, continue to skip failing statements"),
+				mcp.Enum("stop", "continue"),
+				mcp.DefaultString("stop"),
+			),
+			mcp.WithReadOnlyHintAnnotation(false),
+			mcp.WithDestructiveHintAnnotation(false),
+		)
+		s.addTool(runTool, s.handleRunScratchScript)
+	}
+
+	dropTool := mcp.NewTool("drop_scratch_database",
+		mcp.WithDescription("Drop a scratch database created by create_scratch_database"),
+		mcp.WithString("database",
+			mcp.Required(),
+			mcp.Description("The scratch database, e.g. "+db.ScratchPrefix+"experiment"),
+		),
+		mcp.WithString("confirmation_token",
+			mcp.Description("The token returned by the first call, passed once the user confirmed dropping the database"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+	)
+	s.addTool(dropTool, s.handleDropScratchDatabase)
+}
+
+// handleCreateScratchDatabase handles the create_scratch_database tool
+func (s *PostgresMCPServer) handleCreateScratchDatabase(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := stringArg(request, "name", "")
+	if !scratchNamePattern.MatchString(name) {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid name %q, use at most 55 lower case letters, digits and underscores", name)), nil
+	}
+	database := db.ScratchPrefix + name
+	source := stringArg(request, "source", scratchFromTemplate)
+
+	start := time.Now()
+	resp := scratchResponse{Database: database, Source: source}
+	switch source {
+	case scratchFromTemplate:
+		log.Printf("creating scratch database %s", database)
+		if err := s.db.CreateScratchDatabase(ctx, database, stringArg(request, "template", "")); err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to create scratch database", err), nil
+		}
+	case scratchFromSchema, scratchFromDump:
+		if s.dumps == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("source=%s needs the dump directory to be configured", source)), nil
+		}
+		path, err := s.scratchDump(ctx, source, stringArg(request, "dump", ""))
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to prepare the dump", err), nil
+		}
+		resp.Dump = path
+		log.Printf("restoring %s into scratch database %s", path, database)
+
+		// template0 holds none of the objects added to template1, which
+		// the restored ones could collide with
+		if err := s.db.CreateScratchDatabase(ctx, database, "template0"); err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to create scratch database", err), nil
+		}
+		if err := s.db.RestoreDump(ctx, s.scratch.pgRestore, path, database); err != nil {
+			if dropErr := s.db.DropScratchDatabase(context.Background(), database); dropErr != nil {
+				log.Printf("failed to drop scratch database %s after a failed restore: %v", database, dropErr)
+			}
+			return mcp.NewToolResultErrorFromErr("Failed to restore dump", err), nil
+		}
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid source %q", source)), nil
+	}
+
+	url, err := s.db.ScratchDatabaseURL(database)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to connect to scratch database", err), nil
+	}
+	s.planDatabases.add(database, url)
+	resp.ExecutionMS = milliseconds(time.Since(start))
+
+	resultJSON, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// scratchDump returns the path of the dump a scratch database is restored
+// from: a new schema-only dump of this database, or a file of the dump
+// directory
+func (s *PostgresMCPServer) scratchDump(ctx context.Context, source, name string) (string, error) {
+	if source == scratchFromSchema {
+		result, err := s.db.Dump(ctx, db.DumpParams{
+			PgDump:     s.dumps.pgDump,
+			Dir:        s.dumps.dir,
+			Format:     db.DumpCustom,
+			SchemaOnly: true,
+		})
+		if err != nil {
+			return "", err
+		}
+		return result.Path, nil
+	}
+	if name == "" {
+		return "", fmt.Errorf("dump is required with source=dump")
+	}
+	path, _, err := s.dumpFile(name)
+	return path, err
+}
+
+// handleRunScratchScript handles the run_scratch_script tool
+func (s *PostgresMCPServer) handleRunScratchScript(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	database := stringArg(request, "database", "")
+	statements := db.SplitStatements(stringArg(request, "sql", ""))
+	if len(statements) == 0 {
+		return mcp.NewToolResultError("SQL script is required"), nil
+	}
+	if len(statements) > maxScriptStatements {
+		return mcp.NewToolResultError(fmt.Sprintf("The script has %d statements, at most %d are allowed", len(statements), maxScriptStatements)), nil
+	}
+	onError := stringArg(request, "on_error", "stop")
+	if onError != "stop" && onError != "continue" {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid on_error %q, use stop or continue", onError)), nil
+	}
+
+	conn, err := s.otherDatabase(database)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to connect to scratch database", err), nil
+	}
+	log.Printf("running script of %d statements on %s", len(statements), database)
+	result, err := conn.RunScript(ctx, statements, onError == "continue")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to run script", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(s.buildScriptResponse(result), "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleDropScratchDatabase handles the drop_scratch_database tool
+func (s *PostgresMCPServer) handleDropScratchDatabase(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	database := stringArg(request, "database", "")
+	if !strings.HasPrefix(database, db.ScratchPrefix) {
+		return mcp.NewToolResultError(fmt.Sprintf("%q is not a scratch database", database)), nil
+	}
+	if confirm := s.confirmDestructive(ctx, request, "DROP DATABASE "+db.Identifier{database}.Sanitize()); confirm != nil {
+		return confirm, nil
+	}
+	log.Printf("dropping scratch database %s", database)
+
+	if err := s.planDatabases.remove(database); err != nil {
+		log.Printf("failed to close the connection to %s: %v", database, err)
+	}
+	if err := s.db.DropScratchDatabase(ctx, database); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to drop scratch database", err), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Dropped scratch database %s", database)), nil
+}
+
+// otherDatabase returns the connection to a configured or scratch database.
+// Scratch databases created before the server started are registered on
+// first use.
+func (s *PostgresMCPServer) otherDatabase(name string) (*db.DB, error) {
+	if s.scratch != nil && strings.HasPrefix(name, db.ScratchPrefix) && !s.planDatabases.has(name) {
+		url, err := s.db.ScratchDatabaseURL(name)
+		if err != nil {
+			return nil, err
+		}
+		s.planDatabases.add(name, url)
+	}
+	return s.planDatabases.get(name)
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxScriptStatements bounds the number of statements of a script
+const maxScriptStatements = 200
+
+// statementResponse is the outcome of a statement of a script
+type statementResponse struct {
+	Index        int                `json:"index"`
+	SQL          string             `json:"sql"`
+	Status       db.StatementStatus `json:"status"`
+	RowsAffected *int64             `json:"rows_affected,omitempty"`
+	Columns      []db.ResultColumn  `json:"columns,omitempty"`
+	Rows         *orderedRows       `json:"rows,omitempty"`
+	Truncated    bool               `json:"truncated,omitempty"`
+	Backups      []string           `json:"backups,omitempty"`
+	Error        string             `json:"error,omitempty"`
+	ExecutionMS  float64            `json:"execution_ms,omitempty"`
+}
+
+// scriptResponse is the result of the run_script tool
+type scriptResponse struct {
+	Committed  bool                `json:"committed"`
+	Succeeded  int                 `json:"succeeded"`
+	Failed     int                 `json:"failed"`
+	Skipped    int                 `json:"skipped"`
+	Statements []statementResponse `json:"statements"`
+	Notices    []db.Notice         `json:"notices,omitempty"`
+}
+
+// addScriptTools registers the run_script tool, which needs write access and
+// free-form SQL
+func (s *PostgresMCPServer) addScriptTools() {
+	if !s.allowWrite || s.restrictSQL {
+		return
+	}
+	if s.writeApproval != nil {
+		s.addWriteApprovalTools()
+		return
+	}
+
+	runScriptTool := mcp.NewTool("run_script",
+		mcp.WithDescription("Run a multi-statement SQL script, such as a migration or a data fix, atomically in one read-write transaction and report the outcome of every statement. By default the first failing statement rolls the whole script back; with on_error=continue a failing statement only undoes itself and the others are committed."),
+		mcp.WithString("sql",
+			mcp.Required(),
+			mcp.Description("The statements separated by semicolons. BEGIN, COMMIT and ROLLBACK are not allowed."),
+		),
+		mcp.WithString("on_error",
+			mcp.Description("stop to roll back the script at the first error, continue to skip failing statements"),
+			mcp.Enum("stop", "continue"),
+			mcp.DefaultString("stop"),
+		),
+		mcp.WithString("confirmation_token",
+			mcp.Description("The token returned when the script holds DROP, TRUNCATE, or DELETE or UPDATE without WHERE, passed once the user confirmed them"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+	)
+	s.addTool(runScriptTool, s.handleRunScript)
+}
+
+// handleRunScript handles the run_script tool
+func (s *PostgresMCPServer) handleRunScript(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	script := stringArg(request, "sql", "")
+	statements := db.SplitStatements(script)
+	if len(statements) == 0 {
+		return mcp.NewToolResultError("SQL script is required"), nil
+	}
+	if len(statements) > maxScriptStatements {
+		return mcp.NewToolResultError(fmt.Sprintf("The script has %d statements, at most %d are allowed", len(statements), maxScriptStatements)), nil
+	}
+
+	var continueOnError bool
+	switch onError := stringArg(request, "on_error", "stop"); onError {
+	case "stop":
+	case "continue":
+		continueOnError = true
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid on_error %q, use stop or continue", onError)), nil
+	}
+	if confirm := s.confirmDestructive(ctx, request, script); confirm != nil {
+		return confirm, nil
+	}
+	log.Printf("running script of %d statements", len(statements))
+
+	result, err := s.db.RunScript(ctx, statements, continueOnError)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to run script", err), nil
+	}
+
+	return s.scriptResult(result)
+}
+
+// scriptResult returns the outcome of a script as a tool result
+func (s *PostgresMCPServer) scriptResult(result *db.ScriptResult) (*mcp.CallToolResult, error) {
+	resultJSON, err := json.MarshalIndent(s.newScriptResponse(result), "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// newScriptResponse builds the outcome of a script. The schema cache is
+// dropped after a committed script, which may have created, altered or
+// dropped tables.
+func (s *PostgresMCPServer) newScriptResponse(result *db.ScriptResult) scriptResponse {
+	if result.Committed {
+		s.schemaCache.invalidate()
+		if _, _, err := s.syncTableResources(); err != nil {
+			log.Printf("failed to update the table resources after a script: %v", err)
+		}
+	}
+	return s.buildScriptResponse(result)
+}
+
+// buildScriptResponse builds the outcome of a script without touching the
+// schema cache, for scripts run on other databases
+func (s *PostgresMCPServer) buildScriptResponse(result *db.ScriptResult) scriptResponse {
+	resp := scriptResponse{
+		Committed:  result.Committed,
+		Statements: make([]statementResponse, len(result.Statements)),
+		Notices:    result.Notices,
+	}
+	for i, stmt := range result.Statements {
+		switch stmt.Status {
+		case db.StatementOK:
+			resp.Succeeded++
+		case db.StatementFailed:
+			resp.Failed++
+		case db.StatementSkipped:
+			resp.Skipped++
+		}
+		resp.Statements[i] = s.newStatementResponse(i+1, stmt)
+	}
+	return resp
+}
+
+// newStatementResponse builds the outcome of a statement with its rows in
+// column order and the explained error of a failing statement
+func (s *PostgresMCPServer) newStatementResponse(index int, stmt db.StatementResult) statementResponse {
+	out := statementResponse{
+		Index:        index,
+		SQL:          stmt.SQL,
+		Status:       stmt.Status,
+		RowsAffected: stmt.RowsAffected,
+		Columns:      stmt.Columns,
+		Truncated:    stmt.Truncated,
+		Backups:      stmt.Backups,
+		ExecutionMS:  milliseconds(stmt.Duration),
+	}
+	if stmt.Columns != nil {
+		rows := orderedRows{rows: stmt.Rows}
+		for _, col := range stmt.Columns {
+			rows.columns = append(rows.columns, col.Name)
+		}
+		out.Rows = &rows
+	}
+	if stmt.Error != nil {
+		out.Error = toolResultText(s.queryError("Statement failed", stmt.SQL, stmt.Error))
+	}
+	return out
+}
@@ -3,9 +3,20 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/iwanbk/postgres-mcp-go/internal/anonymize"
+	"github.com/iwanbk/postgres-mcp-go/internal/cdc"
+	"github.com/iwanbk/postgres-mcp-go/internal/config"
 	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/iwanbk/postgres-mcp-go/internal/embedding"
+	"github.com/iwanbk/postgres-mcp-go/internal/tracing"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
@@ -13,50 +24,450 @@ import (
 // The schema path component for resource URIs
 const schemaPath = "schema"
 
+// The overview path component for resource URIs
+const overviewPath = "overview"
+
+// DefaultMaxResponseBytes is the default size cap of row-producing tool responses
+const DefaultMaxResponseBytes = 256 * 1024
+
 // PostgresMCPServer represents a PostgreSQL MCP server
 type PostgresMCPServer struct {
-	db     *db.DB
+	db     db.Database
 	server *server.MCPServer
+
+	maxResponseBytes int
+	dbOptions        []db.Option
+	embedder         *embedding.Client
+	subscriptions    channelSubscriptions
+	cdcConfig        *cdcConfig
+	changes          *cdc.Buffer
+	stopCDC          context.CancelFunc
+	jobs             jobManager
+	history          queryHistory
+	namedQueries     []config.NamedQuery
+	customTools      []server.ServerTool
+	toolFactories    []ToolFactory
+	policies         []Policy
+	enabledTools     []string
+	disabledTools    []string
+	toolOverrides    map[string]config.ToolOverride
+	schemaCache      schemaCache
+	// resourceVersions caches the schema resources by schema fingerprint
+	resourceVersions resourceVersions
+	// tableResources maps the tables to the URI of their schema resource
+	tableResources    map[string]string
+	tableResourcesMu  sync.Mutex
+	queryRetries      int
+	queryRetryBackoff time.Duration
+	traceSQL          tracing.SQLMode
+	slowQueries       *slowQueryLog
+	usage             usageTracker
+	restrictSQL       bool
+	allowWrite        bool
+	sizes             sizeHistory
+	// sizeSnapshotInterval enables database size snapshots when positive
+	sizeSnapshotInterval time.Duration
+	stopSizeSnapshots    context.CancelFunc
+	errorLog             *errorLogConfig
+	snapshots            snapshotStore
+	workspaces           workspaces
+	transactions         transactions
+	writeApproval        *writeApproval
+	confirmations        confirmations
+	skipConfirmation     bool
+	backupSchema         string
+	docs                 generatedDocs
+	dumps                *dumpConfig
+	scratch              *scratchConfig
+	capabilities         capabilityState
+	planDatabases        planDatabases
+	pooler               db.Pooler
+	lazyConnect          bool
+	// connected is set once the database has been reached
+	connected     atomic.Bool
+	stopLazySetup context.CancelFunc
+	// databaseURL is empty when the database was given with WithDatabase
+	databaseURL string
+	// toolNames is the set of registered tool names
+	toolNames map[string]bool
+	// schemaWatchInterval enables polling for schema changes when positive
+	schemaWatchInterval time.Duration
+	stopSchemaWatch     context.CancelFunc
+	// ddlNotifications listens for the DDL event triggers on ddlListener
+	ddlNotifications bool
+	ddlListener      *db.NotificationListener
+	// results keeps the full results of truncated query responses
+	results resultStore
+	// compressionLevel is the gzip level of the HTTP responses, 0 disables compression
+	compressionLevel int
+	http             httpConfig
+	// sseIdleTimeout keeps the sessions of dropped event streams for resumption
+	sseIdleTimeout time.Duration
+}
+
+// Option configures a PostgresMCPServer
+type Option func(*PostgresMCPServer)
+
+// WithMaxResponseBytes caps the size of row-producing tool responses.
+// Rows beyond the cap are dropped and the response is marked as truncated.
+// A non-positive value disables the cap.
+func WithMaxResponseBytes(maxBytes int) Option {
+	return func(s *PostgresMCPServer) {
+		s.maxResponseBytes = maxBytes
+	}
+}
+
+// WithNumericFormat sets how numeric/decimal columns are returned by the query tool
+func WithNumericFormat(format db.NumericFormat) Option {
+	return func(s *PostgresMCPServer) {
+		s.dbOptions = append(s.dbOptions, db.WithNumericFormat(format))
+	}
+}
+
+// WithTimeZone sets the time zone queries run in and timestamptz columns are
+// returned in, empty keeps the database default
+func WithTimeZone(name string) Option {
+	return func(s *PostgresMCPServer) {
+		s.dbOptions = append(s.dbOptions, db.WithTimeZone(name))
+	}
+}
+
+// WithByteaFormat sets how bytea columns are returned by the query tool
+func WithByteaFormat(format db.ByteaFormat, maxBytes int) Option {
+	return func(s *PostgresMCPServer) {
+		s.dbOptions = append(s.dbOptions, db.WithByteaFormat(format, maxBytes))
+	}
+}
+
+// WithGeoFormat sets how PostGIS geometry/geography columns are returned by the query tool
+func WithGeoFormat(format db.GeoFormat) Option {
+	return func(s *PostgresMCPServer) {
+		s.dbOptions = append(s.dbOptions, db.WithGeoFormat(format))
+	}
+}
+
+// WithPooler adapts the server to a connection pooler. Behind PgBouncer in
+// transaction mode the tools relying on session state, LISTEN and temporary
+// tables, are not registered.
+func WithPooler(pooler db.Pooler) Option {
+	return func(s *PostgresMCPServer) {
+		s.pooler = pooler
+		s.dbOptions = append(s.dbOptions, db.WithPooler(pooler))
+	}
+}
+
+// WithAnonymizer replaces the values of PII columns in query results with
+// deterministic fake values
+func WithAnonymizer(a *anonymize.Anonymizer) Option {
+	return func(s *PostgresMCPServer) {
+		s.dbOptions = append(s.dbOptions, db.WithAnonymizer(a))
+	}
+}
+
+// WithEmbeddingClient sets the client used by vector_search to embed text queries
+func WithEmbeddingClient(client *embedding.Client) Option {
+	return func(s *PostgresMCPServer) {
+		s.embedder = client
+	}
+}
+
+// WithNamedQueries exposes pre-approved parameterized queries as individual tools
+func WithNamedQueries(queries []config.NamedQuery) Option {
+	return func(s *PostgresMCPServer) {
+		s.namedQueries = queries
+	}
+}
+
+// WithTools registers additional tools next to the built-in ones. Their
+// names must not clash with the built-in tools or the named queries.
+func WithTools(tools ...server.ServerTool) Option {
+	return func(s *PostgresMCPServer) {
+		s.customTools = append(s.customTools, tools...)
+	}
+}
+
+// WithToolSelection registers only the tools matching enabled, all when it
+// is empty, and none matching disabled. Patterns are globs on tool names.
+// The other tools are not listed to clients.
+func WithToolSelection(enabled, disabled []string) Option {
+	return func(s *PostgresMCPServer) {
+		s.enabledTools = enabled
+		s.disabledTools = disabled
+	}
+}
+
+// WithToolOverrides replaces the description, title or annotations of tools
+// by name
+func WithToolOverrides(overrides map[string]config.ToolOverride) Option {
+	return func(s *PostgresMCPServer) {
+		s.toolOverrides = overrides
+	}
+}
+
+// applyOverride applies the configured override of a tool
+func (s *PostgresMCPServer) applyOverride(tool *mcp.Tool) {
+	override, ok := s.toolOverrides[tool.Name]
+	if !ok {
+		return
+	}
+	if override.Description != "" {
+		tool.Description = override.Description
+	}
+	if override.Title != "" {
+		tool.Annotations.Title = override.Title
+	}
+	if override.ReadOnlyHint != nil {
+		tool.Annotations.ReadOnlyHint = override.ReadOnlyHint
+	}
+	if override.DestructiveHint != nil {
+		tool.Annotations.DestructiveHint = override.DestructiveHint
+	}
+	if override.IdempotentHint != nil {
+		tool.Annotations.IdempotentHint = override.IdempotentHint
+	}
+	if override.OpenWorldHint != nil {
+		tool.Annotations.OpenWorldHint = override.OpenWorldHint
+	}
+}
+
+// toolEnabled reports whether a tool is selected by WithToolSelection
+func (s *PostgresMCPServer) toolEnabled(name string) bool {
+	if len(s.enabledTools) > 0 && !matchAny(s.enabledTools, name) {
+		return false
+	}
+	return !matchAny(s.disabledTools, name)
+}
+
+// ToolFactory creates tools whose handlers read the database through the
+// server, which is passed once it is created
+type ToolFactory func(s *PostgresMCPServer) []server.ServerTool
+
+// WithToolFactory registers the tools created by f like WithTools
+func WithToolFactory(f ToolFactory) Option {
+	return func(s *PostgresMCPServer) {
+		s.toolFactories = append(s.toolFactories, f)
+	}
+}
+
+// WithRestrictedSQL disables the tools that run free-form SQL. Only named
+// queries, introspection tools and the structured query tools are available.
+func WithRestrictedSQL() Option {
+	return func(s *PostgresMCPServer) {
+		s.restrictSQL = true
+	}
+}
+
+// WithWriteAccess registers the tools that modify the database. Without it
+// the server only exposes read-only tools.
+func WithWriteAccess() Option {
+	return func(s *PostgresMCPServer) {
+		s.allowWrite = true
+	}
+}
+
+// WithDatabase serves the given database instead of connecting to the
+// database URL, e.g. a dbmock.DatabaseMock in tests or another backend. The
+// db options, such as the result formats, do not apply to it.
+func WithDatabase(database db.Database) Option {
+	return func(s *PostgresMCPServer) {
+		s.db = database
+	}
 }
 
 // New creates a new PostgreSQL MCP server
-func New(databaseURL string) (*PostgresMCPServer, error) {
-	// Create the database connection
-	db, err := db.New(databaseURL)
-	if err != nil {
-		return nil, err
+func New(databaseURL string, opts ...Option) (*PostgresMCPServer, error) {
+	pgServer := &PostgresMCPServer{
+		maxResponseBytes:  DefaultMaxResponseBytes,
+		queryRetries:      DefaultQueryRetries,
+		queryRetryBackoff: DefaultQueryRetryBackoff,
+		traceSQL:          tracing.SQLRedacted,
+		toolNames:         map[string]bool{},
+		compressionLevel:  DefaultCompressionLevel,
+		sseIdleTimeout:    DefaultSSEIdleTimeout,
+	}
+	for _, opt := range opts {
+		opt(pgServer)
+	}
+	pgServer.planDatabases.options = pgServer.dbOptions
+	if pgServer.writeApproval != nil {
+		pgServer.writeApproval.byUser = pgServer.http.trustForwarded
+	}
+
+	// Create the database connection unless one was given
+	if pgServer.db == nil {
+		pgServer.databaseURL = databaseURL
+		database, err := db.New(databaseURL, pgServer.dbOptions...)
+		if err != nil {
+			return nil, err
+		}
+		pgServer.db = database
 	}
 
+	// Release per-session state when a client goes away
+	hooks := &server.Hooks{}
+	hooks.AddOnUnregisterSession(pgServer.releaseSession)
+	hooks.AddAfterReadResource(pgServer.addResourceMeta)
+
 	// Create the MCP server
 	s := server.NewMCPServer(
-		"postgres-mcp-go", // Server name
-		"0.1.0",           // Version
+		"go-mcp-postgres", // Server name
+		"0.2.1",           // Version
+		server.WithResourceCapabilities(true, true),
+		server.WithPromptCapabilities(true),
+		server.WithLogging(),
+		server.WithHooks(hooks),
 	)
 
-	return &PostgresMCPServer{
-		db:     db,
-		server: s,
-	}, nil
+	pgServer.server = s
+
+	return pgServer, nil
 }
 
 // Setup configures the MCP server with resources and tools
 func (s *PostgresMCPServer) Setup() error {
-	// Add resources for each table schema
-	tableNames, err := s.db.GetTableNames()
+	if s.slowQueries != nil {
+		if err := s.slowQueries.open(); err != nil {
+			return err
+		}
+	}
+
+	// With lazy connect, the parts that read the database are set up once it is reachable
+	waitForDatabase := false
+	if s.lazyConnect {
+		if err := s.db.Ping(context.Background()); err != nil {
+			log.Printf("database not reachable yet, tools return errors until it is: %v", err)
+			waitForDatabase = true
+		} else {
+			s.connected.Store(true)
+		}
+	}
+
+	if !waitForDatabase {
+		if err := s.addTableResources(); err != nil {
+			return err
+		}
+	}
+
+	// Add the database overview resource
+	s.addOverviewResource()
+	s.addSchemasResource()
+
+	// Add the tools
+	s.addTools()
+	if !waitForDatabase {
+		s.addExtensionTools()
+	}
+
+	// Add the custom tools and named queries after the built-in tools so name clashes are detected
+	customTools := s.customTools
+	for _, f := range s.toolFactories {
+		customTools = append(customTools, f(s)...)
+	}
+	for _, tool := range customTools {
+		if s.toolNames[tool.Tool.Name] {
+			return fmt.Errorf("tool %q clashes with an existing tool", tool.Tool.Name)
+		}
+		s.addTool(tool.Tool, tool.Handler)
+	}
+	if err := s.addNamedQueryTools(); err != nil {
+		return err
+	}
+	for name := range s.toolOverrides {
+		if !s.toolNames[name] && !waitForDatabase {
+			log.Printf("tool override %q matches no registered tool", name)
+		}
+	}
+
+	// Start recording the database size if enabled
+	if s.sizeSnapshotInterval > 0 {
+		s.startSizeSnapshots()
+	}
+
+	if waitForDatabase {
+		s.startLazySetup()
+		return nil
+	}
+
+	// Start change data capture if enabled
+	if s.cdcConfig != nil {
+		if err := s.setupCDC(); err != nil {
+			return fmt.Errorf("failed to set up change data capture: %w", err)
+		}
+	}
+	if s.schemaWatchInterval > 0 {
+		s.startSchemaWatch()
+	}
+	s.startDDLListener()
+
+	return nil
+}
+
+// addTableResources adds a schema resource for each table and loads the
+// columns of all tables into the schema cache
+func (s *PostgresMCPServer) addTableResources() error {
+	if _, _, err := s.syncTableResources(); err != nil {
+		return err
+	}
+	tableNames, err := s.tableNames()
 	if err != nil {
 		return fmt.Errorf("failed to get table names: %w", err)
 	}
+	// Reading the resources works without the preloaded columns
+	if err := s.preloadTableSchemas(tableNames); err != nil {
+		log.Printf("failed to preload table schemas: %v", err)
+	}
+	return nil
+}
+
+// syncTableResources adds a schema resource for each table without one and
+// removes the resources of dropped tables
+func (s *PostgresMCPServer) syncTableResources() (added, removed []string, err error) {
+	// Foreign tables are marked, querying them goes to a remote server. Both
+	// are read at once, which matters for databases with many tables.
+	var foreignServers map[string]string
+	var foreignErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		foreignServers, foreignErr = s.db.GetForeignTableServers()
+	}()
+	tableNames, err := s.tableNames()
+	wg.Wait()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get table names: %w", err)
+	}
+	if foreignErr != nil {
+		return nil, nil, fmt.Errorf("failed to get foreign tables: %w", foreignErr)
+	}
 
+	s.tableResourcesMu.Lock()
+	defer s.tableResourcesMu.Unlock()
+	if s.tableResources == nil {
+		s.tableResources = map[string]string{}
+	}
+
+	current := make(map[string]bool, len(tableNames))
 	for _, tableName := range tableNames {
+		current[tableName] = true
+		if _, ok := s.tableResources[tableName]; ok {
+			continue
+		}
+
 		// Create a resource for each table schema
 		resourceURI := fmt.Sprintf("%s/%s/%s", s.db.ResourceBaseURL(), tableName, schemaPath)
 		resourceName := fmt.Sprintf("\"%s\" database schema", tableName)
+		description := fmt.Sprintf("Schema information for table %s", tableName)
+		if server, ok := foreignServers[tableName]; ok {
+			resourceName += " (remote)"
+			description = fmt.Sprintf("Schema information for foreign table %s, its rows are fetched from foreign server %s", tableName, server)
+		}
 
 		// Create the resource
 		resource := mcp.NewResource(
 			resourceURI,
 			resourceName,
-			mcp.WithResourceDescription(fmt.Sprintf("Schema information for table %s", tableName)),
+			mcp.WithResourceDescription(description),
 			mcp.WithMIMEType("application/json"),
 		)
 
@@ -65,30 +476,109 @@ func (s *PostgresMCPServer) Setup() error {
 
 		// Add the resource with its handler
 		s.server.AddResource(resource, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-			// Get the schema for this table
-			schema, err := s.db.GetTableSchema(tableNameCopy)
-			if err != nil {
-				return nil, fmt.Errorf("failed to get schema for table %s: %w", tableNameCopy, err)
-			}
-
-			// Convert the schema to JSON
-			schemaJSON, err := json.MarshalIndent(schema, "", "  ")
-			if err != nil {
-				return nil, fmt.Errorf("failed to marshal schema to JSON: %w", err)
-			}
-
-			// Return the schema as a resource content
-			return []mcp.ResourceContents{
-				mcp.TextResourceContents{
-					URI:      request.Params.URI,
-					MIMEType: "application/json",
-					Text:     string(schemaJSON),
-				},
-			}, nil
+			return s.readTableSchemaResource(ctx, request, tableNameCopy)
 		})
+		s.tableResources[tableName] = resourceURI
+		added = append(added, tableName)
 	}
 
-	return nil
+	for tableName, resourceURI := range s.tableResources {
+		if !current[tableName] {
+			s.server.RemoveResource(resourceURI)
+			s.resourceVersions.remove(resourceURI)
+			delete(s.tableResources, tableName)
+			removed = append(removed, tableName)
+		}
+	}
+	sort.Strings(removed)
+	return added, removed, nil
+}
+
+// readTableSchemaResource reads the schema resource of a table, which is only
+// read again after DDL changed the table
+func (s *PostgresMCPServer) readTableSchemaResource(ctx context.Context, request mcp.ReadResourceRequest, tableName string) ([]mcp.ResourceContents, error) {
+	changed := func() { s.schemaCache.invalidateTable(tableName) }
+	return s.readVersionedResource(ctx, request, []string{tableName}, changed, func(mimeType string) (string, error) {
+		// Get the schema for this table
+		schema, err := s.tableSchema(tableName)
+		if err != nil {
+			return "", fmt.Errorf("failed to get schema for table %s: %w", tableName, err)
+		}
+		if mimeType != mimeJSON {
+			return encodeTable(mimeType, tableColumnsTable(schema))
+		}
+
+		// Convert the schema to JSON
+		schemaJSON, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal schema to JSON: %w", err)
+		}
+		return string(schemaJSON), nil
+	})
+}
+
+// handleFormattedTableSchemaResource reads the schema resource of a table with
+// a format suffix, e.g. postgres://host/db/users/schema.csv
+func (s *PostgresMCPServer) handleFormattedTableSchemaResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	tableName := templateArgument(request, "table")
+	s.tableResourcesMu.Lock()
+	_, ok := s.tableResources[tableName]
+	s.tableResourcesMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("table %s not found", tableName)
+	}
+	return s.readTableSchemaResource(ctx, request, tableName)
+}
+
+// addOverviewResource registers the database-level summary resource
+func (s *PostgresMCPServer) addOverviewResource() {
+	resource := mcp.NewResource(
+		fmt.Sprintf("%s/%s", s.db.ResourceBaseURL(), overviewPath),
+		"Database overview",
+		mcp.WithResourceDescription("Server version, database encoding and locale, database size, table count, largest tables, installed extensions and connection activity"),
+		mcp.WithMIMEType("application/json"),
+	)
+
+	s.server.AddResource(resource, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		overview, err := s.db.GetOverview()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get database overview: %w", err)
+		}
+
+		overviewJSON, err := json.MarshalIndent(overview, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal overview to JSON: %w", err)
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: "application/json",
+				Text:     string(overviewJSON),
+			},
+		}, nil
+	})
+}
+
+// addTool registers a tool and remembers its name. Tools disabled by
+// WithToolSelection are skipped.
+func (s *PostgresMCPServer) addTool(tool mcp.Tool, handler server.ToolHandlerFunc) {
+	if !s.toolEnabled(tool.Name) {
+		return
+	}
+	s.applyOverride(&tool)
+	s.toolNames[tool.Name] = true
+	s.server.AddTool(tool, s.traced(tool.Name, s.metered(tool.Name, s.requireDatabase(s.authorize(tool.Name, handler)))))
+}
+
+// releaseSession drops the state kept for a closed client session
+func (s *PostgresMCPServer) releaseSession(ctx context.Context, session server.ClientSession) {
+	s.unsubscribeSession(ctx, session)
+	s.history.forget(session.SessionID())
+	s.usage.forget(session.SessionID())
+	s.workspaces.close(session.SessionID())
+	s.transactions.close(session.SessionID())
+	s.results.forget(session.SessionID())
 }
 
 // Serve starts the MCP server using stdio
@@ -96,7 +586,50 @@ func (s *PostgresMCPServer) Serve() error {
 	return server.ServeStdio(s.server)
 }
 
+// MCPServer returns the underlying MCP server, e.g. to serve it over stdio
+func (s *PostgresMCPServer) MCPServer() *server.MCPServer {
+	return s.server
+}
+
+// Query executes a query in a read-only transaction, encoding the results
+// like the query tool. Custom tools use it to read the database.
+func (s *PostgresMCPServer) Query(ctx context.Context, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	return s.db.ExecuteReadOnlyQueryContext(ctx, query, args...)
+}
+
+// ServeSSE starts the MCP server using SSE on the given address
+func (s *PostgresMCPServer) ServeSSE(addr, baseURL string) error {
+	return s.ServeSSEContext(context.Background(), addr, baseURL)
+}
+
+// ServeSSEContext serves the MCP server using SSE on the given address until
+// ctx is done, then ends the event streams and shuts the HTTP server down
+func (s *PostgresMCPServer) ServeSSEContext(ctx context.Context, addr, baseURL string) error {
+	handler := corsHandler(s.sseHandler(baseURL, s.http.basePath), s.http.corsOrigins)
+	return serveHTTP(ctx, addr, handler)
+}
+
 // Close closes the server and database connection
 func (s *PostgresMCPServer) Close() error {
-	return s.db.Close()
+	if s.stopLazySetup != nil {
+		s.stopLazySetup()
+	}
+	s.jobs.cancelAll()
+	s.workspaces.closeAll()
+	s.transactions.closeAll()
+	if s.stopCDC != nil {
+		s.stopCDC()
+	}
+	if s.stopSizeSnapshots != nil {
+		s.stopSizeSnapshots()
+	}
+	if s.stopSchemaWatch != nil {
+		s.stopSchemaWatch()
+	}
+	if s.ddlListener != nil {
+		if err := s.ddlListener.Close(); err != nil {
+			log.Printf("failed to close the DDL listener: %v", err)
+		}
+	}
+	return errors.Join(s.closeSubscriptions(), s.planDatabases.close(), s.slowQueries.close(), s.db.Close())
 }
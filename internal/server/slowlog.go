@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxSlowQueries is the number of slow queries kept for list_slow_queries
+const maxSlowQueries = 100
+
+// slowQuery is an entry of the slow query log
+type slowQuery struct {
+	ID         int           `json:"id"`
+	Time       time.Time     `json:"time"`
+	DurationMS float64       `json:"duration_ms"`
+	Rows       int           `json:"rows"`
+	SQL        string        `json:"sql"`
+	Args       []interface{} `json:"args,omitempty"`
+	SessionID  string        `json:"session_id,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	// Plan is the estimated plan, added by list_slow_queries on request
+	Plan *db.QueryPlan `json:"plan,omitempty"`
+	// PlanError tells why the plan could not be added
+	PlanError string `json:"plan_error,omitempty"`
+}
+
+// slowQueryLog writes the queries taking longer than the threshold as JSON
+// lines to its own file and keeps the most recent ones in memory
+type slowQueryLog struct {
+	threshold time.Duration
+	path      string
+
+	mu      sync.Mutex
+	out     io.Writer
+	file    *os.File
+	entries []slowQuery
+	nextID  int
+}
+
+// WithSlowQueryLog logs the queries run by the tools that take longer than
+// threshold as JSON lines to the file at path, or to stderr when path is empty
+func WithSlowQueryLog(threshold time.Duration, path string) Option {
+	return func(s *PostgresMCPServer) {
+		s.slowQueries = &slowQueryLog{threshold: threshold, path: path}
+	}
+}
+
+// open opens the log file
+func (l *slowQueryLog) open() error {
+	if l.path == "" {
+		l.out = os.Stderr
+		return nil
+	}
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open slow query log: %w", err)
+	}
+	l.file, l.out = file, file
+	return nil
+}
+
+// close closes the log file
+func (l *slowQueryLog) close() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// record logs a query that took longer than the threshold
+func (l *slowQueryLog) record(ctx context.Context, sql string, args []interface{}, start time.Time, rows int, err error) {
+	if l == nil {
+		return
+	}
+	duration := time.Since(start)
+	if duration < l.threshold {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nextID++
+	entry := slowQuery{
+		ID:         l.nextID,
+		Time:       start,
+		DurationMS: float64(duration.Microseconds()) / 1000,
+		Rows:       rows,
+		SQL:        sql,
+		Args:       args,
+		SessionID:  sessionIDFromContext(ctx),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	line, jsonErr := json.Marshal(entry)
+	if jsonErr != nil {
+		log.Printf("failed to marshal slow query: %v", jsonErr)
+	} else if _, writeErr := l.out.Write(append(line, '\n')); writeErr != nil {
+		log.Printf("failed to write slow query log: %v", writeErr)
+	}
+
+	l.entries = append(l.entries, entry)
+	if len(l.entries) > maxSlowQueries {
+		l.entries = l.entries[len(l.entries)-maxSlowQueries:]
+	}
+}
+
+// recent returns the most recent slow queries, newest first
+func (l *slowQueryLog) recent(limit int) []slowQuery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	result := []slowQuery{}
+	for i := len(l.entries) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, l.entries[i])
+	}
+	return result
+}
+
+// addSlowQueryTools registers the list_slow_queries tool
+func (s *PostgresMCPServer) addSlowQueryTools() {
+	if s.slowQueries == nil {
+		return
+	}
+	listTool := mcp.NewTool("list_slow_queries",
+		mcp.WithDescription(fmt.Sprintf("List the recent queries run by the tools that took longer than %s, newest first, optionally with their estimated plans", s.slowQueries.threshold)),
+		mcp.WithNumber("limit",
+			mcp.Description("The number of queries to return"),
+			mcp.DefaultNumber(20),
+		),
+		mcp.WithBoolean("explain",
+			mcp.Description("Add the current estimated plan of each query; queries with bind parameters are planned generically on PostgreSQL 16 or later"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(listTool, s.handleListSlowQueries)
+}
+
+// handleListSlowQueries handles the list_slow_queries tool
+func (s *PostgresMCPServer) handleListSlowQueries(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	entries := s.slowQueries.recent(intArg(request, "limit", 20))
+	if explain, _ := request.Params.Arguments["explain"].(bool); explain {
+		for i := range entries {
+			plan, err := s.db.ExplainQuery(ctx, entries[i].SQL, nil)
+			if err != nil {
+				entries[i].PlanError = err.Error()
+				continue
+			}
+			entries[i].Plan = plan
+		}
+	}
+
+	resultJSON, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
@@ -0,0 +1,330 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// maxSnapshots is the number of named result snapshots kept
+	maxSnapshots = 100
+	// maxSnapshotRows is the largest result indexed row by row; larger results
+	// only keep an overall hash
+	maxSnapshotRows = 100000
+	// maxSnapshotKeptRows is the largest result kept in full to show changed rows
+	maxSnapshotKeptRows = 1000
+)
+
+// resultSnapshot is a summary of a query result taken at a point in time
+type resultSnapshot struct {
+	Name       string    `json:"name"`
+	SQL        string    `json:"sql"`
+	KeyColumns []string  `json:"key_columns,omitempty"`
+	TakenAt    time.Time `json:"taken_at"`
+	RowCount   int       `json:"row_count"`
+	Hash       string    `json:"hash"`
+	// Indexed is set when row hashes are kept and a row-level diff is possible
+	Indexed bool `json:"indexed"`
+	// RowsKept is set when the full rows are kept and diffs include row contents
+	RowsKept bool `json:"rows_kept"`
+
+	// index maps the row key (the key columns, or the row hash without keys) to row hashes
+	index map[string][]string
+	// rows maps row hashes to rows
+	rows map[string]map[string]interface{}
+}
+
+// changedRow is a row whose key columns are unchanged but other values differ
+type changedRow struct {
+	Key    string                 `json:"key"`
+	Before map[string]interface{} `json:"before,omitempty"`
+	After  map[string]interface{} `json:"after,omitempty"`
+}
+
+// snapshotDiff is the difference between two snapshots
+type snapshotDiff struct {
+	Before         *resultSnapshot          `json:"before"`
+	After          *resultSnapshot          `json:"after"`
+	Identical      bool                     `json:"identical"`
+	AddedCount     int                      `json:"added_count"`
+	RemovedCount   int                      `json:"removed_count"`
+	ChangedCount   int                      `json:"changed_count"`
+	UnchangedCount int                      `json:"unchanged_count"`
+	Added          []map[string]interface{} `json:"added,omitempty"`
+	Removed        []map[string]interface{} `json:"removed,omitempty"`
+	Changed        []changedRow             `json:"changed,omitempty"`
+	Note           string                   `json:"note,omitempty"`
+}
+
+// snapshotStore keeps the named result snapshots
+type snapshotStore struct {
+	mu        sync.Mutex
+	snapshots map[string]*resultSnapshot
+}
+
+// addSnapshotTools registers the result snapshot tools
+func (s *PostgresMCPServer) addSnapshotTools() {
+	if !s.restrictSQL {
+		snapshotQueryTool := mcp.NewTool("snapshot_query",
+			mcp.WithDescription("Run a read-only query and store a named snapshot of its result (row hashes, and the rows for small results) to detect data drift later with compare_snapshots"),
+			mcp.WithString("name",
+				mcp.Required(),
+				mcp.Description("The snapshot name, an existing snapshot with this name is replaced"),
+			),
+			mcp.WithString("sql",
+				mcp.Required(),
+				mcp.Description("The SQL query to snapshot"),
+			),
+			mcp.WithArray("key_columns",
+				mcp.Description("Columns identifying a row, so rows with the same key and different values are reported as changed instead of removed and added"),
+				mcp.Items(map[string]any{"type": "string"}),
+			),
+			mcp.WithReadOnlyHintAnnotation(true),
+		)
+		s.addTool(snapshotQueryTool, s.handleSnapshotQuery)
+	}
+
+	compareSnapshotsTool := mcp.NewTool("compare_snapshots",
+		mcp.WithDescription("Compare a snapshot with another snapshot, or with the current result of its query, and report the added, removed and changed rows"),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The baseline snapshot"),
+		),
+		mcp.WithString("other",
+			mcp.Description("The snapshot to compare with; omit to run the baseline query again"),
+		),
+		mcp.WithBoolean("save",
+			mcp.Description("When running the query again, replace the baseline with the new result"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(compareSnapshotsTool, s.handleCompareSnapshots)
+}
+
+// handleSnapshotQuery handles the snapshot_query tool
+func (s *PostgresMCPServer) handleSnapshotQuery(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, sql := stringArg(request, "name", ""), stringArg(request, "sql", "")
+	if name == "" || sql == "" {
+		return mcp.NewToolResultError("name and sql are required"), nil
+	}
+
+	snapshot, err := s.takeSnapshot(ctx, name, sql, stringSliceArg(request, "key_columns"))
+	if err != nil {
+		return s.queryError("Failed to snapshot query", sql, err), nil
+	}
+	if err := s.snapshots.put(snapshot); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleCompareSnapshots handles the compare_snapshots tool
+func (s *PostgresMCPServer) handleCompareSnapshots(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	before, ok := s.snapshots.get(stringArg(request, "name", ""))
+	if !ok {
+		return mcp.NewToolResultError("Snapshot not found"), nil
+	}
+
+	var after *resultSnapshot
+	if other := stringArg(request, "other", ""); other != "" {
+		if after, ok = s.snapshots.get(other); !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("Snapshot %q not found", other)), nil
+		}
+	} else {
+		var err error
+		after, err = s.takeSnapshot(ctx, before.Name, before.SQL, before.KeyColumns)
+		if err != nil {
+			return s.queryError("Failed to run the snapshot query", before.SQL, err), nil
+		}
+		if save, _ := request.Params.Arguments["save"].(bool); save {
+			if err := s.snapshots.put(after); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+	}
+
+	resultJSON, err := json.MarshalIndent(compareSnapshots(before, after), "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// takeSnapshot runs a query and summarizes its result
+func (s *PostgresMCPServer) takeSnapshot(ctx context.Context, name, sql string, keyColumns []string) (*resultSnapshot, error) {
+	run, err := s.readOnlyQuery(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	rows := run.Rows
+
+	snapshot := &resultSnapshot{
+		Name:       name,
+		SQL:        sql,
+		KeyColumns: keyColumns,
+		TakenAt:    time.Now(),
+		RowCount:   len(rows),
+		Indexed:    len(rows) <= maxSnapshotRows,
+		RowsKept:   len(rows) <= maxSnapshotKeptRows,
+	}
+
+	hashes := make([]string, 0, len(rows))
+	if snapshot.Indexed {
+		snapshot.index = map[string][]string{}
+	}
+	if snapshot.RowsKept {
+		snapshot.rows = map[string]map[string]interface{}{}
+	}
+	for _, row := range rows {
+		hash := rowHash(row)
+		hashes = append(hashes, hash)
+		if snapshot.Indexed {
+			key := hash
+			if len(keyColumns) > 0 {
+				values := make([]interface{}, 0, len(keyColumns))
+				for _, col := range keyColumns {
+					v, ok := row[col]
+					if !ok {
+						return nil, fmt.Errorf("key column %q is not in the result", col)
+					}
+					values = append(values, v)
+				}
+				b, _ := json.Marshal(values)
+				key = string(b)
+			}
+			snapshot.index[key] = append(snapshot.index[key], hash)
+		}
+		if snapshot.RowsKept {
+			snapshot.rows[hash] = row
+		}
+	}
+
+	// The overall hash does not depend on the row order
+	sort.Strings(hashes)
+	sum := sha256.New()
+	for _, h := range hashes {
+		sum.Write([]byte(h))
+	}
+	snapshot.Hash = hex.EncodeToString(sum.Sum(nil))
+	return snapshot, nil
+}
+
+// compareSnapshots diffs two snapshots by row key
+func compareSnapshots(before, after *resultSnapshot) *snapshotDiff {
+	diff := &snapshotDiff{
+		Before:    before,
+		After:     after,
+		Identical: before.Hash == after.Hash,
+	}
+	if !before.Indexed || !after.Indexed {
+		diff.Note = fmt.Sprintf("results larger than %d rows are only compared by hash", maxSnapshotRows)
+		return diff
+	}
+	withRows := before.RowsKept && after.RowsKept
+	if !withRows {
+		diff.Note = fmt.Sprintf("row contents are only shown for results up to %d rows", maxSnapshotKeptRows)
+	}
+
+	keys := map[string]bool{}
+	for key := range before.index {
+		keys[key] = true
+	}
+	for key := range after.index {
+		keys[key] = true
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	for _, key := range sortedKeys {
+		removed, added := unmatchedHashes(before.index[key], after.index[key])
+		diff.UnchangedCount += len(before.index[key]) - len(removed)
+
+		// Rows sharing a key are paired as changed, the rest are added or removed
+		for len(removed) > 0 && len(added) > 0 {
+			diff.ChangedCount++
+			if withRows && len(diff.Changed) < maxDiffRows {
+				diff.Changed = append(diff.Changed, changedRow{Key: key, Before: before.rows[removed[0]], After: after.rows[added[0]]})
+			}
+			removed, added = removed[1:], added[1:]
+		}
+		for _, h := range removed {
+			diff.RemovedCount++
+			if withRows && len(diff.Removed) < maxDiffRows {
+				diff.Removed = append(diff.Removed, before.rows[h])
+			}
+		}
+		for _, h := range added {
+			diff.AddedCount++
+			if withRows && len(diff.Added) < maxDiffRows {
+				diff.Added = append(diff.Added, after.rows[h])
+			}
+		}
+	}
+	return diff
+}
+
+// unmatchedHashes returns the hashes of before and after that have no equal counterpart
+func unmatchedHashes(before, after []string) (removed, added []string) {
+	remaining := map[string]int{}
+	for _, h := range after {
+		remaining[h]++
+	}
+	for _, h := range before {
+		if remaining[h] > 0 {
+			remaining[h]--
+		} else {
+			removed = append(removed, h)
+		}
+	}
+	for _, h := range after {
+		if remaining[h] > 0 {
+			remaining[h]--
+			added = append(added, h)
+		}
+	}
+	return removed, added
+}
+
+// rowHash returns a short hash of the canonical encoding of a row
+func rowHash(row map[string]interface{}) string {
+	sum := sha256.Sum256([]byte(rowKey(row)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// put stores a snapshot, replacing a snapshot with the same name
+func (st *snapshotStore) put(snapshot *resultSnapshot) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.snapshots == nil {
+		st.snapshots = map[string]*resultSnapshot{}
+	}
+	if _, exists := st.snapshots[snapshot.Name]; !exists && len(st.snapshots) >= maxSnapshots {
+		return fmt.Errorf("too many snapshots, at most %d are kept", maxSnapshots)
+	}
+	st.snapshots[snapshot.Name] = snapshot
+	return nil
+}
+
+// get returns a snapshot by name
+func (st *snapshotStore) get(name string) (*resultSnapshot, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	snapshot, ok := st.snapshots[name]
+	return snapshot, ok
+}
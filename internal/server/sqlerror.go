@@ -0,0 +1,203 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxSuggestions is the number of similar names suggested for an unknown table or column
+const maxSuggestions = 3
+
+// likelyCauses explain the common SQLSTATEs of failing queries
+var likelyCauses = map[pq.ErrorCode]string{
+	"42P01": "the table or view does not exist, is misspelled or is in a schema that is not on the search path",
+	"42703": "the column is misspelled or belongs to another table of the query",
+	"42702": "the column exists in several tables of the query, qualify it with the table name or alias",
+	"42883": "the function does not exist or its arguments have other types, add explicit casts",
+	"42601": "the SQL has a syntax error near the reported position",
+	"42803": "a selected column is neither aggregated nor listed in GROUP BY",
+	"42804": "an expression has a different type than expected, add an explicit cast",
+	"42P18": "the type of a parameter could not be determined, add an explicit cast",
+	"3F000": "the schema does not exist or is misspelled",
+	"22P02": "a value could not be converted to the column type, check literals and casts",
+	"22003": "a number is out of the range of its type",
+	"22012": "the query divides by zero, guard the divisor with NULLIF(divisor, 0)",
+	"22007": "a date or time literal has an invalid format",
+	"22008": "a date or time value is out of range",
+	"25006": "the query tries to modify data, but queries run in a read-only transaction",
+	"42501": "the database user lacks the privilege to read the table",
+	"57014": "the query was canceled, e.g. because it took too long; add filters or a LIMIT",
+	"53200": "the query needs more memory than allowed, reduce the result or the sort size",
+}
+
+var (
+	// undefinedRelation extracts the table of 42P01 errors
+	undefinedRelation = regexp.MustCompile(`relation "([^"]+)" does not exist`)
+	// undefinedColumn extracts the column of 42703 errors, with or without table
+	undefinedColumn = regexp.MustCompile(`column "?(?:[^" ]+\.)?([^" ]+)"? does not exist`)
+)
+
+// queryError builds the tool error of a failed query. PostgreSQL errors are
+// enriched with the SQLSTATE, the failing snippet, the likely cause and the
+// table and column names closest to an unknown name, so the query can be
+// corrected.
+func (s *PostgresMCPServer) queryError(msg, query string, err error) *mcp.CallToolResult {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return mcp.NewToolResultErrorFromErr(msg, err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %v\n", msg, err)
+	fmt.Fprintf(&b, "SQLSTATE: %s (%s)\n", pqErr.Code, pqErr.Code.Name())
+	if pqErr.Detail != "" {
+		fmt.Fprintf(&b, "Detail: %s\n", pqErr.Detail)
+	}
+	if pos, err := strconv.Atoi(pqErr.Position); err == nil && pos > 0 {
+		b.WriteString(errorSnippet(query, pos))
+	}
+	if cause, ok := likelyCauses[pqErr.Code]; ok {
+		fmt.Fprintf(&b, "Likely cause: %s\n", cause)
+	}
+	if suggestions := s.similarNames(pqErr); len(suggestions) > 0 {
+		fmt.Fprintf(&b, "Did you mean: %s\n", strings.Join(suggestions, ", "))
+	}
+	if pqErr.Hint != "" {
+		fmt.Fprintf(&b, "Hint: %s\n", pqErr.Hint)
+	}
+	return mcp.NewToolResultError(strings.TrimSuffix(b.String(), "\n"))
+}
+
+// errorSnippet renders the query line holding the 1-based character
+// position with a caret under it
+func errorSnippet(query string, pos int) string {
+	runes := []rune(query)
+	if pos > len(runes) {
+		return ""
+	}
+	line, start := 1, 0
+	for i := 0; i < pos-1; i++ {
+		if runes[i] == '\n' {
+			line++
+			start = i + 1
+		}
+	}
+	end := start
+	for end < len(runes) && runes[end] != '\n' {
+		end++
+	}
+	column := pos - start
+	return fmt.Sprintf("At line %d, column %d:\n  %s\n  %s^\n", line, column,
+		string(runes[start:end]), strings.Repeat(" ", column-1))
+}
+
+// similarNames returns the table or column names closest to the unknown
+// name of an undefined table or column error
+func (s *PostgresMCPServer) similarNames(pqErr *pq.Error) []string {
+	var pattern *regexp.Regexp
+	switch pqErr.Code {
+	case "42P01":
+		pattern = undefinedRelation
+	case "42703":
+		pattern = undefinedColumn
+	default:
+		return nil
+	}
+	match := pattern.FindStringSubmatch(pqErr.Message)
+	if match == nil {
+		return nil
+	}
+	name := match[1]
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+
+	tables, err := s.db.GetAllTableSchemas("", "")
+	if err != nil {
+		log.Printf("failed to read the catalog for name suggestions: %v", err)
+		return nil
+	}
+	var candidates []string
+	seen := map[string]bool{}
+	for _, table := range tables {
+		qualified := table.Table
+		if table.Schema != "public" {
+			qualified = table.Schema + "." + table.Table
+		}
+		if pqErr.Code == "42P01" {
+			candidates = append(candidates, qualified)
+			continue
+		}
+		for _, col := range table.Columns {
+			candidate := qualified + "." + col.Name
+			if !seen[candidate] {
+				seen[candidate] = true
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+	return closestNames(name, candidates)
+}
+
+// closestNames returns up to maxSuggestions candidates whose last name
+// component is within a small edit distance of name or contains it
+func closestNames(name string, candidates []string) []string {
+	name = strings.ToLower(name)
+	limit := max(2, len(name)/3)
+
+	type scored struct {
+		name     string
+		distance int
+	}
+	var matches []scored
+	for _, candidate := range candidates {
+		last := strings.ToLower(candidate[strings.LastIndex(candidate, ".")+1:])
+		distance := editDistance(name, last)
+		if distance > limit && !strings.Contains(last, name) && !strings.Contains(name, last) {
+			continue
+		}
+		matches = append(matches, scored{candidate, distance})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+
+	var result []string
+	for _, m := range matches {
+		if len(result) == maxSuggestions {
+			break
+		}
+		result = append(result, m.name)
+	}
+	return result
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
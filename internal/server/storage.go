@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxSizeSnapshots bounds the number of database size snapshots kept in memory
+const maxSizeSnapshots = 10000
+
+// sizeSnapshot is the database size at a point in time
+type sizeSnapshot struct {
+	Time  time.Time `json:"time"`
+	Bytes int64     `json:"bytes"`
+}
+
+// sizeGrowth is the database growth between the oldest and the latest snapshot
+type sizeGrowth struct {
+	From        sizeSnapshot `json:"from"`
+	To          sizeSnapshot `json:"to"`
+	Bytes       int64        `json:"bytes"`
+	BytesPerDay int64        `json:"bytes_per_day"`
+	Snapshots   int          `json:"snapshots"`
+}
+
+// sizeHistory keeps periodic database size snapshots
+type sizeHistory struct {
+	mu        sync.Mutex
+	snapshots []sizeSnapshot
+}
+
+// WithSizeSnapshots records the database size every interval so database_size
+// can report growth. Snapshots are kept in memory.
+func WithSizeSnapshots(interval time.Duration) Option {
+	return func(s *PostgresMCPServer) {
+		s.sizeSnapshotInterval = interval
+	}
+}
+
+// addStorageTools registers the storage capacity tools
+func (s *PostgresMCPServer) addStorageTools() {
+	databaseSizeTool := mcp.NewTool("database_size",
+		mcp.WithDescription("Report the database size, per-tablespace usage, WAL size, temporary file usage and, when size snapshots are enabled, the growth rate for capacity planning"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(databaseSizeTool, s.handleDatabaseSize)
+}
+
+// startSizeSnapshots records the database size until the server is closed
+func (s *PostgresMCPServer) startSizeSnapshots() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopSizeSnapshots = cancel
+
+	go func() {
+		ticker := time.NewTicker(s.sizeSnapshotInterval)
+		defer ticker.Stop()
+		for {
+			size, err := s.db.DatabaseSize()
+			if err != nil {
+				log.Printf("size snapshot: %v", err)
+			} else {
+				s.sizes.record(sizeSnapshot{Time: time.Now(), Bytes: size})
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// handleDatabaseSize handles the database_size tool
+func (s *PostgresMCPServer) handleDatabaseSize(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	report, err := s.db.GetStorageReport()
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to get database size", err), nil
+	}
+
+	result := struct {
+		*db.StorageReport
+		Growth *sizeGrowth `json:"growth,omitempty"`
+	}{StorageReport: report}
+	if s.sizeSnapshotInterval > 0 {
+		result.Growth = s.sizes.growth()
+		if result.Growth == nil {
+			report.Notes = append(report.Notes, "growth is reported once two size snapshots have been taken")
+		}
+	} else {
+		report.Notes = append(report.Notes, "growth is not tracked, start the server with -size_snapshot_interval")
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// record adds a snapshot, dropping the oldest ones past the limit
+func (h *sizeHistory) record(snapshot sizeSnapshot) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.snapshots = append(h.snapshots, snapshot)
+	if len(h.snapshots) > maxSizeSnapshots {
+		h.snapshots = h.snapshots[len(h.snapshots)-maxSizeSnapshots:]
+	}
+}
+
+// growth returns the growth over the recorded snapshots, nil with fewer than two
+func (h *sizeHistory) growth() *sizeGrowth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.snapshots) < 2 {
+		return nil
+	}
+	from, to := h.snapshots[0], h.snapshots[len(h.snapshots)-1]
+	g := &sizeGrowth{
+		From:      from,
+		To:        to,
+		Bytes:     to.Bytes - from.Bytes,
+		Snapshots: len(h.snapshots),
+	}
+	if elapsed := to.Time.Sub(from.Time); elapsed > 0 {
+		g.BytesPerDay = int64(float64(g.Bytes) / elapsed.Hours() * 24)
+	}
+	return g
+}
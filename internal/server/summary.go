@@ -0,0 +1,247 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// defaultSummaryMaxChars is the default size limit of schema_summary
+	defaultSummaryMaxChars = 8000
+	// charsPerToken estimates the characters of a token for max_tokens
+	charsPerToken = 4
+	// maxSummaryColumns is the number of columns listed per table in full detail
+	maxSummaryColumns = 12
+	// maxSummaryComment truncates table comments to their first sentence or this length
+	maxSummaryComment = 120
+	// minOmittedList is the room kept for naming the tables left out
+	minOmittedList = 60
+)
+
+// keyColumnNames are the columns listed in the brief summary besides the keys
+var keyColumnNames = map[string]bool{
+	"name": true, "title": true, "email": true, "status": true, "type": true, "kind": true, "created_at": true,
+}
+
+// logTableSuffixes mark tables that record events or history
+var logTableSuffixes = []string{"_log", "_logs", "_history", "_audit", "_events"}
+
+// addSummaryTools registers the schema_summary tool
+func (s *PostgresMCPServer) addSummaryTools() {
+	summaryTool := mcp.NewTool("schema_summary",
+		mcp.WithDescription("Get a condensed plain-text description of the database for use as context: table purposes from comments and names, key columns and foreign keys, most referenced and largest tables first, within a size limit"),
+		mcp.WithString("schema",
+			mcp.Description("Only describe the tables of this schema, all but the system schemas by default"),
+		),
+		mcp.WithNumber("max_chars",
+			mcp.Description("Maximum length of the summary in characters"),
+			mcp.DefaultNumber(defaultSummaryMaxChars),
+		),
+		mcp.WithNumber("max_tokens",
+			mcp.Description("Maximum length of the summary in tokens, estimated as 4 characters each; overrides max_chars"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(summaryTool, s.handleSchemaSummary)
+}
+
+// handleSchemaSummary handles the schema_summary tool
+func (s *PostgresMCPServer) handleSchemaSummary(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	maxChars := intArg(request, "max_chars", defaultSummaryMaxChars)
+	if tokens := intArg(request, "max_tokens", 0); tokens > 0 {
+		maxChars = tokens * charsPerToken
+	}
+	if maxChars <= 0 {
+		return mcp.NewToolResultError("max_chars and max_tokens must be positive"), nil
+	}
+
+	tables, err := s.db.GetSchemaSummary(stringArg(request, "schema", ""))
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to summarize the schema", err), nil
+	}
+
+	return mcp.NewToolResultText(summarizeSchema(tables, maxChars)), nil
+}
+
+// summarizeSchema describes the tables in at most maxChars characters. The
+// tables are described in full detail if they fit, otherwise with their key
+// columns only; the least important tables are then only named.
+func summarizeSchema(tables []db.TableSummary, maxChars int) string {
+	orderByImportance(tables)
+
+	header := fmt.Sprintf("Database schema, %d tables. pk = primary key, -> = foreign key.\n", len(tables))
+	for _, brief := range []bool{false, true} {
+		var b strings.Builder
+		b.WriteString(header)
+		for _, table := range tables {
+			b.WriteString(describeTable(table, brief))
+		}
+		if b.Len() <= maxChars {
+			return b.String()
+		}
+	}
+
+	// Add the brief descriptions while there is room to name the rest
+	var b strings.Builder
+	b.WriteString(header)
+	described := 0
+	for i, table := range tables {
+		desc := describeTable(table, true)
+		rest := min(len(omittedTables(tables[i+1:], 0)), minOmittedList)
+		if b.Len()+len(desc)+rest > maxChars {
+			break
+		}
+		b.WriteString(desc)
+		described++
+	}
+	b.WriteString(omittedTables(tables[described:], maxChars-b.Len()))
+
+	out := b.String()
+	if len(out) > maxChars {
+		out = out[:maxChars]
+	}
+	return out
+}
+
+// orderByImportance sorts the tables by the number of foreign keys
+// referencing them, then by size
+func orderByImportance(tables []db.TableSummary) {
+	referenced := map[string]int{}
+	for _, table := range tables {
+		for _, col := range table.Columns {
+			if col.References != "" {
+				referenced[strings.SplitN(col.References, ".", 2)[0]]++
+			}
+		}
+	}
+	sort.SliceStable(tables, func(i, j int) bool {
+		ri, rj := referenced[tables[i].Name], referenced[tables[j].Name]
+		if ri != rj {
+			return ri > rj
+		}
+		return tables[i].RowEstimate > tables[j].RowEstimate
+	})
+}
+
+// describeTable renders a table on two lines, brief lists the key columns only
+func describeTable(table db.TableSummary, brief bool) string {
+	var b strings.Builder
+	b.WriteString(qualifiedTableName(table))
+	fmt.Fprintf(&b, " (%s", table.Kind)
+	if table.RowEstimate > 0 {
+		fmt.Fprintf(&b, ", ~%s rows", approxCount(table.RowEstimate))
+	}
+	b.WriteString(")")
+	if purpose := tablePurpose(table); purpose != "" {
+		b.WriteString(": ")
+		b.WriteString(purpose)
+	}
+	b.WriteString("\n  ")
+
+	var cols []string
+	for _, col := range table.Columns {
+		if brief && !col.PrimaryKey && col.References == "" && !keyColumnNames[col.Name] {
+			continue
+		}
+		if !brief && len(cols) == maxSummaryColumns {
+			break
+		}
+		def := col.Name
+		if !brief {
+			def += " " + col.Type
+		}
+		if col.PrimaryKey {
+			def += " pk"
+		}
+		if col.References != "" {
+			def += " -> " + col.References
+		}
+		cols = append(cols, def)
+	}
+	b.WriteString(strings.Join(cols, ", "))
+	if more := len(table.Columns) - len(cols); more > 0 {
+		if len(cols) > 0 {
+			b.WriteString("; ")
+		}
+		fmt.Fprintf(&b, "+%d more columns", more)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// omittedTables names the tables left out of the summary, within maxChars
+// when it is positive
+func omittedTables(tables []db.TableSummary, maxChars int) string {
+	if len(tables) == 0 {
+		return ""
+	}
+	out := fmt.Sprintf("Other tables (%d): ", len(tables))
+	for i, table := range tables {
+		name := qualifiedTableName(table)
+		if i > 0 {
+			name = ", " + name
+		}
+		if maxChars > 0 && len(out)+len(name)+len(", ...\n") > maxChars {
+			return out + ", ...\n"
+		}
+		out += name
+	}
+	return out + "\n"
+}
+
+// qualifiedTableName names a table, with its schema unless it is public
+func qualifiedTableName(table db.TableSummary) string {
+	if table.Schema == "public" {
+		return table.Name
+	}
+	return table.Schema + "." + table.Name
+}
+
+// tablePurpose is the first sentence of the table comment, or a purpose
+// inferred from the name and the foreign keys
+func tablePurpose(table db.TableSummary) string {
+	if comment := strings.TrimSpace(table.Comment); comment != "" {
+		if i := strings.IndexAny(comment, ".\n"); i > 0 {
+			comment = comment[:i]
+		}
+		if len(comment) > maxSummaryComment {
+			comment = comment[:maxSummaryComment] + "..."
+		}
+		return comment
+	}
+
+	for _, suffix := range logTableSuffixes {
+		if base, ok := strings.CutSuffix(table.Name, suffix); ok && base != "" {
+			return "log of " + strings.ReplaceAll(base, "_", " ") + " events"
+		}
+	}
+
+	// A table made of foreign keys and few other columns links the tables it references
+	var linked []string
+	for _, col := range table.Columns {
+		if col.References != "" {
+			linked = append(linked, strings.SplitN(col.References, ".", 2)[0])
+		}
+	}
+	if len(linked) >= 2 && len(table.Columns)-len(linked) <= 3 {
+		return "links " + strings.Join(linked, " and ")
+	}
+	return ""
+}
+
+// approxCount renders a row estimate with a k or M suffix
+func approxCount(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	default:
+		return fmt.Sprint(n)
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// addTimescaleTools registers the TimescaleDB tools
+func (s *PostgresMCPServer) addTimescaleTools() {
+	listHypertablesTool := mcp.NewTool("list_hypertables",
+		mcp.WithDescription("List the TimescaleDB hypertables with their time column, chunk interval, chunk count, total size across all chunks and compression ratio. Chunks are internal tables of a hypertable; query the hypertable itself."),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(listHypertablesTool, s.handleListHypertables)
+
+	listChunksTool := mcp.NewTool("list_chunks",
+		mcp.WithDescription("List the most recent chunks of a TimescaleDB hypertable with their time range, size and whether they are compressed"),
+		mcp.WithString("table",
+			mcp.Required(),
+			mcp.Description("The hypertable"),
+		),
+		mcp.WithString("schema",
+			mcp.Description("The schema of the hypertable"),
+			mcp.DefaultString("public"),
+		),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(listChunksTool, s.handleListChunks)
+
+	listContinuousAggregatesTool := mcp.NewTool("list_continuous_aggregates",
+		mcp.WithDescription("List the TimescaleDB continuous aggregates with their source hypertable, size, refresh interval, last refresh and definition"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(listContinuousAggregatesTool, s.handleListContinuousAggregates)
+}
+
+// handleListHypertables handles the list_hypertables tool
+func (s *PostgresMCPServer) handleListHypertables(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	tables, err := s.db.ListHypertables()
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list hypertables", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(tables, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleListChunks handles the list_chunks tool
+func (s *PostgresMCPServer) handleListChunks(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	table := stringArg(request, "table", "")
+	if table == "" {
+		return mcp.NewToolResultError("table is required"), nil
+	}
+
+	chunks, err := s.db.ListChunks(stringArg(request, "schema", "public"), table)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list chunks", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(chunks, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleListContinuousAggregates handles the list_continuous_aggregates tool
+func (s *PostgresMCPServer) handleListContinuousAggregates(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	aggregates, err := s.db.ListContinuousAggregates()
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list continuous aggregates", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(aggregates, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
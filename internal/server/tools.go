@@ -0,0 +1,374 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultVectorSearchLimit is the default number of rows returned by vector_search
+const defaultVectorSearchLimit = 10
+
+// addTools registers the tools of the MCP server
+func (s *PostgresMCPServer) addTools() {
+	listTablesTool := mcp.NewTool(
+		"list_tables",
+		mcp.WithDescription("list_tables"),
+	)
+	s.addTool(listTablesTool, s.handleListTables)
+
+	// Add the query tool unless free-form SQL is disabled
+	if !s.restrictSQL {
+		queryTool := mcp.NewTool("query",
+			mcp.WithDescription("Run a read-only SQL query"),
+			mcp.WithString("sql",
+				mcp.Required(),
+				mcp.Description("The SQL query to execute"),
+			),
+			mcp.WithBoolean("explain_only",
+				mcp.Description("Return the estimated plan with its cost and row estimates instead of running the query, to check a heavy query cheaply"),
+			),
+			mcp.WithString("timezone",
+				mcp.Description("Time zone the query runs in and timestamptz values are returned in, e.g. UTC or Europe/Berlin; defaults to the server time zone"),
+			),
+			formatOption("a header row of the column names"),
+		)
+		s.addTool(queryTool, s.handleQuery)
+	}
+
+	queryContextTool := mcp.NewTool("get_query_context",
+		mcp.WithDescription("Get compact schema, relationships, enum-like column values and row estimates for a set of tables in one call. Provide either tables or keyword."),
+		mcp.WithArray("tables",
+			mcp.Description("Names of the tables to describe"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+		mcp.WithString("keyword",
+			mcp.Description("Describe the tables whose name or column names contain this keyword"),
+		),
+	)
+	s.addTool(queryContextTool, s.handleGetQueryContext)
+	s.addSchemasTools()
+	s.addSummaryTools()
+	s.addDocsTools()
+	s.addERDTools()
+	s.addChartTools()
+	s.addSlowQueryTools()
+	s.addUsageTools()
+
+	s.addBuilderTools()
+	s.addDDLTools()
+	s.addAdminTools()
+	s.addScriptTools()
+	s.addTransactionTools()
+	s.addBackupTools()
+	s.addDumpTools()
+	s.addResultResources()
+	s.addScratchTools()
+	s.addPlanTools()
+	s.addStorageTools()
+	s.addErrorLogTools()
+	s.addQualityTools()
+	// LISTEN and temporary tables need a server session, which a transaction pooler does not keep
+	sessionState := s.pooler != db.PoolerPgBouncer
+	// LISTEN also opens its own connection from the database URL
+	if sessionState && s.databaseURL != "" {
+		s.addNotifyTools()
+	}
+	if !s.restrictSQL {
+		s.addJobTools()
+		if sessionState {
+			s.addWorkspaceTools()
+		}
+	}
+	s.addHistoryTools()
+	s.addSnapshotTools()
+	s.addSchemaCacheTools()
+	s.addDDLTriggerTools()
+	s.addCapabilityTools()
+}
+
+// addSpatialTools registers the PostGIS tools
+func (s *PostgresMCPServer) addSpatialTools() {
+	listSpatialTablesTool := mcp.NewTool("list_spatial_tables",
+		mcp.WithDescription("List the PostGIS geometry and geography columns with their geometry type, SRID and dimensions"),
+	)
+	s.addTool(listSpatialTablesTool, s.handleListSpatialTables)
+}
+
+// addVectorTools registers the pgvector tools
+func (s *PostgresMCPServer) addVectorTools() {
+	listVectorColumnsTool := mcp.NewTool("list_vector_columns",
+		mcp.WithDescription("List the pgvector columns with their dimensions and index definitions"),
+	)
+	s.addTool(listVectorColumnsTool, s.handleListVectorColumns)
+
+	vectorSearchTool := mcp.NewTool("vector_search",
+		mcp.WithDescription("Find the rows nearest to a vector in a pgvector column. Provide either vector or text; text requires a configured embedding endpoint."),
+		mcp.WithString("table",
+			mcp.Required(),
+			mcp.Description("The table to search"),
+		),
+		mcp.WithString("column",
+			mcp.Required(),
+			mcp.Description("The vector column to search"),
+		),
+		mcp.WithString("schema",
+			mcp.Description("The schema of the table"),
+			mcp.DefaultString("public"),
+		),
+		mcp.WithArray("vector",
+			mcp.Description("The query vector"),
+			mcp.Items(map[string]any{"type": "number"}),
+		),
+		mcp.WithString("text",
+			mcp.Description("Text to embed and use as the query vector"),
+		),
+		mcp.WithString("metric",
+			mcp.Description("The distance metric"),
+			mcp.Enum(string(db.VectorCosine), string(db.VectorL2), string(db.VectorInnerProduct), string(db.VectorL1)),
+			mcp.DefaultString(string(db.VectorCosine)),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("The number of rows to return"),
+			mcp.DefaultNumber(defaultVectorSearchLimit),
+		),
+		mcp.WithArray("columns",
+			mcp.Description("The columns to return, all but the vector column by default"),
+			mcp.Items(map[string]any{"type": "string"}),
+		),
+	)
+	s.addTool(vectorSearchTool, s.handleVectorSearch)
+}
+
+// handleListTables handles the list_tables tool
+func (s *PostgresMCPServer) handleListTables(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	log.Printf("list tables tool called with request: %v", request)
+	result, err := s.tableNames()
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to get table names", err), nil
+	}
+
+	// Convert the schema to JSON
+	schemaJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema to JSON: %w", err)
+	}
+
+	return mcp.NewToolResultText(string(schemaJSON)), nil
+}
+
+// handleQuery handles the query tool
+func (s *PostgresMCPServer) handleQuery(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract the SQL query from the request
+	sql, ok := request.Params.Arguments["sql"].(string)
+	if !ok {
+		return mcp.NewToolResultError("SQL query is required"), nil
+	}
+	log.Printf("queryTool called with SQL query: %s", sql)
+
+	if explainOnly, _ := request.Params.Arguments["explain_only"].(bool); explainOnly {
+		return s.explainOnly(ctx, sql)
+	}
+	mimeType, err := toolFormat(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if timeZone := stringArg(request, "timezone", ""); timeZone != "" {
+		ctx = db.ContextWithTimeZone(ctx, timeZone)
+	}
+
+	// Execute the query and remember it in the session history
+	start := time.Now()
+	run, err := s.readOnlyQuery(ctx, sql)
+	s.history.record(sessionIDFromContext(ctx), sql, nil, start, run.Rows, err)
+	if err != nil {
+		return s.queryError("Failed to execute query", sql, err), nil
+	}
+
+	// Wrap the rows, truncating them if the response is too large
+	resp, err := s.runResponse(ctx, run)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to build response", err), nil
+	}
+	if mimeType != mimeJSON {
+		hint := resp.Hint
+		if resp.ResultURI != "" {
+			hint += " Append ." + formatExtensions[mimeType] + " to the chunk URIs to read them in this format."
+		}
+		return formattedToolResult(mimeType, rowsTable(run.Columns, resp.Rows.rows), hint)
+	}
+
+	// Convert the result to JSON
+	resultJSON, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+
+	// Return the result
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// explainOnly returns the estimated plan of a query without running it
+func (s *PostgresMCPServer) explainOnly(ctx context.Context, sql string) (*mcp.CallToolResult, error) {
+	plan, err := s.db.ExplainQuery(ctx, sql, nil)
+	if err != nil {
+		return s.queryError("Failed to explain query", sql, err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(struct {
+		ExplainOnly bool          `json:"explain_only"`
+		Plan        *db.QueryPlan `json:"plan"`
+	}{true, plan}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleGetQueryContext handles the get_query_context tool
+func (s *PostgresMCPServer) handleGetQueryContext(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	tableNames := stringSliceArg(request, "tables")
+	keyword, _ := request.Params.Arguments["keyword"].(string)
+	if len(tableNames) == 0 && keyword == "" {
+		return mcp.NewToolResultError("Either tables or keyword is required"), nil
+	}
+
+	if len(tableNames) == 0 {
+		var err error
+		tableNames, err = s.db.FindTables(keyword)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to find tables", err), nil
+		}
+	}
+
+	result, err := s.db.GetQueryContext(tableNames)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to get query context", err), nil
+	}
+
+	// Use compact JSON to keep the response small
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// stringSliceArg returns the string items of an array argument
+func stringSliceArg(request mcp.CallToolRequest, name string) []string {
+	items, _ := request.Params.Arguments[name].([]any)
+	var result []string
+	for _, item := range items {
+		if str, ok := item.(string); ok && str != "" {
+			result = append(result, str)
+		}
+	}
+	return result
+}
+
+// handleListSpatialTables handles the list_spatial_tables tool
+func (s *PostgresMCPServer) handleListSpatialTables(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	columns, err := s.db.ListSpatialColumns()
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list spatial tables", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(columns, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleListVectorColumns handles the list_vector_columns tool
+func (s *PostgresMCPServer) handleListVectorColumns(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	columns, err := s.db.ListVectorColumns()
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list vector columns", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(columns, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// handleVectorSearch handles the vector_search tool
+func (s *PostgresMCPServer) handleVectorSearch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	params := db.VectorSearchParams{
+		Schema:  stringArg(request, "schema", "public"),
+		Table:   stringArg(request, "table", ""),
+		Column:  stringArg(request, "column", ""),
+		Metric:  db.VectorMetric(stringArg(request, "metric", string(db.VectorCosine))),
+		Limit:   intArg(request, "limit", defaultVectorSearchLimit),
+		Columns: stringSliceArg(request, "columns"),
+	}
+	if params.Table == "" || params.Column == "" {
+		return mcp.NewToolResultError("table and column are required"), nil
+	}
+
+	items, _ := request.Params.Arguments["vector"].([]any)
+	for _, item := range items {
+		v, ok := item.(float64)
+		if !ok {
+			return mcp.NewToolResultError("vector must be an array of numbers"), nil
+		}
+		params.Vector = append(params.Vector, v)
+	}
+
+	if text := stringArg(request, "text", ""); len(params.Vector) == 0 && text != "" {
+		if s.embedder == nil {
+			return mcp.NewToolResultError("No embedding endpoint is configured, provide a vector instead of text"), nil
+		}
+		vector, err := s.embedder.Embed(ctx, text)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to embed text", err), nil
+		}
+		params.Vector = vector
+	}
+	if len(params.Vector) == 0 {
+		return mcp.NewToolResultError("Either vector or text is required"), nil
+	}
+
+	result, err := s.db.VectorSearch(params)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to search vectors", err), nil
+	}
+
+	resp, err := newQueryResponse(result.Rows, result.Columns, s.maxResponseBytes)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to build response", err), nil
+	}
+
+	resultJSON, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// stringArg returns a string argument or def when it is missing or empty
+func stringArg(request mcp.CallToolRequest, name, def string) string {
+	if str, ok := request.Params.Arguments[name].(string); ok && str != "" {
+		return str
+	}
+	return def
+}
+
+// intArg returns a numeric argument as int or def when it is missing
+func intArg(request mcp.CallToolRequest, name string, def int) int {
+	if n, ok := request.Params.Arguments[name].(float64); ok {
+		return int(n)
+	}
+	return def
+}
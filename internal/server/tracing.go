@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/tracing"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates the spans of tool calls and queries. It does nothing until
+// a tracer provider is installed, see tracing.Setup.
+var tracer = otel.Tracer("github.com/iwanbk/postgres-mcp-go")
+
+// WithTraceSQL sets how query text is recorded in the query spans,
+// tracing.SQLRedacted by default
+func WithTraceSQL(mode tracing.SQLMode) Option {
+	return func(s *PostgresMCPServer) {
+		s.traceSQL = mode
+	}
+}
+
+// traced wraps a tool handler in a span named after the tool
+func (s *PostgresMCPServer) traced(name string, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, span := tracer.Start(ctx, "tools/call "+name, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("mcp.tool.name", name)))
+		defer span.End()
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			span.SetAttributes(attribute.String("mcp.session.id", session.SessionID()))
+		}
+
+		result, err := handler(ctx, request)
+		switch {
+		case err != nil:
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		case result != nil && result.IsError:
+			span.SetStatus(codes.Error, toolResultText(result))
+		}
+		return result, err
+	}
+}
+
+// startQuerySpan starts the span of a query run by a tool
+func (s *PostgresMCPServer) startQuerySpan(ctx context.Context, sql string) (context.Context, trace.Span) {
+	ctx, span := tracer.Start(ctx, "query", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL))
+	if statement := s.traceSQL.Statement(sql); statement != "" {
+		span.SetAttributes(semconv.DBStatement(statement))
+	}
+	return ctx, span
+}
+
+// endQuerySpan records the outcome of a query and ends its span
+func endQuerySpan(span trace.Span, rows, retries int, err error) {
+	span.SetAttributes(attribute.Int("db.rows", rows), attribute.Int("db.retries", retries))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// requestContext is the SSE context function. It stores the calling user
+// and continues the trace of the HTTP request, if any.
+func (s *PostgresMCPServer) requestContext(ctx context.Context, r *http.Request) context.Context {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(r.Header))
+	return withRequestUser(ctx, r, s.http.trustForwarded)
+}
+
+// toolResultText returns the text of the first content of a tool result
+func toolResultText(result *mcp.CallToolResult) string {
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			return text.Text
+		}
+	}
+	return ""
+}
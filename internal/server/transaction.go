@@ -0,0 +1,286 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sync"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxTransactions bounds the pooled connections pinned by open transactions
+const maxTransactions = 4
+
+// savepointNamePattern restricts savepoint names to plain identifiers
+var savepointNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// transactions keeps the open transaction of each session
+type transactions struct {
+	mu       sync.Mutex
+	sessions map[string]*db.Transaction
+}
+
+// transactionResponse is the state of the transaction of a session
+type transactionResponse struct {
+	Status     string             `json:"status"`
+	Savepoints []string           `json:"savepoints"`
+	Statement  *statementResponse `json:"statement,omitempty"`
+}
+
+// addTransactionTools registers the tools running statements in a
+// transaction kept open across calls, which need write access and free-form
+// SQL. They are left out when writes need approval.
+func (s *PostgresMCPServer) addTransactionTools() {
+	if !s.allowWrite || s.restrictSQL || s.writeApproval != nil {
+		return
+	}
+
+	beginTool := mcp.NewTool("begin_transaction",
+		mcp.WithDescription("Begin a read-write transaction for this session. Statements run with execute_in_transaction are only visible to others after commit_transaction. Idle transactions are rolled back after 5 minutes."),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+	s.addTool(beginTool, s.handleBeginTransaction)
+
+	execTool := mcp.NewTool("execute_in_transaction",
+		mcp.WithDescription("Run one SQL statement in the transaction of this session. A failing statement aborts the transaction until rollback_to_savepoint or rollback_transaction."),
+		mcp.WithString("sql",
+			mcp.Required(),
+			mcp.Description("The SQL statement to execute"),
+		),
+		mcp.WithString("confirmation_token",
+			mcp.Description("The token returned when the statement is a DROP, TRUNCATE, or DELETE or UPDATE without WHERE, passed once the user confirmed it"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+	)
+	s.addTool(execTool, s.handleExecuteInTransaction)
+
+	savepointTool := mcp.NewTool("savepoint",
+		mcp.WithDescription("Create a named savepoint in the transaction of this session, to try a statement and back out of just that step with rollback_to_savepoint"),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The savepoint name, lower case letters, digits and underscores"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+	s.addTool(savepointTool, s.handleSavepoint)
+
+	rollbackToTool := mcp.NewTool("rollback_to_savepoint",
+		mcp.WithDescription("Undo the statements run since a savepoint, keeping the savepoint and the rest of the transaction. Also recovers a transaction aborted by a failing statement."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The savepoint name"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+	s.addTool(rollbackToTool, s.handleRollbackToSavepoint)
+
+	releaseTool := mcp.NewTool("release_savepoint",
+		mcp.WithDescription("Drop a savepoint and the savepoints created after it, keeping their changes in the transaction"),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The savepoint name"),
+		),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+	s.addTool(releaseTool, s.handleReleaseSavepoint)
+
+	commitTool := mcp.NewTool("commit_transaction",
+		mcp.WithDescription("Commit the transaction of this session"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+	)
+	s.addTool(commitTool, s.handleCommitTransaction)
+
+	rollbackTool := mcp.NewTool("rollback_transaction",
+		mcp.WithDescription("Roll back the transaction of this session, undoing all its statements"),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(false),
+	)
+	s.addTool(rollbackTool, s.handleRollbackTransaction)
+}
+
+// handleBeginTransaction handles the begin_transaction tool
+func (s *PostgresMCPServer) handleBeginTransaction(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	sessionID := sessionIDFromContext(ctx)
+	if sessionID == "" {
+		return mcp.NewToolResultError("Transactions require a client session"), nil
+	}
+	tx, err := s.transactions.begin(ctx, s.db, sessionID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to begin transaction", err), nil
+	}
+	return transactionResult("open", tx, nil)
+}
+
+// handleExecuteInTransaction handles the execute_in_transaction tool
+func (s *PostgresMCPServer) handleExecuteInTransaction(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	sql := stringArg(request, "sql", "")
+	if sql == "" {
+		return mcp.NewToolResultError("SQL statement is required"), nil
+	}
+	tx := s.transactions.get(sessionIDFromContext(ctx))
+	if tx == nil {
+		return mcp.NewToolResultError("This session has no open transaction, call begin_transaction first"), nil
+	}
+	if confirm := s.confirmDestructive(ctx, request, sql); confirm != nil {
+		return confirm, nil
+	}
+	log.Printf("executing in transaction: %s", sql)
+
+	result, err := tx.Exec(ctx, sql)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to execute statement", err), nil
+	}
+	stmt := s.newStatementResponse(1, *result)
+	return transactionResult("open", tx, &stmt)
+}
+
+// handleSavepoint handles the savepoint tool
+func (s *PostgresMCPServer) handleSavepoint(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.withSavepoint(ctx, request, (*db.Transaction).Savepoint)
+}
+
+// handleRollbackToSavepoint handles the rollback_to_savepoint tool
+func (s *PostgresMCPServer) handleRollbackToSavepoint(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.withSavepoint(ctx, request, (*db.Transaction).RollbackToSavepoint)
+}
+
+// handleReleaseSavepoint handles the release_savepoint tool
+func (s *PostgresMCPServer) handleReleaseSavepoint(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return s.withSavepoint(ctx, request, (*db.Transaction).ReleaseSavepoint)
+}
+
+// withSavepoint applies a savepoint operation to the transaction of the session
+func (s *PostgresMCPServer) withSavepoint(ctx context.Context, request mcp.CallToolRequest, op func(*db.Transaction, context.Context, string) error) (*mcp.CallToolResult, error) {
+	name := stringArg(request, "name", "")
+	if !savepointNamePattern.MatchString(name) {
+		return mcp.NewToolResultError("name must be a lower case identifier of letters, digits and underscores"), nil
+	}
+	tx := s.transactions.get(sessionIDFromContext(ctx))
+	if tx == nil {
+		return mcp.NewToolResultError("This session has no open transaction, call begin_transaction first"), nil
+	}
+	if err := op(tx, ctx, name); err != nil {
+		return mcp.NewToolResultErrorFromErr("Savepoint operation failed", err), nil
+	}
+	return transactionResult("open", tx, nil)
+}
+
+// handleCommitTransaction handles the commit_transaction tool
+func (s *PostgresMCPServer) handleCommitTransaction(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	tx := s.transactions.take(sessionIDFromContext(ctx))
+	if tx == nil {
+		return mcp.NewToolResultError("This session has no open transaction"), nil
+	}
+	if err := tx.Commit(); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to commit transaction", err), nil
+	}
+
+	// The transaction may have created, altered or dropped tables
+	s.schemaCache.invalidate()
+	if _, _, err := s.syncTableResources(); err != nil {
+		log.Printf("failed to update the table resources after a transaction: %v", err)
+	}
+	return transactionResult("committed", nil, nil)
+}
+
+// handleRollbackTransaction handles the rollback_transaction tool
+func (s *PostgresMCPServer) handleRollbackTransaction(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	tx := s.transactions.take(sessionIDFromContext(ctx))
+	if tx == nil {
+		return mcp.NewToolResultError("This session has no open transaction"), nil
+	}
+	if err := tx.Rollback(); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to roll back transaction", err), nil
+	}
+	return transactionResult("rolled_back", nil, nil)
+}
+
+// transactionResult returns the state of a transaction as a tool result
+func transactionResult(status string, tx *db.Transaction, stmt *statementResponse) (*mcp.CallToolResult, error) {
+	resp := transactionResponse{Status: status, Savepoints: []string{}, Statement: stmt}
+	if tx != nil {
+		resp.Savepoints = tx.Savepoints()
+	}
+	resultJSON, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// begin begins the transaction of a session
+func (t *transactions) begin(ctx context.Context, d db.Database, sessionID string) (*db.Transaction, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.sessions[sessionID]; ok {
+		return nil, fmt.Errorf("this session already has an open transaction")
+	}
+	if len(t.sessions) >= maxTransactions {
+		return nil, fmt.Errorf("too many open transactions, at most %d", maxTransactions)
+	}
+
+	tx, err := d.BeginTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if t.sessions == nil {
+		t.sessions = map[string]*db.Transaction{}
+	}
+	t.sessions[sessionID] = tx
+	return tx, nil
+}
+
+// get returns the transaction of a session, if any
+func (t *transactions) get(sessionID string) *db.Transaction {
+	if sessionID == "" {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessions[sessionID]
+}
+
+// take removes the transaction of a session and returns it, if any
+func (t *transactions) take(sessionID string) *db.Transaction {
+	if sessionID == "" {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tx := t.sessions[sessionID]
+	delete(t.sessions, sessionID)
+	return tx
+}
+
+// close rolls back the transaction of a session
+func (t *transactions) close(sessionID string) {
+	if tx := t.take(sessionID); tx != nil {
+		if err := tx.Rollback(); err != nil {
+			log.Printf("failed to roll back transaction of session %s: %v", sessionID, err)
+		}
+	}
+}
+
+// closeAll rolls back the transactions of all sessions
+func (t *transactions) closeAll() {
+	t.mu.Lock()
+	sessionIDs := make([]string, 0, len(t.sessions))
+	for sessionID := range t.sessions {
+		sessionIDs = append(sessionIDs, sessionID)
+	}
+	t.mu.Unlock()
+
+	for _, sessionID := range sessionIDs {
+		t.close(sessionID)
+	}
+}
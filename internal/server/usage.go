@@ -0,0 +1,192 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// SessionQuotas bound what a client session may use in total, zero values
+// mean no limit
+type SessionQuotas struct {
+	// Rows is the number of rows returned by queries
+	Rows int64
+	// Bytes is the size of the tool results
+	Bytes int64
+	// QueryTime is the time spent running queries
+	QueryTime time.Duration
+}
+
+// sessionUsage is what a session used so far
+type sessionUsage struct {
+	ToolCalls int64         `json:"tool_calls"`
+	Queries   int64         `json:"queries"`
+	Rows      int64         `json:"rows"`
+	Bytes     int64         `json:"bytes"`
+	QueryTime time.Duration `json:"-"`
+}
+
+// usageTracker accounts the usage of each session
+type usageTracker struct {
+	mu       sync.Mutex
+	quotas   SessionQuotas
+	sessions map[string]*sessionUsage
+}
+
+// WithSessionQuotas limits the rows, result bytes and query time of each
+// client session. Once a quota is used up, tool calls of the session fail.
+func WithSessionQuotas(quotas SessionQuotas) Option {
+	return func(s *PostgresMCPServer) {
+		s.usage.quotas = quotas
+	}
+}
+
+// session returns the usage of a session, creating it on first use
+func (t *usageTracker) session(sessionID string) *sessionUsage {
+	if t.sessions == nil {
+		t.sessions = map[string]*sessionUsage{}
+	}
+	usage, ok := t.sessions[sessionID]
+	if !ok {
+		usage = &sessionUsage{}
+		t.sessions[sessionID] = usage
+	}
+	return usage
+}
+
+// recordQuery accounts a query run for a session
+func (t *usageTracker) recordQuery(sessionID string, rows int, duration time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	usage := t.session(sessionID)
+	usage.Queries++
+	usage.Rows += int64(rows)
+	usage.QueryTime += duration
+}
+
+// recordResult accounts a tool result returned to a session
+func (t *usageTracker) recordResult(sessionID string, result *mcp.CallToolResult) {
+	var size int64
+	for _, content := range result.Content {
+		switch c := content.(type) {
+		case mcp.TextContent:
+			size += int64(len(c.Text))
+		case mcp.ImageContent:
+			size += int64(len(c.Data))
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	usage := t.session(sessionID)
+	usage.ToolCalls++
+	usage.Bytes += size
+}
+
+// exceeded returns the quota a session has used up, if any
+func (t *usageTracker) exceeded(sessionID string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	usage := t.session(sessionID)
+	switch q := t.quotas; {
+	case q.Rows > 0 && usage.Rows >= q.Rows:
+		return fmt.Errorf("the session used up its quota of %d rows", q.Rows)
+	case q.Bytes > 0 && usage.Bytes >= q.Bytes:
+		return fmt.Errorf("the session used up its quota of %d result bytes", q.Bytes)
+	case q.QueryTime > 0 && usage.QueryTime >= q.QueryTime:
+		return fmt.Errorf("the session used up its quota of %s query time", q.QueryTime)
+	}
+	return nil
+}
+
+// get returns a copy of the usage of a session
+func (t *usageTracker) get(sessionID string) sessionUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return *t.session(sessionID)
+}
+
+// forget drops the usage of a closed session
+func (t *usageTracker) forget(sessionID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.sessions, sessionID)
+}
+
+// metered wraps a tool handler to account its result and to reject calls of
+// sessions that used up a quota. session_usage is always allowed.
+func (s *PostgresMCPServer) metered(name string, handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sessionID := sessionIDFromContext(ctx)
+		if name != "session_usage" {
+			if err := s.usage.exceeded(sessionID); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Quota exceeded: %v, see session_usage", err)), nil
+			}
+		}
+
+		result, err := handler(ctx, request)
+		if result != nil {
+			s.usage.recordResult(sessionID, result)
+		}
+		return result, err
+	}
+}
+
+// addUsageTools registers the session_usage tool
+func (s *PostgresMCPServer) addUsageTools() {
+	usageTool := mcp.NewTool("session_usage",
+		mcp.WithDescription("Get the rows, result bytes and query time the session used so far, its quotas and what remains of them, to budget further queries"),
+		mcp.WithReadOnlyHintAnnotation(true),
+	)
+	s.addTool(usageTool, s.handleSessionUsage)
+}
+
+// handleSessionUsage handles the session_usage tool
+func (s *PostgresMCPServer) handleSessionUsage(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	usage := s.usage.get(sessionIDFromContext(ctx))
+	quotas := s.usage.quotas
+
+	type limits struct {
+		Rows        *int64   `json:"rows,omitempty"`
+		Bytes       *int64   `json:"bytes,omitempty"`
+		QueryTimeMS *float64 `json:"query_time_ms,omitempty"`
+	}
+	result := struct {
+		sessionUsage
+		QueryTimeMS float64 `json:"query_time_ms"`
+		Quotas      limits  `json:"quotas"`
+		Remaining   limits  `json:"remaining"`
+	}{sessionUsage: usage, QueryTimeMS: milliseconds(usage.QueryTime)}
+	if quotas.Rows > 0 {
+		result.Quotas.Rows = &quotas.Rows
+		remaining := max(quotas.Rows-usage.Rows, 0)
+		result.Remaining.Rows = &remaining
+	}
+	if quotas.Bytes > 0 {
+		result.Quotas.Bytes = &quotas.Bytes
+		remaining := max(quotas.Bytes-usage.Bytes, 0)
+		result.Remaining.Bytes = &remaining
+	}
+	if quotas.QueryTime > 0 {
+		quota := milliseconds(quotas.QueryTime)
+		remaining := milliseconds(max(quotas.QueryTime-usage.QueryTime, 0))
+		result.Quotas.QueryTimeMS = &quota
+		result.Remaining.QueryTimeMS = &remaining
+	}
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// milliseconds returns a duration in fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ifNoneMatchArgument is the resources/read argument carrying the etag of
+// the content the client holds, which is then not sent again
+const ifNoneMatchArgument = "if_none_match"
+
+// resourceVersion is the last generated content of a resource and the
+// schema fingerprint it was generated for
+type resourceVersion struct {
+	etag     string
+	text     string
+	modified time.Time
+}
+
+// resourceVersions caches the content of the schema resources by URI and format,
+// regenerated only when the schema fingerprint of their tables changes
+type resourceVersions struct {
+	mu      sync.Mutex
+	entries map[string]*resourceVersion
+}
+
+// get returns the cached version of a resource, nil if there is none
+func (v *resourceVersions) get(uri string) *resourceVersion {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.entries[uri]
+}
+
+// set caches the version of a resource
+func (v *resourceVersions) set(uri string, version *resourceVersion) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.entries == nil {
+		v.entries = map[string]*resourceVersion{}
+	}
+	v.entries[uri] = version
+}
+
+// remove drops the cached versions of a resource in all formats
+func (v *resourceVersions) remove(uri string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for key := range v.entries {
+		if strings.HasPrefix(key, uri) {
+			delete(v.entries, key)
+		}
+	}
+}
+
+// versionKey is the key of the version of a resource read as a MIME type
+func versionKey(uri, mimeType string) string {
+	return uri + " " + mimeType
+}
+
+// readVersionedResource serves a resource whose content only depends on the
+// catalog definition of the tables, all non-system tables when none are
+// given. The content is generated again only when their schema fingerprint
+// changed since the last read, and not sent at all when the client already
+// holds it, i.e. passes its etag as the if_none_match argument. changed,
+// when set, is called before content cached for an older fingerprint is
+// generated again. generate is passed the MIME type the resource is read as.
+func (s *PostgresMCPServer) readVersionedResource(ctx context.Context, request mcp.ReadResourceRequest, tableNames []string, changed func(), generate func(mimeType string) (string, error)) ([]mcp.ResourceContents, error) {
+	uri := request.Params.URI
+	mimeType, err := resourceFormat(request)
+	if err != nil {
+		return nil, err
+	}
+	etag, err := s.db.SchemaFingerprint(ctx, tableNames)
+	if err != nil {
+		return nil, err
+	}
+
+	key := versionKey(uri, mimeType)
+	version := s.resourceVersions.get(key)
+	if version == nil || version.etag != etag {
+		if version != nil && changed != nil {
+			changed()
+		}
+		text, err := generate(mimeType)
+		if err != nil {
+			return nil, err
+		}
+		version = &resourceVersion{etag: etag, text: text, modified: time.Now()}
+		s.resourceVersions.set(key, version)
+	}
+
+	if ifNoneMatch, _ := request.Params.Arguments[ifNoneMatchArgument].(string); ifNoneMatch == etag {
+		return []mcp.ResourceContents{}, nil
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: mimeType,
+			Text:     version.text,
+		},
+	}, nil
+}
+
+// addResourceMeta adds the etag, size and modification time of versioned
+// resources to the _meta of resources/read results. notModified is set when
+// the content was left out because the client holds it.
+func (s *PostgresMCPServer) addResourceMeta(ctx context.Context, id any, request *mcp.ReadResourceRequest, result *mcp.ReadResourceResult) {
+	mimeType, err := resourceFormat(*request)
+	if err != nil {
+		return
+	}
+	version := s.resourceVersions.get(versionKey(request.Params.URI, mimeType))
+	if version == nil || result == nil {
+		return
+	}
+	if result.Meta == nil {
+		result.Meta = map[string]any{}
+	}
+	result.Meta["etag"] = version.etag
+	result.Meta["size"] = len(version.text)
+	result.Meta["lastModified"] = version.modified.UTC().Format(time.RFC3339)
+	if len(result.Contents) == 0 {
+		result.Meta["notModified"] = true
+	}
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxWorkspaces bounds the pooled connections pinned by session workspaces
+const maxWorkspaces = 8
+
+// tempTableNamePattern restricts temporary table names to plain identifiers
+var tempTableNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// workspaces keeps the workspace connection of each session
+type workspaces struct {
+	mu       sync.Mutex
+	sessions map[string]*db.Workspace
+}
+
+// addWorkspaceTools registers the temporary table tools
+func (s *PostgresMCPServer) addWorkspaceTools() {
+	createTool := mcp.NewTool("create_temp_table",
+		mcp.WithDescription("Create a temporary table from the result of a read-only query. The session is pinned to a dedicated connection, so the table is visible to later query calls of this session and can be modified with INSERT, UPDATE and DELETE through the query tool. Temporary tables are dropped when the session ends."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The table name, lower case letters, digits and underscores"),
+		),
+		mcp.WithString("sql",
+			mcp.Required(),
+			mcp.Description("The SELECT query whose result fills the table; columns of types without a built-in name, e.g. of extensions, are stored as text"),
+		),
+	)
+	s.addTool(createTool, s.handleCreateTempTable)
+
+	dropTool := mcp.NewTool("drop_temp_table",
+		mcp.WithDescription("Drop a temporary table of this session"),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The table name"),
+		),
+	)
+	s.addTool(dropTool, s.handleDropTempTable)
+
+	listTool := mcp.NewTool("list_temp_tables",
+		mcp.WithDescription("List the temporary tables of this session with their estimated row counts and sizes"),
+	)
+	s.addTool(listTool, s.handleListTempTables)
+}
+
+// handleCreateTempTable handles the create_temp_table tool
+func (s *PostgresMCPServer) handleCreateTempTable(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := stringArg(request, "name", "")
+	if !tempTableNamePattern.MatchString(name) {
+		return mcp.NewToolResultError("name must be a lower case identifier of letters, digits and underscores"), nil
+	}
+	sql := stringArg(request, "sql", "")
+	if sql == "" {
+		return mcp.NewToolResultError("SQL query is required"), nil
+	}
+
+	sessionID := sessionIDFromContext(ctx)
+	if sessionID == "" {
+		return mcp.NewToolResultError("Temporary tables require a client session"), nil
+	}
+	workspace, err := s.workspaces.open(ctx, s.db, sessionID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to open workspace", err), nil
+	}
+	log.Printf("creating temporary table %s: %s", name, sql)
+
+	rows, err := workspace.CreateTempTable(ctx, name, sql)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to create temporary table", err), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Created temporary table %s with %d rows", name, rows)), nil
+}
+
+// handleDropTempTable handles the drop_temp_table tool
+func (s *PostgresMCPServer) handleDropTempTable(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name := stringArg(request, "name", "")
+	if !tempTableNamePattern.MatchString(name) {
+		return mcp.NewToolResultError("name must be a lower case identifier of letters, digits and underscores"), nil
+	}
+	workspace := s.workspaces.get(sessionIDFromContext(ctx))
+	if workspace == nil {
+		return mcp.NewToolResultError("This session has no temporary tables"), nil
+	}
+
+	if err := workspace.DropTempTable(ctx, name); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to drop temporary table", err), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Dropped temporary table %s", name)), nil
+}
+
+// handleListTempTables handles the list_temp_tables tool
+func (s *PostgresMCPServer) handleListTempTables(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	tables := []db.TempTable{}
+	if workspace := s.workspaces.get(sessionIDFromContext(ctx)); workspace != nil {
+		var err error
+		tables, err = workspace.ListTempTables(ctx)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to list temporary tables", err), nil
+		}
+	}
+
+	resultJSON, err := json.MarshalIndent(tables, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal result to JSON", err), nil
+	}
+	return mcp.NewToolResultText(string(resultJSON)), nil
+}
+
+// readOnlyQuery runs a query on the session's workspace connection when it
+// has one, so temporary tables are visible, or on the pool otherwise.
+// Transient errors are retried on the pool only: read-only transactions can
+// still write temporary tables, so a workspace query may have committed
+// writes, and a lost workspace connection takes its tables with it.
+func (s *PostgresMCPServer) readOnlyQuery(ctx context.Context, sql string, args ...interface{}) (queryRun, error) {
+	ctx, span := s.startQuerySpan(ctx, sql)
+	start := time.Now()
+	var result *db.QueryResult
+	var retries int
+	var err error
+	if workspace := s.workspaces.get(sessionIDFromContext(ctx)); workspace != nil {
+		result, err = workspace.Query(ctx, sql, args...)
+	} else {
+		result, retries, err = s.withRetries(ctx, func() (*db.QueryResult, error) {
+			return s.db.QueryContext(ctx, sql, args...)
+		})
+	}
+
+	run := queryRun{Retries: retries, Duration: time.Since(start)}
+	if result != nil {
+		run.QueryResult = *result
+	}
+	endQuerySpan(span, len(run.Rows), retries, err)
+	s.slowQueries.record(ctx, sql, args, start, len(run.Rows), err)
+	s.usage.recordQuery(sessionIDFromContext(ctx), len(run.Rows), run.Duration)
+	return run, err
+}
+
+// open returns the workspace of a session, creating it on first use
+func (w *workspaces) open(ctx context.Context, d db.Database, sessionID string) (*db.Workspace, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if workspace, ok := w.sessions[sessionID]; ok {
+		return workspace, nil
+	}
+	if len(w.sessions) >= maxWorkspaces {
+		return nil, fmt.Errorf("too many sessions with temporary tables, at most %d", maxWorkspaces)
+	}
+
+	workspace, err := d.NewWorkspace(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if w.sessions == nil {
+		w.sessions = map[string]*db.Workspace{}
+	}
+	w.sessions[sessionID] = workspace
+	return workspace, nil
+}
+
+// get returns the workspace of a session, if any
+func (w *workspaces) get(sessionID string) *db.Workspace {
+	if sessionID == "" {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sessions[sessionID]
+}
+
+// close drops the workspace of a session
+func (w *workspaces) close(sessionID string) {
+	w.mu.Lock()
+	workspace, ok := w.sessions[sessionID]
+	delete(w.sessions, sessionID)
+	w.mu.Unlock()
+
+	if ok {
+		if err := workspace.Close(); err != nil {
+			log.Printf("failed to close workspace of session %s: %v", sessionID, err)
+		}
+	}
+}
+
+// closeAll drops the workspaces of all sessions
+func (w *workspaces) closeAll() {
+	w.mu.Lock()
+	sessionIDs := make([]string, 0, len(w.sessions))
+	for sessionID := range w.sessions {
+		sessionIDs = append(sessionIDs, sessionID)
+	}
+	w.mu.Unlock()
+
+	for _, sessionID := range sessionIDs {
+		w.close(sessionID)
+	}
+}
@@ -0,0 +1,84 @@
+// Package tracing exports OpenTelemetry spans over OTLP and redacts the SQL
+// recorded in them.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// SQLMode decides how query text is recorded in spans
+type SQLMode string
+
+const (
+	// SQLFull records the query text as is
+	SQLFull SQLMode = "full"
+	// SQLRedacted replaces string and numeric literals with ?
+	SQLRedacted SQLMode = "redacted"
+	// SQLNone leaves the query text out
+	SQLNone SQLMode = "none"
+)
+
+// ParseSQLMode parses the value of the -otel_sql flag
+func ParseSQLMode(s string) (SQLMode, error) {
+	switch mode := SQLMode(s); mode {
+	case SQLFull, SQLRedacted, SQLNone:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid SQL mode %q, expected full, redacted or none", s)
+}
+
+// literal matches string literals, dollar-quoted strings and numbers, and
+// bind parameters so that their number is kept
+var literal = regexp.MustCompile(`\$\d+|'(?:[^']|'')*'|\$[A-Za-z_]*\$[\s\S]*?\$[A-Za-z_]*\$|\b\d+(?:\.\d+)?\b`)
+
+// Statement returns the query text to record in a span, empty for SQLNone
+func (m SQLMode) Statement(sql string) string {
+	switch m {
+	case SQLFull:
+		return sql
+	case SQLRedacted:
+		return literal.ReplaceAllStringFunc(sql, func(m string) string {
+			if m[0] == '$' && m[len(m)-1] != '$' {
+				return m
+			}
+			return "?"
+		})
+	}
+	return ""
+}
+
+// Setup installs a global tracer provider exporting spans to the OTLP/HTTP
+// endpoint, e.g. http://localhost:4318, and the W3C trace context
+// propagator. The OTEL_EXPORTER_OTLP_* environment variables configure the
+// exporter further. The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, endpoint, serviceName string) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,184 @@
+//go:build integration
+
+package pgtest_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	"github.com/iwanbk/postgres-mcp-go/pgtest"
+	"github.com/iwanbk/postgres-mcp-go/pkg/pgmcp"
+)
+
+const schema = `CREATE TABLE users (
+	id serial PRIMARY KEY,
+	email text NOT NULL,
+	balance numeric(10, 2) NOT NULL DEFAULT 0
+);
+INSERT INTO users (email, balance) VALUES ('a@example.com', 12.50), ('b@example.com', 0)`
+
+func TestIntegration(t *testing.T) {
+	pg := pgtest.New(t)
+	pg.Exec(t, schema)
+
+	t.Run("db", func(t *testing.T) {
+		d := newDB(t, pg, db.WithNumericFormat(db.NumericString))
+
+		columns, err := d.GetTableSchema("users")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(columns) != 3 {
+			t.Fatalf("got %d columns, want 3", len(columns))
+		}
+
+		rows, err := d.ExecuteReadOnlyQuery("SELECT balance FROM users WHERE id = $1", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 1 || rows[0]["balance"] != "12.50" {
+			t.Fatalf("got %v, want balance 12.50", rows)
+		}
+
+		rows, err = d.ExecuteReadOnlyQuery(`SELECT '1 year 2 mons 3 days 04:05:06'::interval AS i, int4range(1, 10) AS r, '$1,234.50'::money AS m`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		interval, _ := rows[0]["i"].(map[string]interface{})
+		if interval["iso8601"] != "P1Y2M3DT4H5M6S" || interval["months"] != int64(14) {
+			t.Errorf("got interval %v", rows[0]["i"])
+		}
+		r, _ := rows[0]["r"].(map[string]interface{})
+		if r["lower"] != int64(1) || r["upper"] != int64(10) || r["bounds"] != "[)" {
+			t.Errorf("got range %v", rows[0]["r"])
+		}
+		if rows[0]["m"] != "1234.50" {
+			t.Errorf("got money %v, want 1234.50", rows[0]["m"])
+		}
+
+		if _, err := d.ExecuteReadOnlyQuery("DELETE FROM users"); err == nil {
+			t.Fatal("DELETE succeeded in a read-only transaction")
+		}
+	})
+
+	t.Run("server", func(t *testing.T) {
+		s := pg.NewServer(t)
+
+		var resp struct {
+			Rows []map[string]any `json:"rows"`
+		}
+		pgtest.DecodeJSON(t, pgtest.CallTool(t, s, "query", map[string]any{"sql": "SELECT email FROM users ORDER BY id"}), &resp)
+		if len(resp.Rows) != 2 || resp.Rows[0]["email"] != "a@example.com" {
+			t.Fatalf("got %v", resp.Rows)
+		}
+
+		text := pgtest.CallToolError(t, s, "query", map[string]any{"sql": "UPDATE users SET balance = 1"})
+		if !strings.Contains(text, "read-only") {
+			t.Fatalf("got error %q, want a read-only transaction error", text)
+		}
+	})
+
+	t.Run("restrict_sql", func(t *testing.T) {
+		s := pg.NewServer(t, pgmcp.WithRestrictedSQL())
+
+		result := pgtest.CallToolResult(t, s, "select_rows", map[string]any{"table": "users"})
+		if result.IsError {
+			t.Fatalf("select_rows failed: %v", result.Content)
+		}
+		if _, err := s.CallTool(context.Background(), "query", map[string]any{"sql": "SELECT 1"}); err == nil {
+			t.Fatal("query tool is registered with -restrict_sql")
+		}
+	})
+}
+
+// nullTypes are the types whose NULL and empty values are checked
+var nullTypes = []struct {
+	name  string
+	empty string
+}{
+	{"smallint", "0"},
+	{"integer", "0"},
+	{"bigint", "0"},
+	{"real", "0"},
+	{"double precision", "0"},
+	{"numeric", "0"},
+	{"boolean", "false"},
+	{"text", "''"},
+	{"varchar(10)", "''"},
+	{"char(1)", "' '"},
+	{"bytea", "''"},
+	{"date", "'2000-01-01'"},
+	{"time", "'00:00'"},
+	{"timetz", "'00:00+00'"},
+	{"timestamp", "'2000-01-01'"},
+	{"timestamptz", "'2000-01-01Z'"},
+	{"interval", "'0'"},
+	{"uuid", "'00000000-0000-0000-0000-000000000000'"},
+	{"json", "'{}'"},
+	{"jsonb", "'{}'"},
+	{"inet", "'0.0.0.0'"},
+	{"macaddr", "'00:00:00:00:00:00'"},
+	{"integer[]", "'{}'"},
+	{"text[]", "'{}'"},
+	{"money", "0"},
+	{"int4range", "'empty'"},
+	{"tsvector", "''"},
+	{"bit(1)", "'0'"},
+	{"point", "'(0,0)'"},
+}
+
+func TestNullFidelity(t *testing.T) {
+	pg := pgtest.New(t)
+
+	cols := make([]string, 0, 2*len(nullTypes))
+	for i, typ := range nullTypes {
+		cols = append(cols, fmt.Sprintf("NULL::%s AS null_%d, %s::%s AS empty_%d", typ.name, i, typ.empty, typ.name, i))
+	}
+	query := "SELECT " + strings.Join(cols, ", ")
+
+	check := func(t *testing.T, row map[string]any) {
+		for i, typ := range nullTypes {
+			if v, ok := row[fmt.Sprintf("null_%d", i)]; !ok || v != nil {
+				t.Errorf("NULL::%s is %#v, want null", typ.name, v)
+			}
+			if v := row[fmt.Sprintf("empty_%d", i)]; v == nil {
+				t.Errorf("%s::%s is null", typ.empty, typ.name)
+			}
+		}
+	}
+
+	for _, format := range []db.ByteaFormat{db.ByteaBase64, db.ByteaHex, db.ByteaHash} {
+		t.Run("db/"+string(format), func(t *testing.T) {
+			d := newDB(t, pg, db.WithByteaFormat(format, 0), db.WithNumericFormat(db.NumericObject))
+			rows, err := d.ExecuteReadOnlyQuery(query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			check(t, rows[0])
+		})
+	}
+
+	t.Run("server", func(t *testing.T) {
+		s := pg.NewServer(t)
+		var resp struct {
+			Rows []map[string]any `json:"rows"`
+		}
+		pgtest.DecodeJSON(t, pgtest.CallTool(t, s, "query", map[string]any{"sql": query}), &resp)
+		check(t, resp.Rows[0])
+	})
+}
+
+// newDB connects to the database of pg and closes the connection when the
+// test ends
+func newDB(t *testing.T, pg *pgtest.Postgres, opts ...db.Option) *db.DB {
+	t.Helper()
+	d, err := db.New(pg.URL, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
@@ -0,0 +1,187 @@
+// Package pgtest runs a disposable PostgreSQL in Docker, using
+// testcontainers-go, to test the server and the custom tools of applications
+// embedding it end-to-end against a real database.
+package pgtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/iwanbk/postgres-mcp-go/pkg/pgmcp"
+	"github.com/jmoiron/sqlx"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+)
+
+// DefaultImage is the image started when PGTEST_IMAGE is not set, e.g. to
+// test against postgis/postgis or timescale/timescaledb
+const DefaultImage = "docker.io/postgres:16-alpine"
+
+// startTimeout bounds pulling the image and starting the container
+const startTimeout = 2 * time.Minute
+
+// Postgres is a disposable PostgreSQL container
+type Postgres struct {
+	// URL connects as the superuser that owns the database
+	URL       string
+	container *postgres.PostgresContainer
+}
+
+// Start starts a PostgreSQL container and runs the init scripts, paths of
+// .sql or .sh files, when the database is first created
+func Start(ctx context.Context, image string, initScripts ...string) (*Postgres, error) {
+	ctx, cancel := context.WithTimeout(ctx, startTimeout)
+	defer cancel()
+
+	container, err := postgres.Run(ctx, image,
+		postgres.WithDatabase("pgtest"),
+		postgres.WithUsername("pgtest"),
+		postgres.WithPassword("pgtest"),
+		postgres.WithInitScripts(initScripts...),
+		postgres.BasicWaitStrategies(),
+	)
+	if err != nil {
+		if container != nil {
+			testcontainers.TerminateContainer(container)
+		}
+		return nil, fmt.Errorf("failed to start PostgreSQL container: %w", err)
+	}
+
+	url, err := container.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		testcontainers.TerminateContainer(container)
+		return nil, fmt.Errorf("failed to get connection string: %w", err)
+	}
+	return &Postgres{URL: url, container: container}, nil
+}
+
+// Stop terminates the container
+func (p *Postgres) Stop() error {
+	return testcontainers.TerminateContainer(p.container)
+}
+
+// New starts a PostgreSQL container for the test and terminates it when the
+// test ends. The test is skipped with -short or when Docker is unavailable.
+func New(t *testing.T, initScripts ...string) *Postgres {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping PostgreSQL integration test in short mode")
+	}
+	skipWithoutDocker(t)
+
+	image := os.Getenv("PGTEST_IMAGE")
+	if image == "" {
+		image = DefaultImage
+	}
+	p, err := Start(context.Background(), image, initScripts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := p.Stop(); err != nil {
+			t.Errorf("failed to stop PostgreSQL container: %v", err)
+		}
+	})
+	return p
+}
+
+// skipWithoutDocker skips the test when no Docker daemon is reachable.
+// testcontainers panics when it cannot find one.
+func skipWithoutDocker(t *testing.T) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Skipf("Docker is not available: %v", r)
+		}
+	}()
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+}
+
+// Exec runs setup statements as the superuser, outside of a read-only
+// transaction. Statements without arguments may be separated by semicolons.
+func (p *Postgres) Exec(t testing.TB, query string, args ...any) {
+	t.Helper()
+	conn, err := sqlx.Connect("postgres", p.URL)
+	if err != nil {
+		t.Fatalf("failed to connect to PostgreSQL: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Exec(query, args...); err != nil {
+		t.Fatalf("failed to execute %q: %v", query, err)
+	}
+}
+
+// NewServer creates an embedded MCP server for the database, closed when the
+// test ends. Tables created before are exposed as resources.
+func (p *Postgres) NewServer(t testing.TB, opts ...pgmcp.Option) *pgmcp.Server {
+	t.Helper()
+	s, err := pgmcp.New(append([]pgmcp.Option{pgmcp.WithURL(p.URL)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// ToolCaller calls tools like an MCP client, e.g. a *pgmcp.Server
+type ToolCaller interface {
+	CallTool(ctx context.Context, name string, arguments map[string]any) (*mcp.CallToolResult, error)
+}
+
+// CallTool calls a tool like an MCP client and returns the text of its
+// result. The test fails when the call fails or the tool returns an error.
+func CallTool(t testing.TB, s ToolCaller, name string, arguments map[string]any) string {
+	t.Helper()
+	result := CallToolResult(t, s, name, arguments)
+	text := resultText(result)
+	if result.IsError {
+		t.Fatalf("tool %s returned an error: %s", name, text)
+	}
+	return text
+}
+
+// CallToolError calls a tool that is expected to return an error and returns
+// the error text
+func CallToolError(t testing.TB, s ToolCaller, name string, arguments map[string]any) string {
+	t.Helper()
+	result := CallToolResult(t, s, name, arguments)
+	text := resultText(result)
+	if !result.IsError {
+		t.Fatalf("tool %s succeeded, expected an error: %s", name, text)
+	}
+	return text
+}
+
+// CallToolResult calls a tool and returns the result as received by clients
+func CallToolResult(t testing.TB, s ToolCaller, name string, arguments map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	result, err := s.CallTool(context.Background(), name, arguments)
+	if err != nil {
+		t.Fatalf("failed to call tool %s: %v", name, err)
+	}
+	return result
+}
+
+// DecodeJSON unmarshals the JSON text of a tool result into v
+func DecodeJSON(t testing.TB, text string, v any) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(text), v); err != nil {
+		t.Fatalf("failed to decode tool result %q: %v", text, err)
+	}
+}
+
+// resultText joins the text contents of a tool result
+func resultText(result *mcp.CallToolResult) string {
+	var text string
+	for _, content := range result.Content {
+		if c, ok := content.(mcp.TextContent); ok {
+			text += c.Text
+		}
+	}
+	return text
+}
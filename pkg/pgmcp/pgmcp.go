@@ -0,0 +1,201 @@
+// Package pgmcp embeds the PostgreSQL MCP server in other Go applications.
+// The server exposes the built-in tools and resources next to the custom
+// tools of the application, and can be served over any mcp-go transport.
+//
+//	var srv *pgmcp.Server
+//	countUsers := server.ServerTool{
+//		Tool: mcp.NewTool("count_users", mcp.WithDescription("Count the users")),
+//		Handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+//			rows, err := srv.Query(ctx, "SELECT count(*) AS users FROM users")
+//			...
+//		},
+//	}
+//	srv, err := pgmcp.New(pgmcp.WithDB(pool), pgmcp.WithTools(countUsers))
+package pgmcp
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+	internal "github.com/iwanbk/postgres-mcp-go/internal/server"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// DefaultMaxResponseBytes is the default size cap of row-producing tool responses
+const DefaultMaxResponseBytes = internal.DefaultMaxResponseBytes
+
+// Server is an embedded PostgreSQL MCP server
+type Server struct {
+	s *internal.PostgresMCPServer
+}
+
+// settings collect the options of New
+type settings struct {
+	databaseURL string
+	conn        *sql.DB
+	opts        []internal.Option
+	// err is the first invalid option
+	err error
+}
+
+// Option configures a Server
+type Option func(*settings)
+
+// WithURL connects to the database of a postgres:// URL, a libpq key=value
+// connection string or a unix socket path
+func WithURL(databaseURL string) Option {
+	return func(s *settings) {
+		s.databaseURL = databaseURL
+	}
+}
+
+// WithDB uses an existing connection pool opened with the lib/pq driver
+// ("postgres"). The pool is not closed by Close. The LISTEN/NOTIFY tools
+// open their own connection from a URL and are not available.
+func WithDB(conn *sql.DB) Option {
+	return func(s *settings) {
+		s.conn = conn
+	}
+}
+
+// WithTools registers custom tools next to the built-in ones. Tool names
+// must not clash with the built-in tools.
+func WithTools(tools ...server.ServerTool) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, internal.WithTools(tools...))
+	}
+}
+
+// Policy authorizes tool calls before they run, see WithPolicy
+type Policy = internal.Policy
+
+// PolicyFunc adapts a function to a Policy
+type PolicyFunc = internal.PolicyFunc
+
+// Invocation is a tool call submitted to a Policy: the tool name, its
+// arguments, its SQL, the tables it reads and the identity of the caller
+type Invocation = internal.Invocation
+
+// Identity is the caller of a tool: the MCP session and the user named by
+// the X-Forwarded-User header of an authenticating proxy, trusted with
+// WithForwardedHeaders
+type Identity = internal.Identity
+
+// WithPolicy evaluates every tool call, including the custom tools, against
+// the policy before it runs. An error denies the call.
+func WithPolicy(policy Policy) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, internal.WithPolicy(policy))
+	}
+}
+
+// WithWriteAccess registers the built-in tools that modify the database
+func WithWriteAccess() Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, internal.WithWriteAccess())
+	}
+}
+
+// WithRestrictedSQL disables the built-in tools that run free-form SQL
+func WithRestrictedSQL() Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, internal.WithRestrictedSQL())
+	}
+}
+
+// WithMaxResponseBytes caps the size of row-producing tool responses, zero
+// disables the limit
+func WithMaxResponseBytes(n int) Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, internal.WithMaxResponseBytes(n))
+	}
+}
+
+// WithForwardedHeaders trusts the X-Forwarded-* headers of a reverse proxy
+// in ServeSSE, including the calling user of X-Forwarded-User. Only enable
+// it behind a proxy that sets these headers.
+func WithForwardedHeaders() Option {
+	return func(s *settings) {
+		s.opts = append(s.opts, internal.WithForwardedHeaders())
+	}
+}
+
+// New creates the server and registers its resources and tools. Either
+// WithURL or WithDB is required.
+func New(opts ...Option) (*Server, error) {
+	cfg := &settings{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.err != nil {
+		return nil, cfg.err
+	}
+
+	serverOpts := cfg.opts
+	switch {
+	case cfg.conn != nil:
+		database, err := db.NewFromConn(cfg.conn)
+		if err != nil {
+			return nil, err
+		}
+		serverOpts = append(serverOpts, internal.WithDatabase(unclosedDatabase{database}))
+	case cfg.databaseURL == "":
+		return nil, errors.New("pgmcp: WithURL or WithDB is required")
+	}
+
+	s, err := internal.New(cfg.databaseURL, serverOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Setup(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return &Server{s: s}, nil
+}
+
+// MCPServer returns the mcp-go server, e.g. to serve it with
+// server.ServeStdio or to add prompts
+func (s *Server) MCPServer() *server.MCPServer {
+	return s.s.MCPServer()
+}
+
+// Query executes a query in a read-only transaction and returns the rows
+// encoded like the query tool
+func (s *Server) Query(ctx context.Context, query string, args ...any) ([]map[string]any, error) {
+	return s.s.Query(ctx, query, args...)
+}
+
+// CallTool calls a tool like an MCP client, without a transport, and
+// returns its result, e.g. to test custom tools
+func (s *Server) CallTool(ctx context.Context, name string, arguments map[string]any) (*mcp.CallToolResult, error) {
+	return s.s.CallTool(ctx, name, arguments)
+}
+
+// ServeSSE serves the MCP server over SSE on addr
+func (s *Server) ServeSSE(addr, baseURL string) error {
+	return s.s.ServeSSE(addr, baseURL)
+}
+
+// ServeSSEContext serves the MCP server over SSE on addr until ctx is done
+func (s *Server) ServeSSEContext(ctx context.Context, addr, baseURL string) error {
+	return s.s.ServeSSEContext(ctx, addr, baseURL)
+}
+
+// Close stops the background work of the server and closes the database
+// connection it opened
+func (s *Server) Close() error {
+	return s.s.Close()
+}
+
+// unclosedDatabase leaves closing a pool given with WithDB to its owner
+type unclosedDatabase struct {
+	*db.DB
+}
+
+func (unclosedDatabase) Close() error {
+	return nil
+}
@@ -0,0 +1,81 @@
+package pgmcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	internal "github.com/iwanbk/postgres-mcp-go/internal/server"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Querier runs read-only queries for the tools of a plugin
+type Querier interface {
+	Query(ctx context.Context, query string, args ...any) ([]map[string]any, error)
+}
+
+// Plugin creates organization-specific tools. It is called once per served
+// database with the querier of that database.
+type Plugin func(q Querier) []server.ServerTool
+
+var (
+	pluginsMu sync.RWMutex
+	plugins   = map[string]Plugin{}
+)
+
+// Register makes a plugin available by name. It is meant to be called from
+// the init function of a plugin package, which the postgres-mcp binary
+// loads when the package is imported in plugins.go. Like sql.Register, it
+// panics when the name is registered twice.
+func Register(name string, plugin Plugin) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	if plugin == nil {
+		panic("pgmcp: Register plugin is nil")
+	}
+	if _, dup := plugins[name]; dup {
+		panic("pgmcp: Register called twice for plugin " + name)
+	}
+	plugins[name] = plugin
+}
+
+// Plugins returns the sorted names of the registered plugins
+func Plugins() []string {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PluginOption returns the option of the postgres-mcp server that adds the
+// tools of the named registered plugin
+func PluginOption(name string) (internal.Option, error) {
+	pluginsMu.RLock()
+	plugin, ok := plugins[name]
+	pluginsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("pgmcp: unknown plugin %q", name)
+	}
+	return internal.WithToolFactory(func(s *internal.PostgresMCPServer) []server.ServerTool {
+		return plugin(s)
+	}), nil
+}
+
+// WithPlugins adds the tools of the named registered plugins
+func WithPlugins(names ...string) Option {
+	return func(s *settings) {
+		for _, name := range names {
+			opt, err := PluginOption(name)
+			if err != nil {
+				s.err = err
+				return
+			}
+			s.opts = append(s.opts, opt)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+package main
+
+// Organization-specific tools are compiled in by importing their plugin
+// package for its side effects, which registers the plugin with
+// pgmcp.Register. All registered plugins are loaded at startup.
+import (
+// _ "example.com/acme/pgmcp-customer-lookup"
+)
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/iwanbk/postgres-mcp-go/internal/db"
+)
+
+// runSetupRole implements the setup-role subcommand, which creates the
+// least-privilege read-only role the server should connect as
+func runSetupRole(args []string) error {
+	fs := flag.NewFlagSet("setup-role", flag.ExitOnError)
+	adminURL := fs.String("admin_url", "", "Connection string of a user allowed to create roles and grant privileges on the schemas, in any -database_url format")
+	roleName := fs.String("role", "mcp_readonly", "Name of the read-only role to create or update")
+	password := fs.String("password", "", "Password of the role, empty generates a random password")
+	schemas := fs.String("schemas", "public", "Comma-separated schemas whose tables the role can read")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: postgres-mcp setup-role -admin_url=<database-url> [flags]")
+		fmt.Fprintln(fs.Output(), "Creates a read-only role and prints the database URL to pass to -database_url.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *adminURL == "" {
+		fs.Usage()
+		return fmt.Errorf("please provide the admin connection string using the -admin_url flag")
+	}
+
+	role := db.ReadOnlyRole{Name: *roleName, Password: *password}
+	for _, schema := range strings.Split(*schemas, ",") {
+		if schema = strings.TrimSpace(schema); schema != "" {
+			role.Schemas = append(role.Schemas, schema)
+		}
+	}
+	if role.Password == "" {
+		key := make([]byte, 24)
+		if _, err := rand.Read(key); err != nil {
+			return fmt.Errorf("failed to generate password: %w", err)
+		}
+		role.Password = base64.RawURLEncoding.EncodeToString(key)
+	}
+
+	roleURL, err := db.ConnStringForRole(*adminURL, role.Name, role.Password)
+	if err != nil {
+		return err
+	}
+
+	admin, err := db.New(*adminURL)
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	if err := admin.CreateReadOnlyRole(context.Background(), role); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Role %s can read the tables of: %s\n", role.Name, strings.Join(role.Schemas, ", "))
+	fmt.Println(roleURL)
+	return nil
+}